@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.program_class_enrollments ADD COLUMN waitlist_position INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_program_class_enrollments_waitlist
+    ON public.program_class_enrollments(class_id, waitlist_position)
+    WHERE enrollment_status = 'Waitlisted' AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_program_class_enrollments_waitlist;
+
+UPDATE public.program_class_enrollments
+SET enrollment_status = 'Cancelled', change_reason = 'Waitlist removed'
+WHERE enrollment_status = 'Waitlisted';
+
+ALTER TABLE public.program_class_enrollments DROP COLUMN IF EXISTS waitlist_position;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.program_prerequisites (
+    program_id INTEGER NOT NULL REFERENCES public.programs(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    prerequisite_program_id INTEGER NOT NULL REFERENCES public.programs(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    PRIMARY KEY (program_id, prerequisite_program_id),
+    CONSTRAINT chk_program_prerequisites_not_self CHECK (program_id <> prerequisite_program_id)
+);
+CREATE INDEX idx_program_prerequisites_prerequisite_program_id ON public.program_prerequisites(prerequisite_program_id);
+
+CREATE TABLE public.program_eligibility_overrides (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    program_id INTEGER NOT NULL REFERENCES public.programs(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_program_eligibility_overrides_user_id ON public.program_eligibility_overrides(user_id);
+CREATE INDEX idx_program_eligibility_overrides_class_id ON public.program_eligibility_overrides(class_id);
+CREATE INDEX idx_program_eligibility_overrides_deleted_at ON public.program_eligibility_overrides(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.program_eligibility_overrides;
+DROP TABLE IF EXISTS public.program_prerequisites;
+-- +goose StatementEnd
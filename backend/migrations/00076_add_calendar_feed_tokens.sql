@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.calendar_feed_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    scope VARCHAR(32) NOT NULL,
+    scope_id INTEGER NOT NULL,
+    name VARCHAR(255),
+    nonce VARCHAR(64) NOT NULL,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_calendar_feed_tokens_scope CHECK (scope IN ('facility', 'class', 'instructor', 'resident'))
+);
+CREATE INDEX idx_calendar_feed_tokens_user_id ON public.calendar_feed_tokens(user_id);
+CREATE INDEX idx_calendar_feed_tokens_deleted_at ON public.calendar_feed_tokens(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.calendar_feed_tokens;
+-- +goose StatementEnd
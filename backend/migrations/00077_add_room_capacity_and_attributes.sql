@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.rooms ADD COLUMN capacity INTEGER;
+ALTER TABLE public.rooms ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE public.rooms ADD CONSTRAINT chk_rooms_capacity CHECK (capacity IS NULL OR capacity > 0);
+
+CREATE TABLE public.room_attributes (
+    room_id INTEGER NOT NULL REFERENCES public.rooms(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    attribute VARCHAR(50) NOT NULL,
+    PRIMARY KEY (room_id, attribute),
+    CONSTRAINT chk_room_attributes_attribute CHECK (attribute IN ('computer_lab', 'accessible', 'projector', 'whiteboard', 'kitchen', 'workshop'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.room_attributes;
+ALTER TABLE public.rooms DROP CONSTRAINT IF EXISTS chk_rooms_capacity;
+ALTER TABLE public.rooms DROP COLUMN IF EXISTS is_active;
+ALTER TABLE public.rooms DROP COLUMN IF EXISTS capacity;
+-- +goose StatementEnd
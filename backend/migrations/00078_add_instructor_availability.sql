@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.instructor_availabilities (
+    id SERIAL PRIMARY KEY,
+    instructor_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL,
+    CONSTRAINT chk_instructor_availabilities_day_of_week CHECK (day_of_week BETWEEN 0 AND 6),
+    CONSTRAINT chk_instructor_availabilities_times CHECK (start_time < end_time)
+);
+CREATE INDEX idx_instructor_availabilities_instructor_id ON public.instructor_availabilities(instructor_id);
+
+CREATE TABLE public.instructor_blackouts (
+    id SERIAL PRIMARY KEY,
+    instructor_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_instructor_blackouts_range CHECK (starts_at < ends_at)
+);
+CREATE INDEX idx_instructor_blackouts_instructor_id ON public.instructor_blackouts(instructor_id);
+CREATE INDEX idx_instructor_blackouts_deleted_at ON public.instructor_blackouts(deleted_at);
+
+CREATE TABLE public.instructor_workload_limits (
+    instructor_id INTEGER PRIMARY KEY REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    max_weekly_hours NUMERIC(5,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT chk_instructor_workload_limits_hours CHECK (max_weekly_hours > 0 AND max_weekly_hours <= 168)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.instructor_workload_limits;
+DROP TABLE IF EXISTS public.instructor_blackouts;
+DROP TABLE IF EXISTS public.instructor_availabilities;
+-- +goose StatementEnd
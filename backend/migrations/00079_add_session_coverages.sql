@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.session_coverages (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES public.program_class_events(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    override_id INTEGER NOT NULL REFERENCES public.program_class_event_overrides(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    session_date VARCHAR(10) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    original_instructor_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    substitute_instructor_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_session_coverages_event_date ON public.session_coverages(event_id, session_date) WHERE deleted_at IS NULL;
+CREATE INDEX idx_session_coverages_class_id ON public.session_coverages(class_id);
+CREATE INDEX idx_session_coverages_substitute_instructor_id ON public.session_coverages(substitute_instructor_id);
+CREATE INDEX idx_session_coverages_original_instructor_id ON public.session_coverages(original_instructor_id);
+CREATE INDEX idx_session_coverages_deleted_at ON public.session_coverages(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.session_coverages;
+-- +goose StatementEnd
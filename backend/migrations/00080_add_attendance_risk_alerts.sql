@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.attendance_risk_settings (
+    facility_id INTEGER PRIMARY KEY REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_absences INTEGER NOT NULL DEFAULT 3,
+    min_attendance_rate INTEGER NOT NULL DEFAULT 70,
+    rate_window_sessions INTEGER NOT NULL DEFAULT 10,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE public.attendance_risk_alerts (
+    id SERIAL PRIMARY KEY,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    rule VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    detail VARCHAR(255),
+    consecutive_absences INTEGER NOT NULL DEFAULT 0,
+    attendance_rate INTEGER NOT NULL DEFAULT 0,
+    sessions_considered INTEGER NOT NULL DEFAULT 0,
+    last_session_date VARCHAR(10),
+    last_evaluated_at TIMESTAMP WITH TIME ZONE,
+    snoozed_until TIMESTAMP WITH TIME ZONE,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    note_id INTEGER REFERENCES public.user_notes(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_attendance_risk_alerts_unresolved ON public.attendance_risk_alerts(user_id, class_id, rule) WHERE status <> 'resolved' AND deleted_at IS NULL;
+CREATE INDEX idx_attendance_risk_alerts_facility_status ON public.attendance_risk_alerts(facility_id, status);
+CREATE INDEX idx_attendance_risk_alerts_class_id ON public.attendance_risk_alerts(class_id);
+CREATE INDEX idx_attendance_risk_alerts_deleted_at ON public.attendance_risk_alerts(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.attendance_risk_alerts;
+DROP TABLE IF EXISTS public.attendance_risk_settings;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.certificate_templates (
+    id SERIAL PRIMARY KEY,
+    program_id INTEGER REFERENCES public.programs(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    credit_type VARCHAR(50),
+    template_name VARCHAR(64) NOT NULL,
+    heading VARCHAR(255),
+    signatory VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT certificate_templates_target CHECK ((program_id IS NULL) <> (credit_type IS NULL OR credit_type = ''))
+);
+CREATE INDEX idx_certificate_templates_program_id ON public.certificate_templates(program_id);
+CREATE INDEX idx_certificate_templates_deleted_at ON public.certificate_templates(deleted_at);
+
+CREATE TABLE public.certificates (
+    id SERIAL PRIMARY KEY,
+    program_completion_id INTEGER NOT NULL REFERENCES public.program_completions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    program_id INTEGER NOT NULL,
+    program_class_id INTEGER NOT NULL,
+    verification_code VARCHAR(14) NOT NULL,
+    template_name VARCHAR(64) NOT NULL,
+    heading VARCHAR(255),
+    signatory VARCHAR(255),
+    resident_name VARCHAR(255) NOT NULL,
+    program_name VARCHAR(255) NOT NULL,
+    class_name VARCHAR(255),
+    facility_name VARCHAR(255),
+    credit_type VARCHAR(255),
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_certificates_program_completion_id ON public.certificates(program_completion_id);
+CREATE UNIQUE INDEX idx_certificates_verification_code ON public.certificates(verification_code);
+CREATE INDEX idx_certificates_user_id ON public.certificates(user_id);
+CREATE INDEX idx_certificates_program_class_id ON public.certificates(program_class_id);
+CREATE INDEX idx_certificates_deleted_at ON public.certificates(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.certificates;
+DROP TABLE IF EXISTS public.certificate_templates;
+-- +goose StatementEnd
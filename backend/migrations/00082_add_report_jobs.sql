@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.report_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    request JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    artifact_key VARCHAR(255),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_report_jobs_user_id ON public.report_jobs(user_id);
+CREATE INDEX idx_report_jobs_expires_at ON public.report_jobs(expires_at) WHERE status = 'completed';
+CREATE INDEX idx_report_jobs_deleted_at ON public.report_jobs(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.report_jobs;
+-- +goose StatementEnd
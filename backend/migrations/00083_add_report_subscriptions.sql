@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.report_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    schedule VARCHAR(100) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL,
+    date_range VARCHAR(30) NOT NULL,
+    request JSONB NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_report_subscriptions_user_id ON public.report_subscriptions(user_id);
+CREATE INDEX idx_report_subscriptions_next_run_at ON public.report_subscriptions(next_run_at) WHERE enabled;
+CREATE INDEX idx_report_subscriptions_deleted_at ON public.report_subscriptions(deleted_at);
+
+ALTER TABLE public.report_jobs ADD COLUMN subscription_id INTEGER REFERENCES public.report_subscriptions(id) ON DELETE SET NULL;
+CREATE INDEX idx_report_jobs_subscription_id ON public.report_jobs(subscription_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_report_jobs_subscription_id;
+ALTER TABLE public.report_jobs DROP COLUMN IF EXISTS subscription_id;
+DROP TABLE IF EXISTS public.report_subscriptions;
+-- +goose StatementEnd
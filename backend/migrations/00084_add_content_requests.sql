@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.content_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    normalized_content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    duplicate_of_id INTEGER REFERENCES public.content_requests(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    open_content_provider_id INTEGER REFERENCES public.open_content_providers(id) ON DELETE SET NULL,
+    content_id INTEGER,
+    content_type VARCHAR(20),
+    fulfilled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_content_requests_user_id ON public.content_requests(user_id);
+CREATE INDEX idx_content_requests_facility_id ON public.content_requests(facility_id);
+CREATE INDEX idx_content_requests_status ON public.content_requests(status);
+CREATE INDEX idx_content_requests_normalized_content ON public.content_requests(normalized_content);
+CREATE INDEX idx_content_requests_duplicate_of_id ON public.content_requests(duplicate_of_id);
+CREATE INDEX idx_content_requests_deleted_at ON public.content_requests(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.content_requests;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.attendance_sync_records (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    date VARCHAR(10) NOT NULL,
+    attendance_status VARCHAR(32) NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_by_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    result VARCHAR(20) NOT NULL,
+    message TEXT,
+    recorded_status VARCHAR(32),
+    attendance_id INTEGER REFERENCES public.program_class_event_attendance(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_attendance_sync_records_client_id ON public.attendance_sync_records(client_id);
+CREATE INDEX idx_attendance_sync_records_class_id ON public.attendance_sync_records(class_id);
+CREATE INDEX idx_attendance_sync_records_deleted_at ON public.attendance_sync_records(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.attendance_sync_records;
+-- +goose StatementEnd
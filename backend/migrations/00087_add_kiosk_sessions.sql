@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ADD COLUMN kiosk_pin_hash VARCHAR(255);
+ALTER TABLE public.users ADD COLUMN kiosk_pin_failed_attempts INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE public.kiosk_sessions (
+    id SERIAL PRIMARY KEY,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES public.program_class_events(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    date VARCHAR(10) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    opened_by_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finalized_at TIMESTAMP WITH TIME ZONE,
+    finalized_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_kiosk_sessions_class_id ON public.kiosk_sessions(class_id);
+CREATE INDEX idx_kiosk_sessions_deleted_at ON public.kiosk_sessions(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.kiosk_sessions;
+ALTER TABLE public.users DROP COLUMN IF EXISTS kiosk_pin_failed_attempts;
+ALTER TABLE public.users DROP COLUMN IF EXISTS kiosk_pin_hash;
+-- +goose StatementEnd
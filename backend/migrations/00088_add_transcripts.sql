@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.transcripts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    verification_code VARCHAR(14) NOT NULL,
+    resident_name VARCHAR(255) NOT NULL,
+    doc_id VARCHAR(25),
+    facility_name VARCHAR(255),
+    classes_completed INTEGER NOT NULL DEFAULT 0,
+    total_credit_hours BIGINT NOT NULL DEFAULT 0,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    issued_by_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_transcripts_verification_code ON public.transcripts(verification_code);
+CREATE INDEX idx_transcripts_user_id ON public.transcripts(user_id);
+CREATE INDEX idx_transcripts_deleted_at ON public.transcripts(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.transcripts;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.release_packets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    generated_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    filename VARCHAR(255) NOT NULL,
+    artifact_key VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    checksum VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_release_packets_user_id ON public.release_packets(user_id);
+CREATE INDEX idx_release_packets_deleted_at ON public.release_packets(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.release_packets;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ADD COLUMN release_date VARCHAR(10);
+ALTER TABLE public.users ADD COLUMN release_warned_for VARCHAR(10);
+CREATE INDEX idx_users_release_date ON public.users(release_date) WHERE release_date IS NOT NULL AND deactivated_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_release_date;
+ALTER TABLE public.users DROP COLUMN IF EXISTS release_warned_for;
+ALTER TABLE public.users DROP COLUMN IF EXISTS release_date;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.roster_sync_runs (
+    id SERIAL PRIMARY KEY,
+    filename VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error VARCHAR(512),
+    row_count INTEGER NOT NULL DEFAULT 0,
+    admitted INTEGER NOT NULL DEFAULT 0,
+    transferred INTEGER NOT NULL DEFAULT 0,
+    released INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    triggered_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_roster_sync_runs_deleted_at ON public.roster_sync_runs(deleted_at);
+
+CREATE TABLE public.roster_sync_changes (
+    id SERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES public.roster_sync_runs(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    row_number INTEGER NOT NULL DEFAULT 0,
+    doc_id VARCHAR(255),
+    name_first VARCHAR(255),
+    name_last VARCHAR(255),
+    user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    from_facility_id INTEGER REFERENCES public.facilities(id) ON DELETE SET NULL,
+    to_facility_id INTEGER REFERENCES public.facilities(id) ON DELETE SET NULL,
+    release_date VARCHAR(10),
+    detail VARCHAR(512),
+    applied BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX idx_roster_sync_changes_run_id ON public.roster_sync_changes(run_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.roster_sync_changes;
+DROP TABLE IF EXISTS public.roster_sync_runs;
+-- +goose StatementEnd
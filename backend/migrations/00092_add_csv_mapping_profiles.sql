@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ADD COLUMN housing_unit VARCHAR(50);
+ALTER TABLE public.users ADD COLUMN date_of_birth VARCHAR(10);
+
+CREATE TABLE public.csv_mapping_profiles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    facility_id INTEGER REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    columns JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_csv_mapping_profiles_deleted_at ON public.csv_mapping_profiles(deleted_at);
+CREATE INDEX idx_csv_mapping_profiles_facility_id ON public.csv_mapping_profiles(facility_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.csv_mapping_profiles;
+ALTER TABLE public.users DROP COLUMN IF EXISTS date_of_birth;
+ALTER TABLE public.users DROP COLUMN IF EXISTS housing_unit;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.provider_platforms ADD COLUMN match_auto_confirm DOUBLE PRECISION NOT NULL DEFAULT 0.9;
+ALTER TABLE public.provider_platforms ADD COLUMN match_ambiguous DOUBLE PRECISION NOT NULL DEFAULT 0.5;
+
+CREATE TABLE public.user_match_reviews (
+    id SERIAL PRIMARY KEY,
+    provider_platform_id INTEGER NOT NULL REFERENCES public.provider_platforms(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES public.facilities(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    external_user_id VARCHAR(255) NOT NULL,
+    import_user JSONB NOT NULL,
+    suggested_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    signals JSONB NOT NULL DEFAULT '[]'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    resolved_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    resolved_by_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_user_match_reviews_provider_external ON public.user_match_reviews(provider_platform_id, external_user_id);
+CREATE INDEX idx_user_match_reviews_facility_status ON public.user_match_reviews(facility_id, status);
+CREATE INDEX idx_user_match_reviews_deleted_at ON public.user_match_reviews(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.user_match_reviews;
+ALTER TABLE public.provider_platforms DROP COLUMN IF EXISTS match_ambiguous;
+ALTER TABLE public.provider_platforms DROP COLUMN IF EXISTS match_auto_confirm;
+-- +goose StatementEnd
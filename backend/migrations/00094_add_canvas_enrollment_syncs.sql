@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.program_classes ADD COLUMN canvas_provider_id INTEGER REFERENCES public.provider_platforms(id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE public.program_classes ADD COLUMN canvas_course_id VARCHAR(255);
+
+CREATE TABLE public.canvas_enrollment_syncs (
+    id SERIAL PRIMARY KEY,
+    class_id INTEGER NOT NULL REFERENCES public.program_classes(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    provider_platform_id INTEGER NOT NULL REFERENCES public.provider_platforms(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    canvas_course_id VARCHAR(255) NOT NULL,
+    canvas_user_id VARCHAR(255),
+    canvas_enrollment_id VARCHAR(255),
+    state VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    synced_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    create_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL,
+    update_user_id INTEGER REFERENCES public.users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_canvas_enrollment_syncs_class_user ON public.canvas_enrollment_syncs(class_id, user_id);
+CREATE INDEX idx_canvas_enrollment_syncs_status ON public.canvas_enrollment_syncs(status);
+CREATE INDEX idx_canvas_enrollment_syncs_deleted_at ON public.canvas_enrollment_syncs(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.canvas_enrollment_syncs;
+ALTER TABLE public.program_classes DROP COLUMN IF EXISTS canvas_course_id;
+ALTER TABLE public.program_classes DROP COLUMN IF EXISTS canvas_provider_id;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a resident waitlisted for a class they were also enrolled in directly gives up their place in the queue
+UPDATE public.program_class_enrollments w
+SET enrollment_status = 'Cancelled', waitlist_position = NULL, change_reason = 'Already enrolled', enrollment_ended_at = NOW()
+WHERE w.enrollment_status = 'Waitlisted' AND w.deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM public.program_class_enrollments e
+    WHERE e.class_id = w.class_id AND e.user_id = w.user_id AND e.id <> w.id
+      AND e.enrollment_status = 'Enrolled' AND e.deleted_at IS NULL
+  );
+
+-- any other repeated enrollment keeps the earliest one
+UPDATE public.program_class_enrollments d
+SET enrollment_status = 'Cancelled', waitlist_position = NULL, change_reason = 'Duplicate enrollment', enrollment_ended_at = NOW()
+WHERE d.enrollment_status IN ('Enrolled', 'Waitlisted') AND d.deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM public.program_class_enrollments e
+    WHERE e.class_id = d.class_id AND e.user_id = d.user_id AND e.id < d.id
+      AND e.enrollment_status IN ('Enrolled', 'Waitlisted') AND e.deleted_at IS NULL
+  );
+
+CREATE UNIQUE INDEX idx_program_class_enrollments_active_class_user
+    ON public.program_class_enrollments(class_id, user_id)
+    WHERE enrollment_status IN ('Enrolled', 'Waitlisted') AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_program_class_enrollments_active_class_user;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.idx_attendance_sync_records_client_id;
+CREATE UNIQUE INDEX idx_attendance_sync_records_mark ON public.attendance_sync_records(class_id, user_id, client_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.idx_attendance_sync_records_mark;
+CREATE UNIQUE INDEX idx_attendance_sync_records_client_id ON public.attendance_sync_records(client_id);
+-- +goose StatementEnd
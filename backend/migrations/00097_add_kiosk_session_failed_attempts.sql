@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.kiosk_sessions ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE public.kiosk_sessions ADD COLUMN failed_attempts_since TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.kiosk_sessions DROP COLUMN IF EXISTS failed_attempts_since;
+ALTER TABLE public.kiosk_sessions DROP COLUMN IF EXISTS failed_attempts;
+-- +goose StatementEnd
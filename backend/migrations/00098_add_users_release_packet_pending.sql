@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ADD COLUMN release_packet_pending BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX idx_users_release_packet_pending ON public.users(facility_id) WHERE release_packet_pending;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_release_packet_pending;
+ALTER TABLE public.users DROP COLUMN IF EXISTS release_packet_pending;
+-- +goose StatementEnd
@@ -1,12 +1,15 @@
 package src
 
 import (
+	"UnlockEdv2/src/i18n"
 	"UnlockEdv2/src/models"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"time"
 )
 
 // oncreate gorm function
@@ -56,6 +59,53 @@ type HeaderMapping struct {
 	FirstNameIdx int
 	ResidentIdx  int
 	UsernameIdx  int // -1 if not present
+	// ReleaseDateIdx is the projected release date column, -1 if not present
+	ReleaseDateIdx int
+	// FacilityIdx and StatusIdx are only read by the roster sync, -1 if not present
+	FacilityIdx int
+	StatusIdx   int
+	// FullNameIdx is a single name column split into the last and first name, only read with a mapping profile
+	FullNameIdx    int
+	HousingUnitIdx int
+	DateOfBirthIdx int
+	LanguageIdx    int
+	// Profile is the mapping profile the file was read with, nil when its headers were recognized by name
+	Profile *models.CSVMappingProfile
+	// Today is the date at the facility the residents are added to, release dates before it are rejected. Unchecked when empty
+	Today string
+}
+
+// value reads the cell at idx, cleaned up by the profile's transforms for the field when the file was read with one
+func (h *HeaderMapping) value(row []string, idx int, field models.CSVField) string {
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	if h.Profile != nil {
+		if column := h.Profile.Column(field); column != nil {
+			return column.Apply(row[idx])
+		}
+	}
+	return strings.TrimSpace(row[idx])
+}
+
+// parseDate reads a date cell the way the profile says the field is written, or as YYYY-MM-DD or MM/DD/YYYY
+func (h *HeaderMapping) parseDate(value string, field models.CSVField) (string, error) {
+	if h.Profile != nil {
+		if column := h.Profile.Column(field); column != nil {
+			return column.ParseDate(value)
+		}
+	}
+	return models.ParseReleaseDate(value)
+}
+
+// dateFormat is the date format the profile reads the field in, empty for YYYY-MM-DD or MM/DD/YYYY
+func (h *HeaderMapping) dateFormat(field models.CSVField) string {
+	if h.Profile != nil {
+		if column := h.Profile.Column(field); column != nil {
+			return column.DateFormat
+		}
+	}
+	return ""
 }
 
 func ValidateCSVHeaders(headers []string) (*HeaderMapping, error) {
@@ -72,14 +122,27 @@ func ValidateCSVHeaders(headers []string) (*HeaderMapping, error) {
 	}
 
 	optionalFields := map[string][]string{
-		"username": {"username", "user name", "login", "user", "user_name", "UserName", "user_name", "login name", "loginname"},
+		"username":     {"username", "user name", "login", "user", "user_name", "UserName", "user_name", "login name", "loginname"},
+		"release_date": {"release date", "releasedate", "projected release date", "projected release", "prd", "release"},
+		"facility":     {"facility", "facility name", "facilityname", "institution", "location", "site"},
+		"status":       {"status", "custody status", "movement", "movement type"},
+		"housing_unit": {"housing unit", "housingunit", "housing", "housing location", "cell", "bed assignment"},
+		"dob":          {"date of birth", "dateofbirth", "dob", "birth date", "birthdate"},
+		"language":     {"preferred language", "preferredlanguage", "language", "primary language"},
 	}
 
 	headerMap := &HeaderMapping{
-		LastNameIdx:  -1,
-		FirstNameIdx: -1,
-		ResidentIdx:  -1,
-		UsernameIdx:  -1,
+		LastNameIdx:    -1,
+		FirstNameIdx:   -1,
+		ResidentIdx:    -1,
+		UsernameIdx:    -1,
+		ReleaseDateIdx: -1,
+		FacilityIdx:    -1,
+		StatusIdx:      -1,
+		FullNameIdx:    -1,
+		HousingUnitIdx: -1,
+		DateOfBirthIdx: -1,
+		LanguageIdx:    -1,
 	}
 
 	for field, variations := range requiredFields {
@@ -109,6 +172,18 @@ func ValidateCSVHeaders(headers []string) (*HeaderMapping, error) {
 				switch field {
 				case "username":
 					headerMap.UsernameIdx = idx
+				case "release_date":
+					headerMap.ReleaseDateIdx = idx
+				case "facility":
+					headerMap.FacilityIdx = idx
+				case "status":
+					headerMap.StatusIdx = idx
+				case "housing_unit":
+					headerMap.HousingUnitIdx = idx
+				case "dob":
+					headerMap.DateOfBirthIdx = idx
+				case "language":
+					headerMap.LanguageIdx = idx
 				}
 				break
 			}
@@ -118,16 +193,80 @@ func ValidateCSVHeaders(headers []string) (*HeaderMapping, error) {
 	return headerMap, nil
 }
 
+/*
+ApplyMappingProfile reads the file's headers with a saved mapping profile instead of recognizing them by name. Every
+column the profile maps has to be in the file, matched ignoring case and spacing.
+*/
+func ApplyMappingProfile(headers []string, profile *models.CSVMappingProfile) (*HeaderMapping, error) {
+	if len(headers) == 0 {
+		return nil, errors.New("file could not be processed: missing required headers")
+	}
+	mapping := createHeaderMapping(headers)
+	headerMap := &HeaderMapping{
+		LastNameIdx:    -1,
+		FirstNameIdx:   -1,
+		ResidentIdx:    -1,
+		UsernameIdx:    -1,
+		ReleaseDateIdx: -1,
+		FacilityIdx:    -1,
+		StatusIdx:      -1,
+		FullNameIdx:    -1,
+		HousingUnitIdx: -1,
+		DateOfBirthIdx: -1,
+		LanguageIdx:    -1,
+		Profile:        profile,
+	}
+	for _, column := range profile.Columns {
+		idx, exists := mapping[normalizeHeaderName(column.Column)]
+		if !exists {
+			return nil, fmt.Errorf("file could not be processed: profile %q maps %s to the column %q, which the file doesn't have", profile.Name, column.Field, column.Column)
+		}
+		switch column.Field {
+		case models.CSVFieldLastName:
+			headerMap.LastNameIdx = idx
+		case models.CSVFieldFirstName:
+			headerMap.FirstNameIdx = idx
+		case models.CSVFieldFullName:
+			headerMap.FullNameIdx = idx
+		case models.CSVFieldResidentID:
+			headerMap.ResidentIdx = idx
+		case models.CSVFieldUsername:
+			headerMap.UsernameIdx = idx
+		case models.CSVFieldHousing:
+			headerMap.HousingUnitIdx = idx
+		case models.CSVFieldRelease:
+			headerMap.ReleaseDateIdx = idx
+		case models.CSVFieldBirthDate:
+			headerMap.DateOfBirthIdx = idx
+		case models.CSVFieldLanguage:
+			headerMap.LanguageIdx = idx
+		}
+	}
+	return headerMap, nil
+}
+
+// parseLanguage reads a preferred language written as a language tag or as the language's name
+func parseLanguage(value string) (i18n.Locale, bool) {
+	switch strings.ToLower(value) {
+	case "english", "inglés", "ingles":
+		return i18n.English, true
+	case "spanish", "español", "espanol":
+		return i18n.Spanish, true
+	}
+	return i18n.Parse(value)
+}
+
 // signature to avoid import cycle
 type UserIdentityChecker func(username string, docID string) (bool, bool)
 
-func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existingResidentIDs map[string]int, checkIdentity UserIdentityChecker, existingUsernames map[string]int) (*models.ValidatedUserRow, *models.InvalidUserRow) {
+func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existingResidentIDs map[string]int, checkIdentity UserIdentityChecker, existingUsernames map[string]int, locale i18n.Locale) (*models.ValidatedUserRow, *models.InvalidUserRow) {
 	if len(row) == 0 {
 		return nil, &models.InvalidUserRow{
 			ValidatedUserRow: models.ValidatedUserRow{
 				RowNumber: rowNum,
 			},
-			ErrorReasons: []string{"Row is empty"},
+			ErrorReasons: []string{locale.T("Row is empty")},
+			Row:          row,
 		}
 	}
 
@@ -143,40 +282,87 @@ func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existin
 			ValidatedUserRow: models.ValidatedUserRow{
 				RowNumber: rowNum,
 			},
-			ErrorReasons: []string{"Row is empty"},
+			ErrorReasons: []string{locale.T("Row is empty")},
+			Row:          row,
 		}
 	}
 
-	var lastName, firstName, residentID, username string
+	var lastName, firstName, residentID, username, releaseDate, housingUnit, dateOfBirth, language string
 	var errors []string
 
-	if headerMap.LastNameIdx < len(row) {
-		lastName = strings.TrimSpace(row[headerMap.LastNameIdx])
+	lastName = headerMap.value(row, headerMap.LastNameIdx, models.CSVFieldLastName)
+	firstName = headerMap.value(row, headerMap.FirstNameIdx, models.CSVFieldFirstName)
+	residentID = headerMap.value(row, headerMap.ResidentIdx, models.CSVFieldResidentID)
+	username = headerMap.value(row, headerMap.UsernameIdx, models.CSVFieldUsername)
+	releaseDate = headerMap.value(row, headerMap.ReleaseDateIdx, models.CSVFieldRelease)
+	housingUnit = headerMap.value(row, headerMap.HousingUnitIdx, models.CSVFieldHousing)
+	dateOfBirth = headerMap.value(row, headerMap.DateOfBirthIdx, models.CSVFieldBirthDate)
+	language = headerMap.value(row, headerMap.LanguageIdx, models.CSVFieldLanguage)
+
+	unsplitName := false
+	if headerMap.FullNameIdx != -1 && headerMap.FullNameIdx < len(row) && headerMap.Profile != nil {
+		fullName := strings.TrimSpace(row[headerMap.FullNameIdx])
+		if fullName != "" {
+			var ok bool
+			lastName, firstName, ok = headerMap.Profile.Column(models.CSVFieldFullName).SplitName(fullName)
+			unsplitName = !ok
+		}
 	}
-	if headerMap.FirstNameIdx < len(row) {
-		firstName = strings.TrimSpace(row[headerMap.FirstNameIdx])
+
+	if unsplitName {
+		errors = append(errors, locale.T("Full name could not be split into a last and first name"))
+	} else {
+		if lastName == "" {
+			errors = append(errors, locale.T("Missing required field - Last Name"))
+		}
+		if firstName == "" {
+			errors = append(errors, locale.T("Missing required field - First Name"))
+		}
 	}
-	if headerMap.ResidentIdx < len(row) {
-		residentID = strings.TrimSpace(row[headerMap.ResidentIdx])
+	if residentID == "" {
+		errors = append(errors, locale.T("Missing required field - Resident ID"))
 	}
-
-	if headerMap.UsernameIdx != -1 && headerMap.UsernameIdx < len(row) {
-		username = strings.TrimSpace(row[headerMap.UsernameIdx])
+	if releaseDate != "" {
+		parsed, err := headerMap.parseDate(releaseDate, models.CSVFieldRelease)
+		if format := headerMap.dateFormat(models.CSVFieldRelease); err != nil && format != "" {
+			errors = append(errors, locale.Tf("Release date must be written as %s", format))
+		} else if err != nil {
+			errors = append(errors, locale.T("Release date must be written as YYYY-MM-DD or MM/DD/YYYY"))
+		} else if headerMap.Today != "" && parsed < headerMap.Today {
+			// a past date would deactivate the resident on the next release lifecycle run
+			errors = append(errors, locale.T("Release date cannot be in the past"))
+		} else {
+			releaseDate = parsed
+		}
 	}
-
-	if lastName == "" {
-		errors = append(errors, "Missing required field - Last Name")
+	if dateOfBirth != "" {
+		parsed, err := headerMap.parseDate(dateOfBirth, models.CSVFieldBirthDate)
+		switch format := headerMap.dateFormat(models.CSVFieldBirthDate); {
+		case err != nil && format != "":
+			errors = append(errors, locale.Tf("Date of birth must be written as %s", format))
+		case err != nil:
+			errors = append(errors, locale.T("Date of birth must be written as YYYY-MM-DD or MM/DD/YYYY"))
+		case parsed >= time.Now().Format(models.ReleaseDateLayout):
+			errors = append(errors, locale.T("Date of birth must be in the past"))
+		default:
+			dateOfBirth = parsed
+		}
 	}
-	if firstName == "" {
-		errors = append(errors, "Missing required field - First Name")
+	if len(housingUnit) > 50 {
+		errors = append(errors, locale.T("Housing unit must be 50 characters or fewer"))
 	}
-	if residentID == "" {
-		errors = append(errors, "Missing required field - Resident ID")
+	if language != "" {
+		parsed, ok := parseLanguage(language)
+		if !ok {
+			errors = append(errors, locale.Tf("Preferred language %q is not supported", language))
+		} else {
+			language = string(parsed)
+		}
 	}
 
 	if residentID != "" {
 		if existingRowNum, exists := existingResidentIDs[residentID]; exists {
-			errors = append(errors, fmt.Sprintf("Duplicate Resident ID - also found in row %d", existingRowNum))
+			errors = append(errors, locale.Tf("Duplicate Resident ID - also found in row %d", existingRowNum))
 		} else {
 			existingResidentIDs[residentID] = rowNum
 		}
@@ -184,7 +370,7 @@ func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existin
 
 	if username != "" {
 		if existingRowNum, exists := existingUsernames[username]; exists {
-			errors = append(errors, fmt.Sprintf("Duplicate Username - also found in row %d", existingRowNum))
+			errors = append(errors, locale.Tf("Duplicate Username - also found in row %d", existingRowNum))
 		} else {
 			existingUsernames[username] = rowNum
 		}
@@ -193,12 +379,12 @@ func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existin
 	if residentID != "" {
 		_, docExists := checkIdentity("", residentID)
 		if docExists {
-			errors = append(errors, "Resident ID already exists")
+			errors = append(errors, locale.T("Resident ID already exists"))
 		}
 		if username != "" {
 			usernameExists, _ := checkIdentity(username, "")
 			if usernameExists {
-				errors = append(errors, "Username already exists in system")
+				errors = append(errors, locale.T("Username already exists in system"))
 			}
 		}
 	}
@@ -210,7 +396,7 @@ func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existin
 	if username != "" && residentID != "" {
 		usernameExists, _ := checkIdentity(username, "")
 		if usernameExists {
-			errors = append(errors, "Generated username already exists in system")
+			errors = append(errors, locale.T("Generated username already exists in system"))
 		}
 	}
 
@@ -224,18 +410,24 @@ func ValidateUserRow(row []string, rowNum int, headerMap *HeaderMapping, existin
 				Username:   username,
 			},
 			ErrorReasons: errors,
+			Row:          row,
 		}
 	}
 
 	return &models.ValidatedUserRow{
-		RowNumber:  rowNum,
-		LastName:   lastName,
-		FirstName:  firstName,
-		ResidentID: residentID,
-		Username:   username,
+		RowNumber:         rowNum,
+		LastName:          lastName,
+		FirstName:         firstName,
+		ResidentID:        residentID,
+		Username:          username,
+		ReleaseDate:       releaseDate,
+		HousingUnit:       housingUnit,
+		DateOfBirth:       dateOfBirth,
+		PreferredLanguage: language,
 	}, nil
 }
 
+// GenerateErrorCSV keeps the headers in English so the file can be fixed and uploaded again, only the reasons are translated
 func GenerateErrorCSV(invalidRows []models.InvalidUserRow) ([]byte, error) {
 	var csvContent strings.Builder
 
@@ -257,3 +449,28 @@ func GenerateErrorCSV(invalidRows []models.InvalidUserRow) ([]byte, error) {
 
 	return []byte(csvContent.String()), nil
 }
+
+/*
+GenerateProfileErrorCSV writes the rows that failed validation back out with the uploaded file's own headers and cells
+plus the reasons, so a file read with a mapping profile can be fixed and uploaded again with the same profile.
+*/
+func GenerateProfileErrorCSV(headers []string, invalidRows []models.InvalidUserRow) ([]byte, error) {
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
+	if err := writer.Write(append(slices.Clone(headers), "Error Reason")); err != nil {
+		return nil, err
+	}
+	for _, row := range invalidRows {
+		line := make([]string, len(headers), len(headers)+1)
+		copy(line, row.Row)
+		line = append(line, strings.Join(row.ErrorReasons, "; "))
+		if err := writer.Write(line); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return []byte(csvContent.String()), nil
+}
@@ -106,6 +106,11 @@ func MigrateTesting(db *gorm.DB) {
 		&models.LoginMetrics{},
 		&models.LoginActivity{},
 		&models.Facility{},
+		&models.Room{},
+		&models.RoomAttribute{},
+		&models.InstructorAvailability{},
+		&models.InstructorBlackout{},
+		&models.InstructorWorkloadLimit{},
 		&models.ProviderPlatform{},
 		&models.ProviderUserMapping{},
 		&models.HelpfulLink{},
@@ -118,8 +123,29 @@ func MigrateTesting(db *gorm.DB) {
 		&models.ProgramClassEvent{},
 		&models.ProgramClassEnrollment{},
 		&models.ProgramCompletion{},
+		&models.CertificateTemplate{},
+		&models.CompletionCertificate{},
+		&models.ReportJob{},
+		&models.ReportSubscription{},
+		&models.ContentRequest{},
+		&models.AttendanceSyncRecord{},
+		&models.KioskSession{},
+		&models.LearningRecordEntry{},
+		&models.Transcript{},
+		&models.ReleasePacket{},
+		&models.RosterSyncRun{},
+		&models.RosterSyncChange{},
+		&models.CSVMappingProfile{},
+		&models.UserMatchReview{},
+		&models.CanvasEnrollmentSync{},
+		&models.ProgramPrerequisite{},
+		&models.ProgramEligibilityOverride{},
 		&models.ProgramClassEventOverride{},
 		&models.ProgramClassEventAttendance{},
+		&models.SessionCoverage{},
+		&models.AttendanceRiskSettings{},
+		&models.AttendanceRiskAlert{},
+		&models.CalendarFeedToken{},
 		&models.Milestone{},
 		&models.Outcome{},
 		&models.Activity{},
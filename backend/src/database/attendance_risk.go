@@ -0,0 +1,95 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetAttendanceRiskSettings returns the facility's saved thresholds, or the defaults when it has none
+func (db *DB) GetAttendanceRiskSettings(facilityID uint) (*models.AttendanceRiskSettings, error) {
+	var settings models.AttendanceRiskSettings
+	err := db.Where("facility_id = ?", facilityID).Take(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings = models.DefaultAttendanceRiskSettings(facilityID)
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, newGetRecordsDBError(err, "attendance_risk_settings")
+	}
+	return &settings, nil
+}
+
+func (db *DB) SaveAttendanceRiskSettings(settings *models.AttendanceRiskSettings) error {
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "facility_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "consecutive_absences", "min_attendance_rate", "rate_window_sessions", "updated_at"}),
+	}).Create(settings).Error; err != nil {
+		return newUpdateDBError(err, "attendance_risk_settings")
+	}
+	return nil
+}
+
+/*
+GetAttendanceRiskAlerts lists a facility's alerts, newest first. status filters to one status; "active" (the default)
+means every alert still awaiting attention, i.e. open, acknowledged or snoozed. classID and userID of zero are ignored.
+*/
+func (db *DB) GetAttendanceRiskAlerts(args *models.QueryContext, status string, classID, userID uint) ([]models.AttendanceRiskAlert, error) {
+	alerts := make([]models.AttendanceRiskAlert, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.AttendanceRiskAlert{}).Where("facility_id = ?", args.FacilityID)
+	switch status {
+	case "", "active":
+		tx = tx.Where("status <> ?", models.RiskAlertResolved)
+	case "all":
+	default:
+		tx = tx.Where("status = ?", status)
+	}
+	if classID != 0 {
+		tx = tx.Where("class_id = ?", classID)
+	}
+	if userID != 0 {
+		tx = tx.Where("user_id = ?", userID)
+	}
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "attendance_risk_alerts")
+	}
+	if err := tx.Preload("User").Preload("Class").Preload("Note").
+		Order("created_at DESC, id DESC").
+		Limit(args.PerPage).Offset(args.CalcOffset()).
+		Find(&alerts).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "attendance_risk_alerts")
+	}
+	return alerts, nil
+}
+
+func (db *DB) GetAttendanceRiskAlert(id uint) (*models.AttendanceRiskAlert, error) {
+	var alert models.AttendanceRiskAlert
+	if err := db.Preload("User").Preload("Class").Preload("Note").First(&alert, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "attendance_risk_alerts")
+	}
+	return &alert, nil
+}
+
+/*
+UpdateAttendanceRiskAlert saves an admin's handling of an alert. A non-nil note is added to the
+resident's note timeline in the same transaction and linked from the alert.
+*/
+func (db *DB) UpdateAttendanceRiskAlert(alert *models.AttendanceRiskAlert, note *models.UserNote) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if note != nil {
+			if err := tx.Create(note).Error; err != nil {
+				return newCreateDBError(err, "user_notes")
+			}
+			alert.NoteID = &note.ID
+			alert.Note = note
+		}
+		if err := tx.Model(alert).
+			Select("status", "snoozed_until", "acknowledged_at", "acknowledged_by_id", "resolved_at", "resolved_by_id", "note_id").
+			Updates(alert).Error; err != nil {
+			return newUpdateDBError(err, "attendance_risk_alerts")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+SyncAttendanceMark applies a mark captured offline and saves the outcome under the mark's client ID, which is unique
+per class and resident. A client ID that was already synced returns the saved outcome without touching attendance.
+A mark is not applied over a different status recorded by someone else, or recorded after the mark was captured; the
+outcome is a conflict carrying the recorded status. Marks matching what is recorded are applied without a new history
+entry. The record's Result is set by the caller when the mark was rejected before reaching the database.
+
+The record is inserted before the mark is applied, so when the same batch is synced twice at once the second insert
+waits for the first and then replays its outcome instead of applying the mark again.
+*/
+func (db *DB) SyncAttendanceMark(rec *models.AttendanceSyncRecord, att *models.ProgramClassEventAttendance, className string) (*models.AttendanceSyncRecord, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+		if result.Error != nil {
+			return newCreateDBError(result.Error, "attendance_sync_records")
+		}
+		if result.RowsAffected == 0 {
+			var synced models.AttendanceSyncRecord
+			if err := tx.Where("class_id = ? AND user_id = ? AND client_id = ?", rec.ClassID, rec.UserID, rec.ClientID).
+				First(&synced).Error; err != nil {
+				return newNotFoundDBError(err, "attendance_sync_records")
+			}
+			*rec = synced
+			rec.Replayed = true
+			return nil
+		}
+		if rec.Result == models.AttendanceSyncRejected {
+			return nil
+		}
+		if err := applyAttendanceMark(tx, rec, att, className); err != nil {
+			return err
+		}
+		if err := tx.Model(rec).Select("result", "message", "recorded_status", "attendance_id").Updates(rec).Error; err != nil {
+			return newUpdateDBError(err, "attendance_sync_records")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func applyAttendanceMark(tx *gorm.DB, rec *models.AttendanceSyncRecord, att *models.ProgramClassEventAttendance, className string) error {
+	var existing []models.ProgramClassEventAttendance
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND user_id = ? AND date = ?", att.EventID, att.UserID, att.Date).
+		Limit(1).Find(&existing).Error; err != nil {
+		return newGetRecordsDBError(err, "program_class_event_attendance")
+	}
+	if len(existing) > 0 {
+		current := existing[0]
+		rec.AttendanceID, rec.RecordedStatus = &current.ID, current.AttendanceStatus
+		if current.AttendanceStatus == att.AttendanceStatus {
+			rec.Result = models.AttendanceSyncApplied
+			return nil
+		}
+		recordedBy := current.CreateUserID
+		if current.UpdateUserID != nil {
+			recordedBy = current.UpdateUserID
+		}
+		switch {
+		case recordedBy == nil || *recordedBy != rec.SyncedByID:
+			rec.Result = models.AttendanceSyncConflict
+			rec.Message = fmt.Sprintf("another user already recorded %s for this session", current.AttendanceStatus)
+			return nil
+		case current.UpdatedAt.After(rec.CapturedAt):
+			rec.Result = models.AttendanceSyncConflict
+			rec.Message = fmt.Sprintf("%s was recorded for this session after the mark was captured", current.AttendanceStatus)
+			return nil
+		}
+	}
+	att.UpdateUserID = &rec.SyncedByID
+	if err := recordAttendance(tx, att, &rec.SyncedByID, className); err != nil {
+		return err
+	}
+	var saved models.ProgramClassEventAttendance
+	if err := tx.Select("id").Where("event_id = ? AND user_id = ? AND date = ?", att.EventID, att.UserID, att.Date).
+		First(&saved).Error; err != nil {
+		return newNotFoundDBError(err, "program_class_event_attendance")
+	}
+	rec.Result, rec.AttendanceID, rec.RecordedStatus = models.AttendanceSyncApplied, &saved.ID, att.AttendanceStatus
+	return nil
+}
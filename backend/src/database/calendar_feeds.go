@@ -0,0 +1,70 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) CreateCalendarFeedToken(token *models.CalendarFeedToken) error {
+	if err := Validate().Struct(token); err != nil {
+		return newCreateDBError(err, "calendar_feed_tokens")
+	}
+	nonce, err := models.NewCalendarFeedNonce()
+	if err != nil {
+		return newCreateDBError(err, "calendar_feed_tokens")
+	}
+	token.Nonce = nonce
+	if err := db.Create(token).Error; err != nil {
+		return newCreateDBError(err, "calendar_feed_tokens")
+	}
+	return nil
+}
+
+// GetCalendarFeedTokensForUser returns the feeds a user has created, newest first, including revoked ones
+func (db *DB) GetCalendarFeedTokensForUser(args *models.QueryContext, userID uint) ([]models.CalendarFeedToken, error) {
+	tokens := make([]models.CalendarFeedToken, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.CalendarFeedToken{}).Where("user_id = ?", userID)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "calendar_feed_tokens")
+	}
+	if err := tx.Order("created_at DESC").
+		Limit(args.PerPage).
+		Offset(args.CalcOffset()).
+		Find(&tokens).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "calendar_feed_tokens")
+	}
+	return tokens, nil
+}
+
+func (db *DB) GetCalendarFeedTokenByID(id uint) (*models.CalendarFeedToken, error) {
+	var token models.CalendarFeedToken
+	if err := db.First(&token, "id = ?", id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "calendar_feed_tokens")
+	}
+	return &token, nil
+}
+
+func (db *DB) RevokeCalendarFeedToken(id uint) error {
+	result := db.Model(&models.CalendarFeedToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return newUpdateDBError(result.Error, "calendar_feed_tokens")
+	}
+	if result.RowsAffected == 0 {
+		return newNotFoundDBError(gorm.ErrRecordNotFound, "calendar_feed_tokens")
+	}
+	return nil
+}
+
+// TouchCalendarFeedToken records when a calendar client last pulled the feed
+func (db *DB) TouchCalendarFeedToken(id uint) error {
+	if err := db.Model(&models.CalendarFeedToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_accessed_at", time.Now().UTC()).Error; err != nil {
+		return newUpdateDBError(err, "calendar_feed_tokens")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+	"time"
+
+	"github.com/teambition/rrule-go"
+	"gorm.io/gorm"
+)
+
+const calendarImportCancelReason = "Cancelled in imported calendar"
+
+// GetSchedulableClassesForFacility returns the classes at a facility that can still take new events,
+// with InstructorID filled from the class's earliest event so imports can default to it
+func (db *DB) GetSchedulableClassesForFacility(facilityID uint) ([]models.ProgramClass, error) {
+	var classes []models.ProgramClass
+	if err := db.Select("id", "name", "status", "facility_id", "archived_at").
+		Where("facility_id = ? AND archived_at IS NULL AND status NOT IN ?", facilityID, []models.ClassStatus{models.Completed, models.Cancelled}).
+		Order("name ASC").
+		Find(&classes).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_classes")
+	}
+	if len(classes) == 0 {
+		return classes, nil
+	}
+	classIDs := make([]uint, 0, len(classes))
+	for _, class := range classes {
+		classIDs = append(classIDs, class.ID)
+	}
+	var events []models.ProgramClassEvent
+	if err := db.Select("class_id", "instructor_id").
+		Where("class_id IN ? AND instructor_id IS NOT NULL", classIDs).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_events")
+	}
+	instructorByClass := make(map[uint]*uint, len(events))
+	for _, event := range events {
+		if _, ok := instructorByClass[event.ClassID]; !ok {
+			instructorByClass[event.ClassID] = event.InstructorID
+		}
+	}
+	for idx := range classes {
+		classes[idx].InstructorID = instructorByClass[classes[idx].ID]
+	}
+	return classes, nil
+}
+
+/*
+ImportClassEvents creates an event series for each importable item, plus a cancelled override for each
+of its EXDATEs, in a single transaction. Conflicts are checked again under lock as each series is added,
+so two VEVENTs from the same file cannot double-book a room or instructor. If any item conflicts, nothing
+is written, the conflicts are attached to the items and false is returned.
+*/
+func (db *DB) ImportClassEvents(facilityID uint, items []models.CalendarImportItem) (bool, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		touchedClasses := make(map[uint]bool)
+		conflicted := false
+		for idx := range items {
+			item := &items[idx]
+			if !item.IsImportable() {
+				continue
+			}
+			conflicts, err := LockAndCheckConflicts(tx, &models.ConflictCheckRequest{
+				FacilityID:     facilityID,
+				RoomID:         *item.RoomID,
+				InstructorID:   *item.InstructorID,
+				RecurrenceRule: item.RecurrenceRule,
+				Duration:       item.Duration,
+			})
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				item.Conflicts = conflicts
+				conflicted = true
+				continue
+			}
+			event := &models.ProgramClassEvent{
+				ClassID:        *item.ClassID,
+				Duration:       item.Duration,
+				RecurrenceRule: item.RecurrenceRule,
+				RoomID:         item.RoomID,
+				InstructorID:   item.InstructorID,
+			}
+			if err := Validate().Struct(event); err != nil {
+				return newCreateDBError(err, "program_class_events")
+			}
+			if err := tx.Create(event).Error; err != nil {
+				return newCreateDBError(err, "program_class_events")
+			}
+			item.EventID = &event.ID
+			for _, cancelled := range item.CancelledAt {
+				override := &models.ProgramClassEventOverride{
+					EventID:       event.ID,
+					Duration:      item.Duration,
+					OverrideRrule: singleOccurrenceRule(cancelled),
+					IsCancelled:   true,
+					Reason:        calendarImportCancelReason,
+				}
+				if err := tx.Create(override).Error; err != nil {
+					return newCreateDBError(err, "program_class_event_overrides")
+				}
+			}
+			touchedClasses[*item.ClassID] = true
+		}
+		if conflicted {
+			return errImportConflicts
+		}
+		for classID := range touchedClasses {
+			if err := db.syncClassDateBoundaries(tx, classID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errImportConflicts) {
+		for idx := range items {
+			items[idx].EventID = nil
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+var errImportConflicts = errors.New("imported events conflict with the existing schedule")
+
+// singleOccurrenceRule builds the one-instance rule used by override rows, matching NewEventOverride
+func singleOccurrenceRule(at time.Time) string {
+	rule, err := rrule.NewRRule(rrule.ROption{Freq: rrule.DAILY, Count: 1, Dtstart: at.UTC()})
+	if err != nil {
+		return ""
+	}
+	return rule.String()
+}
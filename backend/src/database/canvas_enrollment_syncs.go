@@ -0,0 +1,99 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+/*
+LinkClassToCanvasCourse points the class's enrollments at a Canvas course, or stops pushing them when providerID and
+courseID are nil. Enrollments pushed to a course the class is no longer linked to are kept and marked inactive, so the
+next run deactivates them in the old course before enrolling the residents in the new one.
+*/
+func (db *DB) LinkClassToCanvasCourse(ctx context.Context, class *models.ProgramClass, providerID *uint, courseID *string) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sameProvider := (class.CanvasProviderID == nil) == (providerID == nil) && (providerID == nil || *class.CanvasProviderID == *providerID)
+		sameCourse := (class.CanvasCourseID == nil) == (courseID == nil) && (courseID == nil || *class.CanvasCourseID == *courseID)
+		if !sameProvider || !sameCourse {
+			if err := tx.Model(&models.CanvasEnrollmentSync{}).
+				Where("class_id = ? AND NOT (state = ? AND status = ?)", class.ID, models.CanvasEnrollmentInactive, models.CanvasSyncSynced).
+				Updates(map[string]any{"state": models.CanvasEnrollmentInactive, "status": "", "attempts": 0, "error": ""}).Error; err != nil {
+				return err
+			}
+		}
+		class.CanvasProviderID, class.CanvasCourseID = providerID, courseID
+		return tx.Model(&models.ProgramClass{DatabaseFields: models.DatabaseFields{ID: class.ID}}).
+			Select("canvas_provider_id", "canvas_course_id").
+			Updates(class).Error
+	})
+	if err != nil {
+		return newUpdateDBError(err, "program_classes")
+	}
+	return nil
+}
+
+// HasCanvasEnrollmentsToPush reports whether the class is linked to a Canvas course or still has enrollments to
+// deactivate in a course it was linked to before
+func (db *DB) HasCanvasEnrollmentsToPush(ctx context.Context, classID int) (bool, error) {
+	var count int64
+	if err := db.WithContext(ctx).Model(&models.ProgramClass{}).
+		Where("id = ? AND canvas_provider_id IS NOT NULL AND canvas_course_id IS NOT NULL", classID).
+		Count(&count).Error; err != nil {
+		return false, newGetRecordsDBError(err, "program_classes")
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := db.WithContext(ctx).Model(&models.CanvasEnrollmentSync{}).
+		Where("class_id = ? AND NOT (state = ? AND status = ?)", classID, models.CanvasEnrollmentInactive, models.CanvasSyncSynced).
+		Count(&count).Error; err != nil {
+		return false, newGetRecordsDBError(err, "canvas_enrollment_syncs")
+	}
+	return count > 0, nil
+}
+
+// GetCanvasClassIDsForUser returns the classes linked to a Canvas course that the resident has enrollments in, so a
+// change to the resident's enrollments can be pushed to each of those courses
+func (db *DB) GetCanvasClassIDsForUser(ctx context.Context, userID uint) ([]int, error) {
+	var classIDs []int
+	if err := db.WithContext(ctx).Table("program_class_enrollments pce").
+		Distinct("pce.class_id").
+		Joins("JOIN program_classes pc ON pc.id = pce.class_id").
+		Where("pce.user_id = ? AND pc.canvas_provider_id IS NOT NULL AND pc.canvas_course_id IS NOT NULL", userID).
+		Pluck("pce.class_id", &classIDs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_enrollments")
+	}
+	return classIDs, nil
+}
+
+// GetCanvasEnrollmentSyncs returns what was pushed to Canvas for the class's residents, failures first
+func (db *DB) GetCanvasEnrollmentSyncs(args *models.QueryContext, classID int, status models.CanvasSyncStatus) ([]models.CanvasEnrollmentSync, error) {
+	syncs := make([]models.CanvasEnrollmentSync, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.CanvasEnrollmentSync{}).Where("class_id = ?", classID)
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "canvas_enrollment_syncs")
+	}
+	if err := tx.Preload("User").
+		Order("CASE WHEN status = 'failed' THEN 0 ELSE 1 END, updated_at DESC, id").
+		Limit(args.PerPage).Offset(args.CalcOffset()).
+		Find(&syncs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "canvas_enrollment_syncs")
+	}
+	return syncs, nil
+}
+
+// RetryCanvasEnrollmentSyncs clears the attempts on the class's failed pushes, including ones that gave up, so the next run tries them again
+func (db *DB) RetryCanvasEnrollmentSyncs(ctx context.Context, classID int) (int64, error) {
+	result := db.WithContext(ctx).Model(&models.CanvasEnrollmentSync{}).
+		Where("class_id = ? AND status = ?", classID, models.CanvasSyncFailed).
+		Update("attempts", 0)
+	if result.Error != nil {
+		return 0, newUpdateDBError(result.Error, "canvas_enrollment_syncs")
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) GetCertificateTemplates() ([]models.CertificateTemplate, error) {
+	var templates []models.CertificateTemplate
+	if err := db.Preload("Program").Order("id").Find(&templates).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "certificate_templates")
+	}
+	return templates, nil
+}
+
+func (db *DB) CreateCertificateTemplate(template *models.CertificateTemplate) error {
+	if err := db.Create(template).Error; err != nil {
+		return newCreateDBError(err, "certificate_templates")
+	}
+	return nil
+}
+
+func (db *DB) DeleteCertificateTemplate(id uint) error {
+	result := db.Delete(&models.CertificateTemplate{}, id)
+	if result.Error != nil {
+		return newDeleteDBError(result.Error, "certificate_templates")
+	}
+	if result.RowsAffected == 0 {
+		return newNotFoundDBError(gorm.ErrRecordNotFound, "certificate_templates")
+	}
+	return nil
+}
+
+// GetClassCompletions returns the class's program completions with their residents, oldest first
+func (db *DB) GetClassCompletions(classID uint) ([]models.ProgramCompletion, error) {
+	var completions []models.ProgramCompletion
+	if err := db.Preload("User").Where("program_class_id = ?", classID).Order("id").Find(&completions).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_completions")
+	}
+	return completions, nil
+}
+
+// GetCertificatesForCompletions maps each completion that already has a certificate to it
+func (db *DB) GetCertificatesForCompletions(completionIDs []uint) (map[uint]models.CompletionCertificate, error) {
+	var certificates []models.CompletionCertificate
+	if err := db.Where("program_completion_id IN ?", completionIDs).Find(&certificates).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "certificates")
+	}
+	byCompletion := make(map[uint]models.CompletionCertificate, len(certificates))
+	for _, certificate := range certificates {
+		byCompletion[certificate.ProgramCompletionID] = certificate
+	}
+	return byCompletion, nil
+}
+
+func (db *DB) CreateCertificates(certificates []models.CompletionCertificate) error {
+	if len(certificates) == 0 {
+		return nil
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&certificates).Error
+	}); err != nil {
+		return newCreateDBError(err, "certificates")
+	}
+	return nil
+}
+
+func (db *DB) GetCertificateByID(id uint) (*models.CompletionCertificate, error) {
+	var certificate models.CompletionCertificate
+	if err := db.First(&certificate, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "certificates")
+	}
+	return &certificate, nil
+}
+
+// GetCertificateByCode returns the certificate with the verification code, or nil when there is none
+func (db *DB) GetCertificateByCode(code string) (*models.CompletionCertificate, error) {
+	var certificate models.CompletionCertificate
+	err := db.Where("verification_code = ?", code).Take(&certificate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newGetRecordsDBError(err, "certificates")
+	}
+	return &certificate, nil
+}
+
+func (db *DB) GetCertificatesForUser(userID uint) ([]models.CompletionCertificate, error) {
+	var certificates []models.CompletionCertificate
+	if err := db.Where("user_id = ?", userID).Order("issued_at DESC").Find(&certificates).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "certificates")
+	}
+	return certificates, nil
+}
+
+func (db *DB) GetCertificatesForClass(classID uint) ([]models.CompletionCertificate, error) {
+	var certificates []models.CompletionCertificate
+	if err := db.Where("program_class_id = ?", classID).Order("resident_name").Find(&certificates).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "certificates")
+	}
+	return certificates, nil
+}
+
+func (db *DB) RevokeCertificate(certificate *models.CompletionCertificate) error {
+	now := time.Now()
+	if err := db.Model(certificate).Update("revoked_at", now).Error; err != nil {
+		return newUpdateDBError(err, "certificates")
+	}
+	certificate.RevokedAt = &now
+	return nil
+}
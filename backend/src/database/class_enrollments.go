@@ -4,6 +4,8 @@ import (
 	"UnlockEdv2/src/models"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 func (db *DB) GetProgramCompletionsForUser(args *models.QueryContext, userId int, classId *int) ([]models.ProgramCompletion, error) {
@@ -59,47 +61,139 @@ func (db *DB) GetProgramClassEnrollmentsForFacility(page, perPage int, facilityI
 	return total, content, nil
 }
 
-// Returns the number of enrollments that were skipped, and possible error
-func (db *DB) CreateProgramClassEnrollments(classID int, userIds []int) (int, error) {
+/*
+CreateProgramClassEnrollments enrolls the residents in the class until it is full and returns how many were turned away
+for lack of seats. Residents already enrolled are left as they are, and a resident waiting on the class's waitlist has
+their waitlisted enrollment converted rather than getting a second one. The eligibility overrides of the residents who
+were enrolled are recorded along with their enrollments.
+*/
+func (db *DB) CreateProgramClassEnrollments(classID int, userIds []int, overrides map[uint]string) (int, error) {
+	skipped := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		enrolled, turnedAway, err := enrollUsersInClass(tx, classID, userIds)
+		if err != nil {
+			return err
+		}
+		skipped = len(turnedAway)
+		if len(enrolled) == 0 && skipped > 0 {
+			return newNotFoundDBError(fmt.Errorf("class is full"), "program_classes")
+		}
+		return createEligibilityOverrides(tx, classID, overridesFor(overrides, enrolled))
+	})
+	if err != nil {
+		return len(userIds), err
+	}
+	return skipped, nil
+}
+
+/*
+EnrollOrWaitlistUsers fills the open seats in the class in the order the residents were given and places everyone
+else at the end of the class waitlist, all in one transaction. It returns how many residents were enrolled and how
+many were waitlisted.
+*/
+func (db *DB) EnrollOrWaitlistUsers(classID int, userIds []int, overrides map[uint]string) (int, int, error) {
+	var enrolled, waitlisted []uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var turnedAway []int
+		var err error
+		enrolled, turnedAway, err = enrollUsersInClass(tx, classID, userIds)
+		if err != nil {
+			return err
+		}
+		waitlisted, err = waitlistUsersForClass(tx, classID, turnedAway)
+		if err != nil {
+			return err
+		}
+		return createEligibilityOverrides(tx, classID, overridesFor(overrides, append(enrolled, waitlisted...)))
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(enrolled), len(waitlisted), nil
+}
+
+// enrollUsersInClass enrolls the residents while seats remain, returning the residents it enrolled and the ones turned away for lack of seats
+func enrollUsersInClass(tx *gorm.DB, classID int, userIds []int) ([]uint, []int, error) {
 	var result struct {
 		Available int
 		Status    models.ClassStatus
 	}
-	err := db.
+	err := tx.
 		Table("program_classes").
 		Select("program_classes.status, program_classes.capacity - COALESCE(COUNT(pce.id), 0) AS available").
 		Joins(`LEFT JOIN program_class_enrollments pce ON pce.class_id = program_classes.id
-			and pce.enrollment_status = 'Enrolled'`).
+			and pce.enrollment_status = 'Enrolled' and pce.deleted_at IS NULL`).
 		Where("program_classes.id = ?", classID).
 		Group("program_classes.status, program_classes.id, program_classes.capacity").
 		Scan(&result).Error
 	if err != nil {
-		return 0, newNotFoundDBError(err, "program_classes")
+		return nil, nil, newNotFoundDBError(err, "program_classes")
 	}
 
-	if result.Available <= 0 {
-		return len(userIds), newNotFoundDBError(fmt.Errorf("class is full"), "program_classes")
+	var existing []models.ProgramClassEnrollment
+	if err := tx.Select("id", "user_id", "enrollment_status").
+		Where("class_id = ? AND user_id IN (?) AND enrollment_status IN (?)", classID, userIds, []models.ProgramEnrollmentStatus{models.Enrolled, models.EnrollmentWaitlisted}).
+		Find(&existing).Error; err != nil {
+		return nil, nil, newGetRecordsDBError(err, "program class enrollments")
+	}
+	current := make(map[uint]models.ProgramClassEnrollment, len(existing))
+	for _, enrollment := range existing {
+		current[enrollment.UserID] = enrollment
 	}
 
-	enrollments := make([]models.ProgramClassEnrollment, 0, min(len(userIds), result.Available))
+	enrollments := make([]models.ProgramClassEnrollment, 0, len(userIds))
+	enrolled := make([]uint, 0, len(userIds))
+	turnedAway := make([]int, 0)
+	promoted := make([]uint, 0)
 	for _, uid := range userIds {
+		enrollment, found := current[uint(uid)]
+		if found && enrollment.EnrollmentStatus == models.Enrolled {
+			continue
+		}
 		if result.Available <= 0 {
-			break
+			turnedAway = append(turnedAway, uid)
+			continue
+		}
+		result.Available--
+		enrolled = append(enrolled, uint(uid))
+		// the resident counts as enrolled from here on, a repeated ID in the request is not enrolled twice
+		current[uint(uid)] = models.ProgramClassEnrollment{UserID: uint(uid), EnrollmentStatus: models.Enrolled}
+		if found {
+			promoted = append(promoted, enrollment.ID)
+			continue
 		}
 		enrollments = append(enrollments, models.ProgramClassEnrollment{
 			ClassID:          uint(classID),
 			UserID:           uint(uid),
 			EnrollmentStatus: models.Enrolled,
 		})
-		result.Available--
 	}
 
-	skipped := len(userIds) - len(enrollments)
-	if err := db.Create(&enrollments).Error; err != nil {
-		return 0, newCreateDBError(err, "class enrollment")
+	if len(promoted) > 0 {
+		updates := map[string]any{
+			"enrollment_status": models.Enrolled,
+			"waitlist_position": nil,
+			"change_reason":     "Enrolled from waitlist",
+		}
+		if actorID, ok := tx.Statement.Context.Value(models.UserIDKey).(uint); ok {
+			updates["update_user_id"] = actorID
+		}
+		if err := tx.Model(&models.ProgramClassEnrollment{}).
+			Where("id IN (?)", promoted).
+			Set("class_id", classID).
+			Updates(updates).Error; err != nil {
+			return nil, nil, newUpdateDBError(err, "class enrollment")
+		}
+		if err := renumberClassWaitlist(tx, uint(classID)); err != nil {
+			return nil, nil, err
+		}
 	}
-
-	return skipped, nil
+	if len(enrollments) > 0 {
+		if err := tx.Create(&enrollments).Error; err != nil {
+			return nil, nil, newCreateDBError(err, "class enrollment")
+		}
+	}
+	return enrolled, turnedAway, nil
 }
 
 func (db *DB) DeleteProgramClassEnrollments(id int) error {
@@ -193,13 +287,21 @@ func (db *DB) UpdateProgramClassEnrollments(classId int, userIds []int, status m
 			updates["update_user_id"] = userID
 		}
 	}
-	if err := db.Model(&models.ProgramClassEnrollment{}).
-		Where("class_id = ? AND user_id IN (?)", classId, userIds).
-		Set("class_id", classId).
-		Updates(updates).Error; err != nil {
-		return newUpdateDBError(err, "class enrollment status")
+	if status != models.EnrollmentWaitlisted {
+		updates["waitlist_position"] = nil
 	}
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ProgramClassEnrollment{}).
+			Where("class_id = ? AND user_id IN (?)", classId, userIds).
+			Set("class_id", classId).
+			Updates(updates).Error; err != nil {
+			return newUpdateDBError(err, "class enrollment status")
+		}
+		if !models.FreesClassSeat(status) {
+			return renumberClassWaitlist(tx, uint(classId))
+		}
+		return promoteFromWaitlist(tx, uint(classId))
+	})
 }
 
 func (db *DB) UpdateProgramClassEnrollmentDate(enrollmentId int, enrolledDate time.Time) error {
@@ -252,6 +354,10 @@ func (db *DB) UpdateProgramClasses(classIDs []int, classMap map[string]any) erro
 				Error; err != nil {
 				return newUpdateDBError(err, "class enrollment statuses")
 			}
+
+			if err := closeClassWaitlist(tx, uint(classID)); err != nil {
+				return err
+			}
 		}
 
 		// Fetch enrollments that will be used create program completions AFTER the update
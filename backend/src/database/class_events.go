@@ -348,7 +348,18 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 	if trans.Error != nil {
 		return NewDBError(trans.Error, "unable to start the database transaction")
 	}
+	if err := db.createOverrideEvents(trans, ctx, overrideEvents); err != nil {
+		trans.Rollback()
+		return err
+	}
+	if err := trans.Commit().Error; err != nil {
+		return NewDBError(err, "unable to commit the database transaction")
+	}
+	return nil
+}
 
+// createOverrideEvents saves the overrides within trans, which the caller commits or rolls back
+func (db *DB) createOverrideEvents(trans *gorm.DB, ctx *models.QueryContext, overrideEvents []*models.ProgramClassEventOverride) error {
 	var (
 		changeLogEntry   *models.ChangeLogEntry
 		eventDate        *string
@@ -368,7 +379,6 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 			if err := trans.Where("linked_override_event_id = ? AND is_cancelled = false AND id != ? AND deleted_at IS NULL",
 				*linkedOverrideID, overrideEvent.ID).
 				Delete(&models.ProgramClassEventOverride{}).Error; err != nil {
-				trans.Rollback()
 				return NewDBError(err, "unable to clean up old reschedule targets")
 			}
 		}
@@ -408,12 +418,10 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 					"linked_override_event_id": overrideEvent.LinkedOverrideEventID,
 					"instructor_id":            overrideEvent.InstructorID,
 				}).Error; err != nil {
-				trans.Rollback()
 				return newCreateDBError(err, "program_class_event_overrides")
 			}
 		} else {
 			if err := trans.Create(&overrideEvent).Error; err != nil {
-				trans.Rollback()
 				return newCreateDBError(err, "program_class_event_overrides")
 			}
 		}
@@ -425,7 +433,6 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 		} else if !overrideEvent.IsCancelled && len(overrideEvents) > 1 {
 			eventSummary, err := overrideEvent.GetRescheduleSummary(ctx.Timezone)
 			if err != nil {
-				trans.Rollback()
 				return NewDBError(err, "unable to parse event summary")
 			}
 			changeLogEntry.FieldName = "event_rescheduled"
@@ -458,11 +465,9 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 		if overrideEvent.IsCancelled || isOverrideUpdate { //delete attendance
 			eventDate, err = overrideEvent.GetFormattedOverrideDate("2006-01-02")
 			if err != nil {
-				trans.Rollback()
 				return NewDBError(err, "unable to parse override date")
 			}
 			if err := deleteEventAttedanceByDate(trans, overrideEvent.EventID, *eventDate); err != nil {
-				trans.Rollback()
 				return err
 			}
 		}
@@ -474,27 +479,20 @@ func (db *DB) CreateOverrideEvents(ctx *models.QueryContext, overrideEvents []*m
 		if err := trans.Unscoped().Model(&models.ProgramClassEventOverride{}).
 			Where("id IN ?", parentCancelIDs).
 			Update("deleted_at", nil).Error; err != nil {
-			trans.Rollback()
 			return NewDBError(err, "unable to restore parent cancel overrides")
 		}
 	}
 
 	if err := trans.Create(&changeLogEntry).Error; err != nil {
-		trans.Rollback()
 		return newCreateDBError(err, "change_log_entries")
 	}
 
 	if len(overrideEvents) > 0 {
 		if err := db.syncClassDateBoundaries(trans, overrideEvents[0].ClassID); err != nil {
-			trans.Rollback()
 			return err
 		}
 	}
 
-	//end transaction
-	if err := trans.Commit().Error; err != nil {
-		return NewDBError(err, "unable to commit the database transaction")
-	}
 	return nil
 }
 
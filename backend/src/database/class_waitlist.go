@@ -0,0 +1,289 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) GetClassWaitlist(args *models.QueryContext, classID int) ([]EnrollmentDetails, error) {
+	content := make([]EnrollmentDetails, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Table("program_class_enrollments pse").
+		Select("pse.*, u.name_first || ' ' || u.name_last as name_full, u.doc_id, c.name as class_name, c.start_dt").
+		Joins("JOIN program_classes c ON pse.class_id = c.id AND c.deleted_at IS NULL").
+		Joins("JOIN users u ON pse.user_id = u.id AND u.deleted_at IS NULL").
+		Where("pse.class_id = ? AND pse.enrollment_status = ? AND pse.deleted_at IS NULL", classID, models.EnrollmentWaitlisted)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "class waitlist")
+	}
+	if err := tx.Order("pse.waitlist_position ASC").
+		Limit(args.PerPage).
+		Offset(args.CalcOffset()).
+		Find(&content).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "class waitlist")
+	}
+	return content, nil
+}
+
+// AddUsersToClassWaitlist queues residents at the end of the class waitlist in the order given and records the eligibility
+// overrides of the residents it queued. Residents who are already enrolled in or waiting for the class are skipped; the number skipped is returned.
+func (db *DB) AddUsersToClassWaitlist(classID int, userIDs []int, overrides map[uint]string) (int, error) {
+	skipped := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		waitlisted, err := waitlistUsersForClass(tx, classID, userIDs)
+		if err != nil {
+			return err
+		}
+		skipped = len(userIDs) - len(waitlisted)
+		return createEligibilityOverrides(tx, classID, overridesFor(overrides, waitlisted))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return skipped, nil
+}
+
+// waitlistUsersForClass queues the residents who aren't already enrolled in or waiting for the class and returns the ones it queued
+func waitlistUsersForClass(tx *gorm.DB, classID int, userIDs []int) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	var existing []uint
+	if err := tx.Model(&models.ProgramClassEnrollment{}).
+		Where("class_id = ? AND user_id IN (?) AND enrollment_status IN (?)", classID, userIDs, []models.ProgramEnrollmentStatus{models.Enrolled, models.EnrollmentWaitlisted}).
+		Pluck("user_id", &existing).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program class enrollments")
+	}
+	alreadyQueued := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		alreadyQueued[id] = true
+	}
+	var lastPosition int
+	if err := tx.Model(&models.ProgramClassEnrollment{}).
+		Select("COALESCE(MAX(waitlist_position), 0)").
+		Where("class_id = ? AND enrollment_status = ?", classID, models.EnrollmentWaitlisted).
+		Scan(&lastPosition).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "class waitlist")
+	}
+	waitlisted := make([]models.ProgramClassEnrollment, 0, len(userIDs))
+	queued := make([]uint, 0, len(userIDs))
+	for _, uid := range userIDs {
+		if alreadyQueued[uint(uid)] {
+			continue
+		}
+		alreadyQueued[uint(uid)] = true
+		lastPosition++
+		position := lastPosition
+		queued = append(queued, uint(uid))
+		waitlisted = append(waitlisted, models.ProgramClassEnrollment{
+			ClassID:          uint(classID),
+			UserID:           uint(uid),
+			EnrollmentStatus: models.EnrollmentWaitlisted,
+			WaitlistPosition: &position,
+		})
+	}
+	if len(waitlisted) == 0 {
+		return queued, nil
+	}
+	if err := tx.Create(&waitlisted).Error; err != nil {
+		return nil, newCreateDBError(err, "class waitlist")
+	}
+	return queued, nil
+}
+
+// RemoveUserFromClassWaitlist takes a resident off the waitlist by cancelling their waitlisted enrollment
+func (db *DB) RemoveUserFromClassWaitlist(classID int, userID int, changeReason string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{
+			"enrollment_status": models.EnrollmentCancelled,
+			"waitlist_position": nil,
+			"change_reason":     changeReason,
+		}
+		if actorID, ok := tx.Statement.Context.Value(models.UserIDKey).(uint); ok {
+			updates["update_user_id"] = actorID
+		}
+		result := tx.Model(&models.ProgramClassEnrollment{}).
+			Where("class_id = ? AND user_id = ? AND enrollment_status = ?", classID, userID, models.EnrollmentWaitlisted).
+			Set("class_id", classID).
+			Updates(updates)
+		if result.Error != nil {
+			return newUpdateDBError(result.Error, "class waitlist")
+		}
+		if result.RowsAffected == 0 {
+			return newNotFoundDBError(gorm.ErrRecordNotFound, "class waitlist entry")
+		}
+		return renumberClassWaitlist(tx, uint(classID))
+	})
+}
+
+// promoteFromWaitlist fills any open seats in the class with the next eligible residents on its waitlist,
+// in queue order, and records each promotion in the resident's account history. Residents who have since
+// been deactivated or moved to another facility are passed over and left on the waitlist.
+func promoteFromWaitlist(tx *gorm.DB, classID uint) error {
+	var class models.ProgramClass
+	if err := tx.First(&class, "id = ?", classID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return newNotFoundDBError(err, "program class")
+	}
+	if class.CannotUpdateClass() {
+		return nil
+	}
+	var enrolled int64
+	if err := tx.Model(&models.ProgramClassEnrollment{}).
+		Where("class_id = ? AND enrollment_status = ?", classID, models.Enrolled).
+		Count(&enrolled).Error; err != nil {
+		return newGetRecordsDBError(err, "program class enrollments")
+	}
+	openSeats := int(class.Capacity - enrolled)
+	if openSeats <= 0 {
+		return nil
+	}
+
+	var candidates []models.ProgramClassEnrollment
+	if err := tx.Model(&models.ProgramClassEnrollment{}).
+		Joins("JOIN users u ON u.id = program_class_enrollments.user_id AND u.deleted_at IS NULL").
+		Where("program_class_enrollments.class_id = ? AND program_class_enrollments.enrollment_status = ?", classID, models.EnrollmentWaitlisted).
+		Where("u.deactivated_at IS NULL AND u.facility_id = ?", class.FacilityID).
+		Order("program_class_enrollments.waitlist_position ASC").
+		Limit(openSeats).
+		Find(&candidates).Error; err != nil {
+		return newGetRecordsDBError(err, "class waitlist")
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var adminID *uint
+	if userID, ok := tx.Statement.Context.Value(models.UserIDKey).(uint); ok {
+		adminID = &userID
+	}
+	for _, candidate := range candidates {
+		updates := map[string]any{
+			"enrollment_status": models.Enrolled,
+			"waitlist_position": nil,
+			"change_reason":     "Promoted from waitlist",
+		}
+		if adminID != nil {
+			updates["update_user_id"] = *adminID
+		}
+		if err := tx.Model(&models.ProgramClassEnrollment{}).
+			Where("id = ?", candidate.ID).
+			Set("class_id", classID).
+			Updates(updates).Error; err != nil {
+			return newUpdateDBError(err, "class waitlist")
+		}
+		history := models.NewUserAccountHistory(candidate.UserID, models.WaitlistPromoted, adminID, nil, &class.FacilityID)
+		history.ClassName = &class.Name
+		if err := tx.Create(history).Error; err != nil {
+			return newCreateDBError(err, "user_account_history")
+		}
+	}
+	return renumberClassWaitlist(tx, classID)
+}
+
+// closeClassWaitlist cancels the class's waitlist, nobody left on it can get a seat once the class is closed
+func closeClassWaitlist(tx *gorm.DB, classID uint) error {
+	if err := tx.
+		Model(&models.ProgramClassEnrollment{}).
+		Where("class_id = ? AND enrollment_status = ?", classID, models.EnrollmentWaitlisted).
+		Set("class_id", classID).
+		Updates(map[string]any{
+			"enrollment_status": models.EnrollmentCancelled,
+			"waitlist_position": nil,
+			"change_reason":     "Class closed before a seat opened",
+		}).
+		Error; err != nil {
+		return newUpdateDBError(err, "class waitlist")
+	}
+	return nil
+}
+
+// renumberClassWaitlist closes the gaps left in the queue after residents leave it, so positions stay 1..n
+func renumberClassWaitlist(tx *gorm.DB, classID uint) error {
+	var waiting []models.ProgramClassEnrollment
+	if err := tx.Model(&models.ProgramClassEnrollment{}).
+		Select("id", "waitlist_position").
+		Where("class_id = ? AND enrollment_status = ?", classID, models.EnrollmentWaitlisted).
+		Order("waitlist_position ASC, created_at ASC").
+		Find(&waiting).Error; err != nil {
+		return newGetRecordsDBError(err, "class waitlist")
+	}
+	for idx, entry := range waiting {
+		position := idx + 1
+		if entry.WaitlistPosition != nil && *entry.WaitlistPosition == position {
+			continue
+		}
+		if err := tx.Model(&models.ProgramClassEnrollment{}).
+			Where("id = ?", entry.ID).
+			UpdateColumn("waitlist_position", position).Error; err != nil {
+			return newUpdateDBError(err, "class waitlist")
+		}
+	}
+	return nil
+}
+
+/*
+withdrawOpenEnrollments ends the resident's open class enrollments within tx, limited to the classes at facilityID when it
+is set: enrolled classes move to status and waitlist spots are cancelled, then the waitlists they left are renumbered and
+the seats they freed are offered to the next resident waiting. Returns the classes whose seats were freed along with the
+number of enrollments ended.
+*/
+func withdrawOpenEnrollments(tx *gorm.DB, userID uint, facilityID *uint, status models.ProgramEnrollmentStatus, changeReason string, endedAt *time.Time) ([]uint, int64, error) {
+	var open []models.ProgramClassEnrollment
+	query := tx.Model(&models.ProgramClassEnrollment{}).
+		Select("program_class_enrollments.id", "program_class_enrollments.class_id", "program_class_enrollments.enrollment_status").
+		Where("program_class_enrollments.user_id = ? AND program_class_enrollments.enrollment_status IN ?", userID, []models.ProgramEnrollmentStatus{models.Enrolled, models.EnrollmentWaitlisted})
+	if facilityID != nil {
+		query = query.Joins("JOIN program_classes pc ON pc.id = program_class_enrollments.class_id").Where("pc.facility_id = ?", *facilityID)
+	}
+	if err := query.Find(&open).Error; err != nil {
+		return nil, 0, newGetRecordsDBError(err, "program_class_enrollments")
+	}
+	var enrolledIDs, waitlistedIDs, vacatedClassIDs, waitlistedClassIDs []uint
+	for _, enrollment := range open {
+		if enrollment.EnrollmentStatus == models.Enrolled {
+			enrolledIDs = append(enrolledIDs, enrollment.ID)
+			vacatedClassIDs = append(vacatedClassIDs, enrollment.ClassID)
+		} else {
+			waitlistedIDs = append(waitlistedIDs, enrollment.ID)
+			waitlistedClassIDs = append(waitlistedClassIDs, enrollment.ClassID)
+		}
+	}
+	if len(enrolledIDs) > 0 {
+		updateData := map[string]any{
+			"enrollment_status": status,
+			"change_reason":     changeReason,
+		}
+		if endedAt != nil {
+			updateData["enrollment_ended_at"] = *endedAt
+		}
+		if err := tx.Model(&models.ProgramClassEnrollment{}).Where("id IN ?", enrolledIDs).Updates(updateData).Error; err != nil {
+			return nil, 0, newUpdateDBError(err, "program_class_enrollments")
+		}
+	}
+	if len(waitlistedIDs) > 0 {
+		waitlistData := map[string]any{
+			"enrollment_status": models.EnrollmentCancelled,
+			"waitlist_position": nil,
+			"change_reason":     changeReason,
+		}
+		if err := tx.Model(&models.ProgramClassEnrollment{}).Where("id IN ?", waitlistedIDs).Updates(waitlistData).Error; err != nil {
+			return nil, 0, newUpdateDBError(err, "program_class_enrollments")
+		}
+	}
+	for _, classID := range waitlistedClassIDs {
+		if err := renumberClassWaitlist(tx, classID); err != nil {
+			return nil, 0, err
+		}
+	}
+	for _, classID := range vacatedClassIDs {
+		if err := promoteFromWaitlist(tx, classID); err != nil {
+			return nil, 0, err
+		}
+	}
+	return vacatedClassIDs, int64(len(open)), nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+/*
+CreateContentRequest saves a resident's request. When another resident already has an open request for the same
+content the new request is saved as its duplicate and takes on its status. A resident asking again for content they
+already have an open request for is refused.
+*/
+func (db *DB) CreateContentRequest(req *models.ContentRequest) error {
+	req.NormalizedContent = models.NormalizeContentRequest(req.Content)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var own int64
+		if err := tx.Model(&models.ContentRequest{}).
+			Where("user_id = ? AND normalized_content = ? AND status IN ?", req.UserID, req.NormalizedContent, models.OpenContentRequestStatuses).
+			Count(&own).Error; err != nil {
+			return newGetRecordsDBError(err, "content_requests")
+		}
+		if own > 0 {
+			return DBError{Status: http.StatusConflict, Message: "you have already requested this content",
+				InternalErr: fmt.Errorf("user %d already has an open request for %q", req.UserID, req.NormalizedContent)}
+		}
+		var primaries []models.ContentRequest
+		if err := tx.Where("normalized_content = ? AND duplicate_of_id IS NULL AND status IN ?", req.NormalizedContent, models.OpenContentRequestStatuses).
+			Order("created_at, id").Limit(1).Find(&primaries).Error; err != nil {
+			return newGetRecordsDBError(err, "content_requests")
+		}
+		req.Status = models.ContentRequestSubmitted
+		if len(primaries) > 0 {
+			req.DuplicateOfID = &primaries[0].ID
+			req.Status = primaries[0].Status
+		}
+		if err := tx.Create(req).Error; err != nil {
+			return newCreateDBError(err, "content_requests")
+		}
+		return nil
+	})
+}
+
+func (db *DB) GetContentRequest(id uint) (*models.ContentRequest, error) {
+	var req models.ContentRequest
+	if err := db.Preload("User").Preload("Facility").Preload("Duplicates", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("created_at")
+	}).Preload("Duplicates.User").Preload("Duplicates.Facility").First(&req, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "content_requests")
+	}
+	return &req, nil
+}
+
+func (db *DB) GetContentRequestsForUser(args *models.QueryContext, userID uint) ([]models.ContentRequest, error) {
+	requests := make([]models.ContentRequest, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.ContentRequest{}).Where("user_id = ?", userID)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "content_requests")
+	}
+	if err := tx.Order("created_at DESC").Limit(args.PerPage).Offset(args.CalcOffset()).Find(&requests).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "content_requests")
+	}
+	return requests, nil
+}
+
+/*
+GetContentRequestQueue lists the requests admins review, oldest first. Duplicates are folded into the request they
+duplicate and counted in its requester_count. Unless allFacilities is set only requests with a requester at the
+facility in the query context are listed.
+*/
+func (db *DB) GetContentRequestQueue(args *models.QueryContext, statuses []models.ContentRequestStatus, allFacilities bool) ([]models.ContentRequest, error) {
+	requests := make([]models.ContentRequest, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.ContentRequest{}).
+		Where("content_requests.duplicate_of_id IS NULL")
+	if len(statuses) > 0 {
+		tx = tx.Where("content_requests.status IN ?", statuses)
+	}
+	if !allFacilities {
+		tx = tx.Where(`EXISTS (SELECT 1 FROM content_requests d WHERE d.deleted_at IS NULL
+			AND (d.id = content_requests.id OR d.duplicate_of_id = content_requests.id) AND d.facility_id = ?)`, args.FacilityID)
+	}
+	if args.Search != "" {
+		tx = tx.Where("LOWER(content_requests.content) LIKE ?", args.SearchQuery())
+	}
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "content_requests")
+	}
+	if err := tx.Preload("User").Preload("Facility").
+		Select(`content_requests.*, 1 + (SELECT COUNT(*) FROM content_requests d
+			WHERE d.duplicate_of_id = content_requests.id AND d.deleted_at IS NULL) AS requester_count`).
+		Order("content_requests.created_at, content_requests.id").
+		Limit(args.PerPage).Offset(args.CalcOffset()).Find(&requests).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "content_requests")
+	}
+	return requests, nil
+}
+
+/*
+UpdateContentRequestReview saves the review of a request and carries it over to the request's open duplicates. It
+returns every request that was updated, the reviewed one first.
+*/
+func (db *DB) UpdateContentRequestReview(req *models.ContentRequest) ([]models.ContentRequest, error) {
+	fields := []string{"status", "review_note", "reviewed_by_id", "reviewed_at", "open_content_provider_id", "content_id", "content_type", "fulfilled_at"}
+	var updated []models.ContentRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var duplicates []models.ContentRequest
+		if err := tx.Where("duplicate_of_id = ? AND status IN ?", req.ID, models.OpenContentRequestStatuses).
+			Find(&duplicates).Error; err != nil {
+			return newGetRecordsDBError(err, "content_requests")
+		}
+		if err := tx.Model(&models.ContentRequest{}).Where("id = ?", req.ID).Select(fields).Updates(req).Error; err != nil {
+			return newUpdateDBError(err, "content_requests")
+		}
+		updated = append(updated, *req)
+		for _, duplicate := range duplicates {
+			duplicate.Status, duplicate.ReviewNote, duplicate.ReviewedByID, duplicate.ReviewedAt = req.Status, req.ReviewNote, req.ReviewedByID, req.ReviewedAt
+			duplicate.OpenContentProviderID, duplicate.ContentID, duplicate.ContentType, duplicate.FulfilledAt = req.OpenContentProviderID, req.ContentID, req.ContentType, req.FulfilledAt
+			if err := tx.Model(&models.ContentRequest{}).Where("id = ?", duplicate.ID).Select(fields).Updates(&duplicate).Error; err != nil {
+				return newUpdateDBError(err, "content_requests")
+			}
+			updated = append(updated, duplicate)
+		}
+		return nil
+	})
+	return updated, err
+}
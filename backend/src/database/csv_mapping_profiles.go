@@ -0,0 +1,49 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+)
+
+// GetCSVMappingProfiles returns the profiles usable at the facility, its own and the ones shared with every facility
+func (db *DB) GetCSVMappingProfiles(ctx context.Context, facilityID uint) ([]models.CSVMappingProfile, error) {
+	profiles := make([]models.CSVMappingProfile, 0)
+	if err := db.WithContext(ctx).
+		Where("facility_id = ? OR facility_id IS NULL", facilityID).
+		Order("name, id").
+		Find(&profiles).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "csv_mapping_profiles")
+	}
+	return profiles, nil
+}
+
+func (db *DB) GetCSVMappingProfile(ctx context.Context, id uint) (*models.CSVMappingProfile, error) {
+	var profile models.CSVMappingProfile
+	if err := db.WithContext(ctx).First(&profile, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "csv_mapping_profiles")
+	}
+	return &profile, nil
+}
+
+func (db *DB) CreateCSVMappingProfile(ctx context.Context, profile *models.CSVMappingProfile) error {
+	if err := db.WithContext(ctx).Create(profile).Error; err != nil {
+		return newCreateDBError(err, "csv_mapping_profiles")
+	}
+	return nil
+}
+
+func (db *DB) UpdateCSVMappingProfile(ctx context.Context, profile *models.CSVMappingProfile) error {
+	if err := db.WithContext(ctx).Model(profile).
+		Select("name", "facility_id", "columns", "update_user_id").
+		Updates(profile).Error; err != nil {
+		return newUpdateDBError(err, "csv_mapping_profiles")
+	}
+	return nil
+}
+
+func (db *DB) DeleteCSVMappingProfile(ctx context.Context, id uint) error {
+	if err := db.WithContext(ctx).Delete(&models.CSVMappingProfile{}, id).Error; err != nil {
+		return newDeleteDBError(err, "csv_mapping_profiles")
+	}
+	return nil
+}
@@ -54,38 +54,43 @@ func (db *DB) LogUserAttendance(attendanceParams []models.ProgramClassEventAtten
 		if existingRow {
 			att.UpdateUserID = &updateUserID
 		}
-
-		// include deleted_at so re-marking a previously soft-deleted record revives it
-		// (excluded.deleted_at is NULL on insert) instead of updating a hidden, soft-deleted row
-		if err := tx.
-			Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "event_id"}, {Name: "user_id"}, {Name: "date"}},
-				DoUpdates: clause.AssignmentColumns([]string{"attendance_status", "note", "reason_category", "check_in_at", "check_out_at", "minutes_attended", "scheduled_minutes", "update_user_id", "deleted_at"}),
-			}).
-			Create(&att).Error; err != nil {
+		if err := recordAttendance(tx, &att, adminID, className); err != nil {
 			tx.Rollback()
-			return newCreateDBError(err, "upserting attendance record")
+			return err
 		}
+	}
 
-		sessionDateParsed, err := time.ParseInLocation("2006-01-02", att.Date, time.Local)
-		if err != nil {
-			tx.Rollback()
-			return NewDBError(err, "invalid session date format")
-		}
+	if err := tx.Commit().Error; err != nil {
+		return NewDBError(err, "unable to commit DB transaction")
+	}
+	return nil
+}
 
-		history := models.NewUserAccountHistory(att.UserID, models.AttendanceRecorded, adminID, nil, nil)
-		history.AttendanceStatus = att.AttendanceStatus
-		history.ClassName = &className
-		history.SessionDate = &sessionDateParsed
+// recordAttendance upserts an attendance mark and writes its AttendanceRecorded history entry
+func recordAttendance(tx *gorm.DB, att *models.ProgramClassEventAttendance, adminID *uint, className string) error {
+	// include deleted_at so re-marking a previously soft-deleted record revives it
+	// (excluded.deleted_at is NULL on insert) instead of updating a hidden, soft-deleted row
+	if err := tx.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}, {Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"attendance_status", "note", "reason_category", "check_in_at", "check_out_at", "minutes_attended", "scheduled_minutes", "update_user_id", "deleted_at"}),
+		}).
+		Create(att).Error; err != nil {
+		return newCreateDBError(err, "upserting attendance record")
+	}
 
-		if err := tx.Create(history).Error; err != nil {
-			tx.Rollback()
-			return newCreateDBError(err, "user_account_history")
-		}
+	sessionDateParsed, err := time.ParseInLocation("2006-01-02", att.Date, time.Local)
+	if err != nil {
+		return NewDBError(err, "invalid session date format")
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return NewDBError(err, "unable to commit DB transaction")
+	history := models.NewUserAccountHistory(att.UserID, models.AttendanceRecorded, adminID, nil, nil)
+	history.AttendanceStatus = att.AttendanceStatus
+	history.ClassName = &className
+	history.SessionDate = &sessionDateParsed
+
+	if err := tx.Create(history).Error; err != nil {
+		return newCreateDBError(err, "user_account_history")
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"UnlockEdv2/src/models"
+	"slices"
 
 	"gorm.io/gorm/clause"
 )
@@ -51,6 +52,23 @@ func (db *DB) UpsertFacilityVisibilityStatuses(args *models.QueryContext, status
 	return nil
 }
 
+// GetFacilitiesNotShowingContent returns those of the facilities where the content is not visible to residents
+func (db *DB) GetFacilitiesNotShowingContent(providerID, contentID uint, facilityIDs []uint) ([]uint, error) {
+	var visible []uint
+	if err := db.Model(&models.FacilityVisibilityStatus{}).
+		Where("open_content_provider_id = ? AND content_id = ? AND facility_id IN ? AND visibility_status = true", providerID, contentID, facilityIDs).
+		Pluck("facility_id", &visible).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "facility_visibility_statuses")
+	}
+	hidden := make([]uint, 0, len(facilityIDs))
+	for _, id := range facilityIDs {
+		if !slices.Contains(visible, id) && !slices.Contains(hidden, id) {
+			hidden = append(hidden, id)
+		}
+	}
+	return hidden, nil
+}
+
 // SQL fragment computing how many non-deleted facilities have this row visible.
 func visibleFacilityCountSubquery(table string) string {
 	return `(
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetInstructorAvailabilityProfile returns an instructor's weekly windows, workload limit and the blackouts that end after since
+func (db *DB) GetInstructorAvailabilityProfile(instructorID uint, since time.Time) (*models.InstructorAvailabilityProfile, error) {
+	profile := &models.InstructorAvailabilityProfile{InstructorID: instructorID}
+	if err := db.Where("instructor_id = ?", instructorID).
+		Order("day_of_week ASC, start_time ASC").
+		Find(&profile.Windows).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "instructor_availabilities")
+	}
+	if err := db.Where("instructor_id = ? AND ends_at > ?", instructorID, since).
+		Order("starts_at ASC").
+		Find(&profile.Blackouts).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "instructor_blackouts")
+	}
+	var limit models.InstructorWorkloadLimit
+	err := db.Where("instructor_id = ?", instructorID).Take(&limit).Error
+	switch {
+	case err == nil:
+		profile.MaxWeeklyHours = &limit.MaxWeeklyHours
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, newGetRecordsDBError(err, "instructor_workload_limits")
+	}
+	return profile, nil
+}
+
+// ReplaceInstructorAvailability swaps an instructor's weekly windows for the given ones and sets, or with nil clears, their weekly hour limit
+func (db *DB) ReplaceInstructorAvailability(instructorID uint, windows []models.InstructorAvailability, maxWeeklyHours *float64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("instructor_id = ?", instructorID).Delete(&models.InstructorAvailability{}).Error; err != nil {
+			return newDeleteDBError(err, "instructor_availabilities")
+		}
+		for idx := range windows {
+			windows[idx].ID = 0
+			windows[idx].InstructorID = instructorID
+		}
+		if len(windows) > 0 {
+			if err := tx.Create(&windows).Error; err != nil {
+				return newCreateDBError(err, "instructor_availabilities")
+			}
+		}
+		if maxWeeklyHours == nil {
+			if err := tx.Where("instructor_id = ?", instructorID).Delete(&models.InstructorWorkloadLimit{}).Error; err != nil {
+				return newDeleteDBError(err, "instructor_workload_limits")
+			}
+			return nil
+		}
+		limit := models.InstructorWorkloadLimit{InstructorID: instructorID, MaxWeeklyHours: *maxWeeklyHours}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "instructor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"max_weekly_hours", "updated_at"}),
+		}).Create(&limit).Error; err != nil {
+			return newUpdateDBError(err, "instructor_workload_limits")
+		}
+		return nil
+	})
+}
+
+func (db *DB) CreateInstructorBlackout(blackout *models.InstructorBlackout) error {
+	if err := Validate().Struct(blackout); err != nil {
+		return newCreateDBError(err, "instructor_blackouts")
+	}
+	if err := db.Create(blackout).Error; err != nil {
+		return newCreateDBError(err, "instructor_blackouts")
+	}
+	return nil
+}
+
+func (db *DB) DeleteInstructorBlackout(instructorID, blackoutID uint) error {
+	result := db.Where("instructor_id = ?", instructorID).Delete(&models.InstructorBlackout{}, blackoutID)
+	if result.Error != nil {
+		return newDeleteDBError(result.Error, "instructor_blackouts")
+	}
+	if result.RowsAffected == 0 {
+		return newNotFoundDBError(gorm.ErrRecordNotFound, "instructor_blackouts")
+	}
+	return nil
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func checkInstructorRRuleConflicts(db *DB, w *conflictWindow, req *models.ConflictCheckRequest) ([]models.RoomConflict, error) {
-	bookings, err := db.getInstructorBookingsInRange(req.FacilityID, req.InstructorID, w.rangeStart, w.rangeEnd, w.facilityTZ)
+	bookings, err := db.getInstructorBookingsInRange(req.FacilityID, req.InstructorID, w.bookingsFrom, w.rangeEnd, w.facilityTZ)
 	if err != nil {
 		return nil, err
 	}
@@ -127,3 +127,110 @@ func expandEventToInstructorBookings(event models.ProgramClassEvent, rangeStart,
 
 	return bookings, nil
 }
+
+// CheckInstructorAvailability reports only availability and workload conflicts for the proposed schedule, for callers
+// that check room bookings separately
+func (db *DB) CheckInstructorAvailability(req *models.ConflictCheckRequest) ([]models.RoomConflict, error) {
+	if req.InstructorID == 0 {
+		return nil, nil
+	}
+	w, err := db.prepareConflictWindow(req)
+	if err != nil {
+		return nil, err
+	}
+	return checkInstructorAvailabilityConflicts(db, w, req)
+}
+
+/*
+checkInstructorAvailabilityConflicts reports each occurrence that falls outside the instructor's weekly availability
+or inside one of their blackouts, and each week in which the proposed sessions would take the instructor past their
+weekly hour limit. Sessions of the excluded event or class are treated as replaced by the proposal: from its first
+occurrence onward for a series, or on the same day for a single occurrence such as an override.
+*/
+func checkInstructorAvailabilityConflicts(db *DB, w *conflictWindow, req *models.ConflictCheckRequest) ([]models.RoomConflict, error) {
+	if len(w.occurrences) == 0 {
+		return nil, nil
+	}
+	first := w.occurrences[0].In(w.tz)
+	last := w.occurrences[len(w.occurrences)-1].In(w.tz)
+	profile, err := db.GetInstructorAvailabilityProfile(req.InstructorID, first)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []models.RoomConflict
+	for _, occ := range w.occurrences {
+		if len(conflicts) >= maxConflictsToReturn {
+			return conflicts, nil
+		}
+		start := occ.In(w.tz)
+		end := start.Add(w.duration)
+		if available, reason := profile.IsAvailable(start, end); !available {
+			conflicts = append(conflicts, models.RoomConflict{
+				StartTime:    start,
+				EndTime:      end,
+				ConflictType: models.ConflictTypeInstructorUnavailable,
+				Message:      reason,
+			})
+		}
+	}
+	if profile.MaxWeeklyHours == nil {
+		return conflicts, nil
+	}
+
+	bookings, err := db.getInstructorBookingsInRange(req.FacilityID, req.InstructorID, startOfWeek(first), startOfWeek(last).AddDate(0, 0, 7), w.facilityTZ)
+	if err != nil {
+		return nil, err
+	}
+	proposedDays := make(map[string]bool, len(w.occurrences))
+	for _, occ := range w.occurrences {
+		proposedDays[occ.In(w.tz).Format("2006-01-02")] = true
+	}
+	firstDay := first.Format("2006-01-02")
+	replaced := func(booking models.RoomBooking) bool {
+		excluded := (req.ExcludeEventID != nil && booking.EventID == *req.ExcludeEventID) ||
+			(req.ExcludeClassID != nil && booking.ClassID == *req.ExcludeClassID)
+		if !excluded {
+			return false
+		}
+		day := booking.StartTime.In(w.tz).Format("2006-01-02")
+		if len(w.occurrences) > 1 {
+			return day >= firstDay
+		}
+		return proposedDays[day]
+	}
+
+	scheduled := make(map[time.Time]float64)
+	for _, booking := range bookings {
+		if replaced(booking) {
+			continue
+		}
+		scheduled[startOfWeek(booking.StartTime.In(w.tz))] += booking.EndTime.Sub(booking.StartTime).Hours()
+	}
+	var weeks []time.Time
+	proposed := make(map[time.Time]float64)
+	for _, occ := range w.occurrences {
+		week := startOfWeek(occ.In(w.tz))
+		if _, seen := proposed[week]; !seen {
+			weeks = append(weeks, week)
+		}
+		proposed[week] += w.duration.Hours()
+	}
+	limit := *profile.MaxWeeklyHours
+	for _, week := range weeks {
+		if len(conflicts) >= maxConflictsToReturn {
+			break
+		}
+		total := scheduled[week] + proposed[week]
+		if total <= limit {
+			continue
+		}
+		conflicts = append(conflicts, models.RoomConflict{
+			StartTime:    week,
+			EndTime:      week.AddDate(0, 0, 7),
+			ConflictType: models.ConflictTypeInstructorOverHours,
+			Message:      fmt.Sprintf("the week of %s would have %.1f teaching hours, over the instructor's limit of %.1f", week.Format("Jan 2, 2006"), total, limit),
+		})
+	}
+	return conflicts, nil
+}
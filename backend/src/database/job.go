@@ -3,6 +3,7 @@ package database
 import (
 	"UnlockEdv2/src/models"
 	"context"
+	"time"
 )
 
 func (db *DB) GetRunnableTask(ctx context.Context, jobType models.JobType) (*models.RunnableTask, error) {
@@ -14,3 +15,16 @@ func (db *DB) GetRunnableTask(ctx context.Context, jobType models.JobType) (*mod
 	}
 	return task, nil
 }
+
+// FinishRunnableTask returns a system job's task to pending once the backend has handled it, as the middleware does
+// for the jobs it handles
+func (db *DB) FinishRunnableTask(ctx context.Context, jobID string, success bool) error {
+	updates := map[string]any{"status": models.StatusPending}
+	if success {
+		updates["last_run"] = time.Now()
+	}
+	if err := db.WithContext(ctx).Model(&models.RunnableTask{}).Where("job_id = ?", jobID).Updates(updates).Error; err != nil {
+		return newUpdateDBError(err, "runnable_tasks")
+	}
+	return nil
+}
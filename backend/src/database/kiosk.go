@@ -0,0 +1,152 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (db *DB) CreateKioskSession(session *models.KioskSession) error {
+	if err := db.Create(session).Error; err != nil {
+		return newCreateDBError(err, "kiosk_sessions")
+	}
+	return nil
+}
+
+func (db *DB) GetKioskSession(id uint) (*models.KioskSession, error) {
+	var session models.KioskSession
+	if err := db.Preload("Class").Preload("Event.Overrides").First(&session, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "kiosk_sessions")
+	}
+	return &session, nil
+}
+
+// GetKioskSessionAttendance returns the attendance recorded for the session's event and date, residents first by name
+func (db *DB) GetKioskSessionAttendance(session *models.KioskSession) ([]models.ProgramClassEventAttendance, error) {
+	var attendance []models.ProgramClassEventAttendance
+	if err := db.Preload("User").
+		Joins("JOIN users u ON u.id = program_class_event_attendance.user_id").
+		Where("program_class_event_attendance.event_id = ? AND program_class_event_attendance.date = ?", session.EventID, session.Date).
+		Order("u.name_last, u.name_first").
+		Find(&attendance).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_event_attendance")
+	}
+	return attendance, nil
+}
+
+/*
+GetKioskResident finds the active resident at the facility with the DOC ID who is enrolled in the class. The lookup
+is the same not found error whichever of these fails, so a kiosk does not reveal which DOC IDs exist.
+*/
+func (db *DB) GetKioskResident(facilityID, classID uint, docID string) (*models.User, error) {
+	var user models.User
+	if err := db.Where("doc_id = ? AND facility_id = ? AND role = ? AND deactivated_at IS NULL", docID, facilityID, models.Student).
+		Where("EXISTS (SELECT 1 FROM program_class_enrollments e WHERE e.user_id = users.id AND e.class_id = ? AND e.enrollment_status = ?)", classID, models.Enrolled).
+		First(&user).Error; err != nil {
+		return nil, newNotFoundDBError(err, "users")
+	}
+	return &user, nil
+}
+
+func (db *DB) UpdateKioskPin(user *models.User) error {
+	if err := db.Model(user).Select("kiosk_pin_hash", "kiosk_pin_failed_attempts").Updates(user).Error; err != nil {
+		return newUpdateDBError(err, "users")
+	}
+	return nil
+}
+
+// RecordKioskPinAttempt counts a wrong PIN against the resident, or clears the count after a correct one
+func (db *DB) RecordKioskPinAttempt(user *models.User, correct bool) error {
+	attempts := gorm.Expr("kiosk_pin_failed_attempts + 1")
+	if correct {
+		if user.KioskPinFailedAttempts == 0 {
+			return nil
+		}
+		attempts = gorm.Expr("0")
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).
+		UpdateColumn("kiosk_pin_failed_attempts", attempts).Error; err != nil {
+		return newUpdateDBError(err, "users")
+	}
+	return nil
+}
+
+// RecordKioskSessionFailure counts an incorrect DOC ID or PIN against the kiosk, starting a new window once the last one is over
+func (db *DB) RecordKioskSessionFailure(sessionID uint, now time.Time) error {
+	windowStart := now.Add(-models.KioskAttemptWindow)
+	if err := db.Model(&models.KioskSession{}).Where("id = ?", sessionID).UpdateColumns(map[string]any{
+		"failed_attempts":       gorm.Expr("CASE WHEN failed_attempts_since IS NULL OR failed_attempts_since <= ? THEN 1 ELSE failed_attempts + 1 END", windowStart),
+		"failed_attempts_since": gorm.Expr("CASE WHEN failed_attempts_since IS NULL OR failed_attempts_since <= ? THEN ? ELSE failed_attempts_since END", windowStart, now),
+	}).Error; err != nil {
+		return newUpdateDBError(err, "kiosk_sessions")
+	}
+	return nil
+}
+
+// GetKioskAttendance returns the resident's attendance for the session, or nil when none has been recorded
+func (db *DB) GetKioskAttendance(session *models.KioskSession, userID uint) (*models.ProgramClassEventAttendance, error) {
+	var attendance []models.ProgramClassEventAttendance
+	if err := db.Where("event_id = ? AND user_id = ? AND date = ?", session.EventID, userID, session.Date).
+		Limit(1).Find(&attendance).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_event_attendance")
+	}
+	if len(attendance) == 0 {
+		return nil, nil
+	}
+	return &attendance[0], nil
+}
+
+/*
+RecordKioskAttendance saves a check-in or check-out made on a kiosk. The attendance row is locked and compared with
+the one the caller read, so two taps on the device that race each other cannot both check the resident in.
+*/
+func (db *DB) RecordKioskAttendance(att *models.ProgramClassEventAttendance, previousCheckIn *string, className string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.ProgramClassEventAttendance
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("event_id = ? AND user_id = ? AND date = ?", att.EventID, att.UserID, att.Date).
+			Limit(1).Find(&existing).Error; err != nil {
+			return newGetRecordsDBError(err, "program_class_event_attendance")
+		}
+		var current *string
+		if len(existing) > 0 {
+			current = existing[0].CheckInAt
+		}
+		if (current == nil) != (previousCheckIn == nil) || (current != nil && *current != *previousCheckIn) {
+			return DBError{Status: http.StatusConflict, Message: "attendance changed while checking in, please try again",
+				InternalErr: fmt.Errorf("check in for user %d on event %d changed", att.UserID, att.EventID)}
+		}
+		return recordAttendance(tx, att, nil, className)
+	})
+}
+
+/*
+FinalizeKioskSession closes the session and saves the attendance the instructor's review produced, check-outs for
+residents still checked in and absences for residents who never checked in.
+*/
+func (db *DB) FinalizeKioskSession(session *models.KioskSession, attendance []models.ProgramClassEventAttendance, adminID uint, className string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.KioskSession{}).Where("id = ? AND finalized_at IS NULL", session.ID).
+			Updates(map[string]any{"finalized_at": now, "finalized_by_id": adminID, "update_user_id": adminID})
+		if result.Error != nil {
+			return newUpdateDBError(result.Error, "kiosk_sessions")
+		}
+		if result.RowsAffected == 0 {
+			return DBError{Status: http.StatusConflict, Message: "kiosk session is already finalized",
+				InternalErr: fmt.Errorf("kiosk session %d already finalized", session.ID)}
+		}
+		for i := range attendance {
+			attendance[i].UpdateUserID = &adminID
+			if err := recordAttendance(tx, &attendance[i], &adminID, className); err != nil {
+				return err
+			}
+		}
+		session.FinalizedAt, session.FinalizedByID = &now, &adminID
+		return nil
+	})
+}
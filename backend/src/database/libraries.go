@@ -3,6 +3,7 @@ package database
 import (
 	"UnlockEdv2/src/models"
 	"fmt"
+	"slices"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -166,8 +167,45 @@ func (db *DB) GetLibraryByID(id int) (*models.Library, error) {
 	return &library, nil
 }
 
-func (db *DB) OpenContentTitleSearch(args *models.QueryContext) ([]models.OpenContentItem, error) {
+// postgres text search configurations for the library languages we carry, other languages are matched without stemming
+var textSearchConfigs = map[string]string{
+	"ara": "arabic",
+	"deu": "german",
+	"eng": "english",
+	"fra": "french",
+	"ita": "italian",
+	"nld": "dutch",
+	"por": "portuguese",
+	"rus": "russian",
+	"spa": "spanish",
+}
+
+func textSearchConfig(language string) string {
+	if config, ok := textSearchConfigs[language]; ok {
+		return config
+	}
+	return "simple"
+}
+
+// OpenContentTitleSearch searches the titles and descriptions of visible videos and of visible libraries in any of
+// the given languages, stemming each library with the text search configuration of its language
+func (db *DB) OpenContentTitleSearch(args *models.QueryContext, languages []string) ([]models.OpenContentItem, error) {
 	var items []models.OpenContentItem
+	configs := make([]string, 0, len(languages))
+	for _, language := range languages {
+		if config := textSearchConfig(language); !slices.Contains(configs, config) {
+			configs = append(configs, config)
+		}
+	}
+	if len(configs) == 0 {
+		configs = append(configs, "english")
+	}
+	libraryMatches := make([]string, 0, len(configs))
+	queryArgs := []any{args.FacilityID, args.Search, args.FacilityID, languages}
+	for _, config := range configs {
+		libraryMatches = append(libraryMatches, fmt.Sprintf("to_tsvector('%[1]s', l.title || ' ' || l.description) @@ plainto_tsquery('%[1]s', ?)", config))
+		queryArgs = append(queryArgs, args.Search)
+	}
 	searchQuery := `SELECT
 			'video' AS content_type,
 			v.id as content_id,
@@ -179,6 +217,7 @@ func (db *DB) OpenContentTitleSearch(args *models.QueryContext) ([]models.OpenCo
 			v.open_content_provider_id,
 			NULL AS provider_name,
 			v.channel_title,
+			NULL AS language,
 			v.created_at
 			FROM videos v
         LEFT OUTER JOIN facility_visibility_statuses fvs
@@ -201,15 +240,17 @@ func (db *DB) OpenContentTitleSearch(args *models.QueryContext) ([]models.OpenCo
 			l.open_content_provider_id,
 			'kiwix' AS provider_name,
 			NULL AS channel_title,
+			l.language,
 			l.created_at
 		FROM libraries l
 		left outer join facility_visibility_statuses fvs on fvs.open_content_provider_id = l.open_content_provider_id
 			and fvs.content_id = l.id
 			and fvs.facility_id = ?
 		WHERE fvs.visibility_status = true
-			and to_tsvector('english', l.title || ' ' || l.description) @@ plainto_tsquery('english', ?)`
+			and l.language IN ?
+			and (` + strings.Join(libraryMatches, " OR ") + `)`
 
-	tx := db.WithContext(args.Ctx).Raw(searchQuery, args.FacilityID, args.Search, args.FacilityID, args.Search)
+	tx := db.WithContext(args.Ctx).Raw(searchQuery, queryArgs...)
 	if err := tx.Scan(&items).Error; err != nil {
 		log.Errorln("Unable to perform content search")
 		return nil, newNotFoundDBError(err, "content search")
@@ -217,36 +258,60 @@ func (db *DB) OpenContentTitleSearch(args *models.QueryContext) ([]models.OpenCo
 	return items, nil
 }
 
-func (db *DB) GetLibrariesByIDsAndLang(ids []int, language string) ([]models.Library, error) {
+// GetLibrariesByIDsAndLangs retrieves the libraries with the given IDs. A single library is returned whatever its
+// language, several are limited to the given languages.
+func (db *DB) GetLibrariesByIDsAndLangs(ids []int, languages []string) ([]models.Library, error) {
 	var libraries []models.Library
 	tx := db.Preload("OpenContentProvider").Where("id in ?", ids)
 	if len(ids) > 1 {
-		tx.Where("language = ?", language)
+		tx.Where("language IN ?", languages)
 	}
 	if err := tx.Find(&libraries).Error; err != nil {
-		log.Errorln("unable to find libraries with these IDs with language as ", language)
+		log.Errorln("unable to find libraries with these IDs with languages ", languages)
 		return nil, newNotFoundDBError(err, "libraries")
 	}
 	return libraries, nil
 }
 
-// Retrieves all libraries by langauge using the given paramenters. A way to find out the different language values is to execute
-// an SQL query against the libraries table to see the different language values (column name is language).
-// language - the language of the library to retrieve
-func (db *DB) GetAllLibrariesByLang(args *models.QueryContext, language string) ([]models.Library, error) {
+// Retrieves all visible libraries in any of the given languages. GetFacilityLibraryLanguages lists the languages
+// of the libraries a facility can see.
+func (db *DB) GetAllLibrariesByLangs(args *models.QueryContext, languages []string) ([]models.Library, error) {
 	var libraries []models.Library
 	tx := db.WithContext(args.Ctx).Model(&models.Library{}).Preload("OpenContentProvider").Select("libraries.*").
 		Joins(`left outer join facility_visibility_statuses fvs on fvs.open_content_provider_id = libraries.open_content_provider_id
 			and fvs.content_id = libraries.id
 			and fvs.facility_id = ?`, args.FacilityID).
-		Where("fvs.visibility_status = true and libraries.language = ?", language)
+		Where("fvs.visibility_status = true and libraries.language IN ?", languages)
 	if err := tx.Find(&libraries).Error; err != nil {
-		log.Errorln("unable to find libraries that are visible with language as ", language)
+		log.Errorln("unable to find libraries that are visible with languages ", languages)
 		return nil, newNotFoundDBError(err, "libraries")
 	}
 	return libraries, nil
 }
 
+// GetFacilityLibraryLanguages counts the libraries visible at the facility per language, along with how often the
+// facility's residents opened them, most used first
+func (db *DB) GetFacilityLibraryLanguages(args *models.QueryContext) ([]models.LibraryLanguage, error) {
+	var languages []models.LibraryLanguage
+	if err := db.WithContext(args.Ctx).Model(&models.Library{}).
+		Select(`libraries.language,
+			COUNT(DISTINCT libraries.id) AS libraries,
+			COUNT(oca.id) AS activity`).
+		Joins(`join facility_visibility_statuses fvs on fvs.open_content_provider_id = libraries.open_content_provider_id
+			and fvs.content_id = libraries.id
+			and fvs.facility_id = ?`, args.FacilityID).
+		Joins(`left outer join open_content_activities oca on oca.open_content_provider_id = libraries.open_content_provider_id
+			and oca.content_id = libraries.id
+			and oca.facility_id = ?`, args.FacilityID).
+		Where("fvs.visibility_status = true and libraries.language IS NOT NULL and libraries.language <> ''").
+		Group("libraries.language").
+		Order("activity DESC, libraries DESC, libraries.language").
+		Scan(&languages).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "libraries")
+	}
+	return languages, nil
+}
+
 func (db *DB) ToggleVisibilityAndRetrieveLibrary(id int, args *models.QueryContext) (*models.Library, error) {
 	var library models.Library
 	query := db.WithContext(args.Ctx).Model(&models.Library{}).Preload("OpenContentProvider").
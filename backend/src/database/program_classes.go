@@ -198,7 +198,7 @@ func (db *DB) UpdateProgramClass(content *models.ProgramClass, id int, conflictR
 		}
 	}
 
-	originalStatus := existing.Status
+	originalStatus, originalCapacity := existing.Status, existing.Capacity
 
 	models.UpdateStruct(existing, content)
 	existing.ID = existingID
@@ -275,6 +275,11 @@ func (db *DB) UpdateProgramClass(content *models.ProgramClass, id int, conflictR
 			return nil, nil, newUpdateDBError(err, "class enrollment statuses")
 		}
 
+		if err := closeClassWaitlist(trans, existing.ID); err != nil {
+			trans.Rollback()
+			return nil, nil, err
+		}
+
 		if newStatus == models.Completed {
 			var completedEnrollments []models.ProgramClassEnrollment
 			if err := trans.
@@ -319,6 +324,14 @@ func (db *DB) UpdateProgramClass(content *models.ProgramClass, id int, conflictR
 		}
 	}
 
+	if existing.Capacity > originalCapacity {
+		// the added seats go to the residents waiting for one
+		if err := promoteFromWaitlist(trans, existing.ID); err != nil {
+			trans.Rollback()
+			return nil, nil, err
+		}
+	}
+
 	if len(allChanges) > 0 {
 		if err := trans.Create(&allChanges).Error; err != nil {
 			trans.Rollback()
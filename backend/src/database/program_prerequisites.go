@@ -0,0 +1,212 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) GetProgramPrerequisites(programID int) ([]models.ProgramPrerequisite, error) {
+	prerequisites := make([]models.ProgramPrerequisite, 0)
+	if err := db.Preload("PrerequisiteProgram").
+		Where("program_id = ?", programID).
+		Find(&prerequisites).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program prerequisites")
+	}
+	return prerequisites, nil
+}
+
+// SetProgramPrerequisites replaces the prerequisites of a program, refusing any set that
+// would make a program its own prerequisite either directly or through a chain of programs
+func (db *DB) SetProgramPrerequisites(programID int, prerequisiteIDs []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if slices.Contains(prerequisiteIDs, uint(programID)) {
+			return newBadRequestDBError(errors.New("program cannot require itself"), "a program cannot be its own prerequisite")
+		}
+		if len(prerequisiteIDs) > 0 {
+			var found int64
+			if err := tx.Model(&models.Program{}).Where("id IN (?)", prerequisiteIDs).Count(&found).Error; err != nil {
+				return newGetRecordsDBError(err, "programs")
+			}
+			if int(found) != len(slices.Compact(slices.Sorted(slices.Values(prerequisiteIDs)))) {
+				return newBadRequestDBError(errors.New("unknown prerequisite program"), "one or more prerequisite programs do not exist")
+			}
+		}
+		var edges []models.ProgramPrerequisite
+		if err := tx.Model(&models.ProgramPrerequisite{}).Where("program_id <> ?", programID).Find(&edges).Error; err != nil {
+			return newGetRecordsDBError(err, "program prerequisites")
+		}
+		graph := make(map[uint][]uint, len(edges))
+		for _, edge := range edges {
+			graph[edge.ProgramID] = append(graph[edge.ProgramID], edge.PrerequisiteProgramID)
+		}
+		graph[uint(programID)] = prerequisiteIDs
+		if cycle := prerequisiteCycle(graph, uint(programID)); cycle {
+			return newBadRequestDBError(fmt.Errorf("prerequisite cycle through program %d", programID), "prerequisites would create a circular dependency")
+		}
+
+		if err := tx.Where("program_id = ?", programID).Delete(&models.ProgramPrerequisite{}).Error; err != nil {
+			return newDeleteDBError(err, "program prerequisites")
+		}
+		if len(prerequisiteIDs) == 0 {
+			return nil
+		}
+		prerequisites := make([]models.ProgramPrerequisite, 0, len(prerequisiteIDs))
+		for _, id := range slices.Compact(slices.Sorted(slices.Values(prerequisiteIDs))) {
+			prerequisites = append(prerequisites, models.ProgramPrerequisite{ProgramID: uint(programID), PrerequisiteProgramID: id})
+		}
+		if err := tx.Create(&prerequisites).Error; err != nil {
+			return newCreateDBError(err, "program prerequisites")
+		}
+		return nil
+	})
+}
+
+// prerequisiteCycle walks the prerequisite graph depth first from start and reports whether start is reachable from itself
+func prerequisiteCycle(graph map[uint][]uint, start uint) bool {
+	visited := make(map[uint]bool)
+	stack := slices.Clone(graph[start])
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == start {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, graph[current]...)
+	}
+	return false
+}
+
+// CheckEnrollmentEligibility returns one entry per resident who has not completed every prerequisite of the class's program.
+// Residents who are eligible are left out, so an empty result means everyone may enroll. Every resident must be at the
+// class's facility.
+func (db *DB) CheckEnrollmentEligibility(classID int, userIDs []int) ([]models.EnrollmentIneligibility, error) {
+	ineligible := make([]models.EnrollmentIneligibility, 0)
+	if len(userIDs) == 0 {
+		return ineligible, nil
+	}
+	var class models.ProgramClass
+	if err := db.Select("id", "program_id", "facility_id").First(&class, "id = ?", classID).Error; err != nil {
+		return nil, newNotFoundDBError(err, "program class")
+	}
+	var users []models.User
+	if err := db.Select("id", "name_first", "name_last").
+		Where("id IN (?) AND facility_id = ?", userIDs, class.FacilityID).
+		Find(&users).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	requested := make(map[int]bool, len(userIDs))
+	for _, id := range userIDs {
+		requested[id] = true
+	}
+	if len(users) != len(requested) {
+		return nil, newBadRequestDBError(errors.New("resident is not at the class's facility"), "residents must be at the class's facility")
+	}
+	prerequisites, err := db.GetProgramPrerequisites(int(class.ProgramID))
+	if err != nil {
+		return nil, err
+	}
+	if len(prerequisites) == 0 {
+		return ineligible, nil
+	}
+	prerequisiteIDs := make([]uint, 0, len(prerequisites))
+	for _, prereq := range prerequisites {
+		prerequisiteIDs = append(prerequisiteIDs, prereq.PrerequisiteProgramID)
+	}
+
+	var completions []models.ProgramCompletion
+	if err := db.Model(&models.ProgramCompletion{}).
+		Select("user_id", "program_id").
+		Where("user_id IN (?) AND program_id IN (?)", userIDs, prerequisiteIDs).
+		Find(&completions).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program completions")
+	}
+	completed := make(map[uint]map[uint]bool, len(userIDs))
+	for _, completion := range completions {
+		if completed[completion.UserID] == nil {
+			completed[completion.UserID] = make(map[uint]bool)
+		}
+		completed[completion.UserID][completion.ProgramID] = true
+	}
+
+	for _, user := range users {
+		missing := make([]models.MissingPrerequisite, 0)
+		for _, prereq := range prerequisites {
+			if completed[user.ID][prereq.PrerequisiteProgramID] {
+				continue
+			}
+			name := ""
+			if prereq.PrerequisiteProgram != nil {
+				name = prereq.PrerequisiteProgram.Name
+			}
+			missing = append(missing, models.MissingPrerequisite{ProgramID: prereq.PrerequisiteProgramID, ProgramName: name})
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		ineligible = append(ineligible, models.EnrollmentIneligibility{
+			UserID:               user.ID,
+			UserName:             user.NameLast + ", " + user.NameFirst,
+			MissingPrerequisites: missing,
+		})
+	}
+	return ineligible, nil
+}
+
+// createEligibilityOverrides records the reason an admin gave for enrolling each resident without the class's prerequisites
+func createEligibilityOverrides(tx *gorm.DB, classID int, reasons map[uint]string) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	var class models.ProgramClass
+	if err := tx.Select("id", "program_id").First(&class, "id = ?", classID).Error; err != nil {
+		return newNotFoundDBError(err, "program class")
+	}
+	overrides := make([]models.ProgramEligibilityOverride, 0, len(reasons))
+	for userID, reason := range reasons {
+		override := models.ProgramEligibilityOverride{
+			UserID:    userID,
+			ClassID:   class.ID,
+			ProgramID: class.ProgramID,
+			Reason:    reason,
+		}
+		if err := Validate().Struct(&override); err != nil {
+			return newCreateDBError(err, "eligibility override")
+		}
+		overrides = append(overrides, override)
+	}
+	if err := tx.Create(&overrides).Error; err != nil {
+		return newCreateDBError(err, "eligibility override")
+	}
+	return nil
+}
+
+// overridesFor keeps the override reasons of the given residents only
+func overridesFor(reasons map[uint]string, userIDs []uint) map[uint]string {
+	kept := make(map[uint]string)
+	for _, id := range userIDs {
+		if reason, ok := reasons[id]; ok {
+			kept[id] = reason
+		}
+	}
+	return kept
+}
+
+func (db *DB) GetEligibilityOverridesForClass(args *models.QueryContext, classID int) ([]models.ProgramEligibilityOverride, error) {
+	overrides := make([]models.ProgramEligibilityOverride, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.ProgramEligibilityOverride{}).Preload("User").Where("class_id = ?", classID)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "eligibility overrides")
+	}
+	if err := tx.Order("created_at desc").Limit(args.PerPage).Offset(args.CalcOffset()).Find(&overrides).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "eligibility overrides")
+	}
+	return overrides, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetReleaseDate sets or clears the resident's projected release date, a nil date clears it
+func (db *DB) SetReleaseDate(ctx context.Context, user *models.User, date *string, adminID *uint) error {
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return NewDBError(tx.Error, "unable to start DB transaction")
+	}
+	defer tx.Rollback()
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("release_date", date).Error; err != nil {
+		return newUpdateDBError(err, "users")
+	}
+	history := models.NewUserAccountHistory(user.ID, models.ReleaseDateSet, adminID, nil, &user.FacilityID)
+	if err := tx.Create(history).Error; err != nil {
+		return newCreateDBError(err, "user_account_history")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return NewDBError(err, "committing transaction after setting release date")
+	}
+	user.ReleaseDate = date
+	return nil
+}
+
+// GetResidentsDueForRelease returns the facility's active residents whose release date is on or before warnBy
+func (db *DB) GetResidentsDueForRelease(ctx context.Context, facilityID uint, warnBy string) ([]models.User, error) {
+	residents := make([]models.User, 0)
+	if err := db.WithContext(ctx).
+		Where("facility_id = ? AND role = ? AND deactivated_at IS NULL AND release_date IS NOT NULL AND release_date <= ?",
+			facilityID, models.Student, warnBy).
+		Order("release_date, id").
+		Find(&residents).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	return residents, nil
+}
+
+// GetUpcomingReleases lists the facility's active residents released between today and warnBy, soonest first
+func (db *DB) GetUpcomingReleases(ctx context.Context, facilityID uint, today, warnBy string) ([]models.UpcomingRelease, error) {
+	releases := make([]models.UpcomingRelease, 0)
+	if err := db.WithContext(ctx).Table("users u").
+		Select(`u.id AS user_id, u.name_first, u.name_last, u.doc_id, u.release_date,
+			(SELECT COUNT(*) FROM program_class_enrollments pce WHERE pce.user_id = u.id
+				AND pce.enrollment_status IN ?) AS open_enrollments`,
+			[]models.ProgramEnrollmentStatus{models.Enrolled, models.EnrollmentWaitlisted}).
+		Where("u.facility_id = ? AND u.role = ? AND u.deactivated_at IS NULL AND u.deleted_at IS NULL", facilityID, models.Student).
+		Where("u.release_date IS NOT NULL AND u.release_date >= ? AND u.release_date <= ?", today, warnBy).
+		Order("u.release_date, u.name_last, u.name_first").
+		Scan(&releases).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	for idx := range releases {
+		releases[idx].DaysUntil = models.DaysUntilRelease(releases[idx].ReleaseDate, today)
+	}
+	return releases, nil
+}
+
+// MarkReleaseWarned records that admins were warned about the resident's release date
+func (db *DB) MarkReleaseWarned(ctx context.Context, user *models.User, adminID *uint) error {
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return NewDBError(tx.Error, "unable to start DB transaction")
+	}
+	defer tx.Rollback()
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("release_warned_for", user.ReleaseDate).Error; err != nil {
+		return newUpdateDBError(err, "users")
+	}
+	history := models.NewUserAccountHistory(user.ID, models.ReleaseWarningSent, adminID, nil, &user.FacilityID)
+	if err := tx.Create(history).Error; err != nil {
+		return newCreateDBError(err, "user_account_history")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return NewDBError(err, "committing transaction after recording release warning")
+	}
+	user.ReleaseWarnedFor = user.ReleaseDate
+	return nil
+}
+
+/*
+ReleaseResident withdraws a resident whose release date has arrived and deactivates their account in one transaction,
+flagging them until their release packet is produced. Returns the number of enrollments ended.
+*/
+func (db *DB) ReleaseResident(ctx context.Context, user *models.User, adminID *uint) (int64, error) {
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return 0, NewDBError(tx.Error, "unable to start DB transaction")
+	}
+	defer tx.Rollback()
+	withdrawn, err := withdrawForRelease(tx, user, adminID)
+	if err != nil {
+		return 0, err
+	}
+	if err := deactivateUser(tx, user.ID, adminID); err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("release_packet_pending", true).Error; err != nil {
+		return 0, newUpdateDBError(err, "users")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return 0, NewDBError(err, "committing transaction after releasing resident")
+	}
+	user.ReleasePacketPending = true
+	return withdrawn, nil
+}
+
+// GetResidentsAwaitingReleasePacket returns the facility's released residents whose release packet could not be produced yet
+func (db *DB) GetResidentsAwaitingReleasePacket(ctx context.Context, facilityID uint) ([]models.User, error) {
+	residents := make([]models.User, 0)
+	if err := db.WithContext(ctx).
+		Where("facility_id = ? AND role = ? AND release_packet_pending = ?", facilityID, models.Student, true).
+		Order("id").
+		Find(&residents).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	return residents, nil
+}
+
+/*
+withdrawForRelease ends the resident's open class enrollments within tx because they are being released: enrolled
+classes are marked Incomplete: Released and waitlist spots are cancelled, freed seats are offered to the next resident
+waiting. Returns the number of enrollments ended, history is only written when there were any.
+*/
+func withdrawForRelease(tx *gorm.DB, user *models.User, adminID *uint) (int64, error) {
+	now := time.Now()
+	_, withdrawn, err := withdrawOpenEnrollments(tx, user.ID, nil, models.EnrollmentIncompleteReleased, models.ReleaseChangeReason, &now)
+	if err != nil || withdrawn == 0 {
+		return 0, err
+	}
+	history := models.NewUserAccountHistory(user.ID, models.ReleaseEnrollmentsWithdrawn, adminID, nil, &user.FacilityID)
+	if err := tx.Create(history).Error; err != nil {
+		return 0, newCreateDBError(err, "user_account_history")
+	}
+	return withdrawn, nil
+}
+
+// GetSystemBatchUserID returns the ID of the system_batch user that scheduled changes are attributed to, or nil when
+// the user has not been seeded
+func (db *DB) GetSystemBatchUserID(ctx context.Context) (*uint, error) {
+	var user models.User
+	err := db.WithContext(ctx).Select("id").Where("username = ?", "system_batch").First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newNotFoundDBError(err, "users")
+	}
+	return &user.ID, nil
+}
+
+// GetFacilityAdmins returns the facility's active admins, who are warned about upcoming releases
+func (db *DB) GetFacilityAdmins(ctx context.Context, facilityID uint) ([]models.User, error) {
+	admins := make([]models.User, 0)
+	if err := db.WithContext(ctx).
+		Where("facility_id = ? AND role IN ? AND deactivated_at IS NULL", facilityID, []models.UserRole{models.FacilityAdmin, models.DepartmentAdmin}).
+		Find(&admins).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	return admins, nil
+}
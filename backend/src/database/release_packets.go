@@ -0,0 +1,115 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+)
+
+/*
+GetReleasePacketData gathers everything a resident's release packet holds. Unlike the favorites page, content from
+providers that have since been disabled is kept: the packet is a record of what the resident used, not of what is
+still available to them.
+*/
+func (db *DB) GetReleasePacketData(ctx context.Context, data *models.ReleasePacketData) error {
+	userID := data.Resident.UserID
+	tx := db.WithContext(ctx)
+	if err := tx.Table("program_completions comp").
+		Select(`comp.program_name, comp.program_class_name AS class_name, comp.facility_name, comp.credit_type,
+			pc.credit_hours, comp.enrolled_on_dt AS enrolled_on, comp.created_at AS completed_at,
+			cert.verification_code AS certificate_code`).
+		Joins("LEFT JOIN program_classes pc ON pc.id = comp.program_class_id").
+		Joins("LEFT JOIN certificates cert ON cert.program_completion_id = comp.id AND cert.deleted_at IS NULL").
+		Where("comp.user_id = ? AND comp.deleted_at IS NULL", userID).
+		Order("comp.created_at, comp.id").
+		Scan(&data.Completions).Error; err != nil {
+		return newGetRecordsDBError(err, "program_completions")
+	}
+	classes, err := db.GetTranscriptClasses(userID)
+	if err != nil {
+		return err
+	}
+	data.Classes = classes
+	entries, err := db.GetLearningRecordEntries(userID)
+	if err != nil {
+		return err
+	}
+	data.LearningRecords = entries
+	if err := tx.Raw(`SELECT content_type, title, url, provider_name, favorited_at FROM (
+			SELECT 'library' AS content_type, COALESCE(NULLIF(f.name, ''), lib.title) AS title,
+				COALESCE(ocu.content_url, lib.url) AS url, ocp.title AS provider_name, f.created_at AS favorited_at
+			FROM open_content_favorites f
+			JOIN open_content_providers ocp ON ocp.id = f.open_content_provider_id
+			JOIN libraries lib ON lib.open_content_provider_id = ocp.id AND lib.id = f.content_id
+			LEFT JOIN open_content_urls ocu ON ocu.id = f.open_content_url_id
+			WHERE f.user_id = ?
+			UNION ALL
+			SELECT 'video' AS content_type, videos.title, videos.url, ocp.title AS provider_name, f.created_at AS favorited_at
+			FROM open_content_favorites f
+			JOIN open_content_providers ocp ON ocp.id = f.open_content_provider_id
+			JOIN videos ON videos.open_content_provider_id = ocp.id AND videos.id = f.content_id
+			WHERE f.user_id = ?
+		) favorites ORDER BY favorited_at`, userID, userID).
+		Scan(&data.Favorites).Error; err != nil {
+		return newGetRecordsDBError(err, "open_content_favorites")
+	}
+	if err := tx.Table("user_enrollments ue").
+		Select(`pp.name AS provider_name, COALESCE(NULLIF(c.alt_name, ''), c.name) AS course_name,
+			ue.created_at AS enrolled_at,
+			(SELECT COUNT(*) FROM milestones m WHERE m.user_id = ue.user_id AND m.course_id = ue.course_id
+				AND m.is_completed = true AND m.deleted_at IS NULL) AS milestones_completed,
+			c.total_progress_milestones AS total_milestones,
+			(SELECT COALESCE(STRING_AGG(o.type, ', '), '') FROM outcomes o WHERE o.user_id = ue.user_id
+				AND o.course_id = ue.course_id AND o.deleted_at IS NULL) AS outcomes`).
+		Joins("JOIN courses c ON c.id = ue.course_id").
+		Joins("JOIN provider_platforms pp ON pp.id = c.provider_platform_id").
+		Where("ue.user_id = ? AND ue.deleted_at IS NULL", userID).
+		Order("ue.created_at").
+		Scan(&data.CourseProgress).Error; err != nil {
+		return newGetRecordsDBError(err, "user_enrollments")
+	}
+	return nil
+}
+
+// CreateReleasePacket records the packet and the account history entry saying it was produced, and clears the
+// resident's pending packet flag
+func (db *DB) CreateReleasePacket(ctx context.Context, packet *models.ReleasePacket) error {
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return NewDBError(tx.Error, "unable to start DB transaction")
+	}
+	defer tx.Rollback()
+	if err := tx.Create(packet).Error; err != nil {
+		return newCreateDBError(err, "release_packets")
+	}
+	history := models.NewUserAccountHistory(packet.UserID, models.ReleasePacketGenerated, packet.GeneratedByID, nil, &packet.FacilityID)
+	if err := tx.Create(history).Error; err != nil {
+		return newCreateDBError(err, "user_account_history")
+	}
+	if err := tx.Model(&models.User{}).Where("id = ? AND release_packet_pending = ?", packet.UserID, true).
+		Update("release_packet_pending", false).Error; err != nil {
+		return newUpdateDBError(err, "users")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return NewDBError(err, "committing transaction after creating release packet")
+	}
+	return nil
+}
+
+func (db *DB) GetReleasePackets(ctx context.Context, userID uint) ([]models.ReleasePacket, error) {
+	packets := make([]models.ReleasePacket, 0)
+	if err := db.WithContext(ctx).Preload("GeneratedBy").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&packets).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "release_packets")
+	}
+	return packets, nil
+}
+
+func (db *DB) GetReleasePacket(ctx context.Context, userID, packetID uint) (*models.ReleasePacket, error) {
+	var packet models.ReleasePacket
+	if err := db.WithContext(ctx).Where("id = ? AND user_id = ?", packetID, userID).First(&packet).Error; err != nil {
+		return nil, newNotFoundDBError(err, "release_packets")
+	}
+	return &packet, nil
+}
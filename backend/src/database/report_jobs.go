@@ -0,0 +1,95 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"time"
+)
+
+func (db *DB) CreateReportJob(job *models.ReportJob) error {
+	if err := db.Create(job).Error; err != nil {
+		return newCreateDBError(err, "report_jobs")
+	}
+	return nil
+}
+
+func (db *DB) GetReportJob(id uint) (*models.ReportJob, error) {
+	var job models.ReportJob
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "report_jobs")
+	}
+	return &job, nil
+}
+
+func (db *DB) GetReportJobsForUser(args *models.QueryContext, userID uint) ([]models.ReportJob, error) {
+	jobs := make([]models.ReportJob, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.ReportJob{}).Where("user_id = ?", userID)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	if err := tx.Order("created_at DESC").Limit(args.PerPage).Offset(args.CalcOffset()).Find(&jobs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	return jobs, nil
+}
+
+// ClaimReportJob moves a pending job to running, reporting false when another worker already claimed it
+func (db *DB) ClaimReportJob(id uint) (bool, error) {
+	result := db.Model(&models.ReportJob{}).Where("id = ? AND status = ?", id, models.ReportJobPending).
+		Updates(map[string]any{"status": models.ReportJobRunning, "started_at": time.Now()})
+	if result.Error != nil {
+		return false, newUpdateDBError(result.Error, "report_jobs")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (db *DB) CompleteReportJob(job *models.ReportJob) error {
+	if err := db.Model(job).Select("status", "row_count", "filename", "content_type", "size_bytes", "artifact_key", "completed_at", "expires_at").
+		Updates(job).Error; err != nil {
+		return newUpdateDBError(err, "report_jobs")
+	}
+	return nil
+}
+
+func (db *DB) FailReportJob(id uint, reason string) error {
+	if err := db.Model(&models.ReportJob{}).Where("id = ?", id).
+		Updates(map[string]any{"status": models.ReportJobFailed, "error": reason, "completed_at": time.Now()}).Error; err != nil {
+		return newUpdateDBError(err, "report_jobs")
+	}
+	return nil
+}
+
+// GetStalledReportJobs returns running jobs started before startedBefore and pending jobs created before createdBefore
+func (db *DB) GetStalledReportJobs(startedBefore, createdBefore time.Time) ([]models.ReportJob, error) {
+	var jobs []models.ReportJob
+	if err := db.Where("(status = ? AND started_at < ?) OR (status = ? AND created_at < ?)",
+		models.ReportJobRunning, startedBefore, models.ReportJobPending, createdBefore).Find(&jobs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	return jobs, nil
+}
+
+// FailStalledReportJob fails a job that is still running since before startedBefore, reporting false when it finished in the meantime
+func (db *DB) FailStalledReportJob(id uint, startedBefore time.Time, reason string) (bool, error) {
+	result := db.Model(&models.ReportJob{}).Where("id = ? AND status = ? AND started_at < ?", id, models.ReportJobRunning, startedBefore).
+		Updates(map[string]any{"status": models.ReportJobFailed, "error": reason, "completed_at": time.Now()})
+	if result.Error != nil {
+		return false, newUpdateDBError(result.Error, "report_jobs")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetExpiredReportJobs returns the jobs whose artifacts are past their expiry but still stored
+func (db *DB) GetExpiredReportJobs(now time.Time) ([]models.ReportJob, error) {
+	var jobs []models.ReportJob
+	if err := db.Where("status = ? AND expires_at <= ? AND artifact_key <> ''", models.ReportJobCompleted, now).Find(&jobs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	return jobs, nil
+}
+
+func (db *DB) ClearReportJobArtifact(id uint) error {
+	if err := db.Model(&models.ReportJob{}).Where("id = ?", id).Update("artifact_key", "").Error; err != nil {
+		return newUpdateDBError(err, "report_jobs")
+	}
+	return nil
+}
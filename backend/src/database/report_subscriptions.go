@@ -0,0 +1,79 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) CreateReportSubscription(sub *models.ReportSubscription) error {
+	if err := db.Create(sub).Error; err != nil {
+		return newCreateDBError(err, "report_subscriptions")
+	}
+	return nil
+}
+
+func (db *DB) GetReportSubscription(id uint) (*models.ReportSubscription, error) {
+	var sub models.ReportSubscription
+	if err := db.First(&sub, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "report_subscriptions")
+	}
+	return &sub, nil
+}
+
+func (db *DB) GetReportSubscriptionsForUser(userID uint) ([]models.ReportSubscription, error) {
+	var subs []models.ReportSubscription
+	if err := db.Where("user_id = ?", userID).Order("name").Find(&subs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_subscriptions")
+	}
+	return subs, nil
+}
+
+func (db *DB) UpdateReportSubscription(sub *models.ReportSubscription) error {
+	if err := db.Model(sub).Select("name", "schedule", "time_zone", "date_range", "request", "enabled", "next_run_at").
+		Updates(sub).Error; err != nil {
+		return newUpdateDBError(err, "report_subscriptions")
+	}
+	return nil
+}
+
+func (db *DB) DeleteReportSubscription(id uint) error {
+	result := db.Delete(&models.ReportSubscription{}, id)
+	if result.Error != nil {
+		return newDeleteDBError(result.Error, "report_subscriptions")
+	}
+	if result.RowsAffected == 0 {
+		return newNotFoundDBError(gorm.ErrRecordNotFound, "report_subscriptions")
+	}
+	return nil
+}
+
+// GetDueReportSubscriptions returns the enabled subscriptions whose next run is at or before now, with their owners
+func (db *DB) GetDueReportSubscriptions(now time.Time) ([]models.ReportSubscription, error) {
+	var subs []models.ReportSubscription
+	if err := db.Preload("User").Where("enabled = ? AND next_run_at <= ?", true, now).Order("next_run_at").Find(&subs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_subscriptions")
+	}
+	return subs, nil
+}
+
+// RecordReportSubscriptionRun stores when the subscription last ran, when it runs next and whether it is still enabled
+func (db *DB) RecordReportSubscriptionRun(sub *models.ReportSubscription) error {
+	if err := db.Model(sub).Select("last_run_at", "next_run_at", "enabled").Updates(sub).Error; err != nil {
+		return newUpdateDBError(err, "report_subscriptions")
+	}
+	return nil
+}
+
+func (db *DB) GetReportSubscriptionRuns(args *models.QueryContext, subID uint) ([]models.ReportJob, error) {
+	jobs := make([]models.ReportJob, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.ReportJob{}).Where("subscription_id = ?", subID)
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	if err := tx.Order("created_at DESC").Limit(args.PerPage).Offset(args.CalcOffset()).Find(&jobs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "report_jobs")
+	}
+	return jobs, nil
+}
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/teambition/rrule-go"
 )
 
 var rosterStatusFilterMap = map[string]string{
@@ -17,6 +21,7 @@ var rosterStatusFilterMap = map[string]string{
 	"Segregated":         "Incomplete: Segregated",
 	"Failed to Complete": "Incomplete: Failed to Complete",
 	"Transfered":         "Incomplete: Transfered",
+	"Released":           "Incomplete: Released",
 }
 
 const sessionsAttendedSubquery = `(SELECT COUNT(*) FROM program_class_event_attendance pcea
@@ -370,3 +375,141 @@ func (db *DB) GenerateResidentProfileReport(ctx context.Context, req *models.Rep
 
 	return rows, nil
 }
+
+/*
+GenerateRoomUtilizationReport expands every class event booked into a room at the requested facilities
+(including room changes and cancellations from overrides) and totals the booked hours per room per week,
+weeks starting Monday in the facility's timezone. Available hours are req.RoomHoursPerWeek, prorated for
+weeks cut off by the date range. Inactive rooms are only listed for weeks they were booked.
+*/
+func (db *DB) GenerateRoomUtilizationReport(ctx context.Context, req *models.ReportGenerateRequest) ([]models.RoomUtilizationReportRow, error) {
+	tx := &DB{db.WithContext(ctx)}
+	var facilities []models.Facility
+	query := tx.Select("id", "name", "timezone").Order("name")
+	switch {
+	case req.FacilityID != nil:
+		query = query.Where("id = ?", *req.FacilityID)
+	case len(req.FacilityIDs) > 0:
+		query = query.Where("id IN ?", req.FacilityIDs)
+	}
+	if err := query.Find(&facilities).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "room utilization report")
+	}
+	hoursPerWeek := models.DefaultRoomHoursPerWeek
+	if req.RoomHoursPerWeek != nil {
+		hoursPerWeek = *req.RoomHoursPerWeek
+	}
+
+	rows := []models.RoomUtilizationReportRow{}
+	for _, facility := range facilities {
+		facilityRows, err := tx.roomUtilizationForFacility(&facility, req.StartDate, req.EndDate, hoursPerWeek)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, facilityRows...)
+	}
+	return rows, nil
+}
+
+func (db *DB) roomUtilizationForFacility(facility *models.Facility, startDate, endDate time.Time, hoursPerWeek float64) ([]models.RoomUtilizationReportRow, error) {
+	tz, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		tz = time.UTC
+	}
+	rangeStart := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, tz)
+	rangeEnd := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, tz).AddDate(0, 0, 1)
+
+	rooms, err := db.GetRoomsForFacility(facility.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.ProgramClassEvent
+	if err := db.Table("program_class_events e").
+		Select("e.*").
+		Joins("JOIN program_classes c ON c.id = e.class_id").
+		Where("c.facility_id = ? AND e.deleted_at IS NULL AND e.is_cancelled = ?", facility.ID, false).
+		Preload("Overrides").
+		Find(&events).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_events")
+	}
+
+	var weeks []time.Time
+	for week := startOfWeek(rangeStart); week.Before(rangeEnd); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, week)
+	}
+	rows := make([]models.RoomUtilizationReportRow, 0, len(rooms)*len(weeks))
+	for _, room := range rooms {
+		byWeek := make(map[time.Time]*models.RoomUtilizationReportRow, len(weeks))
+		for _, week := range weeks {
+			windowStart, windowEnd := week, week.AddDate(0, 0, 7)
+			if windowStart.Before(rangeStart) {
+				windowStart = rangeStart
+			}
+			if windowEnd.After(rangeEnd) {
+				windowEnd = rangeEnd
+			}
+			days := math.Round(windowEnd.Sub(windowStart).Hours() / 24)
+			byWeek[week] = &models.RoomUtilizationReportRow{
+				FacilityName:   facility.Name,
+				RoomName:       room.Name,
+				WeekStart:      week,
+				AvailableHours: hoursPerWeek * days / 7,
+			}
+		}
+		booked := false
+		for _, event := range events {
+			bookings, err := expandEventToBookings(event, rangeStart, rangeEnd, room.ID, facility.Timezone)
+			if err != nil {
+				logrus.Warnf("skipping event %d in room utilization report: %v", event.ID, err)
+				continue
+			}
+			for _, booking := range bookings {
+				row, ok := byWeek[startOfWeek(booking.StartTime.In(tz))]
+				if !ok || booking.StartTime.Before(rangeStart) {
+					continue
+				}
+				row.Sessions++
+				row.BookedHours += booking.EndTime.Sub(booking.StartTime).Hours()
+				booked = true
+			}
+			if event.RoomID == nil || *event.RoomID != room.ID {
+				continue
+			}
+			for _, cancelled := range cancelledOccurrences(event, rangeStart, rangeEnd) {
+				if row, ok := byWeek[startOfWeek(cancelled.In(tz))]; ok {
+					row.CancelledSessions++
+				}
+			}
+		}
+		if !room.IsActive && !booked {
+			continue
+		}
+		for _, week := range weeks {
+			rows = append(rows, *byWeek[week])
+		}
+	}
+	return rows, nil
+}
+
+// cancelledOccurrences lists the sessions of an event that a cancellation override removed
+func cancelledOccurrences(event models.ProgramClassEvent, rangeStart, rangeEnd time.Time) []time.Time {
+	var cancelled []time.Time
+	for _, override := range event.Overrides {
+		if !override.IsCancelled {
+			continue
+		}
+		overrideRule, err := rrule.StrToRRule(override.OverrideRrule)
+		if err != nil {
+			logrus.Warnf("skipping override %d with invalid rrule: %v", override.ID, err)
+			continue
+		}
+		cancelled = append(cancelled, overrideRule.Between(rangeStart, rangeEnd, true)...)
+	}
+	return cancelled
+}
+
+// startOfWeek returns midnight on the Monday on or before t, in t's location
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
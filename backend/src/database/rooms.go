@@ -13,9 +13,13 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func (db *DB) GetRoomsForFacility(facilityID uint) ([]models.Room, error) {
+func (db *DB) GetRoomsForFacility(facilityID uint, activeOnly bool) ([]models.Room, error) {
 	var rooms []models.Room
-	if err := db.Where("facility_id = ?", facilityID).Order("name ASC").Find(&rooms).Error; err != nil {
+	tx := db.Preload("Attributes").Where("facility_id = ?", facilityID)
+	if activeOnly {
+		tx = tx.Where("is_active = ?", true)
+	}
+	if err := tx.Order("name ASC").Find(&rooms).Error; err != nil {
 		return nil, newGetRecordsDBError(err, "rooms")
 	}
 	return rooms, nil
@@ -23,7 +27,7 @@ func (db *DB) GetRoomsForFacility(facilityID uint) ([]models.Room, error) {
 
 func (db *DB) GetRoomByIDForFacility(roomID, facilityID uint) (*models.Room, error) {
 	var room models.Room
-	if err := db.Where("id = ? AND facility_id = ?", roomID, facilityID).First(&room).Error; err != nil {
+	if err := db.Preload("Attributes").Where("id = ? AND facility_id = ?", roomID, facilityID).First(&room).Error; err != nil {
 		return nil, newNotFoundDBError(err, "rooms")
 	}
 	return &room, nil
@@ -39,6 +43,108 @@ func (db *DB) CreateRoom(room *models.Room) (*models.Room, error) {
 	return room, nil
 }
 
+// UpdateRoom saves the name, capacity and active state of a room and replaces its attributes
+func (db *DB) UpdateRoom(room *models.Room) (*models.Room, error) {
+	if err := Validate().Struct(room); err != nil {
+		return nil, newUpdateDBError(err, "room")
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Room{}).Where("id = ? AND facility_id = ?", room.ID, room.FacilityID).
+			Select("name", "capacity", "is_active").
+			Updates(models.Room{Name: room.Name, Capacity: room.Capacity, IsActive: room.IsActive}).Error; err != nil {
+			return newUpdateDBError(err, "room")
+		}
+		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomAttribute{}).Error; err != nil {
+			return newUpdateDBError(err, "room_attributes")
+		}
+		for _, attr := range room.Attributes {
+			if err := tx.Create(&models.RoomAttribute{RoomID: room.ID, Attribute: attr.Attribute}).Error; err != nil {
+				return newUpdateDBError(err, "room_attributes")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.GetRoomByIDForFacility(room.ID, room.FacilityID)
+}
+
+/*
+CheckRoomCapacity compares a room's seat count against the class capacity and current enrollment. A warning is
+returned when either is larger than the room; rooms without a recorded capacity never warn.
+*/
+func (db *DB) CheckRoomCapacity(classID, roomID uint) (*models.RoomCapacityWarning, error) {
+	var room models.Room
+	if err := db.Select("id", "name", "capacity").First(&room, roomID).Error; err != nil {
+		return nil, newNotFoundDBError(err, "rooms")
+	}
+	if room.Capacity == nil {
+		return nil, nil
+	}
+	var class models.ProgramClass
+	if err := db.Select("id", "capacity").First(&class, classID).Error; err != nil {
+		return nil, newNotFoundDBError(err, "program_classes")
+	}
+	enrolled, err := db.GetTotalEnrollmentsByClassID(int(classID))
+	if err != nil {
+		return nil, err
+	}
+	if class.Capacity <= *room.Capacity && enrolled <= *room.Capacity {
+		return nil, nil
+	}
+	warning := &models.RoomCapacityWarning{
+		RoomID:        room.ID,
+		RoomName:      room.Name,
+		RoomCapacity:  *room.Capacity,
+		ClassCapacity: class.Capacity,
+		Enrolled:      enrolled,
+	}
+	if enrolled > *room.Capacity {
+		warning.Message = fmt.Sprintf("%s seats %d but %d residents are enrolled", room.Name, *room.Capacity, enrolled)
+	} else {
+		warning.Message = fmt.Sprintf("%s seats %d but the class allows %d residents", room.Name, *room.Capacity, class.Capacity)
+	}
+	return warning, nil
+}
+
+// FindAvailableRooms returns the active rooms at a facility that have the requested seats and attributes and are free for every occurrence of the rule
+func (db *DB) FindAvailableRooms(req *models.RoomFinderRequest) ([]models.Room, error) {
+	rooms, err := db.GetRoomsForFacility(req.FacilityID, true)
+	if err != nil {
+		return nil, err
+	}
+	w, err := db.prepareConflictWindow(&models.ConflictCheckRequest{
+		FacilityID:     req.FacilityID,
+		RecurrenceRule: req.RecurrenceRule,
+		Duration:       req.Duration,
+	})
+	if err != nil {
+		return nil, err
+	}
+	available := make([]models.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if req.MinCapacity > 0 && (room.Capacity == nil || *room.Capacity < req.MinCapacity) {
+			continue
+		}
+		if !room.HasAttributes(req.Attributes) {
+			continue
+		}
+		conflicts, err := checkRoomRRuleConflicts(db, w, &models.ConflictCheckRequest{
+			FacilityID:     req.FacilityID,
+			RoomID:         room.ID,
+			ExcludeEventID: req.ExcludeEventID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			available = append(available, room)
+		}
+	}
+	return available, nil
+}
+
 func LockAndCheckConflicts(tx *gorm.DB, req *models.ConflictCheckRequest) ([]models.RoomConflict, error) {
 	db := &DB{tx}
 	if req.RoomID != 0 {
@@ -80,6 +186,11 @@ func (db *DB) CheckConflicts(req *models.ConflictCheckRequest) ([]models.RoomCon
 			return nil, err
 		}
 		conflicts = append(conflicts, instructorConflicts...)
+		availabilityConflicts, err := checkInstructorAvailabilityConflicts(db, w, req)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, availabilityConflicts...)
 	}
 	return conflicts, nil
 }
@@ -92,13 +203,21 @@ func (db *DB) CheckRRuleConflicts(req *models.ConflictCheckRequest) ([]models.Ro
 	return checkRoomRRuleConflicts(db, w, req)
 }
 
+/*
+maxSessionLength is how far before the first proposed occurrence bookings are looked up. Bookings are found by their
+start time, so a session that began before the proposed schedule but is still running when it starts would otherwise
+be missed. Sessions are scheduled within a day, one running longer only conflicts from its own start.
+*/
+const maxSessionLength = 24 * time.Hour
+
 type conflictWindow struct {
 	occurrences []time.Time
 	duration    time.Duration
 	tz          *time.Location
 	facilityTZ  string
-	rangeStart  time.Time
-	rangeEnd    time.Time
+	// bookingsFrom is the first proposed occurrence less maxSessionLength
+	bookingsFrom time.Time
+	rangeEnd     time.Time
 }
 
 func (db *DB) prepareConflictWindow(req *models.ConflictCheckRequest) (*conflictWindow, error) {
@@ -144,12 +263,12 @@ func (db *DB) prepareConflictWindow(req *models.ConflictCheckRequest) (*conflict
 	}
 
 	return &conflictWindow{
-		occurrences: rule.Between(rangeStart, until, true),
-		duration:    duration,
-		tz:          tz,
-		facilityTZ:  facility.Timezone,
-		rangeStart:  rangeStart,
-		rangeEnd:    until,
+		occurrences:  rule.Between(rangeStart, until, true),
+		duration:     duration,
+		tz:           tz,
+		facilityTZ:   facility.Timezone,
+		bookingsFrom: rangeStart.Add(-maxSessionLength),
+		rangeEnd:     until,
 	}, nil
 }
 
@@ -212,7 +331,14 @@ func buildConflictsFromBookings(db *DB, w *conflictWindow, bookings []models.Roo
 }
 
 func checkRoomRRuleConflicts(db *DB, w *conflictWindow, req *models.ConflictCheckRequest) ([]models.RoomConflict, error) {
-	bookings, err := db.getRoomBookingsInRange(req.FacilityID, req.RoomID, w.rangeStart, w.rangeEnd, w.facilityTZ)
+	var room models.Room
+	if err := db.Select("id", "is_active").First(&room, req.RoomID).Error; err != nil {
+		return nil, newNotFoundDBError(err, "rooms")
+	}
+	if !room.IsActive {
+		return nil, newBadRequestDBError(errors.New("room is inactive"), "the room is inactive and cannot be scheduled")
+	}
+	bookings, err := db.getRoomBookingsInRange(req.FacilityID, req.RoomID, w.bookingsFrom, w.rangeEnd, w.facilityTZ)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GetRosterResidents returns every resident with a DOC ID, deactivated ones included so readmissions can be spotted
+func (db *DB) GetRosterResidents(ctx context.Context) ([]models.User, error) {
+	residents := make([]models.User, 0)
+	if err := db.WithContext(ctx).
+		Where("role = ? AND doc_id IS NOT NULL AND doc_id <> ''", models.Student).
+		Order("id").
+		Find(&residents).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "users")
+	}
+	return residents, nil
+}
+
+func (db *DB) CreateRosterSyncRun(ctx context.Context, run *models.RosterSyncRun) error {
+	if err := db.WithContext(ctx).Create(run).Error; err != nil {
+		return newCreateDBError(err, "roster_sync_runs")
+	}
+	return nil
+}
+
+func (db *DB) GetRosterSyncRuns(args *models.QueryContext) ([]models.RosterSyncRun, error) {
+	runs := make([]models.RosterSyncRun, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.RosterSyncRun{})
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "roster_sync_runs")
+	}
+	if err := tx.Preload("TriggeredBy").
+		Order("created_at DESC, id DESC").
+		Limit(args.PerPage).Offset(args.CalcOffset()).
+		Find(&runs).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "roster_sync_runs")
+	}
+	return runs, nil
+}
+
+// GetRosterSyncRun returns the run with its changes in the order they were found in the file
+func (db *DB) GetRosterSyncRun(ctx context.Context, id uint) (*models.RosterSyncRun, error) {
+	var run models.RosterSyncRun
+	if err := db.WithContext(ctx).Preload("TriggeredBy").
+		Preload("Changes", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		First(&run, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "roster_sync_runs")
+	}
+	return &run, nil
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GetSessionCoverage returns the coverage recorded for an event's session on date (YYYY-MM-DD), or nil when nobody has covered it
+func (db *DB) GetSessionCoverage(eventID uint, date string) (*models.SessionCoverage, error) {
+	var coverage models.SessionCoverage
+	err := db.Where("event_id = ? AND session_date = ?", eventID, date).Take(&coverage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newGetRecordsDBError(err, "session_coverages")
+	}
+	return &coverage, nil
+}
+
+/*
+AssignSessionSubstitute saves the override handing an event's session to a substitute and records who is covering
+it in one transaction, so a session is never reassigned without its coverage or the other way around.
+*/
+func (db *DB) AssignSessionSubstitute(ctx *models.QueryContext, override *models.ProgramClassEventOverride, coverage *models.SessionCoverage) error {
+	return db.WithContext(ctx.Ctx).Transaction(func(tx *gorm.DB) error {
+		if err := db.createOverrideEvents(tx, ctx, []*models.ProgramClassEventOverride{override}); err != nil {
+			return err
+		}
+		coverage.OverrideID = override.ID
+		return recordSessionCoverage(tx, coverage)
+	})
+}
+
+/*
+recordSessionCoverage saves who is covering an event's session on coverage.SessionDate, replacing any earlier
+substitute for that session. Handing the session back to its original instructor removes the record.
+*/
+func recordSessionCoverage(tx *gorm.DB, coverage *models.SessionCoverage) error {
+	if err := tx.Where("event_id = ? AND session_date = ?", coverage.EventID, coverage.SessionDate).
+		Delete(&models.SessionCoverage{}).Error; err != nil {
+		return newDeleteDBError(err, "session_coverages")
+	}
+	if coverage.OriginalInstructorID != nil && *coverage.OriginalInstructorID == coverage.SubstituteInstructorID {
+		return nil
+	}
+	coverage.ID = 0
+	if err := tx.Create(coverage).Error; err != nil {
+		return newCreateDBError(err, "session_coverages")
+	}
+	return nil
+}
+
+/*
+GetInstructorCoverageSummary totals, for each instructor at the facility, the sessions between startDate and endDate
+(inclusive, YYYY-MM-DD) they covered for someone else and the sessions of theirs that a substitute covered. Coverage
+only counts while its override still assigns the substitute, so undone or cancelled substitutions drop out.
+*/
+func (db *DB) GetInstructorCoverageSummary(facilityID uint, startDate, endDate string) ([]models.InstructorCoverageSummary, error) {
+	instructors, err := db.GetFacilityInstructors(int(facilityID))
+	if err != nil {
+		return nil, err
+	}
+	var coverages []models.SessionCoverage
+	if err := db.Table("session_coverages sc").
+		Select("sc.*").
+		Joins("JOIN program_classes c ON c.id = sc.class_id").
+		Joins("JOIN program_class_event_overrides o ON o.id = sc.override_id AND o.deleted_at IS NULL").
+		Where("c.facility_id = ? AND sc.deleted_at IS NULL AND sc.session_date BETWEEN ? AND ?", facilityID, startDate, endDate).
+		Where("o.is_cancelled = ? AND o.instructor_id = sc.substitute_instructor_id", false).
+		Find(&coverages).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "session_coverages")
+	}
+
+	summaries := make([]models.InstructorCoverageSummary, 0, len(instructors))
+	indexByInstructor := make(map[uint]int, len(instructors))
+	for _, instructor := range instructors {
+		if instructor.ID == 0 {
+			continue // the "Unassigned" placeholder
+		}
+		indexByInstructor[uint(instructor.ID)] = len(summaries)
+		summaries = append(summaries, models.InstructorCoverageSummary{
+			InstructorID: uint(instructor.ID),
+			NameFirst:    instructor.NameFirst,
+			NameLast:     instructor.NameLast,
+		})
+	}
+	for idx := range coverages {
+		coverage := &coverages[idx]
+		if i, ok := indexByInstructor[coverage.SubstituteInstructorID]; ok {
+			summaries[i].SessionsCovered++
+			summaries[i].HoursCovered += coverage.Hours()
+		}
+		if coverage.OriginalInstructorID == nil {
+			continue
+		}
+		if i, ok := indexByInstructor[*coverage.OriginalInstructorID]; ok {
+			summaries[i].SessionsMissed++
+			summaries[i].HoursMissed += coverage.Hours()
+		}
+	}
+	return summaries, nil
+}
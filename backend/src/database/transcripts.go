@@ -0,0 +1,72 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+/*
+GetTranscriptClasses returns every class the resident was enrolled in, at any facility, oldest first. Program and
+facility names come from the completion when one was recorded, since the completion keeps the names the class had
+when it was finished. Waitlist entries are left out, both open ones and ones cancelled before a seat opened, since
+the resident never took part in those classes.
+*/
+func (db *DB) GetTranscriptClasses(userID uint) ([]models.TranscriptClass, error) {
+	var classes []models.TranscriptClass
+	if err := db.Table("program_class_enrollments pce").
+		Select(`
+			COALESCE(NULLIF(comp.program_name, ''), p.name) AS program_name,
+			COALESCE(NULLIF(comp.program_class_name, ''), pc.name) AS class_name,
+			COALESCE(NULLIF(comp.facility_name, ''), f.name) AS facility_name,
+			pce.enrollment_status,
+			pce.enrolled_at,
+			pce.enrollment_ended_at AS ended_at,
+			comp.created_at AS completed_at,
+			pc.credit_hours,
+			`+sessionsAttendedSubquery+`,
+			`+sessionsTotalSubquery+`
+		`).
+		Joins("JOIN program_classes pc ON pc.id = pce.class_id").
+		Joins("JOIN programs p ON p.id = pc.program_id").
+		Joins("JOIN facilities f ON f.id = pc.facility_id").
+		Joins(`LEFT JOIN program_completions comp ON comp.id = (SELECT MAX(c.id) FROM program_completions c
+			WHERE c.user_id = pce.user_id AND c.program_class_id = pce.class_id AND c.deleted_at IS NULL)`).
+		Where("pce.user_id = ? AND pce.deleted_at IS NULL", userID).
+		Where("pce.enrollment_status <> ?", models.EnrollmentWaitlisted).
+		Where("NOT (pce.enrollment_status = ? AND pce.enrolled_at IS NULL)", models.EnrollmentCancelled).
+		Order("pce.enrolled_at, pce.id").
+		Scan(&classes).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "program_class_enrollments")
+	}
+	return classes, nil
+}
+
+func (db *DB) CreateTranscript(transcript *models.Transcript) error {
+	if err := db.Create(transcript).Error; err != nil {
+		return newCreateDBError(err, "transcripts")
+	}
+	return nil
+}
+
+// GetTranscriptByCode returns the transcript with the verification code, or nil when there is none
+func (db *DB) GetTranscriptByCode(code string) (*models.Transcript, error) {
+	var transcript models.Transcript
+	err := db.Where("verification_code = ?", code).Take(&transcript).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newGetRecordsDBError(err, "transcripts")
+	}
+	return &transcript, nil
+}
+
+func (db *DB) GetTranscriptsForUser(userID uint) ([]models.Transcript, error) {
+	var transcripts []models.Transcript
+	if err := db.Where("user_id = ?", userID).Order("issued_at DESC").Find(&transcripts).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "transcripts")
+	}
+	return transcripts, nil
+}
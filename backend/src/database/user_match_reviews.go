@@ -0,0 +1,139 @@
+package database
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+QueueUserMatchReviews keeps the review queue in step with a matcher run: new ambiguous matches are queued, pending
+reviews get the latest suggestion, and a rejected review is reopened only when the matcher now suggests someone else.
+Confirmed reviews are left alone.
+*/
+func (db *DB) QueueUserMatchReviews(ctx context.Context, reviews []models.UserMatchReview) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for idx := range reviews {
+			review := &reviews[idx]
+			var existing models.UserMatchReview
+			err := tx.Where("provider_platform_id = ? AND external_user_id = ?", review.ProviderPlatformID, review.ExternalUserID).
+				First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				review.Status = models.UserMatchPending
+				if err := tx.Create(review).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			sameSuggestion := existing.SuggestedUserID != nil && review.SuggestedUserID != nil && *existing.SuggestedUserID == *review.SuggestedUserID
+			if existing.Status == models.UserMatchConfirmed || (existing.Status == models.UserMatchRejected && sameSuggestion) {
+				*review = existing
+				continue
+			}
+			existing.FacilityID = review.FacilityID
+			existing.ImportUser = review.ImportUser
+			existing.SuggestedUserID = review.SuggestedUserID
+			existing.Score = review.Score
+			existing.Signals = review.Signals
+			existing.Status = models.UserMatchPending
+			existing.ResolvedUserID, existing.ResolvedByID, existing.ResolvedAt = nil, nil, nil
+			if err := tx.Model(&existing).
+				Select("facility_id", "import_user", "suggested_user_id", "score", "signals", "status", "resolved_user_id", "resolved_by_id", "resolved_at").
+				Updates(&existing).Error; err != nil {
+				return err
+			}
+			review.ID = existing.ID
+			review.Status = models.UserMatchPending
+		}
+		return nil
+	})
+	if err != nil {
+		return newUpdateDBError(err, "user_match_reviews")
+	}
+	return nil
+}
+
+/*
+ClosePendingUserMatchReviews removes the facility's pending reviews for provider users the matcher no longer finds
+ambiguous, such as ones since mapped or now matched with confidence, so the queue only holds what the last run left
+open. Nothing was decided on a pending review, so it is deleted outright and queued fresh if it turns up again.
+*/
+func (db *DB) ClosePendingUserMatchReviews(ctx context.Context, providerID, facilityID uint, keepExternalIDs []string) (int64, error) {
+	tx := db.WithContext(ctx).Unscoped().
+		Where("provider_platform_id = ? AND facility_id = ? AND status = ?", providerID, facilityID, models.UserMatchPending)
+	if len(keepExternalIDs) > 0 {
+		tx = tx.Where("external_user_id NOT IN ?", keepExternalIDs)
+	}
+	result := tx.Delete(&models.UserMatchReview{})
+	if result.Error != nil {
+		return 0, newDeleteDBError(result.Error, "user_match_reviews")
+	}
+	return result.RowsAffected, nil
+}
+
+func (db *DB) GetUserMatchReviews(args *models.QueryContext, providerID uint, status models.UserMatchReviewStatus) ([]models.UserMatchReview, error) {
+	reviews := make([]models.UserMatchReview, 0, args.PerPage)
+	tx := db.WithContext(args.Ctx).Model(&models.UserMatchReview{}).
+		Where("provider_platform_id = ? AND facility_id = ?", providerID, args.FacilityID)
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if err := tx.Count(&args.Total).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "user_match_reviews")
+	}
+	if err := tx.Preload("SuggestedUser").Preload("ResolvedUser").
+		Order("score DESC, id").
+		Limit(args.PerPage).Offset(args.CalcOffset()).
+		Find(&reviews).Error; err != nil {
+		return nil, newGetRecordsDBError(err, "user_match_reviews")
+	}
+	return reviews, nil
+}
+
+func (db *DB) GetUserMatchReview(ctx context.Context, providerID, id uint) (*models.UserMatchReview, error) {
+	var review models.UserMatchReview
+	if err := db.WithContext(ctx).Preload("SuggestedUser").
+		Where("provider_platform_id = ?", providerID).
+		First(&review, id).Error; err != nil {
+		return nil, newNotFoundDBError(err, "user_match_reviews")
+	}
+	return &review, nil
+}
+
+func (db *DB) ResolveUserMatchReview(ctx context.Context, review *models.UserMatchReview, status models.UserMatchReviewStatus, resolvedUserID, adminID *uint) error {
+	now := time.Now()
+	if err := db.WithContext(ctx).Model(review).Updates(map[string]any{
+		"status":           status,
+		"resolved_user_id": resolvedUserID,
+		"resolved_by_id":   adminID,
+		"resolved_at":      now,
+	}).Error; err != nil {
+		return newUpdateDBError(err, "user_match_reviews")
+	}
+	review.Status, review.ResolvedUserID, review.ResolvedByID, review.ResolvedAt = status, resolvedUserID, adminID, &now
+	return nil
+}
+
+// ConfirmPendingUserMatchReview closes the provider user's pending review once they're mapped some other way
+func (db *DB) ConfirmPendingUserMatchReview(ctx context.Context, providerID uint, externalUserID string, userID uint, adminID *uint) error {
+	if err := db.WithContext(ctx).Model(&models.UserMatchReview{}).
+		Where("provider_platform_id = ? AND external_user_id = ? AND status = ?", providerID, externalUserID, models.UserMatchPending).
+		Updates(map[string]any{
+			"status":           models.UserMatchConfirmed,
+			"resolved_user_id": userID,
+			"resolved_by_id":   adminID,
+			"resolved_at":      time.Now(),
+		}).Error; err != nil {
+		return newUpdateDBError(err, "user_match_reviews")
+	}
+	return nil
+}
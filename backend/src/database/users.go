@@ -156,21 +156,20 @@ func (db *DB) GetTransferProgramConflicts(ctx context.Context, id uint, transfer
 	return programNames, nil
 }
 
+/*
+TransferResident moves the resident to another facility in a transaction the caller commits: their enrollments at the
+current facility are marked Incomplete: Transferred and their waitlist spots there are cancelled, with the seats they
+free offered to the next resident waiting.
+*/
 func (db *DB) TransferResident(ctx *models.QueryContext, userID int, currFacilityID int, transFacilityID int) (*gorm.DB, error) {
-	trans := db.Begin()
+	trans := db.WithContext(ctx.Ctx).Begin()
 	if trans.Error != nil {
 		return nil, NewDBError(trans.Error, "unable to start DB transaction")
 	}
-	updateQuery := `UPDATE program_class_enrollments AS pce SET enrollment_status = ?
-		FROM program_classes pc
-		WHERE pce.class_id = pc.id
-			AND pce.user_id = ?
-			AND pc.facility_id = ?
-			AND pce.enrollment_status = 'Enrolled'`
-
-	if err := trans.Exec(updateQuery, "Incomplete: Transferred", userID, currFacilityID).Error; err != nil {
+	currFacility := uint(currFacilityID)
+	if _, _, err := withdrawOpenEnrollments(trans, uint(userID), &currFacility, "Incomplete: Transferred", "Transferred to another facility", nil); err != nil {
 		trans.Rollback()
-		return nil, newUpdateDBError(err, "program_class_enrollments")
+		return nil, err
 	}
 	if err := trans.Model(&models.User{}).
 		Where("id = ?", userID).
@@ -708,9 +707,10 @@ func (db *DB) GetUserAccountHistory(args *models.QueryContext, userID uint, cate
 	history := make([]models.ActivityHistoryResponse, 0, args.PerPage)
 
 	categoryActions := map[string][]string{
-		"account":    {"account_creation", "set_password", "reset_password", "user_deactivated"},
+		"account": {"account_creation", "set_password", "reset_password", "user_deactivated", "release_packet_generated",
+			"release_date_set", "release_warning_sent", "release_enrollments_withdrawn"},
 		"facility":   {"facility_transfer"},
-		"enrollment": {"progclass_history"},
+		"enrollment": {"progclass_history", "waitlist_promoted"},
 		"attendance": {"marked_present", "marked_absent_excused", "marked_absent_unexcused", "attendance_recorded"},
 	}
 
@@ -1017,7 +1017,7 @@ func (db *DB) ResetFailedLoginAttempts(userID uint) error {
 	return db.Delete(&models.FailedLoginAttempts{}, "user_id = ?", userID).Error
 }
 
-func (db *DB) CreateUsersBulk(users []models.User, adminID uint) error {
+func (db *DB) CreateUsersBulk(users []models.User, adminID *uint) error {
 	if len(users) == 0 {
 		return newCreateDBError(errors.New("no users to create"), "users")
 	}
@@ -1030,7 +1030,7 @@ func (db *DB) CreateUsersBulk(users []models.User, adminID uint) error {
 			accountCreation := models.NewUserAccountHistory(
 				user.ID,
 				models.AccountCreation,
-				&adminID,
+				adminID,
 				nil,
 				nil,
 			)
@@ -1038,6 +1038,12 @@ func (db *DB) CreateUsersBulk(users []models.User, adminID uint) error {
 				log.Errorf("Error creating account history for user %d: %v", user.ID, err)
 				return newCreateDBError(err, "user_account_history")
 			}
+			if user.ReleaseDate != nil {
+				releaseDateSet := models.NewUserAccountHistory(user.ID, models.ReleaseDateSet, adminID, nil, &user.FacilityID)
+				if err := tx.Create(releaseDateSet).Error; err != nil {
+					return newCreateDBError(err, "user_account_history")
+				}
+			}
 		}
 		return nil
 	})
@@ -1057,26 +1063,28 @@ func (db *DB) DeactivateUser(ctx context.Context, userID uint, adminID *uint) er
 		return NewDBError(tx.Error, "unable to start DB transaction")
 	}
 	defer tx.Rollback()
+	if err := deactivateUser(tx, userID, adminID); err != nil {
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return NewDBError(err, "committing transaction after deactivating user")
+	}
+	return nil
+}
 
+// deactivateUser deactivates the user within tx, withdrawing their enrollments and cancelling their waitlist spots
+func deactivateUser(tx *gorm.DB, userID uint, adminID *uint) error {
 	now := time.Now()
 	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("deactivated_at", now).Error; err != nil {
 		return newUpdateDBError(err, "users")
 	}
-	updateData := map[string]any{
-		"enrollment_status": models.EnrollmentIncompleteWithdrawn,
-		"change_reason":     "Account deactivated",
-	}
-	if err := tx.Model(&models.ProgramClassEnrollment{}).Where("user_id = ? AND enrollment_status = ?", userID, models.Enrolled).Updates(updateData).Error; err != nil {
-		return newUpdateDBError(err, "program_class_enrollments")
+	if _, _, err := withdrawOpenEnrollments(tx, userID, nil, models.EnrollmentIncompleteWithdrawn, "Account deactivated", nil); err != nil {
+		return err
 	}
 	history := models.NewUserAccountHistory(userID, models.UserDeactivated, adminID, nil, nil)
 	if err := tx.Create(&history).Error; err != nil {
 		return newCreateDBError(err, "user_account_history")
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		return NewDBError(err, "committing transaction after deactivating user")
-	}
 	return nil
 }
 
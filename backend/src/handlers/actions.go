@@ -18,6 +18,8 @@ func (srv *Server) registerActionsRoutes() []routeDef {
 		adminFeatureRoute("POST /api/actions/provider-platforms/{id}/import-users", srv.handleImportUsers, axx),
 		adminFeatureRoute("GET /api/actions/provider-platforms/{id}/match-users", srv.handleMatchUsers, axx),
 		adminFeatureRoute("POST /api/actions/provider-platforms/{id}/apply-matches", srv.handleApplyMatches, axx),
+		adminFeatureRoute("GET /api/actions/provider-platforms/{id}/match-reviews", srv.handleIndexUserMatchReviews, axx),
+		adminFeatureRoute("PUT /api/actions/provider-platforms/{id}/match-reviews/{review_id}", srv.handleResolveUserMatchReview, axx),
 	}
 }
 
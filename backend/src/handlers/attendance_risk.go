@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (srv *Server) registerAttendanceRiskRoutes() []routeDef {
+	axx := models.ProgramAccess
+	return []routeDef{
+		adminFeatureRoute("GET /api/attendance-risk/settings", srv.handleGetAttendanceRiskSettings, axx),
+		adminFeatureRoute("PUT /api/attendance-risk/settings", srv.handleUpdateAttendanceRiskSettings, axx),
+		adminFeatureRoute("GET /api/attendance-risk/alerts", srv.handleGetAttendanceRiskAlerts, axx),
+		adminValidatedFeatureRoute("PATCH /api/attendance-risk/alerts/{id}", srv.handleUpdateAttendanceRiskAlert, axx, FacilityAdminResolver("attendance_risk_alerts", "id")),
+	}
+}
+
+func (srv *Server) handleGetAttendanceRiskSettings(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.facilityScopedQueryContext(r)
+	settings, err := srv.Db.GetAttendanceRiskSettings(args.FacilityID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, settings)
+}
+
+func (srv *Server) handleUpdateAttendanceRiskSettings(w http.ResponseWriter, r *http.Request, log sLog) error {
+	facilityID, err := srv.requireFacilityID(r)
+	if err != nil {
+		return err
+	}
+	var settings models.AttendanceRiskSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if err := settings.Validate(); err != nil {
+		return newBadRequestServiceError(err, err.Error())
+	}
+	settings.FacilityID = facilityID
+	log.add("facility_id", facilityID)
+	if err := srv.Db.SaveAttendanceRiskSettings(&settings); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("attendance risk thresholds updated")
+	return writeJsonResponse(w, http.StatusOK, settings)
+}
+
+/**
+* GET: /api/attendance-risk/alerts?status=active|open|acknowledged|snoozed|resolved|all&class_id=&user_id=
+* Lists the facility's attendance-risk alerts raised by the nightly evaluation. Defaults to the active ones.
+ */
+func (srv *Server) handleGetAttendanceRiskAlerts(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.facilityScopedQueryContext(r)
+	status := r.URL.Query().Get("status")
+	validStatuses := []string{"", "active", "all", string(models.RiskAlertOpen), string(models.RiskAlertAcknowledged), string(models.RiskAlertSnoozed), string(models.RiskAlertResolved)}
+	if !slices.Contains(validStatuses, status) {
+		return newInvalidQueryParamServiceError(errors.New("invalid status"), "status")
+	}
+	var classID, userID uint
+	if param := r.URL.Query().Get("class_id"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			return newInvalidQueryParamServiceError(err, "class_id")
+		}
+		classID = uint(id)
+	}
+	// user_id is already read into args as the caller's ID by default, so only an explicit filter applies
+	if param := r.URL.Query().Get("user_id"); param != "" {
+		userID = args.UserID
+	}
+	alerts, err := srv.Db.GetAttendanceRiskAlerts(&args, status, classID, userID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, alerts, args.IntoMeta())
+}
+
+/**
+* PATCH: /api/attendance-risk/alerts/{id}
+* Acknowledges, snoozes (until snoozed_until) or resolves an alert. A note is added to the resident's
+* note timeline and is required to resolve.
+ */
+func (srv *Server) handleUpdateAttendanceRiskAlert(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "alert ID")
+	}
+	var req models.AttendanceRiskAlertActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	log.add("alert_id", id)
+	log.add("action", req.Action)
+	alert, err := srv.Db.GetAttendanceRiskAlert(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if alert.Status == models.RiskAlertResolved {
+		return newBadRequestServiceError(errors.New("alert is resolved"), "the alert is already resolved")
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	noteText := strings.TrimSpace(req.Note)
+	now := time.Now()
+	switch req.Action {
+	case models.AcknowledgeRiskAlert:
+		alert.Status = models.RiskAlertAcknowledged
+		alert.AcknowledgedAt = &now
+		alert.AcknowledgedByID = &claims.UserID
+		alert.SnoozedUntil = nil
+	case models.SnoozeRiskAlert:
+		location, err := time.LoadLocation(claims.TimeZone)
+		if err != nil {
+			location = time.UTC
+		}
+		until, err := time.ParseInLocation("2006-01-02", req.SnoozedUntil, location)
+		if err != nil {
+			return newBadRequestServiceError(err, "snoozed_until must be in YYYY-MM-DD format")
+		}
+		if !until.After(now) {
+			return newBadRequestServiceError(errors.New("snoozed_until is in the past"), "snoozed_until must be a future date")
+		}
+		alert.Status = models.RiskAlertSnoozed
+		alert.SnoozedUntil = &until
+	case models.ResolveRiskAlert:
+		if noteText == "" {
+			return newBadRequestServiceError(errors.New("note is required"), "a note is required to resolve an alert")
+		}
+		alert.Status = models.RiskAlertResolved
+		alert.ResolvedAt = &now
+		alert.ResolvedByID = &claims.UserID
+		alert.SnoozedUntil = nil
+	default:
+		return newBadRequestServiceError(errors.New("invalid action"), "action must be one of acknowledge, snooze or resolve")
+	}
+	var note *models.UserNote
+	if noteText != "" {
+		note = &models.UserNote{UserID: alert.UserID, Note: noteText}
+		note.CreateUserID = &claims.UserID
+	}
+	if err := srv.Db.UpdateAttendanceRiskAlert(alert, note); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("attendance risk alert updated")
+	return writeJsonResponse(w, http.StatusOK, alert)
+}
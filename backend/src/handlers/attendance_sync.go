@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+* POST: /api/program-classes/{class_id}/attendance/sync
+* Applies attendance marks an instructor captured while offline. Each mark carries a client generated ID, so a batch
+* that is sent again after a dropped connection is not applied twice, and the outcome of each mark is returned:
+* applied, a conflict with a status someone else recorded, or rejected.
+ */
+func (srv *Server) handleSyncAttendance(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	class, err := srv.Db.GetClassByID(classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if class.CannotUpdateClass() {
+		return newBadRequestServiceError(errors.New("class cannot be updated"), "cannot perform action on class that is completed cancelled or archived")
+	}
+	var body struct {
+		Marks []models.AttendanceSyncMark `json:"marks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if len(body.Marks) == 0 || len(body.Marks) > models.MaxAttendanceSyncBatch {
+		return newBadRequestServiceError(fmt.Errorf("%d marks in batch", len(body.Marks)),
+			fmt.Sprintf("a sync batch must have between 1 and %d marks", models.MaxAttendanceSyncBatch))
+	}
+	for i := range body.Marks {
+		mark := &body.Marks[i]
+		mark.ClientID = strings.TrimSpace(mark.ClientID)
+		if mark.ClientID == "" || len(mark.ClientID) > 64 {
+			return newBadRequestServiceError(fmt.Errorf("mark %d has client_id %q", i, mark.ClientID), "every mark needs a client_id of at most 64 characters")
+		}
+		if mark.CapturedAt.IsZero() {
+			return newBadRequestServiceError(fmt.Errorf("mark %s has no captured_at", mark.ClientID), "every mark needs the time it was captured_at")
+		}
+	}
+
+	syncedByID := srv.getUserID(r)
+	events := make(map[uint]*models.ProgramClassEvent)
+	results := make([]models.AttendanceSyncRecord, 0, len(body.Marks))
+	counts := make(map[models.AttendanceSyncResult]int)
+	for i := range body.Marks {
+		mark := &body.Marks[i]
+		rec := models.NewAttendanceSyncRecord(class.ID, mark, syncedByID)
+		att := mark.Attendance()
+		if msg := srv.prepareSyncedAttendance(class.ID, mark, &att, events); msg != "" {
+			rec.Reject(msg)
+		}
+		synced, err := srv.WithUserContext(r).SyncAttendanceMark(rec, &att, class.Name)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		counts[synced.Result]++
+		results = append(results, *synced)
+	}
+	log.add("class_id", classID)
+	log.add("applied", counts[models.AttendanceSyncApplied])
+	log.add("conflicts", counts[models.AttendanceSyncConflict])
+	log.add("rejected", counts[models.AttendanceSyncRejected])
+	log.info("offline attendance synced")
+	return writeJsonResponse(w, http.StatusOK, results)
+}
+
+// prepareSyncedAttendance runs the checks the attendance endpoint makes on a mark and fills in its time tracking,
+// returning why the mark is rejected, or an empty string when it can be applied
+func (srv *Server) prepareSyncedAttendance(classID uint, mark *models.AttendanceSyncMark, att *models.ProgramClassEventAttendance, events map[uint]*models.ProgramClassEvent) string {
+	if !mark.AttendanceStatus.IsValid() {
+		return fmt.Sprintf("unknown attendance status %q", mark.AttendanceStatus)
+	}
+	date, err := time.ParseInLocation("2006-01-02", mark.Date, time.Local)
+	if err != nil {
+		return "date must be in YYYY-MM-DD format"
+	}
+	now := time.Now().In(time.Local)
+	if date.After(time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, time.Local)) {
+		return "attempted attendance date in future"
+	}
+	event, ok := events[mark.EventID]
+	if !ok {
+		event, err = srv.Db.GetEventById(int(mark.EventID))
+		if err != nil {
+			event = nil
+		}
+		events[mark.EventID] = event
+	}
+	if event == nil || event.ClassID != classID {
+		return "session is not part of this class"
+	}
+	if isDateCancelled(event.Overrides, mark.Date) {
+		return "cannot record attendance for cancelled class date"
+	}
+	scheduledMinutes := deriveScheduledMinutes(event, findOverrideForDate(event.Overrides, mark.Date))
+	if err := applyTimeTracking(att, scheduledMinutes); err != nil {
+		var svcErr serviceError
+		if errors.As(err, &svcErr) {
+			return svcErr.Message
+		}
+		return err.Error()
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"UnlockEdv2/src/i18n"
 	"UnlockEdv2/src/models"
 	"context"
 	"encoding/json"
@@ -35,6 +36,7 @@ type (
 		SessionID     string                 `json:"session_id"`
 		DocID         string                 `json:"doc_id"`
 		TimeZone      string                 `json:"timezone"`
+		Locale        i18n.Locale            `json:"locale"`
 	}
 )
 
@@ -324,6 +326,7 @@ func (srv *Server) validateOrySession(r *http.Request) (*Claims, bool, error) {
 					FeatureAccess: featureAccess,
 					SessionID:     sessionID,
 					TimeZone:      user.Facility.Timezone,
+					Locale:        user.Locale.OrDefault(),
 				}
 				traitsRole, ok := traits["role"].(string)
 				if !ok || string(user.Role) != traitsRole {
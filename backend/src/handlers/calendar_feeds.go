@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"UnlockEdv2/src/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	calendarFeedLookback  = 30 * 24 * time.Hour
+	calendarFeedLookahead = 180 * 24 * time.Hour
+)
+
+func (srv *Server) registerCalendarFeedRoutes() []routeDef {
+	axx := models.ProgramAccess
+	return []routeDef{
+		validatedFeatureRoute("GET /api/calendar-feeds", srv.handleIndexCalendarFeeds, axx, ResidentFeatureResolver(models.ResidentProgramsAccess)),
+		validatedFeatureRoute("POST /api/calendar-feeds", srv.handleCreateCalendarFeed, axx, ResidentFeatureResolver(models.ResidentProgramsAccess)),
+		validatedFeatureRoute("DELETE /api/calendar-feeds/{id}", srv.handleRevokeCalendarFeed, axx, ResidentFeatureResolver(models.ResidentProgramsAccess)),
+	}
+}
+
+// the .ics route is fetched by calendar clients that have no session, the signed token in the path is the credential
+func (srv *Server) registerICalFeedRoute() {
+	srv.Mux.Handle("GET /api/ical/{token}", srv.handleError(srv.handleGetICalFeed))
+}
+
+type calendarFeedResponse struct {
+	models.CalendarFeedToken
+	URL string `json:"url"`
+}
+
+func newCalendarFeedResponse(token *models.CalendarFeedToken) calendarFeedResponse {
+	return calendarFeedResponse{
+		CalendarFeedToken: *token,
+		URL:               fmt.Sprintf("%s/api/ical/%s", strings.TrimRight(os.Getenv("APP_URL"), "/"), token.SignedToken()),
+	}
+}
+
+func (srv *Server) handleIndexCalendarFeeds(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	args := srv.getQueryContext(r)
+	tokens, err := srv.Db.GetCalendarFeedTokensForUser(&args, claims.UserID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	feeds := make([]calendarFeedResponse, 0, len(tokens))
+	for idx := range tokens {
+		feeds = append(feeds, newCalendarFeedResponse(&tokens[idx]))
+	}
+	return writePaginatedResponse(w, http.StatusOK, feeds, args.IntoMeta())
+}
+
+func (srv *Server) handleCreateCalendarFeed(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	body := struct {
+		Scope   models.CalendarFeedScope `json:"scope"`
+		ScopeID uint                     `json:"scope_id"`
+		Name    string                   `json:"name"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if !body.Scope.IsValid() {
+		return newBadRequestServiceError(errors.New("invalid calendar feed scope"), "scope must be one of facility, class, instructor or resident")
+	}
+	log.add("scope", body.Scope)
+	if !claims.isAdmin() {
+		if body.Scope != models.ResidentCalendarFeed || (body.ScopeID != 0 && body.ScopeID != claims.UserID) {
+			return newForbiddenServiceError(errors.New("resident requested another calendar"), "residents may only subscribe to their own calendar")
+		}
+		body.ScopeID = claims.UserID
+	}
+	facilityID, err := srv.resolveCalendarFeedFacility(r, claims, body.Scope, &body.ScopeID)
+	if err != nil {
+		return err
+	}
+	token := &models.CalendarFeedToken{
+		UserID:     claims.UserID,
+		FacilityID: facilityID,
+		Scope:      body.Scope,
+		ScopeID:    body.ScopeID,
+		Name:       strings.TrimSpace(body.Name),
+	}
+	if err := srv.WithUserContext(r).CreateCalendarFeedToken(token); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("calendar_feed_id", token.ID)
+	log.info("calendar feed token created")
+	return writeJsonResponse(w, http.StatusCreated, newCalendarFeedResponse(token))
+}
+
+// resolveCalendarFeedFacility checks the caller may see the requested calendar and returns the facility it belongs to
+func (srv *Server) resolveCalendarFeedFacility(r *http.Request, claims *Claims, scope models.CalendarFeedScope, scopeID *uint) (uint, error) {
+	switch scope {
+	case models.FacilityCalendarFeed:
+		if *scopeID == 0 || !claims.canSwitchFacility() {
+			*scopeID = srv.facilityScopedQueryContext(r).FacilityID
+		}
+		if _, err := srv.Db.GetFacilityByID(int(*scopeID)); err != nil {
+			return 0, newDatabaseServiceError(err)
+		}
+		return *scopeID, nil
+	case models.ClassCalendarFeed:
+		class, err := srv.Db.GetClassByID(int(*scopeID))
+		if err != nil {
+			return 0, newDatabaseServiceError(err)
+		}
+		if class.FacilityID != claims.FacilityID && !claims.canSwitchFacility() {
+			return 0, newForbiddenServiceError(errors.New("class belongs to another facility"), "class is not at your facility")
+		}
+		return class.FacilityID, nil
+	default:
+		user, err := srv.Db.GetUserByID(*scopeID)
+		if err != nil {
+			return 0, newDatabaseServiceError(err)
+		}
+		if user.FacilityID != claims.FacilityID && !claims.canSwitchFacility() {
+			return 0, newForbiddenServiceError(errors.New("user belongs to another facility"), "user is not at your facility")
+		}
+		if scope == models.ResidentCalendarFeed && user.Role != models.Student {
+			return 0, newBadRequestServiceError(errors.New("user is not a resident"), "resident calendars can only be created for residents")
+		}
+		return user.FacilityID, nil
+	}
+}
+
+func (srv *Server) handleRevokeCalendarFeed(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "calendar feed ID")
+	}
+	log.add("calendar_feed_id", id)
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	token, err := srv.Db.GetCalendarFeedTokenByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	canRevoke := token.UserID == claims.UserID ||
+		(claims.isAdmin() && (token.FacilityID == claims.FacilityID || claims.canSwitchFacility()))
+	if !canRevoke {
+		return newForbiddenServiceError(errors.New("calendar feed belongs to another user"), "you cannot revoke this calendar feed")
+	}
+	if err := srv.WithUserContext(r).RevokeCalendarFeedToken(token.ID); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("calendar feed token revoked")
+	return writeJsonResponse(w, http.StatusOK, "calendar feed revoked")
+}
+
+func (srv *Server) handleGetICalFeed(w http.ResponseWriter, r *http.Request, log sLog) error {
+	token, err := srv.authenticateCalendarFeed(r.PathValue("token"))
+	if err != nil {
+		return err
+	}
+	log.add("calendar_feed_id", token.ID)
+	facility, err := srv.Db.GetFacilityByID(int(token.FacilityID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	features, err := srv.Db.GetFacilityFeatureAccess(facility.ID, srv.features)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if !slices.Contains(features, models.ProgramAccess) {
+		return NewServiceError(errors.New("program access disabled"), http.StatusNotFound, "calendar feed not found")
+	}
+	location, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	now := time.Now().In(location)
+	dtRng := &models.DateRange{Start: now.Add(-calendarFeedLookback), End: now.Add(calendarFeedLookahead), Tzone: location}
+	args := models.QueryContext{
+		Ctx:        r.Context(),
+		FacilityID: token.FacilityID,
+		Timezone:   facility.Timezone,
+		IsAdmin:    token.Scope != models.ResidentCalendarFeed,
+		UserID:     token.ScopeID,
+		All:        true,
+	}
+	var classID int
+	if token.Scope == models.ClassCalendarFeed {
+		classID = int(token.ScopeID)
+	}
+	events, err := srv.Db.GetFacilityCalendar(&args, dtRng, classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if token.Scope == models.InstructorCalendarFeed {
+		events = slices.DeleteFunc(events, func(event models.FacilityProgramClassEvent) bool {
+			return event.InstructorID == nil || *event.InstructorID != token.ScopeID
+		})
+	}
+	calendarName := token.Name
+	if calendarName == "" {
+		calendarName = fmt.Sprintf("%s %s classes", facility.Name, token.Scope)
+	}
+	if err := srv.Db.TouchCalendarFeedToken(token.ID); err != nil {
+		log.warnf("unable to record calendar feed access: %v", err)
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"calendar-%d.ics\"", token.ID))
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	w.WriteHeader(http.StatusOK)
+	if err := services.WriteICalendar(w, calendarName, facility.Timezone, events, time.Now()); err != nil {
+		log.errorf("error writing calendar feed: %v", err)
+	}
+	return nil
+}
+
+/*
+authenticateCalendarFeed resolves a token from a subscription URL to its row. Every failure (bad signature,
+revoked token, owner deactivated or no longer an admin) is reported as not found so the URL leaks nothing.
+*/
+func (srv *Server) authenticateCalendarFeed(raw string) (*models.CalendarFeedToken, error) {
+	notFound := func(err error) error {
+		return NewServiceError(err, http.StatusNotFound, "calendar feed not found")
+	}
+	id, nonce, err := models.ParseCalendarFeedToken(raw)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	token, err := srv.Db.GetCalendarFeedTokenByID(id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if !token.MatchesNonce(nonce) || token.IsRevoked() {
+		return nil, notFound(models.ErrInvalidCalendarFeedToken)
+	}
+	owner, err := srv.Db.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if owner.DeactivatedAt != nil {
+		return nil, notFound(errors.New("calendar feed owner is deactivated"))
+	}
+	if token.Scope != models.ResidentCalendarFeed && !slices.Contains(models.AdminRoles, owner.Role) {
+		return nil, notFound(errors.New("calendar feed owner is no longer an admin"))
+	}
+	return token, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"UnlockEdv2/src/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (srv *Server) registerCalendarImportRoutes() []routeDef {
+	axx := models.ProgramAccess
+	return []routeDef{
+		adminFeatureRoute("POST /api/calendar-imports", srv.handleImportCalendar, axx),
+	}
+}
+
+/*
+handleImportCalendar accepts a multipart upload with the .ics in "file" and, optionally, "mappings": a JSON
+object keyed by VEVENT UID that picks the class, room or instructor for an event (or skips it). With
+?dry_run=true the mapped events and their conflicts are returned without saving anything.
+*/
+func (srv *Server) handleImportCalendar(w http.ResponseWriter, r *http.Request, log sLog) error {
+	facilityID, err := srv.requireFacilityID(r)
+	if err != nil {
+		return err
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	log.add("facility_id", facilityID)
+	log.add("dry_run", dryRun)
+
+	if err := r.ParseMultipartForm(5 << 20); err != nil {
+		return newBadRequestServiceError(err, "file too large or invalid format")
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return newBadRequestServiceError(err, "a calendar file is required")
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.errorf("failed to close file %v", err)
+		}
+	}()
+	log.add("filename", header.Filename)
+	if !strings.HasSuffix(strings.ToLower(header.Filename), ".ics") {
+		return newBadRequestServiceError(errors.New("invalid file type"), "file type not supported - only .ics files can be imported")
+	}
+	mappings := make(map[string]models.CalendarImportMapping)
+	if raw := r.FormValue("mappings"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mappings); err != nil {
+			return newBadRequestServiceError(err, "mappings must be a JSON object keyed by event UID")
+		}
+	}
+
+	service := services.NewCalendarImportService(srv.WithUserContext(r))
+	result, err := service.Import(facilityID, file, mappings, dryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrUnreadableCalendar) {
+			return newBadRequestServiceError(err, err.Error())
+		}
+		return newDatabaseServiceError(err)
+	}
+	switch {
+	case dryRun:
+		return writeJsonResponse(w, http.StatusOK, result)
+	case result.Rejected > 0:
+		log.add("rejected", result.Rejected)
+		log.info("calendar import rejected")
+		return writeJsonResponse(w, http.StatusUnprocessableEntity, result)
+	default:
+		log.add("created", result.Created)
+		log.info("calendar imported")
+		return writeJsonResponse(w, http.StatusCreated, result)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerCanvasEnrollmentSyncRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolve := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		adminValidatedFeatureRoute("PUT /api/program-classes/{class_id}/canvas-course", srv.handleLinkClassToCanvasCourse, axx, resolve),
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/canvas-sync", srv.handleIndexCanvasEnrollmentSyncs, axx, resolve),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/canvas-sync/retry", srv.handleRetryCanvasEnrollmentSyncs, axx, resolve),
+	}
+}
+
+/*
+* PUT: /api/program-classes/{class_id}/canvas-course
+* Links the class to a course on a Canvas provider so its enrollments are pushed there, an empty canvas_course_id unlinks
+* it. Residents need to be mapped to Canvas users for their enrollments to be pushed.
+ */
+func (srv *Server) handleLinkClassToCanvasCourse(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	var form struct {
+		ProviderPlatformID *uint  `json:"provider_platform_id"`
+		CanvasCourseID     string `json:"canvas_course_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	defer r.Body.Close()
+	class, err := srv.Db.GetClassByID(classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	var providerID *uint
+	var courseID *string
+	if course := strings.TrimSpace(form.CanvasCourseID); course != "" {
+		if form.ProviderPlatformID == nil {
+			return newBadRequestServiceError(errors.New("missing provider_platform_id"), "a Canvas provider is required to link a course")
+		}
+		if len(course) > 255 {
+			return newBadRequestServiceError(errors.New("canvas course ID too long"), "Canvas course ID must be 255 characters or fewer")
+		}
+		provider, err := srv.Db.GetProviderPlatformByID(int(*form.ProviderPlatformID))
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		if !isCanvasProvider(provider) {
+			return newBadRequestServiceError(errors.New("provider is not a Canvas platform"), "classes can only be linked to courses on a Canvas provider")
+		}
+		providerID, courseID = &provider.ID, &course
+	}
+	if err := srv.WithUserContext(r).LinkClassToCanvasCourse(r.Context(), class, providerID, courseID); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("class_id", classID)
+	log.add("canvas_course_id", form.CanvasCourseID)
+	log.info("class linked to canvas course")
+	srv.pushCanvasEnrollments(r.Context(), classID)
+	return writeJsonResponse(w, http.StatusOK, class)
+}
+
+/*
+* GET: /api/program-classes/{class_id}/canvas-sync?status=failed
+* What was last pushed to the linked Canvas course for each resident, with the error for pushes that failed.
+ */
+func (srv *Server) handleIndexCanvasEnrollmentSyncs(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	status := models.CanvasSyncStatus(r.URL.Query().Get("status"))
+	if status != "" && status != models.CanvasSyncSynced && status != models.CanvasSyncFailed {
+		return newBadRequestServiceError(errors.New("invalid status"), "status must be synced or failed")
+	}
+	args := srv.getQueryContext(r)
+	syncs, err := srv.Db.GetCanvasEnrollmentSyncs(&args, classID, status)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, syncs, args.IntoMeta())
+}
+
+// POST: /api/program-classes/{class_id}/canvas-sync/retry pushes the class's failed enrollments to Canvas again now
+func (srv *Server) handleRetryCanvasEnrollmentSyncs(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	retried, err := srv.Db.RetryCanvasEnrollmentSyncs(r.Context(), classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("class_id", classID)
+	log.add("retried", retried)
+	log.info("retrying canvas enrollment sync")
+	srv.pushCanvasEnrollments(r.Context(), classID)
+	return writeJsonResponse(w, http.StatusOK, map[string]int64{"retried": retried})
+}
+
+/*
+pushCanvasEnrollments asks the provider middleware to push the class's enrollments to its Canvas course now rather than
+at the next scheduled run. It does nothing for classes that aren't linked to a course and have nothing left to deactivate
+in one, and a failure to publish is only logged since the scheduled run picks up the change anyway.
+*/
+func (srv *Server) pushCanvasEnrollments(ctx context.Context, classID int) {
+	if srv.nats == nil {
+		return
+	}
+	pending, err := srv.Db.HasCanvasEnrollmentsToPush(ctx, classID)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to check whether class %d has enrollments to push to canvas", classID)
+		return
+	}
+	if !pending {
+		return
+	}
+	body, err := json.Marshal(map[string]any{"class_id": classID})
+	if err != nil {
+		logrus.WithError(err).Error("failed to marshal canvas enrollment sync message")
+		return
+	}
+	msg := nats.NewMsg(models.CanvasEnrollmentSyncJob.PubName())
+	msg.Data = body
+	if err := srv.nats.PublishMsg(msg); err != nil {
+		logrus.WithError(err).Errorf("failed to publish canvas enrollment sync for class %d", classID)
+	}
+}
+
+// pushCanvasEnrollmentsForUser pushes every Canvas-linked class the resident is enrolled in, for changes like transfers
+// and releases that end the resident's enrollments in several classes at once
+func (srv *Server) pushCanvasEnrollmentsForUser(ctx context.Context, userID uint) {
+	if srv.nats == nil {
+		return
+	}
+	classIDs, err := srv.Db.GetCanvasClassIDsForUser(ctx, userID)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to look up the canvas classes of user %d", userID)
+		return
+	}
+	for _, classID := range classIDs {
+		srv.pushCanvasEnrollments(ctx, classID)
+	}
+}
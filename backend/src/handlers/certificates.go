@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/jasper"
+	"UnlockEdv2/src/models"
+	"UnlockEdv2/src/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerCertificateRoutes() []routeDef {
+	axx := models.ProgramAccess
+	classResolver := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		deptAdminFeatureRoute("GET /api/certificate-templates", srv.handleIndexCertificateTemplates, axx),
+		deptAdminFeatureRoute("POST /api/certificate-templates", srv.handleCreateCertificateTemplate, axx),
+		deptAdminFeatureRoute("DELETE /api/certificate-templates/{id}", srv.handleDeleteCertificateTemplate, axx),
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/certificates", srv.handleGetClassCertificates, axx, classResolver),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/certificates", srv.handleIssueClassCertificates, axx, classResolver),
+		validatedFeatureRoute("GET /api/users/{id}/certificates", srv.handleGetUserCertificates, axx, UserRoleResolver("id")),
+		validatedFeatureRoute("GET /api/users/{id}/certificates/{certificate_id}/pdf", srv.handleDownloadCertificate, axx, UserRoleResolver("id")),
+		adminValidatedFeatureRoute("DELETE /api/certificates/{id}", srv.handleRevokeCertificate, axx, certificateResolver()),
+	}
+}
+
+// the verification route is used by employers and agencies outside the platform, the code printed on the certificate is all they have
+func (srv *Server) registerCertificateVerifyRoute() {
+	srv.Mux.Handle("GET /api/certificates/verify/{code}", srv.handleError(srv.handleVerifyCertificate))
+}
+
+// certificateResolver limits facility admins to the certificates of their own facility's residents
+func certificateResolver() RouteResolver {
+	return func(tx *database.DB, r *http.Request) bool {
+		claims := r.Context().Value(ClaimsKey).(*Claims)
+		if claims.canSwitchFacility() {
+			return true
+		}
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			return false
+		}
+		certificate, err := tx.GetCertificateByID(uint(id))
+		if err != nil {
+			return false
+		}
+		user, err := tx.GetUserByID(certificate.UserID)
+		return err == nil && user.FacilityID == claims.FacilityID
+	}
+}
+
+func certificateVerifyURL(code string) string {
+	return fmt.Sprintf("%s/api/certificates/verify/%s", strings.TrimRight(os.Getenv("APP_URL"), "/"), code)
+}
+
+func (srv *Server) handleIndexCertificateTemplates(w http.ResponseWriter, r *http.Request, log sLog) error {
+	templates, err := srv.Db.GetCertificateTemplates()
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, templates)
+}
+
+func (srv *Server) handleCreateCertificateTemplate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	var template models.CertificateTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	template.Heading = strings.TrimSpace(template.Heading)
+	template.Signatory = strings.TrimSpace(template.Signatory)
+	if err := template.Validate(); err != nil {
+		return newBadRequestServiceError(err, err.Error())
+	}
+	if !jasper.TemplateExists(template.TemplateName) {
+		return newBadRequestServiceError(fmt.Errorf("template %q not found", template.TemplateName), "template_name is not a template in the templates directory")
+	}
+	if template.CreditType != "" && !slices.Contains(models.AllCreditTypes, template.CreditType) {
+		return newBadRequestServiceError(fmt.Errorf("unknown credit type %q", template.CreditType), "credit_type is not a known credit type")
+	}
+	if template.ProgramID != nil {
+		if _, err := srv.Db.GetProgramByID(int(*template.ProgramID)); err != nil {
+			return newDatabaseServiceError(err)
+		}
+	}
+	if err := srv.Db.CreateCertificateTemplate(&template); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("certificate_template_id", template.ID)
+	log.info("certificate template created")
+	return writeJsonResponse(w, http.StatusCreated, template)
+}
+
+func (srv *Server) handleDeleteCertificateTemplate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "certificate template ID")
+	}
+	log.add("certificate_template_id", id)
+	if err := srv.Db.DeleteCertificateTemplate(uint(id)); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, "certificate template deleted")
+}
+
+func (srv *Server) handleGetClassCertificates(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	certificates, err := srv.Db.GetCertificatesForClass(uint(classID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, certificates)
+}
+
+/**
+* POST: /api/program-classes/{class_id}/certificates
+* Issues certificates for every completion in the class that does not have one yet. Completing a class or its
+* enrollments already does this, the route is there for completions whose certificates could not be issued then.
+ */
+func (srv *Server) handleIssueClassCertificates(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	log.add("class_id", classID)
+	result, err := services.NewCertificateService(srv.WithUserContext(r)).IssueClassCertificates(uint(classID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("issued", len(result.Issued))
+	log.info("class certificates issued")
+	return writeJsonResponse(w, http.StatusCreated, result)
+}
+
+/*
+issueCompletionCertificates issues the certificates for the classes' new completions once a class or its enrollments
+move to Completed. The completions are already saved, so a failure is only logged and the certificates can still be
+issued from the class.
+*/
+func (srv *Server) issueCompletionCertificates(r *http.Request, classIDs ...int) {
+	service := services.NewCertificateService(srv.WithUserContext(r))
+	for _, classID := range classIDs {
+		if _, err := service.IssueClassCertificates(uint(classID)); err != nil {
+			logrus.WithError(err).Errorf("failed to issue certificates for completed class %d", classID)
+		}
+	}
+}
+
+func (srv *Server) handleGetUserCertificates(w http.ResponseWriter, r *http.Request, log sLog) error {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	certificates, err := srv.Db.GetCertificatesForUser(uint(userID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, certificates)
+}
+
+func (srv *Server) handleDownloadCertificate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	id, err := strconv.Atoi(r.PathValue("certificate_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "certificate ID")
+	}
+	log.add("certificate_id", id)
+	certificate, err := srv.Db.GetCertificateByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if certificate.UserID != uint(userID) {
+		return NewServiceError(fmt.Errorf("certificate %d does not belong to user %d", id, userID), http.StatusNotFound, "certificate not found")
+	}
+	if certificate.RevokedAt != nil {
+		return newBadRequestServiceError(fmt.Errorf("certificate %d is revoked", certificate.ID), "this certificate has been revoked")
+	}
+	pdfBytes, err := jasper.GenerateReportPDF(certificate.ToPDF(certificateVerifyURL(certificate.VerificationCode)), nil, certificate.TemplateName)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate certificate PDF with Jasper")
+		return newInternalServerServiceError(err, "failed to generate certificate")
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"certificate-%s.pdf\"", certificate.VerificationCode))
+	_, err = w.Write(pdfBytes)
+	return err
+}
+
+func (srv *Server) handleRevokeCertificate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "certificate ID")
+	}
+	log.add("certificate_id", id)
+	certificate, err := srv.Db.GetCertificateByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if certificate.RevokedAt == nil {
+		if err := srv.WithUserContext(r).RevokeCertificate(certificate); err != nil {
+			return newDatabaseServiceError(err)
+		}
+		log.info("certificate revoked")
+	}
+	return writeJsonResponse(w, http.StatusOK, certificate)
+}
+
+/**
+* GET: /api/certificates/verify/{code}
+* Public. Confirms whether a verification code belongs to a certificate we issued, returning only what is printed on it.
+ */
+func (srv *Server) handleVerifyCertificate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	code := models.NormalizeVerificationCode(r.PathValue("code"))
+	certificate, err := srv.Db.GetCertificateByCode(code)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if certificate == nil {
+		return writeJsonResponse(w, http.StatusNotFound, models.CertificateVerification{Valid: false})
+	}
+	return writeJsonResponse(w, http.StatusOK, models.CertificateVerification{
+		Valid:        certificate.RevokedAt == nil,
+		Revoked:      certificate.RevokedAt != nil,
+		ResidentName: certificate.ResidentName,
+		ProgramName:  certificate.ProgramName,
+		FacilityName: certificate.FacilityName,
+		IssuedAt:     &certificate.IssuedAt,
+	})
+}
@@ -95,8 +95,11 @@ func (srv *Server) handleEnrollUsersInClass(w http.ResponseWriter, r *http.Reque
 		return newBadRequestServiceError(err, "cannot perform action on class that is completed cancelled or archived")
 	}
 	enrollment := struct {
-		UserIDs []int `json:"user_ids"`
-		Confirm bool  `json:"confirm"` // Check for explicit confirmation
+		UserIDs  []int `json:"user_ids"`
+		Confirm  bool  `json:"confirm"`  // Check for explicit confirmation
+		Waitlist bool  `json:"waitlist"` // Queue anyone who doesn't fit instead of turning them away
+		// Admin-approved exceptions for residents missing the program's prerequisites
+		EligibilityOverrides []eligibilityOverride `json:"eligibility_overrides"`
 	}{}
 	err = json.NewDecoder(r.Body).Decode(&enrollment)
 	if err != nil {
@@ -111,6 +114,14 @@ func (srv *Server) handleEnrollUsersInClass(w http.ResponseWriter, r *http.Reque
 		return newBadRequestServiceError(errors.New("cannot enroll deactivated user"), "deactivated user")
 	}
 
+	overrideReasons, ineligible, err := srv.resolveEligibility(classID, enrollment.UserIDs, enrollment.EligibilityOverrides)
+	if err != nil {
+		return err
+	}
+	if len(ineligible) > 0 {
+		return writeIneligibleResponse(w, ineligible)
+	}
+
 	if !enrollment.Confirm {
 		conflicts, err := srv.Db.CheckSchedulingConflicts(classID, enrollment.UserIDs)
 		if err != nil {
@@ -124,7 +135,15 @@ func (srv *Server) handleEnrollUsersInClass(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	skipped, err := srv.WithUserContext(r).CreateProgramClassEnrollments(classID, enrollment.UserIDs)
+	if len(overrideReasons) > 0 {
+		log.add("eligibility_overrides", len(overrideReasons))
+	}
+
+	if enrollment.Waitlist {
+		return srv.enrollOrWaitlistUsers(w, r, classID, enrollment.UserIDs, overrideReasons)
+	}
+
+	skipped, err := srv.WithUserContext(r).CreateProgramClassEnrollments(classID, enrollment.UserIDs, overrideReasons)
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
@@ -132,6 +151,22 @@ func (srv *Server) handleEnrollUsersInClass(w http.ResponseWriter, r *http.Reque
 	if skipped > 0 {
 		response = fmt.Sprintf("%d users were enrolled, %d were not added because capacity is full.", len(enrollment.UserIDs)-skipped, skipped)
 	}
+	srv.pushCanvasEnrollments(r.Context(), classID)
+	return writeJsonResponse(w, http.StatusCreated, response)
+}
+
+// enrollOrWaitlistUsers fills the open seats in the class in the order the residents were given
+// and places everyone else at the end of the class waitlist.
+func (srv *Server) enrollOrWaitlistUsers(w http.ResponseWriter, r *http.Request, classID int, userIDs []int, overrideReasons map[uint]string) error {
+	enrolled, waitlisted, err := srv.WithUserContext(r).EnrollOrWaitlistUsers(classID, userIDs, overrideReasons)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	response := "users enrolled"
+	if waitlisted > 0 {
+		response = fmt.Sprintf("%d users were enrolled, %d were added to the waitlist because capacity is full.", enrolled, waitlisted)
+	}
+	srv.pushCanvasEnrollments(r.Context(), classID)
 	return writeJsonResponse(w, http.StatusCreated, response)
 }
 
@@ -146,6 +181,7 @@ func (srv *Server) handleDeleteProgramClassEnrollments(w http.ResponseWriter, r
 		return newDatabaseServiceError(err)
 	}
 	log.info("class enrollment deleted")
+	srv.pushCanvasEnrollments(r.Context(), id)
 	return writeJsonResponse(w, http.StatusNoContent, "Class enrollment deleted successfully")
 }
 
@@ -183,6 +219,10 @@ func (srv *Server) handleUpdateProgramClassEnrollments(w http.ResponseWriter, r
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
+	if enrollment.EnrollmentStatus == models.EnrollmentCompleted {
+		srv.issueCompletionCertificates(r, classId)
+	}
+	srv.pushCanvasEnrollments(r.Context(), classId)
 	return writeJsonResponse(w, http.StatusOK, "updated")
 }
 
@@ -28,6 +28,7 @@ func (srv *Server) registerClassEventsRoutes() []routeDef {
 		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/events/{event_override_id}/uncancel", srv.handleUncancelOverride, axx, resolver),
 		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/events/{event_id}/uncancel-series", srv.handleUncancelSeries, axx, resolver),
 		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/events", srv.handleCreateEvent, axx, resolver),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/schedule-check", srv.handleCheckClassSchedule, axx, resolver),
 		adminValidatedFeatureRoute("PUT /api/program-classes/{class_id}/events", srv.handleRescheduleEventSeries, axx, resolver),
 	}
 }
@@ -137,35 +138,63 @@ func (srv *Server) handleEventOverrides(w http.ResponseWriter, r *http.Request,
 		overrides[i].ClassID = uint(classID)
 	}
 	var class *models.ProgramClass
+	var seriesInstructorID *uint
+	var seriesDuration string
 	for _, override := range overrides {
-		if !override.IsCancelled && override.RoomID != nil {
+		if !override.IsCancelled {
 			var err error
 			class, err = srv.Db.GetClassByID(classID)
 			if err != nil {
 				return newDatabaseServiceError(err)
 			}
+			// overrides without their own instructor are taught by the series' instructor
+			event, err := srv.Db.GetEventById(eventId)
+			if err != nil {
+				return newDatabaseServiceError(err)
+			}
+			seriesInstructorID, seriesDuration = event.InstructorID, event.Duration
 			break
 		}
 	}
+	eventIDUint := uint(eventId)
+	classIDUint := uint(classID)
 	for _, override := range overrides {
-		if override.IsCancelled || override.RoomID == nil {
+		if override.IsCancelled {
 			continue
 		}
-		if _, err := srv.Db.GetRoomByIDForFacility(*override.RoomID, class.FacilityID); err != nil {
-			return newDatabaseServiceError(err)
-		}
-		eventIDUint := uint(eventId)
-		classIDUint := uint(classID)
-		conflicts, err := srv.Db.CheckRRuleConflicts(&models.ConflictCheckRequest{
+		conflictReq := &models.ConflictCheckRequest{
 			FacilityID:     class.FacilityID,
-			RoomID:         *override.RoomID,
 			RecurrenceRule: override.OverrideRrule,
 			Duration:       override.Duration,
 			ExcludeEventID: &eventIDUint,
 			ExcludeClassID: &classIDUint,
-		})
-		if err != nil {
-			return newDatabaseServiceError(err)
+		}
+		var conflicts []models.RoomConflict
+		if override.RoomID != nil {
+			if _, err := srv.Db.GetRoomByIDForFacility(*override.RoomID, class.FacilityID); err != nil {
+				return newDatabaseServiceError(err)
+			}
+			conflictReq.RoomID = *override.RoomID
+			roomConflicts, err := srv.Db.CheckRRuleConflicts(conflictReq)
+			if err != nil {
+				return newDatabaseServiceError(err)
+			}
+			conflicts = append(conflicts, roomConflicts...)
+		}
+		instructorID := seriesInstructorID
+		if override.InstructorID != nil {
+			instructorID = override.InstructorID
+		}
+		if instructorID != nil {
+			conflictReq.InstructorID = *instructorID
+			if conflictReq.Duration == "" {
+				conflictReq.Duration = seriesDuration
+			}
+			availabilityConflicts, err := srv.Db.CheckInstructorAvailability(conflictReq)
+			if err != nil {
+				return newDatabaseServiceError(err)
+			}
+			conflicts = append(conflicts, availabilityConflicts...)
 		}
 		if len(conflicts) > 0 {
 			return writeConflictResponse(w, conflicts)
@@ -175,7 +204,17 @@ func (srv *Server) handleEventOverrides(w http.ResponseWriter, r *http.Request,
 	if err := srv.WithUserContext(r).CreateOverrideEvents(&ctx, overrides); err != nil {
 		return newDatabaseServiceError(err)
 	}
-	return writeJsonResponse(w, http.StatusOK, "Override(s) created successfully")
+	roomIDs := make([]*uint, 0, len(overrides))
+	for _, override := range overrides {
+		if !override.IsCancelled {
+			roomIDs = append(roomIDs, override.RoomID)
+		}
+	}
+	warnings, err := srv.roomCapacityWarnings(uint(classID), roomIDs...)
+	if err != nil {
+		return err
+	}
+	return writeScheduleSavedResponse(w, http.StatusOK, "Override(s) created successfully", warnings)
 }
 
 type patchEventOverrideRequest struct {
@@ -324,6 +363,11 @@ func (srv *Server) handlePatchEventOverride(w http.ResponseWriter, r *http.Reque
 				InstructorID:  newInstructorID,
 			},
 		}
+		if conflicts, err := srv.checkSessionInstructorAvailability(event, newInstructorID, newRRule, newDuration); err != nil {
+			return err
+		} else if len(conflicts) > 0 {
+			return writeConflictResponse(w, conflicts)
+		}
 		if err := srv.WithUserContext(r).CreateOverrideEvents(&ctx, overrides); err != nil {
 			return newDatabaseServiceError(err)
 		}
@@ -362,12 +406,46 @@ func (srv *Server) handlePatchEventOverride(w http.ResponseWriter, r *http.Reque
 		RoomID:        req.RoomID,
 		InstructorID:  req.InstructorID,
 	}
+	// only a new time or a new instructor can make the instructor unavailable, a room change alone cannot
+	if !req.IsCancelled && (req.InstructorID != nil || req.NewStartTime != "") {
+		instructorID := event.InstructorID
+		if req.InstructorID != nil {
+			instructorID = req.InstructorID
+		}
+		if conflicts, err := srv.checkSessionInstructorAvailability(event, instructorID, overrideRRule, overrideDuration); err != nil {
+			return err
+		} else if len(conflicts) > 0 {
+			return writeConflictResponse(w, conflicts)
+		}
+	}
 	if err := srv.WithUserContext(r).CreateOverrideEvents(&ctx, []*models.ProgramClassEventOverride{override}); err != nil {
 		return newDatabaseServiceError(err)
 	}
 	return writeJsonResponse(w, http.StatusOK, "Override created successfully")
 }
 
+// checkSessionInstructorAvailability checks a single moved or reassigned session of event against the instructor's availability and weekly hour limit
+func (srv *Server) checkSessionInstructorAvailability(event *models.ProgramClassEvent, instructorID *uint, recurrenceRule, duration string) ([]models.RoomConflict, error) {
+	if instructorID == nil {
+		return nil, nil
+	}
+	class, err := srv.Db.GetClassByID(int(event.ClassID))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	conflicts, err := srv.Db.CheckInstructorAvailability(&models.ConflictCheckRequest{
+		FacilityID:     class.FacilityID,
+		InstructorID:   *instructorID,
+		RecurrenceRule: recurrenceRule,
+		Duration:       duration,
+		ExcludeEventID: &event.ID,
+	})
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	return conflicts, nil
+}
+
 func (srv *Server) handleDeleteEventOverride(w http.ResponseWriter, r *http.Request, log sLog) error {
 	id, err := strconv.Atoi(r.PathValue("event_override_id"))
 	if err != nil {
@@ -479,7 +557,103 @@ func (srv *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request, log
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
-	return writeJsonResponse(w, http.StatusCreated, "Event created successfully")
+	warnings, err := srv.roomCapacityWarnings(uint(classID), event.RoomID)
+	if err != nil {
+		return err
+	}
+	return writeScheduleSavedResponse(w, http.StatusCreated, "Event created successfully", warnings)
+}
+
+// roomCapacityWarnings returns a warning for each of the rooms that is too small for the class, rooms are only checked once
+func (srv *Server) roomCapacityWarnings(classID uint, roomIDs ...*uint) ([]models.RoomCapacityWarning, error) {
+	warnings := []models.RoomCapacityWarning{}
+	checked := make(map[uint]bool, len(roomIDs))
+	for _, roomID := range roomIDs {
+		if roomID == nil || checked[*roomID] {
+			continue
+		}
+		checked[*roomID] = true
+		warning, err := srv.Db.CheckRoomCapacity(classID, *roomID)
+		if err != nil {
+			return nil, newDatabaseServiceError(err)
+		}
+		if warning != nil {
+			warnings = append(warnings, *warning)
+		}
+	}
+	return warnings, nil
+}
+
+// writeScheduleSavedResponse confirms a saved schedule along with the rooms too small for the class, which do not block saving
+func writeScheduleSavedResponse(w http.ResponseWriter, status int, message string, warnings []models.RoomCapacityWarning) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	resp := models.Resource[models.ScheduleCheckResult]{
+		Message: message,
+		Data:    models.ScheduleCheckResult{Conflicts: []models.RoomConflict{}, Warnings: warnings},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		return newResponseServiceError(err)
+	}
+	return nil
+}
+
+/**
+* POST: /api/program-classes/{class_id}/schedule-check
+* Previews a proposed schedule for a class without saving it. Room and instructor double-bookings, and sessions
+* outside the instructor's availability or over their weekly hour limit, are returned as conflicts (the same ones
+* that make the save endpoints respond 409) and a room too small for the class or its current enrollment is
+* returned as a warning, which does not block saving.
+ */
+func (srv *Server) handleCheckClassSchedule(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class_id")
+	}
+	var body struct {
+		RoomID         *uint  `json:"room_id"`
+		InstructorID   *uint  `json:"instructor_id"`
+		RecurrenceRule string `json:"recurrence_rule"`
+		Duration       string `json:"duration"`
+		EventID        *uint  `json:"event_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	class, err := srv.Db.GetClassByID(classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	result := models.ScheduleCheckResult{Conflicts: []models.RoomConflict{}, Warnings: []models.RoomCapacityWarning{}}
+	conflictReq := &models.ConflictCheckRequest{
+		FacilityID:     class.FacilityID,
+		RecurrenceRule: body.RecurrenceRule,
+		Duration:       body.Duration,
+		ExcludeEventID: body.EventID,
+	}
+	if body.RoomID != nil {
+		if _, err := srv.Db.GetRoomByIDForFacility(*body.RoomID, class.FacilityID); err != nil {
+			return newDatabaseServiceError(err)
+		}
+		conflictReq.RoomID = *body.RoomID
+		warnings, err := srv.roomCapacityWarnings(class.ID, body.RoomID)
+		if err != nil {
+			return err
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+	if body.InstructorID != nil {
+		conflictReq.InstructorID = *body.InstructorID
+	}
+	if body.RecurrenceRule != "" && body.Duration != "" {
+		conflicts, err := srv.Db.CheckConflicts(conflictReq)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		result.Conflicts = append(result.Conflicts, conflicts...)
+	}
+	log.add("class_id", classID)
+	return writeJsonResponse(w, http.StatusOK, result)
 }
 
 func (srv *Server) handleRescheduleEventSeries(w http.ResponseWriter, r *http.Request, log sLog) error {
@@ -501,32 +675,48 @@ func (srv *Server) handleRescheduleEventSeries(w http.ResponseWriter, r *http.Re
 	if err := json.NewDecoder(r.Body).Decode(&eventSeriesRequest); err != nil {
 		return newJSONReqBodyServiceError(err)
 	}
-	// A cancelled series frees the room, so skip the conflict check for cancellations.
-	if eventSeriesRequest.EventSeries.RoomID != nil {
+	// A cancelled series frees the room and instructor, so skip the conflict check for cancellations.
+	series := eventSeriesRequest.EventSeries
+	if series.RoomID != nil || series.InstructorID != nil {
 		class, err := srv.Db.GetClassByID(classID)
 		if err != nil {
 			return newDatabaseServiceError(err)
 		}
-		if _, err := srv.Db.GetRoomByIDForFacility(*eventSeriesRequest.EventSeries.RoomID, class.FacilityID); err != nil {
-			return newDatabaseServiceError(err)
+		if series.RoomID != nil {
+			if _, err := srv.Db.GetRoomByIDForFacility(*series.RoomID, class.FacilityID); err != nil {
+				return newDatabaseServiceError(err)
+			}
 		}
-		if !eventSeriesRequest.EventSeries.IsCancelled {
+		if !series.IsCancelled {
 			var excludeEventID *uint
 			if eventSeriesRequest.ClosedEventSeries.ID > 0 {
 				closedID := eventSeriesRequest.ClosedEventSeries.ID
 				excludeEventID = &closedID
-			} else if eventSeriesRequest.EventSeries.ID > 0 {
-				excludeEventID = &eventSeriesRequest.EventSeries.ID
+			} else if series.ID > 0 {
+				excludeEventID = &series.ID
 			}
-			conflicts, err := srv.Db.CheckRRuleConflicts(&models.ConflictCheckRequest{
+			conflictReq := &models.ConflictCheckRequest{
 				FacilityID:     class.FacilityID,
-				RoomID:         *eventSeriesRequest.EventSeries.RoomID,
-				RecurrenceRule: eventSeriesRequest.EventSeries.RecurrenceRule,
-				Duration:       eventSeriesRequest.EventSeries.Duration,
+				RecurrenceRule: series.RecurrenceRule,
+				Duration:       series.Duration,
 				ExcludeEventID: excludeEventID,
-			})
-			if err != nil {
-				return newDatabaseServiceError(err)
+			}
+			var conflicts []models.RoomConflict
+			if series.RoomID != nil {
+				conflictReq.RoomID = *series.RoomID
+				roomConflicts, err := srv.Db.CheckRRuleConflicts(conflictReq)
+				if err != nil {
+					return newDatabaseServiceError(err)
+				}
+				conflicts = append(conflicts, roomConflicts...)
+			}
+			if series.InstructorID != nil {
+				conflictReq.InstructorID = *series.InstructorID
+				availabilityConflicts, err := srv.Db.CheckInstructorAvailability(conflictReq)
+				if err != nil {
+					return newDatabaseServiceError(err)
+				}
+				conflicts = append(conflicts, availabilityConflicts...)
 			}
 			if len(conflicts) > 0 {
 				return writeConflictResponse(w, conflicts)
@@ -540,7 +730,14 @@ func (srv *Server) handleRescheduleEventSeries(w http.ResponseWriter, r *http.Re
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
-	return writeJsonResponse(w, http.StatusCreated, "Event rescheduled successfully")
+	warnings := []models.RoomCapacityWarning{}
+	if !series.IsCancelled {
+		warnings, err = srv.roomCapacityWarnings(uint(classID), series.RoomID)
+		if err != nil {
+			return err
+		}
+	}
+	return writeScheduleSavedResponse(w, http.StatusCreated, "Event rescheduled successfully", warnings)
 }
 
 func (srv *Server) cannotUpdateEvent(classID int) (bool, error) {
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (srv *Server) registerClassWaitlistRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolve := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/waitlist", srv.handleGetClassWaitlist, axx, resolve),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/waitlist", srv.handleAddUsersToClassWaitlist, axx, resolve),
+		adminValidatedFeatureRoute("DELETE /api/program-classes/{class_id}/waitlist/{user_id}", srv.handleRemoveUserFromClassWaitlist, axx, resolve),
+	}
+}
+
+func (srv *Server) handleGetClassWaitlist(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	log.add("class_id", classID)
+	args := srv.getQueryContext(r)
+	waitlist, err := srv.Db.GetClassWaitlist(&args, classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, waitlist, args.IntoMeta())
+}
+
+func (srv *Server) handleAddUsersToClassWaitlist(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	log.add("class_id", classID)
+	class, err := srv.Db.GetClassByID(classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if class.CannotUpdateClass() {
+		return newBadRequestServiceError(errors.New("class is not accepting enrollments"), "cannot perform action on class that is completed cancelled or archived")
+	}
+	body := struct {
+		UserIDs              []int                 `json:"user_ids"`
+		EligibilityOverrides []eligibilityOverride `json:"eligibility_overrides"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if len(body.UserIDs) == 0 {
+		return newBadRequestServiceError(errors.New("no user ids provided"), "user_ids is required")
+	}
+	deactivatedUsers, err := srv.Db.DeactivatedUsersPresent(body.UserIDs)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if deactivatedUsers {
+		return newBadRequestServiceError(errors.New("cannot waitlist deactivated user"), "deactivated user")
+	}
+	overrideReasons, ineligible, err := srv.resolveEligibility(classID, body.UserIDs, body.EligibilityOverrides)
+	if err != nil {
+		return err
+	}
+	if len(ineligible) > 0 {
+		return writeIneligibleResponse(w, ineligible)
+	}
+	skipped, err := srv.WithUserContext(r).AddUsersToClassWaitlist(classID, body.UserIDs, overrideReasons)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	response := "users added to waitlist"
+	if skipped > 0 {
+		response = fmt.Sprintf("%d users were added to the waitlist, %d were already enrolled or waitlisted.", len(body.UserIDs)-skipped, skipped)
+	}
+	return writeJsonResponse(w, http.StatusCreated, response)
+}
+
+func (srv *Server) handleRemoveUserFromClassWaitlist(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	log.add("class_id", classID)
+	log.add("user_id", userID)
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	if reason == "" {
+		reason = "Removed from waitlist"
+	}
+	if err := srv.WithUserContext(r).RemoveUserFromClassWaitlist(classID, userID, reason); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("resident removed from class waitlist")
+	return writeJsonResponse(w, http.StatusNoContent, "removed from waitlist")
+}
@@ -248,6 +248,12 @@ func (srv *Server) handleCreateClass(w http.ResponseWriter, r *http.Request, log
 	if len(conflicts) > 0 {
 		return writeConflictResponse(w, conflicts)
 	}
+	if conflictReq != nil {
+		newClass.RoomCapacityWarnings, err = srv.roomCapacityWarnings(newClass.ID, class.Events[0].RoomID)
+		if err != nil {
+			return err
+		}
+	}
 	log.add("program_id", id)
 	log.add("class_id", newClass.ID)
 	return writeJsonResponse(w, http.StatusCreated, newClass)
@@ -361,6 +367,22 @@ func (srv *Server) handleUpdateClass(w http.ResponseWriter, r *http.Request, log
 	if len(conflicts) > 0 {
 		return writeConflictResponse(w, conflicts)
 	}
+	if updated.Status == models.Completed {
+		srv.issueCompletionCertificates(r, id)
+	}
+	// completing or cancelling the class ends its enrollments and added seats promote from the waitlist
+	srv.pushCanvasEnrollments(r.Context(), id)
+	if len(existing.Events) > 0 {
+		// a new capacity can outgrow the class's room as much as a new room can be too small for it
+		roomID := existing.Events[0].RoomID
+		if len(class.Events) > 0 && class.Events[0].RoomID != nil {
+			roomID = class.Events[0].RoomID
+		}
+		updated.RoomCapacityWarnings, err = srv.roomCapacityWarnings(updated.ID, roomID)
+		if err != nil {
+			return err
+		}
+	}
 	return writeJsonResponse(w, http.StatusOK, updated)
 }
 
@@ -386,6 +408,12 @@ func (srv *Server) handleUpdateClasses(w http.ResponseWriter, r *http.Request, l
 	if err := srv.WithUserContext(r).UpdateProgramClasses(classIDs, classMap); err != nil {
 		return newDatabaseServiceError(err)
 	}
+	if classMap["status"] == string(models.Completed) {
+		srv.issueCompletionCertificates(r, classIDs...)
+	}
+	for _, classID := range classIDs {
+		srv.pushCanvasEnrollments(r.Context(), classID)
+	}
 
 	return writeJsonResponse(w, http.StatusOK, "Successfully updated program class")
 }
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerContentRequestRoutes() []routeDef {
+	axx := models.OpenContentAccess
+	return []routeDef{
+		featureRoute("GET /api/open-content/requests", srv.handleIndexOwnContentRequests, axx, models.RequestContentAccess),
+		adminFeatureRoute("GET /api/content-requests", srv.handleIndexContentRequestQueue, axx),
+		adminFeatureRoute("GET /api/content-requests/{id}", srv.handleGetContentRequest, axx),
+		adminFeatureRoute("PATCH /api/content-requests/{id}", srv.handleReviewContentRequest, axx),
+		adminFeatureRoute("PUT /api/content-requests/{id}/fulfill", srv.handleFulfillContentRequest, axx),
+	}
+}
+
+func (srv *Server) handleIndexOwnContentRequests(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.getQueryContext(r)
+	requests, err := srv.Db.GetContentRequestsForUser(&args, args.UserID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, requests, args.IntoMeta())
+}
+
+/**
+* GET: /api/content-requests?status=submitted&status=under_review
+* The admin review queue. Defaults to the requests still being worked on, department admins see every facility's.
+ */
+func (srv *Server) handleIndexContentRequestQueue(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.getQueryContext(r)
+	statuses := make([]models.ContentRequestStatus, 0, len(r.URL.Query()["status"]))
+	for _, status := range r.URL.Query()["status"] {
+		statuses = append(statuses, models.ContentRequestStatus(status))
+	}
+	if len(statuses) == 0 {
+		statuses = models.OpenContentRequestStatuses
+	}
+	requests, err := srv.Db.GetContentRequestQueue(&args, statuses, args.CanSwitchFacility)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, requests, args.IntoMeta())
+}
+
+// getQueuedContentRequest loads a request from the admin queue, facility admins only get requests with a requester
+// at their facility
+func (srv *Server) getQueuedContentRequest(r *http.Request, log sLog) (*models.ContentRequest, error) {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "content request ID")
+	}
+	log.add("content_request_id", id)
+	req, err := srv.Db.GetContentRequest(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if claims.canSwitchFacility() {
+		return req, nil
+	}
+	atFacility := func(request models.ContentRequest) bool { return request.FacilityID == claims.FacilityID }
+	if !atFacility(*req) && !slices.ContainsFunc(req.Duplicates, atFacility) {
+		return nil, NewServiceError(fmt.Errorf("content request %d has no requester at facility %d", req.ID, claims.FacilityID), http.StatusNotFound, "content request not found")
+	}
+	return req, nil
+}
+
+/*
+getReviewableContentRequest loads a request for review. A review carries over to the request's open duplicates, so a
+facility admin may only review requests whose open requesters are all at their facility, the others are left to a
+department admin.
+*/
+func (srv *Server) getReviewableContentRequest(r *http.Request, log sLog) (*models.ContentRequest, error) {
+	req, err := srv.getQueuedContentRequest(r, log)
+	if err != nil {
+		return nil, err
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	if claims.canSwitchFacility() {
+		return req, nil
+	}
+	for _, facilityID := range openContentRequesterFacilities(req) {
+		if facilityID != claims.FacilityID {
+			return nil, NewServiceError(fmt.Errorf("content request %d has a requester at facility %d", req.ID, facilityID), http.StatusForbidden,
+				"this content was also requested at other facilities, a department administrator must review it")
+		}
+	}
+	return req, nil
+}
+
+// openContentRequesterFacilities returns the facilities of the request and of its open duplicates, the ones a review reaches
+func openContentRequesterFacilities(req *models.ContentRequest) []uint {
+	facilityIDs := []uint{req.FacilityID}
+	for _, duplicate := range req.Duplicates {
+		if slices.Contains(models.OpenContentRequestStatuses, duplicate.Status) && !slices.Contains(facilityIDs, duplicate.FacilityID) {
+			facilityIDs = append(facilityIDs, duplicate.FacilityID)
+		}
+	}
+	return facilityIDs
+}
+
+func (srv *Server) handleGetContentRequest(w http.ResponseWriter, r *http.Request, log sLog) error {
+	req, err := srv.getQueuedContentRequest(r, log)
+	if err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, req)
+}
+
+/**
+* PATCH: /api/content-requests/{id}
+* Moves a request through review: under_review, approved or declined. The request's open duplicates follow it.
+ */
+func (srv *Server) handleReviewContentRequest(w http.ResponseWriter, r *http.Request, log sLog) error {
+	var body struct {
+		Status     models.ContentRequestStatus `json:"status"`
+		ReviewNote string                      `json:"review_note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if body.Status == models.ContentRequestFulfilled {
+		return newBadRequestServiceError(fmt.Errorf("status %s set by review", body.Status), "requests are fulfilled by linking the content that was added")
+	}
+	req, err := srv.getReviewableContentRequest(r, log)
+	if err != nil {
+		return err
+	}
+	if err := checkContentRequestTransition(req, body.Status); err != nil {
+		return err
+	}
+	req.Status = body.Status
+	req.ReviewNote = strings.TrimSpace(body.ReviewNote)
+	srv.recordContentRequestReview(r, req)
+	updated, err := srv.WithUserContext(r).UpdateContentRequestReview(req)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("status", req.Status)
+	log.add("requests_updated", len(updated))
+	log.info("content request reviewed")
+	if req.Status == models.ContentRequestDeclined {
+		srv.notifyContentRequesters(updated, "your content request was declined")
+	}
+	return writeJsonResponse(w, http.StatusOK, updated[0])
+}
+
+/**
+* PUT: /api/content-requests/{id}/fulfill
+* Links an approved request, and its open duplicates, to the library or video that was added for it and notifies
+* every resident who asked for it.
+ */
+func (srv *Server) handleFulfillContentRequest(w http.ResponseWriter, r *http.Request, log sLog) error {
+	var body struct {
+		ContentType string `json:"content_type"`
+		ContentID   int    `json:"content_id"`
+		ReviewNote  string `json:"review_note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	req, err := srv.getReviewableContentRequest(r, log)
+	if err != nil {
+		return err
+	}
+	if err := checkContentRequestTransition(req, models.ContentRequestFulfilled); err != nil {
+		return err
+	}
+	var providerID uint
+	switch body.ContentType {
+	case "library":
+		library, err := srv.Db.GetLibraryByID(body.ContentID)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		providerID = library.OpenContentProviderID
+	case "video":
+		video, err := srv.Db.GetVideoByID(body.ContentID, req.FacilityID)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		providerID = video.OpenContentProviderID
+	default:
+		return newBadRequestServiceError(fmt.Errorf("unknown content type %q", body.ContentType), "content_type must be library or video")
+	}
+	contentID := uint(body.ContentID)
+	// every resident who asked is pointed at the content, so it has to be visible where they are
+	hidden, err := srv.Db.GetFacilitiesNotShowingContent(providerID, contentID, openContentRequesterFacilities(req))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if len(hidden) > 0 {
+		return newBadRequestServiceError(fmt.Errorf("%s %d is not visible at facilities %v", body.ContentType, contentID, hidden),
+			fmt.Sprintf("the %s is not visible at every requester's facility, make it visible before fulfilling the request", body.ContentType))
+	}
+	now := time.Now()
+	req.Status = models.ContentRequestFulfilled
+	req.ContentType, req.ContentID, req.OpenContentProviderID, req.FulfilledAt = body.ContentType, &contentID, &providerID, &now
+	if note := strings.TrimSpace(body.ReviewNote); note != "" {
+		req.ReviewNote = note
+	}
+	srv.recordContentRequestReview(r, req)
+	updated, err := srv.WithUserContext(r).UpdateContentRequestReview(req)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("content_type", req.ContentType)
+	log.add("content_id", contentID)
+	log.add("requests_updated", len(updated))
+	log.info("content request fulfilled")
+	srv.notifyContentRequesters(updated, fmt.Sprintf("the content you requested is now available: %s", req.Content))
+	return writeJsonResponse(w, http.StatusOK, updated[0])
+}
+
+func checkContentRequestTransition(req *models.ContentRequest, status models.ContentRequestStatus) error {
+	if req.DuplicateOfID != nil {
+		return NewServiceError(fmt.Errorf("content request %d duplicates %d", req.ID, *req.DuplicateOfID), http.StatusConflict,
+			fmt.Sprintf("this request is a duplicate, review request %d instead", *req.DuplicateOfID))
+	}
+	if err := req.CanTransitionTo(status); err != nil {
+		return NewServiceError(err, http.StatusConflict, err.Error())
+	}
+	return nil
+}
+
+func (srv *Server) recordContentRequestReview(r *http.Request, req *models.ContentRequest) {
+	now := time.Now()
+	reviewerID := srv.getUserID(r)
+	req.ReviewedByID, req.ReviewedAt = &reviewerID, &now
+}
+
+func (srv *Server) notifyContentRequesters(requests []models.ContentRequest, msg string) {
+	if srv.wsClient == nil {
+		return
+	}
+	for _, req := range requests {
+		srv.wsClient.notifyUser(WsMsg{
+			EventType: ContentRequestEvent,
+			UserID:    req.UserID,
+			Msg:       MsgContent{Msg: msg, ContentRequestID: req.ID, ContentPath: req.ContentPath()},
+		})
+	}
+}
+
+// emailNewContentRequest lets the content team know a request is waiting in the queue, the request itself is the
+// record so a failed email only gets logged
+func (srv *Server) emailNewContentRequest(r *http.Request, req *models.ContentRequest) {
+	if srv.sesClient == nil || req.DuplicateOfID != nil {
+		return
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	// the email goes to staff rather than the requester, so it is written in the default locale
+	locale := i18n.Default()
+	subject := locale.T("Content Request") + " - " + claims.Username + " - " + claims.FacilityName
+	bodyHTML := getBodyHTML(locale, req.ID, claims.Username, claims.FacilityName, req.Content)
+	if err := srv.sendEmail(r.Context(), subject, locale.T("Content request received for UnlockEd"), bodyHTML); err != nil {
+		logrus.WithError(err).Errorf("failed to email content request %d", req.ID)
+	}
+}
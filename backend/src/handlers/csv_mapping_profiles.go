@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (srv *Server) registerCSVMappingProfileRoutes() []routeDef {
+	resolver := csvMappingProfileResolver()
+	return []routeDef{
+		newAdminRoute("GET /api/users/bulk/profiles", srv.handleIndexCSVMappingProfiles),
+		newAdminRoute("POST /api/users/bulk/profiles", srv.handleCreateCSVMappingProfile),
+		validatedAdminRoute("PUT /api/users/bulk/profiles/{id}", srv.handleUpdateCSVMappingProfile, resolver),
+		validatedAdminRoute("DELETE /api/users/bulk/profiles/{id}", srv.handleDeleteCSVMappingProfile, resolver),
+	}
+}
+
+// facility admins can change their own facility's profiles, the ones shared with every facility are left to department admins
+func csvMappingProfileResolver() RouteResolver {
+	return func(tx *database.DB, r *http.Request) bool {
+		claims := r.Context().Value(ClaimsKey).(*Claims)
+		if claims.canSwitchFacility() {
+			return true
+		}
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			return false
+		}
+		profile, err := tx.GetCSVMappingProfile(r.Context(), uint(id))
+		return err == nil && profile.FacilityID != nil && *profile.FacilityID == claims.FacilityID
+	}
+}
+
+func (srv *Server) handleIndexCSVMappingProfiles(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	profiles, err := srv.Db.GetCSVMappingProfiles(r.Context(), claims.FacilityID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, profiles)
+}
+
+// decodeCSVMappingProfile reads and validates a profile, facility admins' profiles always belong to their facility
+func decodeCSVMappingProfile(r *http.Request, claims *Claims) (*models.CSVMappingProfile, error) {
+	var profile models.CSVMappingProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		return nil, newJSONReqBodyServiceError(err)
+	}
+	defer r.Body.Close()
+	profile.Name = strings.TrimSpace(profile.Name)
+	if err := profile.Validate(); err != nil {
+		return nil, newBadRequestServiceError(err, err.Error())
+	}
+	if len(profile.Name) > 100 {
+		return nil, newBadRequestServiceError(errors.New("profile name too long"), "profile name must be 100 characters or fewer")
+	}
+	if !claims.canSwitchFacility() {
+		profile.FacilityID = &claims.FacilityID
+	}
+	return &profile, nil
+}
+
+func (srv *Server) handleCreateCSVMappingProfile(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	profile, err := decodeCSVMappingProfile(r, claims)
+	if err != nil {
+		return err
+	}
+	if err := srv.Db.CreateCSVMappingProfile(r.Context(), profile); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("csv_mapping_profile_id", profile.ID)
+	log.info("csv mapping profile created")
+	return writeJsonResponse(w, http.StatusCreated, profile)
+}
+
+func (srv *Server) handleUpdateCSVMappingProfile(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "csv mapping profile ID")
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	profile, err := decodeCSVMappingProfile(r, claims)
+	if err != nil {
+		return err
+	}
+	existing, err := srv.Db.GetCSVMappingProfile(r.Context(), uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	existing.Name = profile.Name
+	existing.FacilityID = profile.FacilityID
+	existing.Columns = profile.Columns
+	existing.UpdateUserID = &claims.UserID
+	if err := srv.Db.UpdateCSVMappingProfile(r.Context(), existing); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("csv_mapping_profile_id", id)
+	log.info("csv mapping profile updated")
+	return writeJsonResponse(w, http.StatusOK, existing)
+}
+
+func (srv *Server) handleDeleteCSVMappingProfile(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "csv mapping profile ID")
+	}
+	log.add("csv_mapping_profile_id", id)
+	if err := srv.Db.DeleteCSVMappingProfile(r.Context(), uint(id)); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, "csv mapping profile deleted")
+}
+
+// bulkUploadProfile loads the mapping profile picked for an upload, which has to be usable at the admin's facility
+func (srv *Server) bulkUploadProfile(r *http.Request, claims *Claims) (*models.CSVMappingProfile, error) {
+	profileID := strings.TrimSpace(r.FormValue("profile_id"))
+	if profileID == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(profileID)
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "csv mapping profile ID")
+	}
+	profile, err := srv.Db.GetCSVMappingProfile(r.Context(), uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if profile.FacilityID != nil && *profile.FacilityID != claims.FacilityID {
+		return nil, newBadRequestServiceError(errors.New("profile belongs to another facility"), "the mapping profile belongs to another facility")
+	}
+	return profile, nil
+}
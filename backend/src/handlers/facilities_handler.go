@@ -3,8 +3,11 @@ package handlers
 import (
 	"UnlockEdv2/src/models"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 func (srv *Server) registerFacilitiesRoutes() []routeDef {
@@ -18,6 +21,8 @@ func (srv *Server) registerFacilitiesRoutes() []routeDef {
 		newDeptAdminRoute("PATCH /api/facilities/{id}", srv.handleUpdateFacility),
 		adminFeatureRoute("GET /api/rooms", srv.handleGetRooms, axx),
 		adminFeatureRoute("POST /api/rooms", srv.handleCreateRoom, axx),
+		adminFeatureRoute("GET /api/rooms/available", srv.handleFindAvailableRooms, axx),
+		adminFeatureRoute("PATCH /api/rooms/{id}", srv.handleUpdateRoom, axx),
 		adminValidatedFeatureRoute("GET /api/facilities/{facilityId}/instructors",
 			srv.handleGetFacilityInstructors, models.ProgramAccess, FacilityAdminResolver("facilities", "facilityId")),
 	}
@@ -138,7 +143,8 @@ func (srv *Server) handleDeleteFacility(w http.ResponseWriter, r *http.Request,
 func (srv *Server) handleGetRooms(w http.ResponseWriter, r *http.Request, log sLog) error {
 	facilityID := srv.facilityScopedQueryContext(r).FacilityID
 	log.add("facility_id", facilityID)
-	rooms, err := srv.Db.GetRoomsForFacility(facilityID)
+	activeOnly, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+	rooms, err := srv.Db.GetRoomsForFacility(facilityID, activeOnly)
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
@@ -155,15 +161,150 @@ func (srv *Server) handleCreateRoom(w http.ResponseWriter, r *http.Request, log
 		return newJSONReqBodyServiceError(err)
 	}
 	room.FacilityID = facilityID
+	room.IsActive = true
 	log.add("facility_id", facilityID)
 	log.add("room_name", room.Name)
-	created, err := srv.Db.CreateRoom(&room)
+	if err := validateRoomAttributes(room.Attributes); err != nil {
+		return err
+	}
+	created, err := srv.WithUserContext(r).CreateRoom(&room)
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
 	return writeJsonResponse(w, http.StatusCreated, created)
 }
 
+func (srv *Server) handleUpdateRoom(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "room ID")
+	}
+	facilityID, err := srv.requireFacilityID(r)
+	if err != nil {
+		return err
+	}
+	log.add("room_id", id)
+	room, err := srv.Db.GetRoomByIDForFacility(uint(id), facilityID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	var body struct {
+		Name       *string                 `json:"name"`
+		Capacity   *int64                  `json:"capacity"`
+		IsActive   *bool                   `json:"is_active"`
+		Attributes *[]models.RoomAttribute `json:"attributes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if body.Name != nil {
+		room.Name = *body.Name
+	}
+	if body.Capacity != nil {
+		room.Capacity = body.Capacity
+		if *body.Capacity == 0 {
+			room.Capacity = nil // zero clears an unknown capacity
+		}
+	}
+	if body.IsActive != nil {
+		room.IsActive = *body.IsActive
+	}
+	if body.Attributes != nil {
+		if err := validateRoomAttributes(*body.Attributes); err != nil {
+			return err
+		}
+		room.Attributes = *body.Attributes
+	}
+	updated, err := srv.WithUserContext(r).UpdateRoom(room)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, updated)
+}
+
+func validateRoomAttributes(attrs []models.RoomAttribute) error {
+	for _, attr := range attrs {
+		if !attr.Attribute.IsValid() {
+			return newBadRequestServiceError(fmt.Errorf("invalid room attribute %q", attr.Attribute), "unknown room attribute: "+string(attr.Attribute))
+		}
+	}
+	return nil
+}
+
+/**
+* GET: /api/rooms/available
+* Lists the active rooms that are free for a window, given either start/end (RFC 3339) for a single session or
+* recurrence_rule/duration for a series. min_capacity and repeated attribute params narrow the list; class_id
+* requires seats for the larger of the class capacity and its current enrollment.
+ */
+func (srv *Server) handleFindAvailableRooms(w http.ResponseWriter, r *http.Request, log sLog) error {
+	facilityID, err := srv.requireFacilityID(r)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	req := &models.RoomFinderRequest{
+		FacilityID:     facilityID,
+		RecurrenceRule: query.Get("recurrence_rule"),
+		Duration:       query.Get("duration"),
+	}
+	if req.RecurrenceRule == "" {
+		start, err := time.Parse(time.RFC3339, query.Get("start"))
+		if err != nil {
+			return newInvalidQueryParamServiceError(err, "start")
+		}
+		end, err := time.Parse(time.RFC3339, query.Get("end"))
+		if err != nil {
+			return newInvalidQueryParamServiceError(err, "end")
+		}
+		if !end.After(start) {
+			return newBadRequestServiceError(errors.New("end before start"), "end must be after start")
+		}
+		req.RecurrenceRule = fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=1", start.UTC().Format("20060102T150405Z"))
+		req.Duration = end.Sub(start).String()
+	}
+	if raw := query.Get("min_capacity"); raw != "" {
+		if req.MinCapacity, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return newInvalidQueryParamServiceError(err, "min_capacity")
+		}
+	}
+	if raw := query.Get("class_id"); raw != "" {
+		classID, err := strconv.Atoi(raw)
+		if err != nil {
+			return newInvalidQueryParamServiceError(err, "class_id")
+		}
+		class, err := srv.Db.GetClassByID(classID)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		enrolled, err := srv.Db.GetTotalEnrollmentsByClassID(classID)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		req.MinCapacity = max(req.MinCapacity, class.Capacity, enrolled)
+	}
+	if raw := query.Get("exclude_event_id"); raw != "" {
+		eventID, err := strconv.Atoi(raw)
+		if err != nil {
+			return newInvalidQueryParamServiceError(err, "exclude_event_id")
+		}
+		req.ExcludeEventID = models.UintPtr(uint(eventID))
+	}
+	for _, attr := range query["attribute"] {
+		attribute := models.RoomAttributeType(attr)
+		if !attribute.IsValid() {
+			return newInvalidQueryParamServiceError(fmt.Errorf("invalid room attribute %q", attr), "attribute")
+		}
+		req.Attributes = append(req.Attributes, attribute)
+	}
+	log.add("facility_id", facilityID)
+	rooms, err := srv.Db.FindAvailableRooms(req)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, rooms)
+}
+
 /**
 * GET: /api/facilities/{facilityId}/instructors
  */
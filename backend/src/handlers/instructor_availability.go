@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (srv *Server) registerInstructorAvailabilityRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolver := FacilityAdminResolver("users", "id")
+	return []routeDef{
+		adminValidatedFeatureRoute("GET /api/instructors/{id}/availability", srv.handleGetInstructorAvailability, axx, resolver),
+		adminValidatedFeatureRoute("PUT /api/instructors/{id}/availability", srv.handleUpdateInstructorAvailability, axx, resolver),
+		adminValidatedFeatureRoute("POST /api/instructors/{id}/blackouts", srv.handleCreateInstructorBlackout, axx, resolver),
+		adminValidatedFeatureRoute("DELETE /api/instructors/{id}/blackouts/{blackout_id}", srv.handleDeleteInstructorBlackout, axx, resolver),
+	}
+}
+
+// getInstructorFromPath loads the instructor named by the {id} path value, rejecting users who cannot teach classes
+func (srv *Server) getInstructorFromPath(r *http.Request, log sLog) (*models.User, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "instructor ID")
+	}
+	log.add("instructor_id", id)
+	user, err := srv.Db.GetUserByID(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if !slices.Contains([]models.UserRole{models.FacilityAdmin, models.DepartmentAdmin}, user.Role) {
+		return nil, newBadRequestServiceError(errors.New("user is not an instructor"), "user cannot be assigned as an instructor")
+	}
+	return user, nil
+}
+
+func (srv *Server) handleGetInstructorAvailability(w http.ResponseWriter, r *http.Request, log sLog) error {
+	instructor, err := srv.getInstructorFromPath(r, log)
+	if err != nil {
+		return err
+	}
+	profile, err := srv.Db.GetInstructorAvailabilityProfile(instructor.ID, time.Now())
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, profile)
+}
+
+func (srv *Server) handleUpdateInstructorAvailability(w http.ResponseWriter, r *http.Request, log sLog) error {
+	instructor, err := srv.getInstructorFromPath(r, log)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Windows        []models.InstructorAvailability `json:"windows"`
+		MaxWeeklyHours *float64                        `json:"max_weekly_hours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	for _, window := range body.Windows {
+		if window.DayOfWeek < 0 || window.DayOfWeek > 6 {
+			return newBadRequestServiceError(errors.New("invalid day_of_week"), "day_of_week must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		start, startErr := time.Parse("15:04", window.StartTime)
+		end, endErr := time.Parse("15:04", window.EndTime)
+		if startErr != nil || endErr != nil {
+			return newBadRequestServiceError(errors.Join(startErr, endErr), "start_time and end_time must be in HH:MM format")
+		}
+		if !end.After(start) {
+			return newBadRequestServiceError(errors.New("availability window ends before it starts"), "end_time must be after start_time")
+		}
+	}
+	if body.MaxWeeklyHours != nil && (*body.MaxWeeklyHours <= 0 || *body.MaxWeeklyHours > 168) {
+		return newBadRequestServiceError(errors.New("invalid max_weekly_hours"), "max_weekly_hours must be greater than 0 and at most 168")
+	}
+	if body.Windows == nil {
+		body.Windows = []models.InstructorAvailability{}
+	}
+	if err := srv.WithUserContext(r).ReplaceInstructorAvailability(instructor.ID, body.Windows, body.MaxWeeklyHours); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	profile, err := srv.Db.GetInstructorAvailabilityProfile(instructor.ID, time.Now())
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("instructor availability updated")
+	return writeJsonResponse(w, http.StatusOK, profile)
+}
+
+/**
+* POST: /api/instructors/{id}/blackouts
+* Blocks whole days, from start_date through end_date (YYYY-MM-DD, in the instructor's facility timezone),
+* so no class sessions can be scheduled for the instructor on them.
+ */
+func (srv *Server) handleCreateInstructorBlackout(w http.ResponseWriter, r *http.Request, log sLog) error {
+	instructor, err := srv.getInstructorFromPath(r, log)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	location := time.UTC
+	if facility, err := srv.Db.GetFacilityByID(int(instructor.FacilityID)); err == nil {
+		if loc, err := time.LoadLocation(facility.Timezone); err == nil {
+			location = loc
+		}
+	}
+	startDate, err := time.ParseInLocation("2006-01-02", body.StartDate, location)
+	if err != nil {
+		return newBadRequestServiceError(err, "start_date must be in YYYY-MM-DD format")
+	}
+	endDate := startDate
+	if body.EndDate != "" {
+		endDate, err = time.ParseInLocation("2006-01-02", body.EndDate, location)
+		if err != nil {
+			return newBadRequestServiceError(err, "end_date must be in YYYY-MM-DD format")
+		}
+	}
+	if endDate.Before(startDate) {
+		return newBadRequestServiceError(fmt.Errorf("end_date %s is before start_date %s", body.EndDate, body.StartDate), "end_date cannot be before start_date")
+	}
+	blackout := &models.InstructorBlackout{
+		InstructorID: instructor.ID,
+		StartsAt:     startDate,
+		EndsAt:       endDate.AddDate(0, 0, 1),
+		Reason:       strings.TrimSpace(body.Reason),
+	}
+	if err := srv.WithUserContext(r).CreateInstructorBlackout(blackout); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("blackout_id", blackout.ID)
+	log.info("instructor blackout created")
+	return writeJsonResponse(w, http.StatusCreated, blackout)
+}
+
+func (srv *Server) handleDeleteInstructorBlackout(w http.ResponseWriter, r *http.Request, log sLog) error {
+	instructor, err := srv.getInstructorFromPath(r, log)
+	if err != nil {
+		return err
+	}
+	blackoutID, err := strconv.Atoi(r.PathValue("blackout_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "blackout ID")
+	}
+	log.add("blackout_id", blackoutID)
+	if err := srv.WithUserContext(r).DeleteInstructorBlackout(instructor.ID, uint(blackoutID)); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, "instructor blackout deleted")
+}
@@ -0,0 +1,534 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+const kioskTokenHeader = "X-Kiosk-Token"
+
+func (srv *Server) registerKioskRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolver := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/events/{event_id}/kiosk-sessions", srv.handleOpenKioskSession, axx, resolver),
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/kiosk-sessions/{id}", srv.handleReviewKioskSession, axx, resolver),
+		adminValidatedFeatureRoute("PUT /api/program-classes/{class_id}/kiosk-sessions/{id}/finalize", srv.handleFinalizeKioskSession, axx, resolver),
+		validatedAdminRoute("PUT /api/users/{id}/kiosk-pin", srv.handleSetKioskPin, FacilityAdminResolver("users", "id")),
+	}
+}
+
+// the kiosk device has no session, the signed token in the X-Kiosk-Token header is the credential and is good for one class session
+func (srv *Server) registerKioskCheckInRoutes() {
+	srv.Mux.Handle("GET /api/kiosk/session", srv.handleError(srv.handleGetKioskSession))
+	srv.Mux.Handle("POST /api/kiosk/check-in", srv.handleError(srv.handleKioskCheckIn))
+	srv.Mux.Handle("POST /api/kiosk/check-out", srv.handleError(srv.handleKioskCheckOut))
+}
+
+type kioskSessionResponse struct {
+	models.KioskSession
+	Token string `json:"token,omitempty"`
+}
+
+type kioskReviewResponse struct {
+	Session        models.KioskSession                  `json:"session"`
+	Attendance     []models.ProgramClassEventAttendance `json:"attendance"`
+	NotCheckedIn   []models.User                        `json:"not_checked_in"`
+	StillCheckedIn int                                  `json:"still_checked_in"`
+}
+
+/**
+* POST: /api/program-classes/{class_id}/events/{event_id}/kiosk-sessions
+* Opens kiosk check-in on a shared device for today's session of the event. The token in the response is shown only
+* once and goes to the device, it cannot be used for any other event or date.
+ */
+func (srv *Server) handleOpenKioskSession(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	eventID, err := strconv.Atoi(r.PathValue("event_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "event ID")
+	}
+	class, err := srv.Db.GetClassByID(classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if class.CannotUpdateClass() {
+		return newBadRequestServiceError(errors.New("class cannot be updated"), "cannot perform action on class that is completed cancelled or archived")
+	}
+	event, err := srv.Db.GetEventById(eventID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if event.ClassID != class.ID {
+		return newBadRequestServiceError(fmt.Errorf("event %d is not part of class %d", event.ID, class.ID), "session is not part of this class")
+	}
+	now, err := srv.kioskNow(class.FacilityID)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	today := now.Format("2006-01-02")
+	if body.Date == "" {
+		body.Date = today
+	}
+	if body.Date != today {
+		return newBadRequestServiceError(fmt.Errorf("kiosk requested for %s", body.Date), "kiosk check-in can only be opened for today's session")
+	}
+	if isDateCancelled(event.Overrides, body.Date) {
+		return newBadRequestServiceError(errors.New("session cancelled"), "cannot record attendance for cancelled class date")
+	}
+	session, err := models.NewKioskSession(class, event.ID, body.Date, srv.getUserID(r))
+	if err != nil {
+		return newInternalServerServiceError(err, "unable to open kiosk session")
+	}
+	if err := srv.WithUserContext(r).CreateKioskSession(session); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("kiosk_session_id", session.ID)
+	log.add("event_id", event.ID)
+	log.info("kiosk session opened")
+	return writeJsonResponse(w, http.StatusCreated, kioskSessionResponse{KioskSession: *session, Token: session.SignedToken()})
+}
+
+/**
+* GET: /api/program-classes/{class_id}/kiosk-sessions/{id}
+* Shows the instructor what the kiosk recorded, who is still checked in and which enrolled residents never checked in.
+ */
+func (srv *Server) handleReviewKioskSession(w http.ResponseWriter, r *http.Request, log sLog) error {
+	session, err := srv.getClassKioskSession(r)
+	if err != nil {
+		return err
+	}
+	review, err := srv.reviewKioskSession(r.Context(), session)
+	if err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, review)
+}
+
+/**
+* PUT: /api/program-classes/{class_id}/kiosk-sessions/{id}/finalize
+* Closes the kiosk session. Residents still checked in are checked out now, their minutes capped at the scheduled
+* length, and with mark_absent set enrolled residents who never checked in are recorded absent.
+ */
+func (srv *Server) handleFinalizeKioskSession(w http.ResponseWriter, r *http.Request, log sLog) error {
+	session, err := srv.getClassKioskSession(r)
+	if err != nil {
+		return err
+	}
+	if session.FinalizedAt != nil {
+		return NewServiceError(errors.New("kiosk session already finalized"), http.StatusConflict, "kiosk session is already finalized")
+	}
+	var body struct {
+		MarkAbsent bool `json:"mark_absent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	review, err := srv.reviewKioskSession(r.Context(), session)
+	if err != nil {
+		return err
+	}
+	now, err := srv.kioskNow(session.FacilityID)
+	if err != nil {
+		return err
+	}
+	checkOutAt := kioskCheckOutTime(session, now)
+	scheduledMinutes := deriveScheduledMinutes(session.Event, findOverrideForDate(session.Event.Overrides, session.Date))
+	attendance := make([]models.ProgramClassEventAttendance, 0, len(review.Attendance))
+	for _, att := range review.Attendance {
+		if att.CheckInAt == nil || att.CheckOutAt != nil {
+			continue
+		}
+		att.User, att.AttendanceStatus = nil, ""
+		if err := checkOutKioskAttendance(&att, checkOutAt, scheduledMinutes); err != nil {
+			return err
+		}
+		attendance = append(attendance, att)
+	}
+	if body.MarkAbsent {
+		for _, user := range review.NotCheckedIn {
+			attendance = append(attendance, models.ProgramClassEventAttendance{
+				EventID:          session.EventID,
+				UserID:           user.ID,
+				Date:             session.Date,
+				AttendanceStatus: models.Absent_Unexcused,
+			})
+		}
+	}
+	adminID := srv.getUserID(r)
+	if err := srv.WithUserContext(r).FinalizeKioskSession(session, attendance, adminID, session.Class.Name); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("kiosk_session_id", session.ID)
+	log.add("attendance_recorded", len(attendance))
+	log.info("kiosk session finalized")
+	if review, err = srv.reviewKioskSession(r.Context(), session); err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, review)
+}
+
+/**
+* PUT: /api/users/{id}/kiosk-pin
+* Sets the PIN a resident checks in with on a class kiosk, and unlocks kiosk check-in after too many wrong PINs.
+ */
+func (srv *Server) handleSetKioskPin(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	var body struct {
+		Pin string `json:"pin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	user, err := srv.Db.GetUserByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if user.Role != models.Student {
+		return newBadRequestServiceError(fmt.Errorf("user %d is not a resident", user.ID), "only residents check in with a kiosk PIN")
+	}
+	if err := user.SetKioskPin(strings.TrimSpace(body.Pin)); err != nil {
+		if errors.Is(err, models.ErrInvalidKioskPin) {
+			return newBadRequestServiceError(err, err.Error())
+		}
+		return newInternalServerServiceError(err, "unable to set kiosk PIN")
+	}
+	if err := srv.WithUserContext(r).UpdateKioskPin(user); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("user_id", user.ID)
+	log.info("kiosk PIN set")
+	return writeJsonResponse(w, http.StatusOK, "Kiosk PIN updated")
+}
+
+type kioskCheckInRequest struct {
+	DocID string `json:"doc_id"`
+	Pin   string `json:"pin"`
+}
+
+type kioskAttendanceResponse struct {
+	NameFirst        string            `json:"name_first"`
+	NameLast         string            `json:"name_last"`
+	AttendanceStatus models.Attendance `json:"attendance_status"`
+	CheckInAt        *string           `json:"check_in_at"`
+	CheckOutAt       *string           `json:"check_out_at"`
+}
+
+/**
+* GET: /api/kiosk/session
+* Describes the class session the kiosk token is for, so the device can show what residents are checking in to.
+ */
+func (srv *Server) handleGetKioskSession(w http.ResponseWriter, r *http.Request, log sLog) error {
+	session, err := srv.authenticateKioskSession(r)
+	if err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, map[string]any{
+		"class_name": session.Class.Name,
+		"event_id":   session.EventID,
+		"date":       session.Date,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+/**
+* POST: /api/kiosk/check-in
+* Checks a resident in to the kiosk's class session with their DOC ID and PIN, recording them present from now.
+ */
+func (srv *Server) handleKioskCheckIn(w http.ResponseWriter, r *http.Request, log sLog) error {
+	session, user, existing, err := srv.authenticateKioskResident(r, log)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.CheckInAt != nil {
+		return NewServiceError(errors.New("already checked in"), http.StatusConflict, "you are already checked in to this class")
+	}
+	now, err := srv.kioskNow(session.FacilityID)
+	if err != nil {
+		return err
+	}
+	checkIn := now.Format("15:04")
+	att := models.ProgramClassEventAttendance{
+		EventID:   session.EventID,
+		UserID:    user.ID,
+		Date:      session.Date,
+		CheckInAt: &checkIn,
+	}
+	scheduledMinutes := deriveScheduledMinutes(session.Event, findOverrideForDate(session.Event.Overrides, session.Date))
+	if err := applyTimeTracking(&att, scheduledMinutes); err != nil {
+		return err
+	}
+	var previous *string
+	if existing != nil {
+		previous = existing.CheckInAt
+	}
+	if err := srv.Db.RecordKioskAttendance(&att, previous, session.Class.Name); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("user_id", user.ID)
+	log.info("resident checked in on kiosk")
+	return writeJsonResponse(w, http.StatusOK, newKioskAttendanceResponse(user, &att))
+}
+
+/**
+* POST: /api/kiosk/check-out
+* Checks a resident out of the kiosk's class session, recording them present, or partial when they left early.
+ */
+func (srv *Server) handleKioskCheckOut(w http.ResponseWriter, r *http.Request, log sLog) error {
+	session, user, existing, err := srv.authenticateKioskResident(r, log)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.CheckInAt == nil {
+		return NewServiceError(errors.New("not checked in"), http.StatusConflict, "you have not checked in to this class")
+	}
+	if existing.CheckOutAt != nil {
+		return NewServiceError(errors.New("already checked out"), http.StatusConflict, "you are already checked out of this class")
+	}
+	now, err := srv.kioskNow(session.FacilityID)
+	if err != nil {
+		return err
+	}
+	att := *existing
+	att.AttendanceStatus = ""
+	scheduledMinutes := deriveScheduledMinutes(session.Event, findOverrideForDate(session.Event.Overrides, session.Date))
+	if err := checkOutKioskAttendance(&att, kioskCheckOutTime(session, now), scheduledMinutes); err != nil {
+		return err
+	}
+	if err := srv.Db.RecordKioskAttendance(&att, existing.CheckInAt, session.Class.Name); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("user_id", user.ID)
+	log.info("resident checked out on kiosk")
+	return writeJsonResponse(w, http.StatusOK, newKioskAttendanceResponse(user, &att))
+}
+
+func newKioskAttendanceResponse(user *models.User, att *models.ProgramClassEventAttendance) kioskAttendanceResponse {
+	return kioskAttendanceResponse{
+		NameFirst:        user.NameFirst,
+		NameLast:         user.NameLast,
+		AttendanceStatus: att.AttendanceStatus,
+		CheckInAt:        att.CheckInAt,
+		CheckOutAt:       att.CheckOutAt,
+	}
+}
+
+// checkOutKioskAttendance checks a resident out at checkOutAt, or a minute after they checked in so the range is never empty
+func checkOutKioskAttendance(att *models.ProgramClassEventAttendance, checkOutAt time.Time, scheduledMinutes int) error {
+	checkOut := checkOutAt.Format("15:04")
+	if checkIn, err := time.Parse("15:04", *att.CheckInAt); err == nil {
+		if out, _ := time.Parse("15:04", checkOut); !out.After(checkIn) {
+			checkOut = checkIn.Add(time.Minute).Format("15:04")
+		}
+	}
+	att.CheckOutAt = &checkOut
+	return applyTimeTracking(att, scheduledMinutes)
+}
+
+// kioskCheckOutTime is now, or the session's scheduled end when residents are checked out after the class ended
+func kioskCheckOutTime(session *models.KioskSession, now time.Time) time.Time {
+	if end, ok := kioskSessionEnd(session, now.Location()); ok && now.After(end) {
+		return end
+	}
+	return now
+}
+
+// kioskSessionEnd is when the session is scheduled to end on its date, using the date's override when it was rescheduled
+func kioskSessionEnd(session *models.KioskSession, loc *time.Location) (time.Time, bool) {
+	event := session.Event
+	duration := event.Duration
+	var start time.Time
+	if override := findOverrideForDate(event.Overrides, session.Date); override != nil && !override.IsCancelled {
+		rRule, err := rrule.StrToRRule(override.OverrideRrule)
+		if err != nil || len(rRule.All()) == 0 {
+			return time.Time{}, false
+		}
+		start = rRule.All()[0]
+		if override.Duration != "" {
+			duration = override.Duration
+		}
+	} else {
+		day, err := time.ParseInLocation("2006-01-02", session.Date, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		rule, err := event.GetRRuleWithTimezone(loc.String())
+		if err != nil {
+			return time.Time{}, false
+		}
+		occurrences := rule.Between(day, day.AddDate(0, 0, 1), true)
+		if len(occurrences) == 0 {
+			return time.Time{}, false
+		}
+		start = occurrences[0]
+	}
+	length, err := time.ParseDuration(duration)
+	if err != nil || length <= 0 {
+		return time.Time{}, false
+	}
+	return start.Add(length).In(loc), true
+}
+
+func (srv *Server) authenticateKioskSession(r *http.Request) (*models.KioskSession, error) {
+	unauthorized := func(err error) error {
+		return NewServiceError(err, http.StatusUnauthorized, "kiosk session is not open")
+	}
+	id, nonce, err := models.ParseKioskSessionToken(r.Header.Get(kioskTokenHeader))
+	if err != nil {
+		return nil, unauthorized(err)
+	}
+	session, err := srv.Db.GetKioskSession(id)
+	if err != nil {
+		return nil, unauthorized(err)
+	}
+	if !session.MatchesNonce(nonce) || !session.IsOpen() {
+		return nil, unauthorized(models.ErrInvalidKioskToken)
+	}
+	if session.Class.CannotUpdateClass() {
+		return nil, unauthorized(errors.New("class cannot be updated"))
+	}
+	features, err := srv.Db.GetFacilityFeatureAccess(session.FacilityID, srv.features)
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if !slices.Contains(features, models.ProgramAccess) {
+		return nil, unauthorized(errors.New("program access disabled"))
+	}
+	return session, nil
+}
+
+/*
+authenticateKioskResident checks the kiosk token and the resident's DOC ID and PIN, returning the resident's
+attendance for the session when some was recorded. A wrong DOC ID, a wrong PIN and a locked PIN get the same answer,
+enough wrong PINs in a row lock the resident out of kiosk check-in until an admin sets a new PIN, and too many
+incorrect attempts of any kind pause the kiosk for a few minutes so it cannot be used to guess DOC IDs.
+*/
+func (srv *Server) authenticateKioskResident(r *http.Request, log sLog) (*models.KioskSession, *models.User, *models.ProgramClassEventAttendance, error) {
+	session, err := srv.authenticateKioskSession(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	log.add("kiosk_session_id", session.ID)
+	var body kioskCheckInRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, nil, nil, newJSONReqBodyServiceError(err)
+	}
+	now := time.Now()
+	if session.IsRateLimited(now) {
+		return nil, nil, nil, NewServiceError(fmt.Errorf("kiosk session %d rate limited", session.ID), http.StatusTooManyRequests,
+			"too many incorrect attempts on this kiosk, try again in a few minutes")
+	}
+	incorrect := func(err error) error {
+		if dbErr := srv.Db.RecordKioskSessionFailure(session.ID, now); dbErr != nil {
+			return newDatabaseServiceError(dbErr)
+		}
+		return NewServiceError(err, http.StatusUnauthorized, "DOC ID or PIN is incorrect")
+	}
+	body.DocID = strings.TrimSpace(body.DocID)
+	if body.DocID == "" || body.Pin == "" {
+		return nil, nil, nil, incorrect(errors.New("missing DOC ID or PIN"))
+	}
+	user, err := srv.Db.GetKioskResident(session.FacilityID, session.ClassID, body.DocID)
+	if err != nil {
+		return nil, nil, nil, incorrect(err)
+	}
+	if user.IsKioskPinLocked() {
+		log.add("user_id", user.ID)
+		return nil, nil, nil, incorrect(fmt.Errorf("kiosk PIN locked for user %d", user.ID))
+	}
+	correct := user.CheckKioskPin(body.Pin)
+	if err := srv.Db.RecordKioskPinAttempt(user, correct); err != nil {
+		return nil, nil, nil, newDatabaseServiceError(err)
+	}
+	if !correct {
+		log.add("user_id", user.ID)
+		return nil, nil, nil, incorrect(errors.New("incorrect kiosk PIN"))
+	}
+	existing, err := srv.Db.GetKioskAttendance(session, user.ID)
+	if err != nil {
+		return nil, nil, nil, newDatabaseServiceError(err)
+	}
+	return session, user, existing, nil
+}
+
+func (srv *Server) getClassKioskSession(r *http.Request) (*models.KioskSession, error) {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "class ID")
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "kiosk session ID")
+	}
+	session, err := srv.Db.GetKioskSession(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if session.ClassID != uint(classID) {
+		return nil, NewServiceError(fmt.Errorf("kiosk session %d is not for class %d", id, classID), http.StatusNotFound, "kiosk session not found")
+	}
+	return session, nil
+}
+
+func (srv *Server) reviewKioskSession(ctx context.Context, session *models.KioskSession) (*kioskReviewResponse, error) {
+	attendance, err := srv.Db.GetKioskSessionAttendance(session)
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	enrolledIDs, err := srv.Db.GetEnrolledUserIDsForClass(session.ClassID, nil)
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	review := &kioskReviewResponse{Session: *session, Attendance: attendance, NotCheckedIn: []models.User{}}
+	recorded := make(map[uint]bool, len(attendance))
+	for _, att := range attendance {
+		recorded[att.UserID] = true
+		if att.CheckInAt != nil && att.CheckOutAt == nil {
+			review.StillCheckedIn++
+		}
+	}
+	missing := slices.DeleteFunc(enrolledIDs, func(id uint) bool { return recorded[id] })
+	if len(missing) > 0 {
+		users, err := srv.Db.GetUsersByIDs(ctx, missing, session.FacilityID, models.Student)
+		if err != nil {
+			return nil, newDatabaseServiceError(err)
+		}
+		review.NotCheckedIn = users
+	}
+	return review, nil
+}
+
+// kioskNow is the current time at the session's facility, which check-in times and the session date are recorded in
+func (srv *Server) kioskNow(facilityID uint) (time.Time, error) {
+	facility, err := srv.Db.GetFacilityByID(int(facilityID))
+	if err != nil {
+		return time.Time{}, newDatabaseServiceError(err)
+	}
+	location, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return time.Now().In(location), nil
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKioskCheckOutTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &models.KioskSession{
+		Date: "2026-03-04",
+		Event: &models.ProgramClassEvent{
+			Duration:       "2h",
+			RecurrenceRule: "DTSTART;TZID=Local:20260302T090000\nRRULE:FREQ=DAILY;COUNT=10",
+		},
+	}
+	end := time.Date(2026, 3, 4, 11, 0, 0, 0, loc)
+
+	during := time.Date(2026, 3, 4, 10, 15, 0, 0, loc)
+	assert.Equal(t, during, kioskCheckOutTime(session, during), "residents leaving during class are checked out when they leave")
+	afterClass := time.Date(2026, 3, 4, 17, 30, 0, 0, loc)
+	assert.True(t, end.Equal(kioskCheckOutTime(session, afterClass)), "checking out after class ends is recorded at the scheduled end")
+	nextDay := time.Date(2026, 3, 5, 8, 0, 0, 0, loc)
+	assert.True(t, end.Equal(kioskCheckOutTime(session, nextDay)), "a session finalized the next day ends on its own date")
+
+	session.Event.Overrides = []models.ProgramClassEventOverride{{
+		Duration:      "1h",
+		OverrideRrule: "DTSTART:20260304T200000Z\nRRULE:FREQ=DAILY;COUNT=1",
+	}}
+	rescheduledEnd := time.Date(2026, 3, 4, 15, 0, 0, 0, loc)
+	assert.True(t, rescheduledEnd.Equal(kioskCheckOutTime(session, afterClass)), "a rescheduled session ends when the override does")
+}
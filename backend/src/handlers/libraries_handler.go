@@ -102,6 +102,16 @@ func (srv *Server) handleGetQuerySuggestions(w http.ResponseWriter, r *http.Requ
 	return err
 }
 
+// maxSearchLanguages bounds how many kiwix searches a single request can fan out to
+const maxSearchLanguages = 10
+
+/**
+* GET: /api/open-content/search?search=...&language=eng&language=spa
+* Searches video and library titles and the pages of the kiwix libraries in the requested languages, or language=all
+* for every language the facility can see. Without a language, the facility's most used library language is searched.
+* Kiwix can only search books of one language at a time, so each language is searched separately and gets an equal
+* share of the page.
+ */
 func (srv *Server) handleSearchOpenContent(w http.ResponseWriter, r *http.Request, log sLog) error {
 	page, perPage := srv.getPaginationInfo(r)
 	search := r.URL.Query().Get("search")
@@ -120,11 +130,20 @@ func (srv *Server) handleSearchOpenContent(w http.ResponseWriter, r *http.Reques
 			libraryIDs = append(libraryIDs, libID)
 		}
 	}
+	queryCtx := srv.facilityScopedQueryContext(r)
+	available, err := srv.Db.GetFacilityLibraryLanguages(&queryCtx)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	languages := searchLanguages(r.URL.Query()["language"], available)
+	if len(languages) > maxSearchLanguages {
+		return newBadRequestServiceError(fmt.Errorf("%d languages requested", len(languages)), fmt.Sprintf("cannot search more than %d languages at once", maxSearchLanguages))
+	}
+	log.add("languages", languages)
 	// if we are on a library viewer page, we want to search
 	// only the included library, so we omit title search
-	queryCtx := srv.facilityScopedQueryContext(r)
 	if page == 1 && len(libraryIDs) == 0 {
-		titleSearch, err = srv.Db.OpenContentTitleSearch(&queryCtx)
+		titleSearch, err = srv.Db.OpenContentTitleSearch(&queryCtx, languages)
 		if err != nil {
 			log.error("error performing title search on open content")
 		}
@@ -136,65 +155,52 @@ func (srv *Server) handleSearchOpenContent(w http.ResponseWriter, r *http.Reques
 		index := (page * perPage) - (perPage - 1)
 		channels = append(channels, rss.SerializeSearchMeta(index, perPage, len(titleSearch), search))
 		channels[0].AppendTitleSearchResults(titleSearch)
+		channels[0].Languages = languageFacets(available, languages, nil, titleSearch)
 		return writePaginatedResponse(w, http.StatusOK, channels, paginationData)
 	}
 	if len(libraryIDs) > 0 {
-		libraries, err = srv.Db.GetLibrariesByIDsAndLang(libraryIDs, "eng")
+		libraries, err = srv.Db.GetLibrariesByIDsAndLangs(libraryIDs, languages)
 	} else {
-		libraries, err = srv.Db.GetAllLibrariesByLang(&queryCtx, "eng")
+		libraries, err = srv.Db.GetAllLibrariesByLangs(&queryCtx, languages)
 	}
 	if err != nil {
 		log.add("library_ids", libraryIDs)
 		return newDatabaseServiceError(err)
 	}
 	channels := make([]*models.OpenContentSearchResult, 0, 1) //only ever going to be one
+	kiwixTotals := make(map[string]int64)
 	if len(libraries) > 0 {
-		nextPage := (page-1)*perPage + 1
-		queryParams := url.Values{}
-		for _, library := range libraries {
-			queryParams.Add("books.name", path.Base(library.Url))
-		}
-		queryParams.Add("format", "xml")
-		queryParams.Add("pattern", search)
-		kiwixSearchURL := fmt.Sprintf("%s/search?start=%d&pageLength=%d&%s", models.KiwixLibraryUrl, nextPage, perPage, queryParams.Encode())
-		request, err := http.NewRequest(http.MethodGet, kiwixSearchURL, nil)
-		log.add("kiwix_search_url", kiwixSearchURL)
-		if err != nil {
-			return newInternalServerServiceError(err, "unable to create new request to kiwix")
-		}
-		resp, err := srv.Client.Do(request)
-		if err != nil {
-			return newInternalServerServiceError(err, "error executing kiwix search request")
-		}
-		defer func() {
-			if resp.Body.Close() != nil {
-				log.error("error closing response body")
-			}
-		}()
-		if resp.StatusCode != http.StatusOK {
-			log.add("status_code", resp.StatusCode)
-			body, err := io.ReadAll(resp.Body)
+		groups := groupLibrariesByLanguage(libraries, languages)
+		share := (perPage + len(groups) - 1) / len(groups)
+		var (
+			merged   *models.OpenContentSearchResult
+			total    int64
+			lastPage int
+		)
+		for _, group := range groups {
+			rss, count, err := srv.searchKiwix(group.libraries, search, (page-1)*share+1, share, log)
 			if err != nil {
-				return newInternalServerServiceError(err, "executing request returned unexpected status, and failed to read error from its response")
+				return err
+			}
+			kiwixTotals[group.language] = count
+			total += count
+			lastPage = max(lastPage, int((count+int64(share)-1)/int64(share)))
+			result := rss.SerializeSearchResults(group.libraries)
+			if merged == nil {
+				merged = result
+			} else {
+				merged.Items = append(merged.Items, result.Items...)
 			}
-			log.add("kiwix_error", string(body))
-			return newBadRequestServiceError(errors.New("api call to kiwix failed"), "response contained unexpected status code from kiwix")
-		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return newInternalServerServiceError(err, "error reading body of response")
-		}
-		var rss models.RSS
-		err = xml.Unmarshal(body, &rss)
-		if err != nil {
-			return newInternalServerServiceError(err, "error parsing response body into XML")
 		}
-		total, err := strconv.ParseInt(strings.ReplaceAll(rss.Channel.TotalResults, ",", ""), 10, 64)
-		if err != nil {
-			return newInternalServerServiceError(err, "error parsing the total results value into an int64")
+		if len(groups) > 1 {
+			merged.TotalResults = strconv.FormatInt(total, 10)
+			merged.StartIndex = strconv.Itoa((page-1)*perPage + 1)
+			merged.ItemsPerPage = strconv.Itoa(perPage)
 		}
 		paginationData = models.NewPaginationInfo(page, perPage, int64(total+int64(len(titleSearch))))
-		channels = append(channels, rss.SerializeSearchResults(libraries))
+		// a language with more results than its share of each page needs more pages than the combined total suggests
+		paginationData.LastPage = max(paginationData.LastPage, lastPage)
+		channels = append(channels, merged)
 	} else {
 		paginationData = models.NewPaginationInfo(page, perPage, int64(len(titleSearch)))
 	}
@@ -211,9 +217,141 @@ func (srv *Server) handleSearchOpenContent(w http.ResponseWriter, r *http.Reques
 		}
 		slices.Reverse(channels[0].Items)
 	}
+	if len(channels) > 0 {
+		channels[0].Languages = languageFacets(available, languages, kiwixTotals, titleSearch)
+	}
 	return writePaginatedResponse(w, http.StatusOK, channels, paginationData)
 }
 
+// searchLanguages resolves the requested languages, "all" meaning every language the facility can see. With none
+// requested it falls back to the facility's most used library language, then to English.
+func searchLanguages(requested []string, available []models.LibraryLanguage) []string {
+	languages := make([]string, 0, len(requested))
+	for _, language := range requested {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if language == "all" {
+			languages = languages[:0]
+			for _, lang := range available {
+				languages = append(languages, lang.Language)
+			}
+			break
+		}
+		if language != "" && !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) > 0 {
+		return languages
+	}
+	if len(available) > 0 {
+		return []string{available[0].Language}
+	}
+	return []string{"eng"}
+}
+
+type libraryLanguageGroup struct {
+	language  string
+	libraries []models.Library
+}
+
+// groupLibrariesByLanguage groups the libraries in the order of the requested languages, a library outside of them
+// (a single library viewed directly) gets a group of its own language
+func groupLibrariesByLanguage(libraries []models.Library, languages []string) []libraryLanguageGroup {
+	groups := make([]libraryLanguageGroup, 0, len(languages))
+	for _, language := range languages {
+		groups = append(groups, libraryLanguageGroup{language: language})
+	}
+	for _, library := range libraries {
+		language := ""
+		if library.Language != nil {
+			language = *library.Language
+		}
+		idx := slices.IndexFunc(groups, func(group libraryLanguageGroup) bool { return group.language == language })
+		if idx == -1 {
+			groups = append(groups, libraryLanguageGroup{language: language})
+			idx = len(groups) - 1
+		}
+		groups[idx].libraries = append(groups[idx].libraries, library)
+	}
+	return slices.DeleteFunc(groups, func(group libraryLanguageGroup) bool { return len(group.libraries) == 0 })
+}
+
+// searchKiwix runs a full text search across the given libraries, which kiwix requires to share a language
+func (srv *Server) searchKiwix(libraries []models.Library, search string, start, pageLength int, log sLog) (*models.RSS, int64, error) {
+	queryParams := url.Values{}
+	for _, library := range libraries {
+		queryParams.Add("books.name", path.Base(library.Url))
+	}
+	queryParams.Add("format", "xml")
+	queryParams.Add("pattern", search)
+	kiwixSearchURL := fmt.Sprintf("%s/search?start=%d&pageLength=%d&%s", models.KiwixLibraryUrl, start, pageLength, queryParams.Encode())
+	request, err := http.NewRequest(http.MethodGet, kiwixSearchURL, nil)
+	log.add("kiwix_search_url", kiwixSearchURL)
+	if err != nil {
+		return nil, 0, newInternalServerServiceError(err, "unable to create new request to kiwix")
+	}
+	resp, err := srv.Client.Do(request)
+	if err != nil {
+		return nil, 0, newInternalServerServiceError(err, "error executing kiwix search request")
+	}
+	defer func() {
+		if resp.Body.Close() != nil {
+			log.error("error closing response body")
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		log.add("status_code", resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, newInternalServerServiceError(err, "executing request returned unexpected status, and failed to read error from its response")
+		}
+		log.add("kiwix_error", string(body))
+		return nil, 0, newBadRequestServiceError(errors.New("api call to kiwix failed"), "response contained unexpected status code from kiwix")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, newInternalServerServiceError(err, "error reading body of response")
+	}
+	var rss models.RSS
+	err = xml.Unmarshal(body, &rss)
+	if err != nil {
+		return nil, 0, newInternalServerServiceError(err, "error parsing response body into XML")
+	}
+	total, err := strconv.ParseInt(strings.ReplaceAll(rss.Channel.TotalResults, ",", ""), 10, 64)
+	if err != nil {
+		return nil, 0, newInternalServerServiceError(err, "error parsing the total results value into an int64")
+	}
+	return &rss, total, nil
+}
+
+// languageFacets lists every library language the facility can see, with result counts for the searched ones
+func languageFacets(available []models.LibraryLanguage, selected []string, kiwixTotals map[string]int64, titleSearch []models.OpenContentItem) []models.LanguageFacet {
+	facets := make([]models.LanguageFacet, 0, len(available)+len(selected))
+	for _, lang := range available {
+		facets = append(facets, models.LanguageFacet{Language: lang.Language, Libraries: lang.Libraries})
+	}
+	for _, language := range selected {
+		if !slices.ContainsFunc(facets, func(facet models.LanguageFacet) bool { return facet.Language == language }) {
+			facets = append(facets, models.LanguageFacet{Language: language})
+		}
+	}
+	for idx := range facets {
+		facet := &facets[idx]
+		if !slices.Contains(selected, facet.Language) {
+			continue
+		}
+		results := kiwixTotals[facet.Language]
+		for _, item := range titleSearch {
+			if item.Language == facet.Language {
+				results++
+			}
+		}
+		facet.Selected = true
+		facet.Results = &results
+	}
+	return facets
+}
+
 func (srv *Server) handleToggleLibraryVisibility(w http.ResponseWriter, r *http.Request, log sLog) error {
 	args := srv.facilityScopedQueryContext(r)
 	id, err := strconv.Atoi(r.PathValue("id"))
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"UnlockEdv2/src/i18n"
 	"UnlockEdv2/src/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"net/http"
@@ -80,38 +82,54 @@ func (srv *Server) handleBookmarkOpenContent(w http.ResponseWriter, r *http.Requ
 	return writeJsonResponse(w, http.StatusOK, "Bookmark toggled successfully")
 }
 
+/**
+* POST: /api/open-content/request-content
+* Saves the resident's request for review. A request for content another resident already asked for is saved as a
+* duplicate of theirs.
+ */
 func (srv *Server) handleRequestOpenContent(w http.ResponseWriter, r *http.Request, log sLog) error {
-	var req struct {
+	var body struct {
 		Content string `json:"content"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		return newBadRequestServiceError(err, "error reading content requests")
 	}
-	context := r.Context()
-	claims := context.Value(ClaimsKey).(*Claims)
-	username := claims.Username
-	facilityName := claims.FacilityName
-	subject := "Content Request - " + username + " - " + facilityName
-	bodyHTML := getBodyHTML(username, facilityName, req.Content)
-	err := srv.sendEmail(context, subject, "Content requests recieved for UnlockEd", bodyHTML)
-	if err != nil {
-		return newBadRequestServiceError(err, "error sending email")
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	req := models.ContentRequest{
+		UserID:     claims.UserID,
+		FacilityID: claims.FacilityID,
+		Content:    strings.TrimSpace(body.Content),
+	}
+	if models.NormalizeContentRequest(req.Content) == "" {
+		return newBadRequestServiceError(errors.New("empty content request"), "please describe the content you are requesting")
+	}
+	if err := srv.WithUserContext(r).CreateContentRequest(&req); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("content_request_id", req.ID)
+	if req.DuplicateOfID != nil {
+		log.add("duplicate_of_id", *req.DuplicateOfID)
 	}
-	return writeJsonResponse(w, http.StatusOK, "content requests sent")
+	log.info("content request submitted")
+	srv.emailNewContentRequest(r, &req)
+	return writeJsonResponse(w, http.StatusCreated, req)
 }
 
-func getBodyHTML(username, facility, bodyText string) string {
-	pasteableString := `=SPLIT("` + username + `|` + facility + `|` + bodyText + `", "|")`
+func getBodyHTML(locale i18n.Locale, requestID uint, username, facility, bodyText string) string {
 	return fmt.Sprintf(`<div style="font-family: Arial, sans-serif; font-size: 16px;">
-        <p>Content Request from %s at %s</p>
-        <p>We have received the following information:</p>
-		<h3>Username</h3>
+        <p>%s</p>
+        <p>%s</p>
+		<h3>%s</h3>
 		<p>%s</p>
-		<h3>Facility Name</h3>
+		<h3>%s</h3>
 		<p>%s</p>
-		<h3>Content Request</h3>
+		<h3>%s</h3>
 		<p>%s</p>
-		<p>Pasteable string for quick import into csv file:</p>
 		<p>%s</p>
-    </div>`, html.EscapeString(username), html.EscapeString(facility), html.EscapeString(username), html.EscapeString(facility), html.EscapeString(bodyText), pasteableString)
+    </div>`, locale.Tf("Content Request #%d from %s at %s", requestID, html.EscapeString(username), html.EscapeString(facility)),
+		locale.T("We have received the following information:"),
+		locale.T("Username"), html.EscapeString(username),
+		locale.T("Facility Name"), html.EscapeString(facility),
+		locale.T("Content Request"), html.EscapeString(bodyText),
+		locale.T("The request is waiting for review in the content request queue."))
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"UnlockEdv2/src/models"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -201,15 +202,10 @@ func (srv *Server) handleUpdatePasswordKratos(claims *Claims, password string, r
 	return nil
 }
 
-func (srv *Server) updateFacilityInKratosIdentity(userID int, transFacilityID int) error {
+func (srv *Server) updateFacilityInKratosIdentity(user *models.User, transFacilityID int) error {
 	ctx := context.Background()
-	user, err := srv.Db.GetUserByID(uint(userID))
-	if err != nil {
-		log.Errorf("error retrieving user with id %d: %v", userID, err)
-		return err
-	}
 	if user.KratosID == "" {
-		log.Errorf("user %d has no Kratos identity ID, skipping identity update", userID)
+		log.Errorf("user %d has no Kratos identity ID, skipping identity update", user.ID)
 		return nil
 	}
 	identity, resp, err := srv.OryClient.IdentityAPI.GetIdentity(ctx, user.KratosID).Execute()
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (srv *Server) registerProgramPrerequisiteRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolve := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		featureRoute("GET /api/programs/{id}/prerequisites", srv.handleGetProgramPrerequisites, axx),
+		adminFeatureRoute("PUT /api/programs/{id}/prerequisites", srv.handleSetProgramPrerequisites, axx),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/enrollment-eligibility", srv.handleCheckEnrollmentEligibility, axx, resolve),
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/eligibility-overrides", srv.handleGetEligibilityOverrides, axx, resolve),
+	}
+}
+
+func (srv *Server) handleGetProgramPrerequisites(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "program ID")
+	}
+	prerequisites, err := srv.Db.GetProgramPrerequisites(id)
+	if err != nil {
+		log.add("program_id", id)
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, prerequisites)
+}
+
+func (srv *Server) handleSetProgramPrerequisites(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "program ID")
+	}
+	log.add("program_id", id)
+	body := struct {
+		PrerequisiteProgramIDs []uint `json:"prerequisite_program_ids"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if uint(id) >= models.CanvasProgramIDOffset {
+		return newBadRequestServiceError(errors.New("canvas program"), "prerequisites cannot be set on canvas programs")
+	}
+	if _, err := srv.Db.GetProgramByID(id); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if err := srv.WithUserContext(r).SetProgramPrerequisites(id, body.PrerequisiteProgramIDs); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	prerequisites, err := srv.Db.GetProgramPrerequisites(id)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("program prerequisites updated")
+	return writeJsonResponse(w, http.StatusOK, prerequisites)
+}
+
+func (srv *Server) handleCheckEnrollmentEligibility(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	body := struct {
+		UserIDs []int `json:"user_ids"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	ineligible, err := srv.Db.CheckEnrollmentEligibility(classID, body.UserIDs)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, map[string]any{"ineligible": ineligible})
+}
+
+func (srv *Server) handleGetEligibilityOverrides(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "class ID")
+	}
+	args := srv.getQueryContext(r)
+	overrides, err := srv.Db.GetEligibilityOverridesForClass(&args, classID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, overrides, args.IntoMeta())
+}
+
+type eligibilityOverride struct {
+	UserID int    `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+/*
+resolveEligibility checks the residents against the class program's prerequisites and matches any
+ineligible resident up with an override from the request. It returns the override reasons to record
+once the enrollment succeeds, and the residents who are still ineligible without an override.
+*/
+func (srv *Server) resolveEligibility(classID int, userIDs []int, overrides []eligibilityOverride) (map[uint]string, []models.EnrollmentIneligibility, error) {
+	ineligible, err := srv.Db.CheckEnrollmentEligibility(classID, userIDs)
+	if err != nil {
+		return nil, nil, newDatabaseServiceError(err)
+	}
+	reasonByUser := make(map[uint]string, len(overrides))
+	for _, override := range overrides {
+		reason := strings.TrimSpace(override.Reason)
+		if reason == "" {
+			return nil, nil, newBadRequestServiceError(errors.New("override reason is required"), "a reason is required to override enrollment eligibility")
+		}
+		reasonByUser[uint(override.UserID)] = reason
+	}
+	reasons := make(map[uint]string)
+	blocked := make([]models.EnrollmentIneligibility, 0)
+	for _, entry := range ineligible {
+		if reason, ok := reasonByUser[entry.UserID]; ok {
+			reasons[entry.UserID] = reason
+			continue
+		}
+		blocked = append(blocked, entry)
+	}
+	return reasons, blocked, nil
+}
+
+func writeIneligibleResponse(w http.ResponseWriter, ineligible []models.EnrollmentIneligibility) error {
+	return writeJsonResponse(w, http.StatusUnprocessableEntity, map[string]any{
+		"message":    "Residents have not completed the program prerequisites",
+		"ineligible": ineligible,
+	})
+}
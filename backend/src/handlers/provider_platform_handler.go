@@ -198,6 +198,24 @@ func (srv *Server) handleUpdateProvider(w http.ResponseWriter, r *http.Request,
 	}
 	dbWithCtx := srv.WithUserContext(r)
 
+	if platform.MatchAutoConfirm != 0 || platform.MatchAmbiguous != 0 {
+		existingPlatform, err := dbWithCtx.GetProviderPlatformByID(id)
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		autoConfirm, ambiguous := existingPlatform.MatchThresholds()
+		if platform.MatchAutoConfirm != 0 {
+			autoConfirm = platform.MatchAutoConfirm
+		}
+		if platform.MatchAmbiguous != 0 {
+			ambiguous = platform.MatchAmbiguous
+		}
+		if err := models.ValidateMatchThresholds(autoConfirm, ambiguous); err != nil {
+			return newBadRequestServiceError(err, err.Error())
+		}
+		platform.MatchAutoConfirm, platform.MatchAmbiguous = autoConfirm, ambiguous
+	}
+
 	if platform.BaseUrl != "" || platform.AccessKey != "" || platform.AccountID != "" || (platform.State != "" && platform.State == models.Enabled) {
 		existingPlatform, err := dbWithCtx.GetProviderPlatformByID(id)
 		if err != nil {
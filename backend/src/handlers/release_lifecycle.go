@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerReleaseLifecycleRoutes() []routeDef {
+	return []routeDef{
+		newAdminRoute("GET /api/users/upcoming-releases", srv.handleGetUpcomingReleases),
+		validatedAdminRoute("PUT /api/users/{id}/release-date", srv.handleSetReleaseDate, FacilityAdminResolver("users", "id")),
+	}
+}
+
+func releaseWarnBy(today string) string {
+	date, _ := time.Parse(models.ReleaseDateLayout, today)
+	return date.AddDate(0, 0, models.ReleaseWarningDays()).Format(models.ReleaseDateLayout)
+}
+
+func (srv *Server) handleGetUpcomingReleases(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	facility, err := srv.Db.GetFacilityByID(int(claims.FacilityID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	today := facility.Today(time.Now())
+	releases, err := srv.Db.GetUpcomingReleases(r.Context(), facility.ID, today, releaseWarnBy(today))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, releases)
+}
+
+/*
+* PUT: /api/users/{id}/release-date
+* Sets the resident's projected release date, or clears it when release_date is null. Dates are accepted in the same
+* layouts as the bulk import and cannot be in the past, a past date would deactivate the resident on the next run.
+ */
+func (srv *Server) handleSetReleaseDate(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	var form struct {
+		ReleaseDate *string `json:"release_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	defer r.Body.Close()
+	user, err := srv.Db.GetUserByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if user.Role != models.Student {
+		return newBadRequestServiceError(fmt.Errorf("user %d is not a resident", user.ID), "release dates can only be set for residents")
+	}
+	if user.DeactivatedAt != nil {
+		return newBadRequestServiceError(fmt.Errorf("user %d is deactivated", user.ID), "release dates cannot be set for deactivated residents")
+	}
+	var releaseDate *string
+	if form.ReleaseDate != nil && strings.TrimSpace(*form.ReleaseDate) != "" {
+		date, err := models.ParseReleaseDate(*form.ReleaseDate)
+		if err != nil {
+			return newBadRequestServiceError(err, err.Error())
+		}
+		facility, err := srv.Db.GetFacilityByID(int(user.FacilityID))
+		if err != nil {
+			return newDatabaseServiceError(err)
+		}
+		if date < facility.Today(time.Now()) {
+			return newBadRequestServiceError(errors.New("release date is in the past"), "release date cannot be in the past")
+		}
+		releaseDate = &date
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	if err := srv.Db.SetReleaseDate(r.Context(), user, releaseDate, &claims.UserID); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("user_id", user.ID)
+	log.info("release date set")
+	return writeJsonResponse(w, http.StatusOK, user)
+}
+
+/*
+RunReleaseLifecycle moves residents along toward their release date, using each facility's own date: admins are warned
+once a resident's release date is within RELEASE_WARNING_DAYS, and on the date the resident's open enrollments are
+withdrawn and the account is deactivated, then a release packet is produced. Each step is written to the resident's
+account history. A packet that fails does not hold up the release, the next run produces it.
+*/
+func (srv *Server) RunReleaseLifecycle(ctx context.Context, now time.Time) error {
+	facilities, err := srv.Db.GetAllFacilitiesOrdered()
+	if err != nil {
+		return err
+	}
+	batchID, err := srv.Db.GetSystemBatchUserID(ctx)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for idx := range facilities {
+		facility := &facilities[idx]
+		today := facility.Today(now)
+		pending, err := srv.Db.GetResidentsAwaitingReleasePacket(ctx, facility.ID)
+		if err != nil {
+			return err
+		}
+		for pidx := range pending {
+			resident := &pending[pidx]
+			if _, err := srv.createReleasePacket(ctx, resident, batchID, resident.Locale.OrDefault()); err != nil {
+				logrus.WithError(err).Errorf("failed to produce the release packet of released resident %d", resident.ID)
+				failed++
+			}
+		}
+		residents, err := srv.Db.GetResidentsDueForRelease(ctx, facility.ID, releaseWarnBy(today))
+		if err != nil {
+			return err
+		}
+		warned := make([]models.User, 0)
+		for ridx := range residents {
+			resident := &residents[ridx]
+			if *resident.ReleaseDate <= today {
+				if err := srv.releaseResident(ctx, resident, batchID); err != nil {
+					logrus.WithError(err).Errorf("failed to release resident %d", resident.ID)
+					failed++
+				}
+				continue
+			}
+			if resident.ReleaseWarnedFor != nil && *resident.ReleaseWarnedFor == *resident.ReleaseDate {
+				continue
+			}
+			if err := srv.Db.MarkReleaseWarned(ctx, resident, batchID); err != nil {
+				logrus.WithError(err).Errorf("failed to record release warning for resident %d", resident.ID)
+				failed++
+				continue
+			}
+			warned = append(warned, *resident)
+		}
+		srv.warnUpcomingReleases(ctx, facility, warned, today)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d residents could not be moved along their release lifecycle", failed)
+	}
+	return nil
+}
+
+// errReleasePacketPending means the resident was released but their release packet still has to be produced
+var errReleasePacketPending = errors.New("resident released, release packet pending")
+
+/*
+releaseResident withdraws and deactivates a resident whose release date has arrived, then produces their release
+packet. The packet is built after the withdrawal so it records it, and a packet that fails leaves the release in place
+with the resident flagged for RunReleaseLifecycle to try the packet again.
+*/
+func (srv *Server) releaseResident(ctx context.Context, resident *models.User, batchID *uint) error {
+	if _, err := srv.Db.ReleaseResident(ctx, resident, batchID); err != nil {
+		return err
+	}
+	srv.pushCanvasEnrollmentsForUser(ctx, resident.ID)
+	if _, err := srv.createReleasePacket(ctx, resident, batchID, resident.Locale.OrDefault()); err != nil {
+		return fmt.Errorf("%w: %w", errReleasePacketPending, err)
+	}
+	return nil
+}
+
+// warnUpcomingReleases lets the facility's admins know which residents are about to be released, warnings are written
+// to account history before they are sent so a failed notification only gets logged
+func (srv *Server) warnUpcomingReleases(ctx context.Context, facility *models.Facility, residents []models.User, today string) {
+	if len(residents) == 0 {
+		return
+	}
+	if srv.wsClient != nil {
+		admins, err := srv.Db.GetFacilityAdmins(ctx, facility.ID)
+		if err != nil {
+			logrus.WithError(err).Errorf("failed to load admins to warn about releases at facility %d", facility.ID)
+		}
+		for _, admin := range admins {
+			locale := admin.Locale.OrDefault()
+			for _, resident := range residents {
+				srv.wsClient.notifyUser(WsMsg{
+					EventType: ReleaseDateEvent,
+					UserID:    admin.ID,
+					Msg: MsgContent{Msg: locale.Tf("%s %s (%s) is scheduled for release in %d days",
+						resident.NameFirst, resident.NameLast, resident.DocID, models.DaysUntilRelease(*resident.ReleaseDate, today))},
+				})
+			}
+		}
+	}
+	if srv.sesClient == nil {
+		return
+	}
+	// the email goes to staff rather than a resident, so it is written in the default locale
+	locale := i18n.Default()
+	subject := locale.T("Upcoming Resident Releases") + " - " + facility.Name
+	var text, body strings.Builder
+	body.WriteString("<ul>")
+	for _, resident := range residents {
+		line := locale.Tf("%s %s (%s) is scheduled for release on %s", resident.NameFirst, resident.NameLast, resident.DocID, *resident.ReleaseDate)
+		text.WriteString(line + "\n")
+		body.WriteString("<li>" + html.EscapeString(line) + "</li>")
+	}
+	body.WriteString("</ul>")
+	if err := srv.sendEmail(ctx, subject, text.String(), body.String()); err != nil {
+		logrus.WithError(err).Errorf("failed to email release warnings for facility %d", facility.ID)
+	}
+}
+
+func (srv *Server) subscribeReleaseLifecycle() error {
+	if srv.nats == nil {
+		return errors.New("no NATS connection")
+	}
+	_, err := srv.nats.QueueSubscribe(models.ReleaseLifecycleJob.PubName(), reportWorkersQueue, srv.handleReleaseLifecycleTask)
+	return err
+}
+
+func (srv *Server) handleReleaseLifecycleTask(msg *nats.Msg) {
+	var params map[string]any
+	if err := json.Unmarshal(msg.Data, &params); err != nil {
+		logrus.Errorf("invalid %s message: %v", models.ReleaseLifecycleJob, err)
+		return
+	}
+	jobID, _ := params["job_id"].(string)
+	err := srv.RunReleaseLifecycle(context.Background(), time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("failed to run release lifecycle")
+	}
+	if jobID == "" {
+		logrus.Error("release lifecycle task is missing its job_id")
+		return
+	}
+	if err := srv.Db.FinishRunnableTask(context.Background(), jobID, err == nil); err != nil {
+		logrus.WithError(err).Error("failed to finish release lifecycle task")
+	}
+}
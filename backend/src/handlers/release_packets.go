@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+func (srv *Server) registerReleasePacketRoutes() []routeDef {
+	resolver := FacilityAdminResolver("users", "id")
+	return []routeDef{
+		validatedAdminRoute("GET /api/users/{id}/release-packets", srv.handleIndexReleasePackets, resolver),
+		validatedAdminRoute("POST /api/users/{id}/release-packets", srv.handleCreateReleasePacket, resolver),
+		validatedAdminRoute("GET /api/users/{id}/release-packets/{packet_id}/download", srv.handleDownloadReleasePacket, resolver),
+	}
+}
+
+// the verification route is used by case managers and agencies outside the platform, the packet they were handed is all they have
+func (srv *Server) registerReleasePacketVerifyRoute() {
+	srv.Mux.Handle("POST /api/release-packets/verify", srv.handleError(srv.handleVerifyReleasePacket))
+}
+
+func (srv *Server) handleIndexReleasePackets(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	packets, err := srv.Db.GetReleasePackets(r.Context(), uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, packets)
+}
+
+/*
+* POST: /api/users/{id}/release-packets
+* Produces a release packet for the resident now. Packets are usually produced when the resident is deactivated, this
+* lets an admin produce another one, e.g. after correcting a completion.
+ */
+func (srv *Server) handleCreateReleasePacket(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	user, err := srv.Db.GetUserByID(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	packet, err := srv.createReleasePacket(r.Context(), user, &claims.UserID, claims.Locale.OrDefault())
+	if err != nil {
+		return err
+	}
+	log.add("release_packet_id", packet.ID)
+	log.info("release packet generated")
+	return writeJsonResponse(w, http.StatusCreated, packet)
+}
+
+func (srv *Server) handleDownloadReleasePacket(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	packetID, err := strconv.Atoi(r.PathValue("packet_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "release packet ID")
+	}
+	packet, err := srv.Db.GetReleasePacket(r.Context(), uint(id), uint(packetID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if srv.packetStore == nil {
+		return newReleasePacketsDisabledServiceError()
+	}
+	artifact, err := srv.packetStore.Open(r.Context(), packet.ArtifactKey)
+	if err != nil {
+		return newInternalServerServiceError(err, "failed to open release packet")
+	}
+	defer artifact.Close()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", packet.Filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(packet.SizeBytes, 10))
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, artifact)
+	return err
+}
+
+// maxReleasePacketUpload is far larger than any packet we produce, it only keeps the public route from reading forever
+const maxReleasePacketUpload = 20 << 20
+
+/**
+* POST: /api/release-packets/verify
+* Public. Takes a packet ZIP as the "file" form field and confirms it is one we produced and that nothing in it was
+* changed, returning only what its signed manifest says about it.
+ */
+func (srv *Server) handleVerifyReleasePacket(w http.ResponseWriter, r *http.Request, log sLog) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxReleasePacketUpload)
+	if err := r.ParseMultipartForm(maxReleasePacketUpload); err != nil {
+		return newBadRequestServiceError(err, "file too large or invalid format")
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return newBadRequestServiceError(err, "a release packet file is required")
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.errorf("failed to close file %v", err)
+		}
+	}()
+	zipBytes, err := io.ReadAll(file)
+	if err != nil {
+		return newBadRequestServiceError(err, "unable to read the release packet")
+	}
+	manifest, err := models.VerifyReleasePacket(zipBytes)
+	if err != nil {
+		log.add("reason", err.Error())
+		log.info("release packet failed verification")
+		return writeJsonResponse(w, http.StatusUnprocessableEntity, models.ReleasePacketVerification{Valid: false, Reason: err.Error()})
+	}
+	return writeJsonResponse(w, http.StatusOK, models.ReleasePacketVerification{
+		Valid:       true,
+		DocID:       manifest.DocID,
+		GeneratedAt: &manifest.GeneratedAt,
+	})
+}
+
+func newReleasePacketsDisabledServiceError() error {
+	return NewServiceError(errors.New("release packet store is not configured"), http.StatusServiceUnavailable,
+		"release packets are not available, RELEASE_PACKET_DIR or S3_BUCKET_NAME must be configured")
+}
+
+/*
+createReleasePacket builds the resident's packet in the given locale, keeps the ZIP in the artifact store and records
+that it was produced. generatedByID is nil when the packet is produced by the release lifecycle job without a
+system_batch user to attribute it to.
+*/
+func (srv *Server) createReleasePacket(ctx context.Context, user *models.User, generatedByID *uint, locale i18n.Locale) (*models.ReleasePacket, error) {
+	if user.Role != models.Student {
+		return nil, newBadRequestServiceError(fmt.Errorf("user %d is not a resident", user.ID), "release packets are only available for residents")
+	}
+	if srv.packetStore == nil {
+		return nil, newReleasePacketsDisabledServiceError()
+	}
+	data := models.NewReleasePacketData(user, locale)
+	if err := srv.Db.GetReleasePacketData(ctx, data); err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	zipBytes, err := data.ToZip()
+	if err != nil {
+		return nil, newInternalServerServiceError(err, "failed to write release packet")
+	}
+	artifact := &reportArtifact{Filename: data.Filename(), ContentType: "application/zip", Data: zipBytes}
+	key := fmt.Sprintf("release-packets/%d/%s.zip", user.ID, uuid.NewString())
+	if err := srv.packetStore.Put(ctx, key, artifact); err != nil {
+		return nil, newInternalServerServiceError(err, "failed to store release packet")
+	}
+	packet := &models.ReleasePacket{
+		UserID:        user.ID,
+		FacilityID:    user.FacilityID,
+		GeneratedByID: generatedByID,
+		Filename:      artifact.Filename,
+		ArtifactKey:   key,
+		SizeBytes:     int64(len(zipBytes)),
+		Checksum:      models.ReleasePacketChecksum(zipBytes),
+	}
+	if generatedByID != nil {
+		ctx = context.WithValue(ctx, models.UserIDKey, *generatedByID)
+	}
+	if err := srv.Db.CreateReleasePacket(ctx, packet); err != nil {
+		_ = srv.packetStore.Delete(ctx, key)
+		return nil, newDatabaseServiceError(err)
+	}
+	return packet, nil
+}
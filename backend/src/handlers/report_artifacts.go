@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// reportArtifactStore keeps generated report files until their job expires
+type reportArtifactStore interface {
+	Put(ctx context.Context, key string, artifact *reportArtifact) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+/*
+newReportArtifactStore stores artifacts in S3 when a bucket is configured, otherwise on local disk under
+REPORT_ARTIFACT_DIR. The disk store is only suitable when a single backend instance serves the API. Outside of
+development one of the two is required, reports kept in the system temp directory would not survive a restart.
+*/
+func (srv *Server) newReportArtifactStore() (reportArtifactStore, error) {
+	if srv.s3 != nil && srv.s3Bucket != "" {
+		return &s3ArtifactStore{client: srv.s3, bucket: srv.s3Bucket}, nil
+	}
+	dir := os.Getenv("REPORT_ARTIFACT_DIR")
+	if dir == "" {
+		if !srv.dev && !srv.testingMode {
+			return nil, errors.New("REPORT_ARTIFACT_DIR must be set when S3_BUCKET_NAME is not")
+		}
+		dir = filepath.Join(os.TempDir(), "unlocked-reports")
+	}
+	return &diskArtifactStore{dir: dir}, nil
+}
+
+/*
+newReleasePacketStore keeps release packets in S3 when a bucket is configured, otherwise on local disk under
+RELEASE_PACKET_DIR. Packets are handed to residents and case managers long after they are produced, so there is no
+temp directory fallback: without one of the two, release packets cannot be produced.
+*/
+func (srv *Server) newReleasePacketStore() (reportArtifactStore, error) {
+	if srv.s3 != nil && srv.s3Bucket != "" {
+		return &s3ArtifactStore{client: srv.s3, bucket: srv.s3Bucket}, nil
+	}
+	dir := os.Getenv("RELEASE_PACKET_DIR")
+	if dir == "" {
+		return nil, errors.New("RELEASE_PACKET_DIR must be set when S3_BUCKET_NAME is not")
+	}
+	return &diskArtifactStore{dir: dir}, nil
+}
+
+type diskArtifactStore struct {
+	dir string
+}
+
+func (store *diskArtifactStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", errors.New("invalid artifact key")
+	}
+	return filepath.Join(store.dir, filepath.FromSlash(key)), nil
+}
+
+func (store *diskArtifactStore) Put(ctx context.Context, key string, artifact *reportArtifact) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, artifact.Data, 0o640)
+}
+
+func (store *diskArtifactStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (store *diskArtifactStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type s3ArtifactStore struct {
+	client *s3.Client
+	bucket string
+}
+
+func (store *s3ArtifactStore) Put(ctx context.Context, key string, artifact *reportArtifact) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(store.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(artifact.Data),
+		ContentType: aws.String(artifact.ContentType),
+	})
+	return err
+}
+
+func (store *s3ArtifactStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := store.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (store *s3ArtifactStore) Delete(ctx context.Context, key string) error {
+	_, err := store.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
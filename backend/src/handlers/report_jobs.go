@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reportJobsSubject  = "reports.generate"
+	reportWorkersQueue = "report-workers"
+	// queued reports are the long ones, statewide exports over a fiscal year can take several minutes
+	reportJobTimeout         = 15 * time.Minute
+	defaultReportArtifactTTL = 72 * time.Hour
+)
+
+func reportArtifactTTL() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("REPORT_ARTIFACT_TTL_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultReportArtifactTTL
+}
+
+/**
+* POST: /api/reports/generate with "async": true
+* Saves the already scoped request as a pending ReportJob and hands it to a report worker, the admin polls
+* /api/reports/jobs/{id} (or waits for the websocket event) and downloads the file once it is completed.
+ */
+func (srv *Server) enqueueReportJob(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims, log sLog) error {
+	req.Async = false
+	job := models.ReportJob{
+		UserID:     claims.UserID,
+		FacilityID: claims.FacilityID,
+		Type:       req.Type,
+		Format:     req.Format,
+		Request:    *req,
+		Status:     models.ReportJobPending,
+	}
+	if err := srv.WithUserContext(r).CreateReportJob(&job); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("report_job_id", job.ID)
+	log.add("report_type", job.Type)
+	srv.dispatchReportJob(job.ID)
+	log.info("report job queued")
+	return writeJsonResponse(w, http.StatusAccepted, job)
+}
+
+// dispatchReportJob publishes the job to the report workers, every backend instance joins the same queue group so
+// exactly one of them builds it. Without NATS the job runs in this process.
+func (srv *Server) dispatchReportJob(jobID uint) {
+	if srv.nats != nil && srv.nats.IsConnected() {
+		body, err := json.Marshal(map[string]uint{"job_id": jobID})
+		if err == nil {
+			if err = srv.nats.Publish(reportJobsSubject, body); err == nil {
+				return
+			}
+		}
+		logrus.WithError(err).Warnf("failed to publish report job %d, running it locally", jobID)
+	}
+	if srv.testingMode {
+		srv.runReportJob(context.Background(), jobID)
+		return
+	}
+	go srv.runReportJob(context.Background(), jobID)
+}
+
+func (srv *Server) subscribeReportJobs() error {
+	if srv.nats == nil {
+		return errors.New("no NATS connection")
+	}
+	_, err := srv.nats.QueueSubscribe(reportJobsSubject, reportWorkersQueue, func(msg *nats.Msg) {
+		var body struct {
+			JobID uint `json:"job_id"`
+		}
+		if err := json.Unmarshal(msg.Data, &body); err != nil || body.JobID == 0 {
+			logrus.Errorf("invalid report job message: %s", string(msg.Data))
+			return
+		}
+		// a report can take minutes, building it in the callback would hold up every other job sent to this instance
+		go srv.runReportJob(context.Background(), body.JobID)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = srv.nats.QueueSubscribe(models.RunReportSubscriptionsJob.PubName(), reportWorkersQueue, srv.handleRunReportSubscriptionsTask)
+	return err
+}
+
+// runReportJob builds, renders and stores the report for a pending job, then lets the requesting admin know
+func (srv *Server) runReportJob(ctx context.Context, jobID uint) {
+	claimed, err := srv.Db.ClaimReportJob(jobID)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to claim report job %d", jobID)
+		return
+	}
+	if !claimed {
+		return
+	}
+	job, err := srv.Db.GetReportJob(jobID)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to load report job %d", jobID)
+		return
+	}
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, reportJobTimeout)
+	defer cancel()
+
+	report, scope, err := srv.generateReport(ctx, &job.Request)
+	if err != nil {
+		srv.failReportJob(ctx, job, startTime, 0, err)
+		return
+	}
+	artifact, err := srv.renderReport(report, &job.Request, scope)
+	if err != nil {
+		srv.failReportJob(ctx, job, startTime, report.Len(), err)
+		return
+	}
+	key := fmt.Sprintf("reports/%s%s", uuid.NewString(), filepath.Ext(artifact.Filename))
+	if err := srv.reportStore.Put(ctx, key, artifact); err != nil {
+		srv.failReportJob(ctx, job, startTime, report.Len(), err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(reportArtifactTTL())
+	job.Status = models.ReportJobCompleted
+	job.RowCount = report.Len()
+	job.Filename = artifact.Filename
+	job.ContentType = artifact.ContentType
+	job.SizeBytes = int64(len(artifact.Data))
+	job.ArtifactKey = key
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+	if err := srv.Db.CompleteReportJob(job); err != nil {
+		logrus.WithError(err).Errorf("failed to complete report job %d", job.ID)
+		return
+	}
+	srv.logReportSuccess(job.UserID, string(job.Type), &job.Request, startTime, report.Len(), reportAuditFacilityID(&job.Request, job.FacilityID), scope.facilityCount)
+	srv.notifyReportJob(job, "your report is ready to download")
+}
+
+func (srv *Server) failReportJob(ctx context.Context, job *models.ReportJob, startTime time.Time, rowCount int, err error) {
+	reason := reportFailureReason(err)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = fmt.Sprintf("report took longer than %s to generate, try a shorter date range", reportJobTimeout)
+	}
+	logrus.WithError(err).Errorf("report job %d failed", job.ID)
+	if err := srv.Db.FailReportJob(job.ID, reason); err != nil {
+		logrus.WithError(err).Errorf("failed to record failure of report job %d", job.ID)
+	}
+	srv.logReportFailure(job.UserID, string(job.Type), &job.Request, startTime, rowCount)
+	srv.notifyReportJob(job, reason)
+}
+
+// reportFailureReason is the message shown to the admin for a failed job, internal errors are only logged
+func reportFailureReason(err error) string {
+	var svcErr serviceError
+	if errors.As(err, &svcErr) && svcErr.Message != "" {
+		return svcErr.Message
+	}
+	return "failed to generate report"
+}
+
+func (srv *Server) notifyReportJob(job *models.ReportJob, msg string) {
+	if srv.wsClient == nil {
+		return
+	}
+	srv.wsClient.notifyUser(WsMsg{
+		EventType: ReportJobEvent,
+		UserID:    job.UserID,
+		Msg:       MsgContent{Msg: msg, ReportJobID: job.ID},
+	})
+}
+
+/*
+cleanupReportJobs removes stored report files once their jobs expire, the job rows stay as history. It also looks after
+jobs whose worker went away: a job still running well past reportJobTimeout is failed, and a job nobody claimed (e.g.
+its NATS message was lost) is dispatched again.
+*/
+func (srv *Server) cleanupReportJobs(ctx context.Context) {
+	ticker := time.NewTicker(reportJobTimeout)
+	defer ticker.Stop()
+	for {
+		srv.deleteExpiredReportArtifacts(ctx)
+		srv.recoverStalledReportJobs(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (srv *Server) recoverStalledReportJobs(now time.Time) {
+	startedBefore := now.Add(-2 * reportJobTimeout)
+	jobs, err := srv.Db.GetStalledReportJobs(startedBefore, now.Add(-reportJobTimeout))
+	if err != nil {
+		logrus.WithError(err).Error("failed to fetch stalled report jobs")
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Status == models.ReportJobPending {
+			logrus.Warnf("report job %d was never picked up, dispatching it again", job.ID)
+			srv.dispatchReportJob(job.ID)
+			continue
+		}
+		reason := "report generation was interrupted, please generate it again"
+		failed, err := srv.Db.FailStalledReportJob(job.ID, startedBefore, reason)
+		if err != nil {
+			logrus.WithError(err).Errorf("failed to fail stalled report job %d", job.ID)
+			continue
+		}
+		if failed {
+			logrus.Warnf("report job %d stopped running without finishing", job.ID)
+			srv.notifyReportJob(job, reason)
+		}
+	}
+}
+
+func (srv *Server) deleteExpiredReportArtifacts(ctx context.Context) {
+	jobs, err := srv.Db.GetExpiredReportJobs(time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("failed to fetch expired report jobs")
+		return
+	}
+	for _, job := range jobs {
+		if err := srv.reportStore.Delete(ctx, job.ArtifactKey); err != nil {
+			logrus.WithError(err).Errorf("failed to delete artifact of report job %d", job.ID)
+			continue
+		}
+		if err := srv.Db.ClearReportJobArtifact(job.ID); err != nil {
+			logrus.WithError(err).Errorf("failed to clear artifact of report job %d", job.ID)
+		}
+	}
+}
+
+func (srv *Server) handleIndexReportJobs(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	args := srv.getQueryContext(r)
+	jobs, err := srv.Db.GetReportJobsForUser(&args, claims.UserID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, jobs, args.IntoMeta())
+}
+
+// getOwnReportJob loads a report job, only the admin who queued it may see it
+func (srv *Server) getOwnReportJob(r *http.Request, log sLog) (*models.ReportJob, error) {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "report job ID")
+	}
+	log.add("report_job_id", id)
+	job, err := srv.Db.GetReportJob(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if job.UserID != claims.UserID {
+		return nil, NewServiceError(fmt.Errorf("report job %d belongs to user %d", job.ID, job.UserID), http.StatusNotFound, "report job not found")
+	}
+	return job, nil
+}
+
+func (srv *Server) handleGetReportJob(w http.ResponseWriter, r *http.Request, log sLog) error {
+	job, err := srv.getOwnReportJob(r, log)
+	if err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, job)
+}
+
+func (srv *Server) handleDownloadReportJob(w http.ResponseWriter, r *http.Request, log sLog) error {
+	job, err := srv.getOwnReportJob(r, log)
+	if err != nil {
+		return err
+	}
+	switch job.Status {
+	case models.ReportJobCompleted:
+	case models.ReportJobExpired:
+		return NewServiceError(fmt.Errorf("report job %d expired at %s", job.ID, job.ExpiresAt), http.StatusGone, "this report has expired, please generate it again")
+	default:
+		return NewServiceError(fmt.Errorf("report job %d is %s", job.ID, job.Status), http.StatusConflict, fmt.Sprintf("this report is %s and cannot be downloaded", job.Status))
+	}
+	artifact, err := srv.reportStore.Open(r.Context(), job.ArtifactKey)
+	if err != nil {
+		return newInternalServerServiceError(err, "failed to open report")
+	}
+	defer artifact.Close()
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", job.Filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(job.SizeBytes, 10))
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, artifact)
+	return err
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverStalledReportJobs(t *testing.T) {
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	srv := newTestingServer()
+	now := time.Now()
+
+	createJob := func(status models.ReportJobStatus, createdAt time.Time, startedAt *time.Time) *models.ReportJob {
+		job := &models.ReportJob{UserID: 1, FacilityID: 1, Type: models.AttendanceReport, Format: models.FormatCSV, Status: status, StartedAt: startedAt}
+		if err := srv.Db.Create(job).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.Db.Model(job).UpdateColumn("created_at", createdAt).Error; err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	stalled := createJob(models.ReportJobRunning, now.Add(-time.Hour), TimePtr(now.Add(-time.Hour)))
+	running := createJob(models.ReportJobRunning, now.Add(-time.Minute), TimePtr(now.Add(-time.Minute)))
+	lost := createJob(models.ReportJobPending, now.Add(-time.Hour), nil)
+	queued := createJob(models.ReportJobPending, now, nil)
+
+	srv.recoverStalledReportJobs(now)
+
+	status := func(job *models.ReportJob) models.ReportJobStatus {
+		reloaded, err := srv.Db.GetReportJob(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reloaded.Status
+	}
+	assert.Equal(t, models.ReportJobFailed, status(stalled), "a job running well past the timeout is failed")
+	assert.Equal(t, models.ReportJobRunning, status(running), "a job within the timeout is left running")
+	assert.NotEqual(t, models.ReportJobPending, status(lost), "a job nobody claimed is dispatched again")
+	assert.Equal(t, models.ReportJobPending, status(queued), "a freshly queued job is left for its worker")
+}
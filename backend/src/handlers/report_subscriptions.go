@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerReportSubscriptionRoutes() []routeDef {
+	axx := models.ProgramAccess
+	return []routeDef{
+		adminFeatureRoute("GET /api/report-subscriptions", srv.handleIndexReportSubscriptions, axx),
+		adminFeatureRoute("POST /api/report-subscriptions", srv.handleCreateReportSubscription, axx),
+		adminFeatureRoute("PATCH /api/report-subscriptions/{id}", srv.handleUpdateReportSubscription, axx),
+		adminFeatureRoute("DELETE /api/report-subscriptions/{id}", srv.handleDeleteReportSubscription, axx),
+		adminFeatureRoute("GET /api/report-subscriptions/{id}/runs", srv.handleGetReportSubscriptionRuns, axx),
+		adminFeatureRoute("POST /api/report-subscriptions/{id}/run", srv.handleRunReportSubscription, axx),
+	}
+}
+
+func (srv *Server) handleIndexReportSubscriptions(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	subs, err := srv.Db.GetReportSubscriptionsForUser(claims.UserID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, subs)
+}
+
+func (srv *Server) handleCreateReportSubscription(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	sub := models.ReportSubscription{Enabled: true, TimeZone: claims.TimeZone}
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	sub.UserID = claims.UserID
+	if err := prepareReportSubscription(&sub, claims); err != nil {
+		return err
+	}
+	if err := srv.WithUserContext(r).CreateReportSubscription(&sub); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("report_subscription_id", sub.ID)
+	log.info("report subscription created")
+	return writeJsonResponse(w, http.StatusCreated, sub)
+}
+
+// prepareReportSubscription validates the schedule and the saved request as the caller would run it today, and
+// works out when the subscription runs next
+func prepareReportSubscription(sub *models.ReportSubscription, claims *Claims) error {
+	sub.Name = strings.TrimSpace(sub.Name)
+	if err := sub.Validate(); err != nil {
+		return newBadRequestServiceError(err, err.Error())
+	}
+	now := time.Now()
+	req, err := sub.RequestAt(now)
+	if err != nil {
+		return newBadRequestServiceError(err, err.Error())
+	}
+	if err := validateReportRequest(&req, claims); err != nil {
+		return err
+	}
+	// the dates are resolved again on every run
+	req.StartDate, req.EndDate = time.Time{}, time.Time{}
+	sub.Request = req
+	sub.NextRunAt = nil
+	if sub.Enabled {
+		next, err := sub.NextRun(now)
+		if err != nil {
+			return newBadRequestServiceError(err, err.Error())
+		}
+		sub.NextRunAt = &next
+	}
+	return nil
+}
+
+// getOwnReportSubscription loads a report subscription, only its owner may see or change it
+func (srv *Server) getOwnReportSubscription(r *http.Request, log sLog) (*models.ReportSubscription, error) {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "report subscription ID")
+	}
+	log.add("report_subscription_id", id)
+	sub, err := srv.Db.GetReportSubscription(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if sub.UserID != claims.UserID {
+		return nil, NewServiceError(fmt.Errorf("report subscription %d belongs to user %d", sub.ID, sub.UserID), http.StatusNotFound, "report subscription not found")
+	}
+	return sub, nil
+}
+
+func (srv *Server) handleUpdateReportSubscription(w http.ResponseWriter, r *http.Request, log sLog) error {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	sub, err := srv.getOwnReportSubscription(r, log)
+	if err != nil {
+		return err
+	}
+	id, userID := sub.ID, sub.UserID
+	if err := json.NewDecoder(r.Body).Decode(sub); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	sub.ID, sub.UserID = id, userID
+	if err := prepareReportSubscription(sub, claims); err != nil {
+		return err
+	}
+	if err := srv.WithUserContext(r).UpdateReportSubscription(sub); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("report subscription updated")
+	return writeJsonResponse(w, http.StatusOK, sub)
+}
+
+func (srv *Server) handleDeleteReportSubscription(w http.ResponseWriter, r *http.Request, log sLog) error {
+	sub, err := srv.getOwnReportSubscription(r, log)
+	if err != nil {
+		return err
+	}
+	if err := srv.WithUserContext(r).DeleteReportSubscription(sub.ID); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, "report subscription deleted")
+}
+
+func (srv *Server) handleGetReportSubscriptionRuns(w http.ResponseWriter, r *http.Request, log sLog) error {
+	sub, err := srv.getOwnReportSubscription(r, log)
+	if err != nil {
+		return err
+	}
+	args := srv.getQueryContext(r)
+	runs, err := srv.Db.GetReportSubscriptionRuns(&args, sub.ID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, runs, args.IntoMeta())
+}
+
+/**
+* POST: /api/report-subscriptions/{id}/run
+* Runs the subscription now, outside of its schedule. The run is recorded in its history like any scheduled run.
+ */
+func (srv *Server) handleRunReportSubscription(w http.ResponseWriter, r *http.Request, log sLog) error {
+	sub, err := srv.getOwnReportSubscription(r, log)
+	if err != nil {
+		return err
+	}
+	owner, err := srv.Db.GetUserByID(sub.UserID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	job, err := srv.queueReportSubscriptionRun(sub, owner, time.Now())
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("report_job_id", job.ID)
+	return writeJsonResponse(w, http.StatusAccepted, job)
+}
+
+/*
+queueReportSubscriptionRun saves a run of the subscription as a report job and hands it to the report workers. The
+owner's role is read fresh for every run, so the request is scoped by what the owner may see now rather than when
+the subscription was saved. Runs that can no longer be made are recorded as failed jobs.
+*/
+func (srv *Server) queueReportSubscriptionRun(sub *models.ReportSubscription, owner *models.User, now time.Time) (*models.ReportJob, error) {
+	req, err := sub.RequestAt(now)
+	job := &models.ReportJob{
+		UserID:         sub.UserID,
+		SubscriptionID: &sub.ID,
+		Type:           req.Type,
+		Format:         req.Format,
+		Status:         models.ReportJobPending,
+	}
+	if err == nil {
+		if owner == nil || !owner.IsAdmin() {
+			err = newForbiddenServiceError(fmt.Errorf("user %d is not an administrator", sub.UserID),
+				"the subscription's owner is no longer an administrator")
+		} else if owner.DeactivatedAt != nil {
+			err = newForbiddenServiceError(fmt.Errorf("user %d is deactivated", sub.UserID),
+				"the subscription's owner has been deactivated")
+		} else {
+			job.FacilityID = owner.FacilityID
+			err = validateReportRequest(&req, &Claims{Role: owner.Role, UserID: owner.ID, FacilityID: owner.FacilityID, Locale: owner.Locale})
+		}
+	}
+	job.Request = req
+	if err != nil {
+		logrus.WithError(err).Warnf("report subscription %d cannot run", sub.ID)
+		job.Status = models.ReportJobFailed
+		job.Error = reportFailureReason(err)
+		job.CompletedAt = &now
+	}
+	if err := srv.Db.CreateReportJob(job); err != nil {
+		return nil, err
+	}
+	if job.Status == models.ReportJobPending {
+		srv.dispatchReportJob(job.ID)
+	}
+	return job, nil
+}
+
+// runDueReportSubscriptions queues a run of every subscription whose time has come and schedules its next run.
+// Subscriptions whose owner is no longer an administrator, or was deactivated, are disabled after recording the failed run.
+func (srv *Server) runDueReportSubscriptions(now time.Time) error {
+	subs, err := srv.Db.GetDueReportSubscriptions(now)
+	if err != nil {
+		return err
+	}
+	for idx := range subs {
+		sub := &subs[idx]
+		if _, err := srv.queueReportSubscriptionRun(sub, sub.User, now); err != nil {
+			logrus.WithError(err).Errorf("failed to queue run of report subscription %d", sub.ID)
+			continue
+		}
+		sub.LastRunAt = &now
+		if sub.User == nil || !sub.User.IsAdmin() || sub.User.DeactivatedAt != nil {
+			sub.Enabled = false
+		}
+		next, err := sub.NextRun(now)
+		if err != nil {
+			logrus.WithError(err).Errorf("report subscription %d has an invalid schedule, disabling it", sub.ID)
+			sub.Enabled = false
+		} else {
+			sub.NextRunAt = &next
+		}
+		if err := srv.Db.RecordReportSubscriptionRun(sub); err != nil {
+			logrus.WithError(err).Errorf("failed to record run of report subscription %d", sub.ID)
+		}
+	}
+	return nil
+}
+
+func (srv *Server) handleRunReportSubscriptionsTask(msg *nats.Msg) {
+	var params map[string]any
+	if err := json.Unmarshal(msg.Data, &params); err != nil {
+		logrus.Errorf("invalid %s message: %v", models.RunReportSubscriptionsJob, err)
+		return
+	}
+	jobID, _ := params["job_id"].(string)
+	err := srv.runDueReportSubscriptions(time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("failed to run report subscriptions")
+	}
+	if jobID == "" {
+		logrus.Error("report subscriptions task is missing its job_id")
+		return
+	}
+	if err := srv.Db.FinishRunnableTask(context.Background(), jobID, err == nil); err != nil {
+		logrus.WithError(err).Error("failed to finish report subscriptions task")
+	}
+}
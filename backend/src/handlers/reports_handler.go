@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"UnlockEdv2/src/i18n"
 	"UnlockEdv2/src/jasper"
 	"UnlockEdv2/src/models"
+	"bytes"
 	"context"
 	"encoding/csv"
 	"encoding/json"
@@ -30,6 +32,9 @@ func (srv *Server) registerReportsRoutes() []routeDef {
 			features:    []models.FeatureAccess{axx},
 			resolver:    nil,
 		},
+		adminFeatureRoute("GET /api/reports/jobs", srv.handleIndexReportJobs, axx),
+		adminFeatureRoute("GET /api/reports/jobs/{id}", srv.handleGetReportJob, axx),
+		adminFeatureRoute("GET /api/reports/jobs/{id}/download", srv.handleDownloadReportJob, axx),
 	}
 }
 
@@ -40,7 +45,43 @@ func (srv *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request,
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return newJSONReqBodyServiceError(err)
 	}
+	if err := validateReportRequest(&req, claims); err != nil {
+		return err
+	}
+
+	if req.Async {
+		return srv.enqueueReportJob(w, r, &req, claims, log)
+	}
+
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	report, scope, err := srv.generateReport(ctx, &req)
+	if err != nil {
+		srv.logReportFailure(claims.UserID, string(req.Type), &req, startTime, 0)
+		return err
+	}
+
+	artifact, err := srv.renderReport(report, &req, scope)
+	if err != nil {
+		srv.logReportFailure(claims.UserID, string(req.Type), &req, startTime, report.Len())
+		return err
+	}
+
+	if err := artifact.write(w); err != nil {
+		srv.logReportFailure(claims.UserID, string(req.Type), &req, startTime, report.Len())
+		return newInternalServerServiceError(err, "failed to write report")
+	}
 
+	srv.logReportSuccess(claims.UserID, string(req.Type), &req, startTime, report.Len(), reportAuditFacilityID(&req, claims.FacilityID), scope.facilityCount)
+	return nil
+}
+
+// validateReportRequest checks the request and narrows its scope to what the caller's role may see. It is applied
+// again whenever a saved request is run, so the scope always follows the caller's current role.
+func validateReportRequest(req *models.ReportGenerateRequest, claims *Claims) error {
 	if !isValidReportType(req.Type) {
 		return newBadRequestServiceError(errors.New("invalid report type"),
 			"invalid report type specified")
@@ -64,6 +105,12 @@ func (srv *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request,
 		req.FacilityIDs = nil
 	}
 
+	// Reports are written in the requester's language unless the request asks for another.
+	if req.Locale == "" {
+		req.Locale = claims.Locale
+	}
+	req.Locale = req.Locale.OrDefault()
+
 	if req.Type == models.FacilityComparisonReport {
 		if !claims.canSwitchFacility() {
 			return newForbiddenServiceError(errors.New("role insufficient"),
@@ -84,79 +131,92 @@ func (srv *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request,
 			"class_id is required for class roster reports")
 	}
 
+	if req.RoomHoursPerWeek != nil && (*req.RoomHoursPerWeek <= 0 || *req.RoomHoursPerWeek > 168) {
+		return newBadRequestServiceError(errors.New("invalid room_hours_per_week"),
+			"room_hours_per_week must be between 0 and 168")
+	}
+
 	if req.Type == models.ResidentProfileReport && req.UserID == nil {
 		return newBadRequestServiceError(errors.New("missing user_id"),
 			"user_id is required for resident profile reports")
 	}
+	return nil
+}
+
+// reportScope carries the names shown in a report's filter summary and how many facilities it covers
+type reportScope struct {
+	facilityName  string
+	residentName  string
+	facilityCount int
+}
+
+// reportAuditFacilityID is the facility recorded in the report audit log, comparisons span several so record none
+func reportAuditFacilityID(req *models.ReportGenerateRequest, facilityID uint) uint {
+	if req.Type == models.FacilityComparisonReport {
+		return 0
+	}
+	return facilityID
+}
 
+func (srv *Server) generateReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
 	switch req.Type {
 	case models.AttendanceReport:
-		return srv.handleAttendanceReport(w, r, &req, claims)
+		return srv.generateAttendanceReport(ctx, req)
 	case models.ProgramOutcomesReport:
-		return srv.handleProgramOutcomesReport(w, r, &req, claims)
+		return srv.generateProgramOutcomesReport(ctx, req)
 	case models.FacilityComparisonReport:
-		return srv.handleFacilityComparisonReport(w, r, &req, claims)
+		return srv.generateFacilityComparisonReport(ctx, req)
 	case models.ClassRosterReport:
-		return srv.handleClassRosterReport(w, r, &req, claims)
+		return srv.generateClassRosterReport(ctx, req)
 	case models.ResidentProfileReport:
-		return srv.handleResidentProfileReport(w, r, &req, claims)
+		return srv.generateResidentProfileReport(ctx, req)
+	case models.RoomUtilizationReport:
+		return srv.generateRoomUtilizationReport(ctx, req)
 	default:
-		return newBadRequestServiceError(errors.New("invalid report type"),
+		return nil, reportScope{}, newBadRequestServiceError(errors.New("invalid report type"),
 			"invalid report type specified")
 	}
 }
 
-func (srv *Server) handleAttendanceReport(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims) error {
-	startTime := time.Now()
-
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
-
-	rows, err := srv.Db.GenerateAttendanceReport(ctx, req)
-	if err != nil {
-		srv.logReportFailure(claims.UserID, "attendance", req, startTime, 0)
-		return newDatabaseServiceError(err)
-	}
-
-	report := models.AttendanceReportData{Data: rows}
-
-	facilityName := ""
+func (srv *Server) reportFacilityName(req *models.ReportGenerateRequest) string {
 	if req.FacilityID != nil {
 		if facility, err := srv.Db.GetFacilityByID(int(*req.FacilityID)); err == nil && facility != nil {
-			facilityName = facility.Name
+			return facility.Name
 		}
 	}
+	return ""
+}
 
-	residentName := ""
+func (srv *Server) reportResidentName(req *models.ReportGenerateRequest) string {
 	if req.UserID != nil {
 		if user, err := srv.Db.GetUserByID(*req.UserID); err == nil && user != nil {
-			residentName = fmt.Sprintf("%s, %s", user.NameLast, user.NameFirst)
+			return fmt.Sprintf("%s, %s", user.NameLast, user.NameFirst)
 		}
 	}
+	return ""
+}
 
-	if err := srv.exportReport(w, report, req.Format, req, facilityName, residentName); err != nil {
-		srv.logReportFailure(claims.UserID, "attendance", req, startTime, report.Len())
-		return err
+func (srv *Server) generateAttendanceReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
+	rows, err := srv.Db.GenerateAttendanceReport(ctx, req)
+	if err != nil {
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
-
-	srv.logReportSuccess(claims.UserID, "attendance", req, startTime, report.Len(), claims.FacilityID, 1)
-	return nil
+	scope := reportScope{
+		facilityName:  srv.reportFacilityName(req),
+		residentName:  srv.reportResidentName(req),
+		facilityCount: 1,
+	}
+	return models.AttendanceReportData{Data: rows, Locale: req.Locale}, scope, nil
 }
 
-func (srv *Server) handleProgramOutcomesReport(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims) error {
-	startTime := time.Now()
-
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
-
+func (srv *Server) generateProgramOutcomesReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
 	rows, err := srv.Db.GenerateProgramOutcomesReport(ctx, req)
 	if err != nil {
-		srv.logReportFailure(claims.UserID, "program_outcomes", req, startTime, 0)
-		return newDatabaseServiceError(err)
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
 
 	// Surface the Active/Inactive column only when inactive programs are in scope.
-	report := models.ProgramOutcomesReportData{Data: rows, IncludeStatus: req.IncludeInactive}
+	report := models.ProgramOutcomesReportData{Data: rows, IncludeStatus: req.IncludeInactive, Locale: req.Locale}
 
 	// Class-level breakdown is only meaningful for a single program at a single
 	// facility (the frontend enforces this before enabling the option).
@@ -164,62 +224,28 @@ func (srv *Server) handleProgramOutcomesReport(w http.ResponseWriter, r *http.Re
 		if programID, facilityID, ok := singleBreakdownScope(req); ok {
 			classes, err := srv.Db.GenerateProgramClassBreakdown(ctx, req, programID, facilityID)
 			if err != nil {
-				srv.logReportFailure(claims.UserID, "program_outcomes", req, startTime, 0)
-				return newDatabaseServiceError(err)
+				return nil, reportScope{}, newDatabaseServiceError(err)
 			}
 			report.Classes = classes
 		}
 	}
 
-	facilityName := ""
-	if req.FacilityID != nil {
-		if facility, err := srv.Db.GetFacilityByID(int(*req.FacilityID)); err == nil && facility != nil {
-			facilityName = facility.Name
-		}
-	}
-
-	if err := srv.exportReport(w, report, req.Format, req, facilityName, ""); err != nil {
-		srv.logReportFailure(claims.UserID, "program_outcomes", req, startTime, report.Len())
-		return err
-	}
-
-	srv.logReportSuccess(claims.UserID, "program_outcomes", req, startTime, report.Len(), claims.FacilityID, 1)
-	return nil
+	return report, reportScope{facilityName: srv.reportFacilityName(req), facilityCount: 1}, nil
 }
 
-func (srv *Server) handleFacilityComparisonReport(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims) error {
-	startTime := time.Now()
-
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
-
+func (srv *Server) generateFacilityComparisonReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
 	rows, err := srv.Db.GenerateFacilityComparisonReport(ctx, req, req.FacilityIDs)
 	if err != nil {
-		srv.logReportFailure(claims.UserID, "facility_comparison", req, startTime, 0)
-		return newDatabaseServiceError(err)
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
-
-	report := models.FacilityComparisonReportData{Data: rows}
-
-	if err := srv.exportReport(w, report, req.Format, req, "Multiple Facilities", ""); err != nil {
-		srv.logReportFailure(claims.UserID, "facility_comparison", req, startTime, report.Len())
-		return err
-	}
-
-	srv.logReportSuccess(claims.UserID, "facility_comparison", req, startTime, report.Len(), 0, len(req.FacilityIDs))
-	return nil
+	scope := reportScope{facilityName: req.Locale.T("Multiple Facilities"), facilityCount: len(req.FacilityIDs)}
+	return models.FacilityComparisonReportData{Data: rows, Locale: req.Locale}, scope, nil
 }
 
-func (srv *Server) handleClassRosterReport(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims) error {
-	startTime := time.Now()
-
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
-
+func (srv *Server) generateClassRosterReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
 	rows, err := srv.Db.GenerateClassRosterReport(ctx, req)
 	if err != nil {
-		srv.logReportFailure(claims.UserID, "class_roster", req, startTime, 0)
-		return newDatabaseServiceError(err)
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
 
 	report := models.ClassRosterReportData{
@@ -227,104 +253,92 @@ func (srv *Server) handleClassRosterReport(w http.ResponseWriter, r *http.Reques
 		IncludeIncompleteReason: req.IncludeIncompleteReason,
 		IncludeAttendanceRate:   req.IncludeAttendanceRate,
 		IncludeEnrollmentDates:  req.IncludeEnrollmentDates,
+		Locale:                  req.Locale,
 	}
-
-	facilityName := ""
-	if req.FacilityID != nil {
-		if facility, err := srv.Db.GetFacilityByID(int(*req.FacilityID)); err == nil && facility != nil {
-			facilityName = facility.Name
-		}
-	}
-
-	if err := srv.exportReport(w, report, req.Format, req, facilityName, ""); err != nil {
-		srv.logReportFailure(claims.UserID, "class_roster", req, startTime, report.Len())
-		return err
-	}
-
-	srv.logReportSuccess(claims.UserID, "class_roster", req, startTime, report.Len(), claims.FacilityID, 1)
-	return nil
+	return report, reportScope{facilityName: srv.reportFacilityName(req), facilityCount: 1}, nil
 }
 
-func (srv *Server) handleResidentProfileReport(w http.ResponseWriter, r *http.Request, req *models.ReportGenerateRequest, claims *Claims) error {
-	startTime := time.Now()
-
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
-
+func (srv *Server) generateResidentProfileReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
 	rows, err := srv.Db.GenerateResidentProfileReport(ctx, req)
 	if err != nil {
-		srv.logReportFailure(claims.UserID, "resident_profile", req, startTime, 0)
-		return newDatabaseServiceError(err)
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
+	scope := reportScope{residentName: srv.reportResidentName(req), facilityCount: 1}
+	return models.ResidentProfileReportData{Data: rows, Locale: req.Locale}, scope, nil
+}
 
-	report := models.ResidentProfileReportData{Data: rows}
-
-	residentName := ""
-	if req.UserID != nil {
-		if user, err := srv.Db.GetUserByID(*req.UserID); err == nil && user != nil {
-			residentName = fmt.Sprintf("%s, %s", user.NameLast, user.NameFirst)
-		}
+func (srv *Server) generateRoomUtilizationReport(ctx context.Context, req *models.ReportGenerateRequest) (reportExporter, reportScope, error) {
+	rows, err := srv.Db.GenerateRoomUtilizationReport(ctx, req)
+	if err != nil {
+		return nil, reportScope{}, newDatabaseServiceError(err)
 	}
 
-	if err := srv.exportReport(w, report, req.Format, req, "", residentName); err != nil {
-		srv.logReportFailure(claims.UserID, "resident_profile", req, startTime, report.Len())
-		return err
+	scope := reportScope{facilityCount: len(req.FacilityIDs)}
+	if req.FacilityID != nil {
+		scope.facilityCount = 1
+		scope.facilityName = srv.reportFacilityName(req)
 	}
+	return models.RoomUtilizationReportData{Data: rows, Locale: req.Locale}, scope, nil
+}
 
-	srv.logReportSuccess(claims.UserID, "resident_profile", req, startTime, report.Len(), claims.FacilityID, 1)
-	return nil
+// reportArtifact is a rendered report file, written straight to the response or kept in the artifact store
+type reportArtifact struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
-func (srv *Server) exportReport(w http.ResponseWriter, report reportExporter, format models.ReportFormat, req *models.ReportGenerateRequest, facilityName, residentName string) error {
-	switch format {
+func (artifact *reportArtifact) write(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", artifact.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", artifact.Filename))
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(artifact.Data)
+	return err
+}
+
+func (srv *Server) renderReport(report reportExporter, req *models.ReportGenerateRequest, scope reportScope) (*reportArtifact, error) {
+	config, err := report.ToPDF()
+	if err != nil {
+		return nil, newInternalServerServiceError(err, "failed to generate PDF config")
+	}
+	date := time.Now().Format("2006-01-02")
+
+	switch req.Format {
 	case models.FormatCSV:
 		csvData, err := report.ToCSV()
 		if err != nil {
-			return newInternalServerServiceError(err, "failed to format CSV")
+			return nil, newInternalServerServiceError(err, "failed to format CSV")
 		}
 
-		pdfConfig, err := report.ToPDF()
-		if err != nil {
-			return newInternalServerServiceError(err, "failed to generate PDF config")
-		}
-		filename := fmt.Sprintf("%s-Report-%s.csv", pdfConfig.Title, time.Now().Format("2006-01-02"))
-		w.Header().Set("Content-Type", "text/csv")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-		w.WriteHeader(http.StatusOK)
-
-		writer := csv.NewWriter(w)
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
 		if err := writer.WriteAll(csvData); err != nil {
-			return newInternalServerServiceError(err, "failed to write CSV")
+			return nil, newInternalServerServiceError(err, "failed to write CSV")
 		}
-		return nil
+		return &reportArtifact{
+			Filename:    fmt.Sprintf("%s-Report-%s.csv", config.Title, date),
+			ContentType: "text/csv",
+			Data:        buf.Bytes(),
+		}, nil
 
 	case models.FormatExcel:
 		f, err := report.ToExcel()
 		if err != nil {
-			return newInternalServerServiceError(err, "failed to create Excel file")
+			return nil, newInternalServerServiceError(err, "failed to create Excel file")
 		}
 
-		pdfConfig, err := report.ToPDF()
+		buf, err := f.WriteToBuffer()
 		if err != nil {
-			return newInternalServerServiceError(err, "failed to generate PDF config")
-		}
-		filename := fmt.Sprintf("%s-Report-%s.xlsx", pdfConfig.Title, time.Now().Format("2006-01-02"))
-		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-		w.WriteHeader(http.StatusOK)
-
-		if err := f.Write(w); err != nil {
-			return newInternalServerServiceError(err, "failed to write Excel file")
+			return nil, newInternalServerServiceError(err, "failed to write Excel file")
 		}
-		return nil
+		return &reportArtifact{
+			Filename:    fmt.Sprintf("%s-Report-%s.xlsx", config.Title, date),
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Data:        buf.Bytes(),
+		}, nil
 
 	case models.FormatPDF:
-		config, err := report.ToPDF()
-		if err != nil {
-			return newInternalServerServiceError(err, "failed to generate PDF config")
-		}
-
-		filterSummary := srv.buildFilterSummary(req, facilityName, residentName)
+		filterSummary := srv.buildFilterSummary(req, scope.facilityName, scope.residentName)
 
 		templateName := ""
 		switch req.Type {
@@ -338,35 +352,32 @@ func (srv *Server) exportReport(w http.ResponseWriter, report reportExporter, fo
 			templateName = "class_roster_report"
 		case models.ResidentProfileReport:
 			templateName = "resident_profile_report"
+		case models.RoomUtilizationReport:
+			templateName = "room_utilization_report"
 		default:
-			return newBadRequestServiceError(errors.New("unsupported report type"), "unsupported report type for PDF generation")
+			return nil, newBadRequestServiceError(errors.New("unsupported report type"), "unsupported report type for PDF generation")
 		}
 
 		pdfBytes, err := jasper.GenerateReportPDF(config, filterSummary, templateName)
 
 		if err != nil {
 			logrus.WithError(err).Error("Failed to generate PDF with Jasper")
-			return newInternalServerServiceError(err, "failed to generate PDF")
+			return nil, newInternalServerServiceError(err, "failed to generate PDF")
 		}
-
-		filename := fmt.Sprintf("%s-Report-%s.pdf", config.Title, time.Now().Format("2006-01-02"))
-		w.Header().Set("Content-Type", "application/pdf")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-		w.WriteHeader(http.StatusOK)
-
-		if _, err := w.Write(pdfBytes); err != nil {
-			return newInternalServerServiceError(err, "failed to write PDF")
-		}
-		return nil
+		return &reportArtifact{
+			Filename:    fmt.Sprintf("%s-Report-%s.pdf", config.Title, date),
+			ContentType: "application/pdf",
+			Data:        pdfBytes,
+		}, nil
 
 	default:
-		return newBadRequestServiceError(errors.New("invalid format"), "invalid export format")
+		return nil, newBadRequestServiceError(errors.New("invalid format"), "invalid export format")
 	}
 }
 
-func summarizeFilterValues(all bool, names []string) string {
+func summarizeFilterValues(locale i18n.Locale, all bool, names []string) string {
 	if all || len(names) == 0 {
-		return "All"
+		return locale.T("All")
 	}
 	const maxChars = 110 //set for a specific font size and style--dejavu sans
 	var b strings.Builder
@@ -383,18 +394,28 @@ func summarizeFilterValues(all bool, names []string) string {
 		shown++
 	}
 	if shown < len(names) {
-		fmt.Fprintf(&b, " +%d more", len(names)-shown)
+		b.WriteString(locale.Tf(" +%d more", len(names)-shown))
 	}
 	return b.String()
 }
 
+// buildFilterSummary lists the filters a PDF report was run with, in the request's language
 func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilityName, residentName string) []models.PDFFilterLine {
+	filters := srv.reportFilters(req, facilityName, residentName)
+	for i := range filters {
+		filters[i].Label = req.Locale.T(filters[i].Label)
+	}
+	return filters
+}
+
+func (srv *Server) reportFilters(req *models.ReportGenerateRequest, facilityName, residentName string) []models.PDFFilterLine {
+	l := req.Locale
 	dateRange := fmt.Sprintf("%s - %s",
-		req.StartDate.Format("January 2, 2006"),
-		req.EndDate.Format("January 2, 2006"))
+		l.FormatDate(req.StartDate, "January 2, 2006"),
+		l.FormatDate(req.EndDate, "January 2, 2006"))
 
 	if req.Type == models.ProgramOutcomesReport {
-		facilityValue := "All"
+		facilityValue := l.T("All")
 		if facilityName != "" {
 			facilityValue = facilityName
 		} else if len(req.FacilityIDs) > 0 {
@@ -402,34 +423,34 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 			if err != nil {
 				logrus.WithError(err).Warn("failed to resolve facility names for report filter summary")
 			}
-			facilityValue = summarizeFilterValues(false, names)
+			facilityValue = summarizeFilterValues(l, false, names)
 		}
 
-		programValue := "All"
+		programValue := l.T("All")
 		if len(req.ProgramIDs) > 0 {
 			names, err := srv.Db.GetProgramNamesByIDs(req.ProgramIDs)
 			if err != nil {
 				logrus.WithError(err).Warn("failed to resolve program names for report filter summary")
 			}
-			programValue = summarizeFilterValues(false, names)
+			programValue = summarizeFilterValues(l, false, names)
 		}
 
 		typeNames := make([]string, 0, len(req.ProgramTypes))
 		for _, pt := range req.ProgramTypes {
-			typeNames = append(typeNames, pt.HumanReadable())
+			typeNames = append(typeNames, l.T(pt.HumanReadable()))
 		}
 
 		return []models.PDFFilterLine{
 			{Label: "Facilities", Value: facilityValue},
 			{Label: "Programs", Value: programValue},
-			{Label: "Program Types", Value: summarizeFilterValues(len(req.ProgramTypes) == 0, typeNames)},
+			{Label: "Program Types", Value: summarizeFilterValues(l, len(req.ProgramTypes) == 0, typeNames)},
 			{Label: "Date Range", Value: dateRange},
 		}
 	}
 
 	if req.Type == models.AttendanceReport {
 		var filters []models.PDFFilterLine
-		classValue := "All classes"
+		classValue := l.T("All classes")
 		if req.ClassID != nil {
 			if class, err := srv.Db.GetClassByID(int(*req.ClassID)); err == nil && class != nil {
 				classValue = class.Name
@@ -437,7 +458,7 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 		}
 		filters = append(filters, models.PDFFilterLine{Label: "Class", Value: classValue})
 
-		residentValue := "All residents"
+		residentValue := l.T("All residents")
 		if residentName != "" {
 			residentValue = residentName
 		}
@@ -460,7 +481,7 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 		}
 		filters = append(filters, models.PDFFilterLine{
 			Label: "Enrollment Statuses",
-			Value: summarizeFilterValues(len(req.EnrollmentStatuses) == 0, req.EnrollmentStatuses),
+			Value: summarizeFilterValues(l, len(req.EnrollmentStatuses) == 0, l.TAll(req.EnrollmentStatuses)),
 		})
 		if facilityName != "" {
 			filters = append(filters, models.PDFFilterLine{Label: "Facility", Value: facilityName})
@@ -468,6 +489,28 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 		return filters
 	}
 
+	if req.Type == models.RoomUtilizationReport {
+		hoursPerWeek := models.DefaultRoomHoursPerWeek
+		if req.RoomHoursPerWeek != nil {
+			hoursPerWeek = *req.RoomHoursPerWeek
+		}
+		facilityValue := l.T("All")
+		if facilityName != "" {
+			facilityValue = facilityName
+		} else if len(req.FacilityIDs) > 0 {
+			names, err := srv.Db.GetFacilityNamesByIDs(req.FacilityIDs)
+			if err != nil {
+				logrus.WithError(err).Warn("failed to resolve facility names for report filter summary")
+			}
+			facilityValue = summarizeFilterValues(l, false, names)
+		}
+		return []models.PDFFilterLine{
+			{Label: "Facilities", Value: facilityValue},
+			{Label: "Date Range", Value: dateRange},
+			{Label: "Room Hours", Value: l.Tf("%g hours per week", hoursPerWeek)},
+		}
+	}
+
 	if req.Type == models.ResidentProfileReport {
 		if residentName != "" {
 			return []models.PDFFilterLine{{Label: "Resident", Value: residentName}}
@@ -488,13 +531,13 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 	}
 
 	if req.ClassStatus != nil && *req.ClassStatus != "" && *req.ClassStatus != "All" {
-		filters = append(filters, models.PDFFilterLine{Label: "Class Status", Value: *req.ClassStatus})
+		filters = append(filters, models.PDFFilterLine{Label: "Class Status", Value: l.T(*req.ClassStatus)})
 	}
 
 	if len(req.ProgramTypes) > 0 {
 		var types []string
 		for _, pt := range req.ProgramTypes {
-			types = append(types, pt.HumanReadable())
+			types = append(types, l.T(pt.HumanReadable()))
 		}
 		filters = append(filters, models.PDFFilterLine{Label: "Program Types", Value: strings.Join(types, ", ")})
 	}
@@ -502,7 +545,7 @@ func (srv *Server) buildFilterSummary(req *models.ReportGenerateRequest, facilit
 	if len(req.FundingTypes) > 0 {
 		var types []string
 		for _, ft := range req.FundingTypes {
-			types = append(types, ft.HumanReadable())
+			types = append(types, l.T(ft.HumanReadable()))
 		}
 		filters = append(filters, models.PDFFilterLine{Label: "Funding Types", Value: strings.Join(types, ", ")})
 	}
@@ -533,7 +576,7 @@ func singleBreakdownScope(req *models.ReportGenerateRequest) (programID, facilit
 func isValidReportType(rt models.ReportType) bool {
 	switch rt {
 	case models.AttendanceReport, models.ProgramOutcomesReport, models.FacilityComparisonReport,
-		models.ClassRosterReport, models.ResidentProfileReport:
+		models.ClassRosterReport, models.ResidentProfileReport, models.RoomUtilizationReport:
 		return true
 	default:
 		return false
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"UnlockEdv2/src"
+	"UnlockEdv2/src/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+var errRosterSyncNotConfigured = errors.New("ROSTER_SYNC_DIR is not set")
+
+func (srv *Server) registerRosterSyncRoutes() []routeDef {
+	return []routeDef{
+		newDeptAdminRoute("GET /api/roster-sync/runs", srv.handleIndexRosterSyncRuns),
+		newDeptAdminRoute("GET /api/roster-sync/runs/{id}", srv.handleShowRosterSyncRun),
+		newDeptAdminRoute("POST /api/roster-sync/runs", srv.handleRunRosterSync),
+	}
+}
+
+func (srv *Server) handleIndexRosterSyncRuns(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.getQueryContext(r)
+	runs, err := srv.Db.GetRosterSyncRuns(&args)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, runs, args.IntoMeta())
+}
+
+func (srv *Server) handleShowRosterSyncRun(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "roster sync run ID")
+	}
+	run, err := srv.Db.GetRosterSyncRun(r.Context(), uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, run)
+}
+
+/*
+* POST: /api/roster-sync/runs
+* Syncs the latest roster file now instead of waiting for the nightly job. With dry_run the report of what would change
+* is returned and nothing is written, not even the report.
+ */
+func (srv *Server) handleRunRosterSync(w http.ResponseWriter, r *http.Request, log sLog) error {
+	var form struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	defer r.Body.Close()
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	run, err := srv.RunRosterSync(r.Context(), form.DryRun, &claims.UserID)
+	if errors.Is(err, errRosterSyncNotConfigured) {
+		return newBadRequestServiceError(err, "roster sync is not configured")
+	}
+	if err != nil {
+		return newInternalServerServiceError(err, err.Error())
+	}
+	log.add("dry_run", form.DryRun)
+	log.add("filename", run.Filename)
+	log.info("roster sync run")
+	if run.DryRun {
+		return writeJsonResponse(w, http.StatusOK, run)
+	}
+	return writeJsonResponse(w, http.StatusCreated, run)
+}
+
+// latestRosterFile is the most recently dropped CSV in the roster directory, the DOC may leave earlier ones behind
+func latestRosterFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var latest os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".csv") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		if latest == nil || info.ModTime().After(latest.ModTime()) {
+			latest = info
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no roster file found in %s", dir)
+	}
+	return filepath.Join(dir, latest.Name()), nil
+}
+
+/*
+RunRosterSync diffs the latest roster file in ROSTER_SYNC_DIR against our residents and, unless dryRun, applies the
+changes and saves the run as a report for admins to review. A file that can't be read as a roster, or that would release
+more than ROSTER_SYNC_MAX_RELEASE_PERCENT of active residents, fails the run without changing anything. Changes are
+attributed to triggeredByID, or the system_batch user when the nightly job runs.
+*/
+func (srv *Server) RunRosterSync(ctx context.Context, dryRun bool, triggeredByID *uint) (*models.RosterSyncRun, error) {
+	dir := models.RosterSyncDir()
+	if dir == "" {
+		return nil, errRosterSyncNotConfigured
+	}
+	path, err := latestRosterFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(contents)
+	run := &models.RosterSyncRun{
+		Filename:      filepath.Base(path),
+		Checksum:      hex.EncodeToString(checksum[:]),
+		DryRun:        dryRun,
+		Status:        models.RosterSyncCompleted,
+		TriggeredByID: triggeredByID,
+	}
+	residents, err := srv.Db.GetRosterResidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	facilities, err := srv.Db.GetAllFacilitiesOrdered()
+	if err != nil {
+		return nil, err
+	}
+	records, err := src.ParseCSVFile(contents)
+	if err == nil {
+		run.RowCount = len(records) - 1
+		run.Changes, err = src.PlanRosterSync(records, residents, facilities, srv.Db.UserIdentityExists, time.Now())
+	}
+	if err != nil {
+		run.Status = models.RosterSyncFailed
+		run.Error = err.Error()
+	} else if err := checkRosterReleases(run, residents); err != nil {
+		run.Status = models.RosterSyncFailed
+		run.Error = err.Error()
+	}
+	if run.Status == models.RosterSyncCompleted && !dryRun {
+		srv.applyRosterSync(ctx, run)
+	}
+	run.Tally()
+	if dryRun {
+		return run, nil
+	}
+	if err := srv.Db.CreateRosterSyncRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// checkRosterReleases stops a run that would release more of the population than a nightly roster ever should
+func checkRosterReleases(run *models.RosterSyncRun, residents []models.User) error {
+	active, releases := 0, 0
+	for idx := range residents {
+		if residents[idx].DeactivatedAt == nil {
+			active++
+		}
+	}
+	for _, change := range run.Changes {
+		if change.Action == models.RosterSyncRelease {
+			releases++
+		}
+	}
+	limit := models.RosterSyncMaxReleasePercent()
+	if releases > 0 && releases*100 > active*limit {
+		return fmt.Errorf("roster would release %d of %d active residents, more than the %d%% allowed in one run", releases, active, limit)
+	}
+	return nil
+}
+
+// applyRosterSync makes the run's changes, a change that fails is marked unapplied with the reason and the rest go ahead
+func (srv *Server) applyRosterSync(ctx context.Context, run *models.RosterSyncRun) {
+	adminID := run.TriggeredByID
+	if adminID == nil {
+		batchID, err := srv.Db.GetSystemBatchUserID(ctx)
+		if err != nil {
+			logrus.WithError(err).Error("failed to look up the system_batch user for the roster sync")
+		}
+		adminID = batchID
+	}
+	failed := func(change *models.RosterSyncChange, err error) {
+		detail := fmt.Sprintf("%s (failed: %v)", change.Detail, err)
+		if len(detail) > 512 {
+			detail = detail[:512]
+		}
+		change.Detail = detail
+		logrus.WithError(err).Errorf("roster sync could not %s resident %s", change.Action, change.DocID)
+	}
+
+	admissions := make([]models.User, 0)
+	admitted := make([]*models.RosterSyncChange, 0)
+	for idx := range run.Changes {
+		change := &run.Changes[idx]
+		if change.Action != models.RosterSyncAdmit {
+			continue
+		}
+		user := models.User{
+			Username:   stripNonAlphaChars(change.DocID, func(char rune) bool { return unicode.IsLetter(char) || unicode.IsDigit(char) }),
+			NameFirst:  change.NameFirst,
+			NameLast:   change.NameLast,
+			DocID:      change.DocID,
+			Role:       models.Student,
+			FacilityID: *change.ToFacilityID,
+		}
+		if change.ReleaseDate != "" {
+			user.ReleaseDate = &change.ReleaseDate
+		}
+		admissions = append(admissions, user)
+		admitted = append(admitted, change)
+	}
+	if len(admissions) > 0 {
+		if err := srv.Db.CreateUsersBulk(admissions, adminID); err != nil {
+			for _, change := range admitted {
+				failed(change, err)
+			}
+		} else {
+			for idx, change := range admitted {
+				change.UserID = &admissions[idx].ID
+				change.Applied = true
+			}
+		}
+	}
+
+	for _, action := range []models.RosterSyncAction{models.RosterSyncTransfer, models.RosterSyncReleaseDate, models.RosterSyncRelease} {
+		for idx := range run.Changes {
+			change := &run.Changes[idx]
+			if change.Action != action {
+				continue
+			}
+			user, err := srv.Db.GetUserByID(*change.UserID)
+			if err != nil {
+				failed(change, err)
+				continue
+			}
+			switch action {
+			case models.RosterSyncTransfer:
+				err = srv.transferResident(&models.QueryContext{Ctx: ctx}, user, int(*change.FromFacilityID), int(*change.ToFacilityID), adminID)
+			case models.RosterSyncReleaseDate:
+				err = srv.Db.SetReleaseDate(ctx, user, &change.ReleaseDate, adminID)
+			case models.RosterSyncRelease:
+				err = srv.releaseResident(ctx, user, adminID)
+				if errors.Is(err, errReleasePacketPending) {
+					// the release went through, the release lifecycle job produces the packet
+					logrus.WithError(err).Errorf("released resident %d without a release packet", user.ID)
+					err = nil
+				}
+			}
+			if err != nil {
+				failed(change, err)
+				continue
+			}
+			change.Applied = true
+		}
+	}
+}
+
+func (srv *Server) subscribeRosterSync() error {
+	if srv.nats == nil {
+		return errors.New("no NATS connection")
+	}
+	_, err := srv.nats.QueueSubscribe(models.RosterSyncJob.PubName(), reportWorkersQueue, srv.handleRosterSyncTask)
+	return err
+}
+
+func (srv *Server) handleRosterSyncTask(msg *nats.Msg) {
+	var params map[string]any
+	if err := json.Unmarshal(msg.Data, &params); err != nil {
+		logrus.Errorf("invalid %s message: %v", models.RosterSyncJob, err)
+		return
+	}
+	jobID, _ := params["job_id"].(string)
+	run, err := srv.RunRosterSync(context.Background(), models.RosterSyncDryRun(), nil)
+	switch {
+	case errors.Is(err, errRosterSyncNotConfigured):
+		// most deployments don't get a roster feed
+		err = nil
+	case err != nil:
+		logrus.WithError(err).Error("failed to run roster sync")
+	case run.Status == models.RosterSyncFailed:
+		err = errors.New(run.Error)
+		logrus.Errorf("roster sync of %s failed: %s", run.Filename, run.Error)
+	default:
+		logrus.Infof("roster sync of %s (dry run: %t): %d admitted, %d transferred, %d released, %d release dates updated, %d skipped, %d failed",
+			run.Filename, run.DryRun, run.Admitted, run.Transferred, run.Released, run.Updated, run.Skipped, run.Failed)
+	}
+	if jobID == "" {
+		logrus.Error("roster sync task is missing its job_id")
+		return
+	}
+	if err := srv.Db.FinishRunnableTask(context.Background(), jobID, err == nil); err != nil {
+		logrus.WithError(err).Error("failed to finish roster sync task")
+	}
+}
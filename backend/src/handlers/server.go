@@ -43,6 +43,8 @@ type Server struct {
 	wsClient       *ClientManager
 	scheduler      *tasks.Scheduler
 	canvasInflight sync.Map
+	reportStore    reportArtifactStore
+	packetStore    reportArtifactStore
 }
 
 type routeDef struct {
@@ -76,6 +78,11 @@ func (srv *Server) RegisterRoutes() {
 	srv.registerProxyRoutes()
 	srv.registerImageRoutes()
 	srv.registerWebsocketRoute()
+	srv.registerICalFeedRoute()
+	srv.registerCertificateVerifyRoute()
+	srv.registerKioskCheckInRoutes()
+	srv.registerTranscriptVerifyRoute()
+	srv.registerReleasePacketVerifyRoute()
 	srv.Mux.Handle("/api/metrics", promhttp.Handler())
 	srv.Mux.HandleFunc("/api/healthcheck", func(w http.ResponseWriter, r *http.Request) {
 		if _, err := w.Write([]byte("OK")); err != nil {
@@ -103,10 +110,24 @@ func (srv *Server) RegisterRoutes() {
 		srv.registerOpenContentRoutes,
 		srv.registerLibraryRoutes,
 		srv.registerProgramsRoutes,
+		srv.registerProgramPrerequisiteRoutes,
 		srv.registerClassesRoutes,
 		srv.registerClassEventsRoutes,
+		srv.registerInstructorAvailabilityRoutes,
+		srv.registerSubstituteRoutes,
+		srv.registerAttendanceRiskRoutes,
+		srv.registerCertificateRoutes,
+		srv.registerReportSubscriptionRoutes,
+		srv.registerContentRequestRoutes,
+		srv.registerCalendarFeedRoutes,
+		srv.registerCalendarImportRoutes,
 		srv.registerProgramClassEnrollmentsRoutes,
+		srv.registerClassWaitlistRoutes,
 		srv.registerAttendanceRoutes,
+		srv.registerKioskRoutes,
+		srv.registerTranscriptRoutes,
+		srv.registerReleasePacketRoutes, srv.registerReleaseLifecycleRoutes, srv.registerRosterSyncRoutes,
+		srv.registerCSVMappingProfileRoutes, srv.registerCanvasEnrollmentSyncRoutes,
 		srv.registerVideoRoutes,
 		srv.registerDemoSeedRoutes,
 		srv.registerOpenContentActivityRoutes,
@@ -207,6 +228,27 @@ func newServer(ctx context.Context) *Server {
 		log.Fatalf("Failed to setup JetStream KV store: %v", err)
 	}
 	server.initAwsConfig(ctx)
+	server.reportStore, err = server.newReportArtifactStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.packetStore, err = server.newReleasePacketStore()
+	if err != nil {
+		if !server.dev {
+			log.Fatal(err)
+		}
+		log.Warnf("%v, release packets are disabled", err)
+	}
+	if err := server.subscribeReportJobs(); err != nil {
+		log.Errorf("Failed to subscribe to report jobs: %v", err)
+	}
+	if err := server.subscribeReleaseLifecycle(); err != nil {
+		log.Errorf("Failed to subscribe to release lifecycle jobs: %v", err)
+	}
+	if err := server.subscribeRosterSync(); err != nil {
+		log.Errorf("Failed to subscribe to roster sync jobs: %v", err)
+	}
+	go server.cleanupReportJobs(ctx)
 	server.RegisterRoutes()
 	if err := server.setupDefaultAdminInKratos(ctx); err != nil {
 		log.Fatal("Error setting up default admin in Kratos")
@@ -233,7 +275,7 @@ func (srv *Server) initAwsConfig(ctx context.Context) {
 func newTestingServer() *Server {
 	db := database.InitDB(true)
 	features := models.AllFeatures
-	return &Server{
+	srv := &Server{
 		Db:          db,
 		Mux:         http.NewServeMux(),
 		OryClient:   nil,
@@ -241,6 +283,9 @@ func newTestingServer() *Server {
 		features:    features,
 		testingMode: true,
 	}
+	srv.reportStore, _ = srv.newReportArtifactStore()
+	srv.packetStore, _ = srv.newReleasePacketStore()
+	return srv
 }
 
 func setupNats() (*nats.Conn, error) {
@@ -585,16 +630,26 @@ func writeConflictResponse(w http.ResponseWriter, conflicts []models.RoomConflic
 }
 
 func conflictResponseMessage(conflicts []models.RoomConflict) string {
-	var hasRoom, hasInstructor bool
+	var hasRoom, hasInstructor, isUnavailable, isOverHours bool
 	for _, c := range conflicts {
 		switch c.ConflictType {
 		case models.ConflictTypeInstructor:
 			hasInstructor = true
+		case models.ConflictTypeInstructorUnavailable:
+			isUnavailable = true
+		case models.ConflictTypeInstructorOverHours:
+			isOverHours = true
 		default:
 			hasRoom = true
 		}
 	}
 	switch {
+	case !hasRoom && !hasInstructor && isUnavailable && isOverHours:
+		return "the instructor is unavailable and would be over their weekly hour limit"
+	case !hasRoom && !hasInstructor && isUnavailable:
+		return "the instructor is not available during this time"
+	case !hasRoom && !hasInstructor && isOverHours:
+		return "this schedule would put the instructor over their weekly hour limit"
 	case hasRoom && hasInstructor:
 		return "the room and instructor are already booked during this time"
 	case hasInstructor:
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/models"
+	"UnlockEdv2/src/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (srv *Server) registerSubstituteRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolver := FacilityAdminResolver("program_classes", "class_id")
+	return []routeDef{
+		adminValidatedFeatureRoute("GET /api/program-classes/{class_id}/events/{event_id}/substitutes", srv.handleFindSubstitutes, axx, resolver),
+		adminValidatedFeatureRoute("POST /api/program-classes/{class_id}/events/{event_id}/substitute", srv.handleAssignSubstitute, axx, resolver),
+		adminFeatureRoute("GET /api/instructors/coverage-summary", srv.handleGetInstructorCoverageSummary, axx),
+	}
+}
+
+func substituteServiceError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSessionDate),
+		errors.Is(err, services.ErrNoSessionOnDate),
+		errors.Is(err, services.ErrSessionCancelled),
+		errors.Is(err, services.ErrAlreadyTeaching),
+		errors.Is(err, services.ErrNotASubstitute),
+		errors.Is(err, services.ErrEventNotInClass):
+		return newBadRequestServiceError(err, err.Error())
+	default:
+		return newDatabaseServiceError(err)
+	}
+}
+
+func parseClassEventPath(r *http.Request) (uint, uint, error) {
+	classID, err := strconv.Atoi(r.PathValue("class_id"))
+	if err != nil {
+		return 0, 0, newInvalidIdServiceError(err, "class ID")
+	}
+	eventID, err := strconv.Atoi(r.PathValue("event_id"))
+	if err != nil {
+		return 0, 0, newInvalidIdServiceError(err, "event ID")
+	}
+	return uint(classID), uint(eventID), nil
+}
+
+/**
+* GET: /api/program-classes/{class_id}/events/{event_id}/substitutes?date=YYYY-MM-DD
+* Lists the instructors who are free to teach the session on date in place of its instructor.
+ */
+func (srv *Server) handleFindSubstitutes(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, eventID, err := parseClassEventPath(r)
+	if err != nil {
+		return err
+	}
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return newBadRequestServiceError(errors.New("date is required"), "date is required")
+	}
+	log.add("class_id", classID)
+	log.add("event_id", eventID)
+	instructors, err := services.NewSubstituteService(srv.Db).FindSubstitutes(classID, eventID, date)
+	if err != nil {
+		return substituteServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, instructors)
+}
+
+func (srv *Server) handleAssignSubstitute(w http.ResponseWriter, r *http.Request, log sLog) error {
+	classID, eventID, err := parseClassEventPath(r)
+	if err != nil {
+		return err
+	}
+	cannotUpdate, err := srv.cannotUpdateEvent(int(classID))
+	if err != nil {
+		return err
+	}
+	if cannotUpdate {
+		return newBadRequestServiceError(errors.New("cannot assign a substitute for a completed or cancelled class"), "cannot assign substitute")
+	}
+	var req models.AssignSubstituteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	if req.Date == "" || req.InstructorID == 0 {
+		return newBadRequestServiceError(errors.New("date and instructor_id are required"), "date and instructor_id are required")
+	}
+	if len(req.Reason) > 255 {
+		return newBadRequestServiceError(errors.New("reason exceeds maximum length of 255 characters"), "reason exceeds maximum length of 255 characters")
+	}
+	log.add("class_id", classID)
+	log.add("event_id", eventID)
+	log.add("substitute_id", req.InstructorID)
+	args := srv.getQueryContext(r)
+	coverage, conflicts, err := services.NewSubstituteService(srv.WithUserContext(r)).AssignSubstitute(&args, classID, eventID, &req)
+	if err != nil {
+		return substituteServiceError(err)
+	}
+	if len(conflicts) > 0 {
+		return writeConflictResponse(w, conflicts)
+	}
+	if coverage.ID == 0 {
+		log.info("session handed back to its scheduled instructor")
+		return writeJsonResponse(w, http.StatusOK, "session handed back to its scheduled instructor")
+	}
+	log.info("substitute instructor assigned")
+	return writeJsonResponse(w, http.StatusCreated, coverage)
+}
+
+/**
+* GET: /api/instructors/coverage-summary?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+* Per instructor totals of sessions covered for others and sessions of theirs covered by a substitute.
+* Defaults to the last 30 days.
+ */
+func (srv *Server) handleGetInstructorCoverageSummary(w http.ResponseWriter, r *http.Request, log sLog) error {
+	args := srv.facilityScopedQueryContext(r)
+	endDate := r.URL.Query().Get("end_date")
+	startDate := r.URL.Query().Get("start_date")
+	if endDate == "" {
+		endDate = time.Now().Format("2006-01-02")
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return newInvalidQueryParamServiceError(err, "end_date")
+	}
+	if startDate == "" {
+		startDate = end.AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return newInvalidQueryParamServiceError(err, "start_date")
+	}
+	if end.Before(start) {
+		return newBadRequestServiceError(errors.New("end_date is before start_date"), "end_date cannot be before start_date")
+	}
+	summaries, err := srv.Db.GetInstructorCoverageSummary(args.FacilityID, startDate, endDate)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, summaries)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"UnlockEdv2/src/jasper"
+	"UnlockEdv2/src/models"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (srv *Server) registerTranscriptRoutes() []routeDef {
+	axx := models.ProgramAccess
+	resolver := AllResolvers(UserRoleResolver("id"), ResidentFeatureResolver(models.ResidentProgramsAccess))
+	return []routeDef{
+		validatedFeatureRoute("GET /api/users/{id}/transcript", srv.handleGetTranscript, axx, resolver),
+		validatedFeatureRoute("GET /api/users/{id}/transcripts", srv.handleIndexTranscripts, axx, resolver),
+		validatedFeatureRoute("POST /api/users/{id}/transcripts", srv.handleIssueTranscript, axx, resolver),
+	}
+}
+
+// the verification route is used by case managers and agencies outside the platform, the code printed on the transcript is all they have
+func (srv *Server) registerTranscriptVerifyRoute() {
+	srv.Mux.Handle("GET /api/transcripts/verify/{code}", srv.handleError(srv.handleVerifyTranscript))
+}
+
+func transcriptVerifyURL(code string) string {
+	return fmt.Sprintf("%s/api/transcripts/verify/%s", strings.TrimRight(os.Getenv("APP_URL"), "/"), code)
+}
+
+/**
+* GET: /api/users/{id}/transcript
+* Previews the resident's transcript as it would be issued now. The preview has no verification code.
+ */
+func (srv *Server) handleGetTranscript(w http.ResponseWriter, r *http.Request, log sLog) error {
+	data, err := srv.buildTranscript(r)
+	if err != nil {
+		return err
+	}
+	return writeJsonResponse(w, http.StatusOK, data)
+}
+
+func (srv *Server) handleIndexTranscripts(w http.ResponseWriter, r *http.Request, log sLog) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "user ID")
+	}
+	transcripts, err := srv.Db.GetTranscriptsForUser(uint(id))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writeJsonResponse(w, http.StatusOK, transcripts)
+}
+
+/**
+* POST: /api/users/{id}/transcripts?format=pdf|csv|json
+* Issues the resident's transcript with a new verification code and returns it: a PDF for the resident, or CSV and
+* JSON for case managers to load into their own systems.
+ */
+func (srv *Server) handleIssueTranscript(w http.ResponseWriter, r *http.Request, log sLog) error {
+	format := models.ReportFormat(strings.ToLower(r.URL.Query().Get("format")))
+	if format == "" {
+		format = models.FormatPDF
+	}
+	if format != models.FormatPDF && format != models.FormatCSV && format != "json" {
+		return newBadRequestServiceError(fmt.Errorf("transcript format %q", format), "format must be one of pdf, csv or json")
+	}
+	data, err := srv.buildTranscript(r)
+	if err != nil {
+		return err
+	}
+	userID, _ := strconv.Atoi(r.PathValue("id"))
+	transcript := data.NewTranscript(uint(userID), srv.getUserID(r))
+	if err := srv.WithUserContext(r).CreateTranscript(transcript); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	data.Issue(transcript, transcriptVerifyURL(transcript.VerificationCode))
+	log.add("transcript_id", transcript.ID)
+	log.add("format", format)
+	log.info("transcript issued")
+
+	filename := fmt.Sprintf("transcript-%s", transcript.VerificationCode)
+	switch format {
+	case models.FormatCSV:
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.WriteAll(data.ToCSV()); err != nil {
+			return newInternalServerServiceError(err, "failed to write CSV")
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", filename))
+		_, err = w.Write(buf.Bytes())
+		return err
+	case models.FormatPDF:
+		pdfBytes, err := jasper.GenerateReportPDF(data.ToPDF(), nil, "resident_transcript")
+		if err != nil {
+			logrus.WithError(err).Error("Failed to generate transcript PDF with Jasper")
+			return newInternalServerServiceError(err, "failed to generate transcript")
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", filename))
+		_, err = w.Write(pdfBytes)
+		return err
+	default:
+		return writeJsonResponse(w, http.StatusCreated, data)
+	}
+}
+
+/**
+* GET: /api/transcripts/verify/{code}
+* Public. Confirms whether a verification code belongs to a transcript we issued, returning only its printed totals.
+ */
+func (srv *Server) handleVerifyTranscript(w http.ResponseWriter, r *http.Request, log sLog) error {
+	code := models.NormalizeVerificationCode(r.PathValue("code"))
+	transcript, err := srv.Db.GetTranscriptByCode(code)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if transcript == nil {
+		return writeJsonResponse(w, http.StatusNotFound, models.TranscriptVerification{Valid: false})
+	}
+	return writeJsonResponse(w, http.StatusOK, models.TranscriptVerification{
+		Valid:            true,
+		ResidentName:     transcript.ResidentName,
+		FacilityName:     transcript.FacilityName,
+		ClassesCompleted: transcript.ClassesCompleted,
+		TotalCreditHours: transcript.TotalCreditHours,
+		IssuedAt:         &transcript.IssuedAt,
+	})
+}
+
+func (srv *Server) buildTranscript(r *http.Request) (*models.TranscriptData, error) {
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, newInvalidIdServiceError(err, "user ID")
+	}
+	user, err := srv.Db.GetUserByID(uint(id))
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	if user.Role != models.Student {
+		return nil, newBadRequestServiceError(fmt.Errorf("user %d is not a resident", user.ID), "transcripts are only available for residents")
+	}
+	classes, err := srv.Db.GetTranscriptClasses(user.ID)
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	entries, err := srv.Db.GetLearningRecordEntries(user.ID)
+	if err != nil {
+		return nil, newDatabaseServiceError(err)
+	}
+	return models.NewTranscriptData(user, classes, entries, claims.Locale.OrDefault()), nil
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"UnlockEdv2/src"
 	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/i18n"
 	"UnlockEdv2/src/jasper"
 	"UnlockEdv2/src/models"
 	"UnlockEdv2/src/services"
@@ -17,11 +18,14 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/sirupsen/logrus"
 )
 
 func (srv *Server) registerUserRoutes() []routeDef {
 	resolver := UserRoleResolver("id")
 	return []routeDef{
+		newRoute("PUT /api/users/me/locale", srv.handleUpdateOwnLocale),
 		validatedRoute("GET /api/users/{id}", srv.handleShowUser, resolver),
 		validatedRoute("GET /api/users/{id}/programs", srv.handleGetUserPrograms,
 			AllResolvers(resolver, ResidentFeatureResolver(models.ResidentProgramsAccess))),
@@ -425,9 +429,44 @@ func validateUser(user *models.User) string {
 	}) {
 		return "alphanum"
 	}
+	if user.Locale != "" {
+		locale, ok := i18n.Parse(string(user.Locale))
+		if !ok {
+			return "unsupported locale"
+		}
+		user.Locale = locale
+	}
 	return ""
 }
 
+/**
+* PUT: /api/users/me/locale
+* Sets the language the signed in user's reports, exports and messages are written in. Takes effect from the next
+* request, when the claims are read again.
+ */
+func (srv *Server) handleUpdateOwnLocale(w http.ResponseWriter, r *http.Request, log sLog) error {
+	var body struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	locale, ok := i18n.Parse(body.Locale)
+	if !ok {
+		return newBadRequestServiceError(fmt.Errorf("unsupported locale %q", body.Locale), "unsupported locale")
+	}
+	user, err := srv.Db.GetUserByID(srv.getUserID(r))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	user.Locale = locale
+	if err := srv.WithUserContext(r).UpdateUser(user); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("locale", locale)
+	return writeJsonResponse(w, http.StatusOK, user)
+}
+
 func (srv *Server) handleGetUserAccountHistory(w http.ResponseWriter, r *http.Request, log sLog) error {
 	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
@@ -498,30 +537,41 @@ func (srv *Server) handleResidentTransfer(w http.ResponseWriter, r *http.Request
 	if user.DeactivatedAt != nil {
 		return newBadRequestServiceError(errors.New("cannot transfer deactivated user"), "User is deactivated")
 	}
-	tx, err := srv.Db.TransferResident(&args, transRequest.UserID, transRequest.CurrFacilityID, transRequest.TransFacilityID)
+	if err := srv.transferResident(&args, user, transRequest.CurrFacilityID, transRequest.TransFacilityID, &args.UserID); err != nil {
+		log.error("error transferring resident: ", err)
+		return err
+	}
+	log.info("successfully transferred resident")
+	return writeJsonResponse(w, http.StatusOK, "successfully transferred resident")
+}
+
+// transferResident moves the resident to another facility, withdrawing their enrollments at the current one
+func (srv *Server) transferResident(args *models.QueryContext, user *models.User, currFacilityID, transFacilityID int, adminID *uint) error {
+	tx, err := srv.Db.TransferResident(args, int(user.ID), currFacilityID, transFacilityID)
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
-	err = srv.updateFacilityInKratosIdentity(transRequest.UserID, transRequest.TransFacilityID)
+	err = srv.updateFacilityInKratosIdentity(user, transFacilityID)
 	if err != nil {
 		tx.Rollback()
 		return newInternalServerServiceError(err, "error updating facility in kratos")
 	}
-	log.info("successfully transferred resident")
-	transFacilityID := uint(transRequest.TransFacilityID)
-	facilityTransfer := models.NewUserAccountHistory(uint(transRequest.UserID), models.FacilityTransfer, &args.UserID, nil, &transFacilityID)
-	if err := srv.Db.InsertUserAccountHistoryAction(r.Context(), facilityTransfer); err != nil {
+	transFacility := uint(transFacilityID)
+	facilityTransfer := models.NewUserAccountHistory(user.ID, models.FacilityTransfer, adminID, nil, &transFacility)
+	if err := tx.Create(facilityTransfer).Error; err != nil {
 		tx.Rollback()
 		return newCreateRequestServiceError(err)
 	}
 	if err := tx.Commit().Error; err != nil {
 		// transfer back to original facility if we cannot commit tx
-		err = srv.updateFacilityInKratosIdentity(transRequest.UserID, transRequest.CurrFacilityID)
-		// pray that this never happens 🙏
-		log.error("Error committing transaction AND updating user facility in kratos: " + err.Error())
+		if kratosErr := srv.updateFacilityInKratosIdentity(user, currFacilityID); kratosErr != nil {
+			// pray that this never happens 🙏
+			logrus.Error("Error committing transaction AND updating user facility in kratos: " + kratosErr.Error())
+		}
 		return newDatabaseServiceError(err)
 	}
-	return writeJsonResponse(w, http.StatusOK, "successfully transferred resident")
+	srv.pushCanvasEnrollmentsForUser(args.Ctx, user.ID)
+	return nil
 }
 
 func (srv *Server) handleGetUserPrograms(w http.ResponseWriter, r *http.Request, log sLog) error {
@@ -734,12 +784,28 @@ func (srv *Server) handleBulkUpload(w http.ResponseWriter, r *http.Request, log
 		return newBadRequestServiceError(errors.New("empty file"), "file is empty - no data found")
 	}
 
+	profile, err := srv.bulkUploadProfile(r, claims)
+	if err != nil {
+		return err
+	}
+
 	headers := records[0]
-	headerMap, err := src.ValidateCSVHeaders(headers)
+	var headerMap *src.HeaderMapping
+	if profile != nil {
+		log.add("csv_mapping_profile_id", profile.ID)
+		headerMap, err = src.ApplyMappingProfile(headers, profile)
+	} else {
+		headerMap, err = src.ValidateCSVHeaders(headers)
+	}
 	if err != nil {
 		log.add("validation_error", err.Error())
 		return newBadRequestServiceError(err, err.Error())
 	}
+	facility, err := srv.Db.GetFacilityByID(int(claims.FacilityID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	headerMap.Today = facility.Today(time.Now())
 
 	var validRows []models.ValidatedUserRow
 	var invalidRows []models.InvalidUserRow
@@ -753,7 +819,7 @@ func (srv *Server) handleBulkUpload(w http.ResponseWriter, r *http.Request, log
 	for i, record := range records[1:] {
 		rowNum := i + 2
 
-		validRow, invalidRow := src.ValidateUserRow(record, rowNum, headerMap, existingResidentIDs, checkIdentity, existingUsernames)
+		validRow, invalidRow := src.ValidateUserRow(record, rowNum, headerMap, existingResidentIDs, checkIdentity, existingUsernames, claims.Locale)
 		if validRow != nil {
 			validRows = append(validRows, *validRow)
 		}
@@ -767,7 +833,13 @@ func (srv *Server) handleBulkUpload(w http.ResponseWriter, r *http.Request, log
 	log.info("CSV upload processed and validated")
 
 	var errorCSVData []byte
-	if len(invalidRows) > 0 {
+	if len(invalidRows) > 0 && profile != nil {
+		errorCSVData, err = src.GenerateProfileErrorCSV(headers, invalidRows)
+		if err != nil {
+			log.add("error", err.Error())
+			return newInternalServerServiceError(err, "failed to generate error report")
+		}
+	} else if len(invalidRows) > 0 {
 		errorCSVData, err = src.GenerateErrorCSV(invalidRows)
 		if err != nil {
 			log.add("error", err.Error())
@@ -821,10 +893,30 @@ func (srv *Server) handleBulkCreate(w http.ResponseWriter, r *http.Request, log
 			Role:       models.Student,
 			FacilityID: facilityID,
 		}
+		if validRow.ReleaseDate != "" {
+			releaseDate, err := models.ParseReleaseDate(validRow.ReleaseDate)
+			if err != nil {
+				return newBadRequestServiceError(err, fmt.Sprintf("row %d: %s", validRow.RowNumber, err.Error()))
+			}
+			user.ReleaseDate = &releaseDate
+		}
+		if validRow.HousingUnit != "" {
+			user.HousingUnit = &validRow.HousingUnit
+		}
+		if validRow.DateOfBirth != "" {
+			dateOfBirth, err := models.ParseReleaseDate(validRow.DateOfBirth)
+			if err != nil {
+				return newBadRequestServiceError(err, fmt.Sprintf("row %d: %s", validRow.RowNumber, err.Error()))
+			}
+			user.DateOfBirth = &dateOfBirth
+		}
+		if locale, ok := i18n.Parse(validRow.PreferredLanguage); ok {
+			user.Locale = locale
+		}
 		usersToCreate = append(usersToCreate, user)
 	}
 
-	err = srv.Db.CreateUsersBulk(usersToCreate, claims.UserID)
+	err = srv.Db.CreateUsersBulk(usersToCreate, &claims.UserID)
 	if err != nil {
 		log.add("transaction_error", err.Error())
 		log.error("Bulk user creation transaction failed")
@@ -848,10 +940,39 @@ func (srv *Server) handleDeactivateUser(w http.ResponseWriter, r *http.Request,
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
+	srv.pushCanvasEnrollmentsForUser(r.Context(), uint(id))
+
+	// the release packet is produced after deactivation so it records the withdrawn enrollments. The deactivation has
+	// already been committed by then, so a packet that fails is reported alongside it and can be produced again later.
+	if r.URL.Query().Get("release_packet") == "true" {
+		resp := deactivateUserResponse{Message: "User deactivated successfully"}
+		user, err := srv.Db.GetUserByID(uint(id))
+		if err == nil {
+			resp.ReleasePacket, err = srv.createReleasePacket(r.Context(), user, &claims.UserID, claims.Locale.OrDefault())
+		}
+		if err != nil {
+			log.add("user_id", id)
+			log.error("user deactivated but the release packet failed: ", err)
+			resp.ReleasePacketError = "the release packet could not be produced, produce it again from the resident's release packets"
+			var svcErr serviceError
+			if errors.As(err, &svcErr) {
+				resp.ReleasePacketError = svcErr.Message
+			}
+			return writeJsonResponse(w, http.StatusOK, resp)
+		}
+		log.add("release_packet_id", resp.ReleasePacket.ID)
+		return writeJsonResponse(w, http.StatusOK, resp)
+	}
 
 	return writeJsonResponse(w, http.StatusOK, "User deactivated successfully")
 }
 
+type deactivateUserResponse struct {
+	Message            string                `json:"message"`
+	ReleasePacket      *models.ReleasePacket `json:"release_packet,omitempty"`
+	ReleasePacketError string                `json:"release_packet_error,omitempty"`
+}
+
 func (srv *Server) handleGenerateUsageReportPDF(w http.ResponseWriter, r *http.Request, log sLog) error {
 	userID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
@@ -1082,6 +1203,7 @@ func (srv *Server) handleBulkDeactivateUsers(w http.ResponseWriter, r *http.Requ
 			failures = append(failures, failedEntry{UserID: user.ID, Username: user.Username, Name: user.NameFirst + " " + user.NameLast, Reason: "error deactivating user"})
 			continue
 		}
+		srv.pushCanvasEnrollmentsForUser(r.Context(), user.ID)
 		successCount++
 	}
 	return writeJsonResponse(w, http.StatusOK, map[string]any{
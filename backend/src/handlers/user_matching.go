@@ -3,8 +3,12 @@ package handlers
 import (
 	"UnlockEdv2/src/models"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -14,10 +18,12 @@ import (
 
 // --- Types ---
 
+// UserMatchResult pairs a provider user with a resident, CanvasUser keeps its name from when only Canvas was matched
 type UserMatchResult struct {
-	CanvasUser    models.ImportUser `json:"canvas_user"`
-	SuggestedUser *models.User      `json:"suggested_user,omitempty"`
-	Score         float64           `json:"score"`
+	CanvasUser    models.ImportUser        `json:"canvas_user"`
+	SuggestedUser *models.User             `json:"suggested_user,omitempty"`
+	Score         float64                  `json:"score"`
+	Signals       []models.UserMatchSignal `json:"signals"`
 }
 
 type MatchUsersResponse struct {
@@ -66,25 +72,105 @@ func NameSimilarity(a, b string) float64 {
 	return 0.6*jw + 0.4*swg
 }
 
+// normalizeIdentifier compares IDs and usernames the way admins type them: ignoring case, punctuation and leading zeros
+func normalizeIdentifier(value string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, value)
+	return strings.TrimLeft(normalized, "0")
+}
+
+func emailLocalPart(email string) string {
+	local, _, found := strings.Cut(email, "@")
+	if !found {
+		return ""
+	}
+	return local
+}
+
+func sameIdentifier(a, b string) bool {
+	a, b = normalizeIdentifier(a), normalizeIdentifier(b)
+	return a != "" && a == b
+}
+
+const (
+	// docIDBoost is added to the name score when both sides have the same resident ID
+	docIDBoost = 0.4
+	// docIDNameAgreement is how alike the names must be for a shared resident ID to count, so a mistyped or reused ID
+	// doesn't tie two different people together
+	docIDNameAgreement = 0.55
+	// identifierBoost is added to the name score when the usernames or email addresses agree
+	identifierBoost = 0.2
+	// docIDConflictPenalty is taken off the name score when both sides have a resident ID and they differ
+	docIDConflictPenalty = 0.3
+)
+
+/*
+ScoreUserMatch scores how likely the provider user and the resident are the same person, starting from their name
+similarity. The same resident ID raises it strongly as long as the names also agree, a matching username or email
+local-part raises it, and a different resident ID lowers it. The signals are what the two were found to have in
+common, names count as in common once they are as alike as the provider's ambiguous threshold.
+*/
+func ScoreUserMatch(importUser *models.ImportUser, user *models.User, ambiguous float64) (float64, []models.UserMatchSignal) {
+	signals := make([]models.UserMatchSignal, 0, 3)
+	nameScore := NameSimilarity(importUser.NameFirst+" "+importUser.NameLast, user.NameFirst+" "+user.NameLast)
+	score := nameScore
+	if nameScore >= ambiguous {
+		signals = append(signals, models.MatchSignalName)
+	}
+	importDocID, userDocID := normalizeIdentifier(importUser.DocID), normalizeIdentifier(user.DocID)
+	switch {
+	case importDocID == "" || userDocID == "":
+	case importDocID == userDocID:
+		signals = append(signals, models.MatchSignalDocID)
+		if nameScore >= docIDNameAgreement {
+			score += docIDBoost
+		}
+	default:
+		score -= docIDConflictPenalty
+	}
+	username := sameIdentifier(importUser.Username, user.Username) || sameIdentifier(importUser.ExternalUsername, user.Username)
+	local := emailLocalPart(importUser.Email)
+	email := sameIdentifier(local, user.Username) || sameIdentifier(local, emailLocalPart(user.Email))
+	if username {
+		signals = append(signals, models.MatchSignalUsername)
+	}
+	if email {
+		signals = append(signals, models.MatchSignalEmail)
+	}
+	if username || email {
+		score += identifierBoost
+	}
+	return min(max(score, 0), 1), signals
+}
+
 // --- Matching logic ---
 
 type matchCandidate struct {
 	canvasIdx int
 	unlockIdx int
 	score     float64
+	signals   []models.UserMatchSignal
 }
 
-// MatchUsers performs greedy 1:1 matching. All pairs are scored, sorted by
-// score descending, then assigned greedily — each Canvas user and each
-// UnlockEd user can only appear in one match.
+// MatchUsers matches with the default thresholds, see MatchUsersWithThresholds
 func MatchUsers(canvasUsers []models.ImportUser, unlockEdUsers []models.User) MatchUsersResponse {
-	// Score every canvas×unlocked pair
+	return MatchUsersWithThresholds(canvasUsers, unlockEdUsers, models.DefaultMatchAutoConfirm, models.DefaultMatchAmbiguous)
+}
+
+// MatchUsersWithThresholds performs greedy 1:1 matching of any provider's users. All pairs are scored, sorted by
+// score descending, then assigned greedily — each provider user and each UnlockEd user can only appear in one match.
+// Matches scoring at least autoConfirm are auto-confirmed, ones below ambiguous are left unmatched.
+func MatchUsersWithThresholds(canvasUsers []models.ImportUser, unlockEdUsers []models.User, autoConfirm, ambiguous float64) MatchUsersResponse {
+	// Score every provider×unlocked pair
 	candidates := make([]matchCandidate, 0, len(canvasUsers)*len(unlockEdUsers))
-	for ci, cu := range canvasUsers {
-		canvasName := cu.NameFirst + " " + cu.NameLast
-		for ui, u := range unlockEdUsers {
-			score := NameSimilarity(canvasName, u.NameFirst+" "+u.NameLast)
-			candidates = append(candidates, matchCandidate{ci, ui, score})
+	for ci := range canvasUsers {
+		for ui := range unlockEdUsers {
+			score, signals := ScoreUserMatch(&canvasUsers[ci], &unlockEdUsers[ui], ambiguous)
+			candidates = append(candidates, matchCandidate{ci, ui, score, signals})
 		}
 	}
 	sort.SliceStable(candidates, func(i, j int) bool {
@@ -105,7 +191,7 @@ func MatchUsers(canvasUsers []models.ImportUser, unlockEdUsers []models.User) Ma
 		if assignedCanvas[c.canvasIdx] || assignedUnlock[c.unlockIdx] {
 			continue
 		}
-		if c.score < 0.50 {
+		if c.score < ambiguous {
 			break // remaining candidates all score below threshold
 		}
 		assignedCanvas[c.canvasIdx] = true
@@ -124,8 +210,8 @@ func MatchUsers(canvasUsers []models.ImportUser, unlockEdUsers []models.User) Ma
 			continue
 		}
 		c := bestForCanvas[ci]
-		mr := UserMatchResult{CanvasUser: cu, SuggestedUser: &unlockEdUsers[c.unlockIdx], Score: c.score}
-		if c.score >= 0.90 {
+		mr := UserMatchResult{CanvasUser: cu, SuggestedUser: &unlockEdUsers[c.unlockIdx], Score: c.score, Signals: c.signals}
+		if c.score >= autoConfirm {
 			result.AutoConfirmed = append(result.AutoConfirmed, mr)
 		} else {
 			result.Ambiguous = append(result.Ambiguous, mr)
@@ -152,11 +238,157 @@ func (srv *Server) handleMatchUsers(w http.ResponseWriter, r *http.Request, log
 	if err != nil {
 		return newDatabaseServiceError(err)
 	}
+	provider, err := srv.Db.GetProviderPlatformByID(int(service.ProviderPlatformID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
 
-	result := MatchUsers(canvasUsers, unlockEdUsers)
+	autoConfirm, ambiguous := provider.MatchThresholds()
+	result := MatchUsersWithThresholds(canvasUsers, unlockEdUsers, autoConfirm, ambiguous)
+	reviews := make([]models.UserMatchReview, 0, len(result.Ambiguous))
+	externalIDs := make([]string, 0, len(result.Ambiguous))
+	for _, match := range result.Ambiguous {
+		externalIDs = append(externalIDs, match.CanvasUser.ExternalUserID)
+		reviews = append(reviews, models.UserMatchReview{
+			ProviderPlatformID: provider.ID,
+			FacilityID:         facilityID,
+			ExternalUserID:     match.CanvasUser.ExternalUserID,
+			ImportUser:         match.CanvasUser,
+			SuggestedUserID:    &match.SuggestedUser.ID,
+			Score:              match.Score,
+			Signals:            match.Signals,
+		})
+	}
+	closed, err := srv.Db.ClosePendingUserMatchReviews(r.Context(), provider.ID, facilityID, externalIDs)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if err := srv.Db.QueueUserMatchReviews(r.Context(), reviews); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.add("queued_for_review", len(reviews))
+	log.add("closed_reviews", closed)
 	return writeJsonResponse(w, http.StatusOK, result)
 }
 
+/*
+* GET: /api/actions/provider-platforms/{id}/match-reviews?status=pending
+* The matches queued for review at the admin's facility, best scores first. Leaving out status lists every review.
+ */
+func (srv *Server) handleIndexUserMatchReviews(w http.ResponseWriter, r *http.Request, log sLog) error {
+	providerID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "provider platform ID")
+	}
+	status := models.UserMatchReviewStatus(r.URL.Query().Get("status"))
+	if !slices.Contains([]models.UserMatchReviewStatus{"", models.UserMatchPending, models.UserMatchConfirmed, models.UserMatchRejected}, status) {
+		return newBadRequestServiceError(fmt.Errorf("unknown status %q", status), "status must be pending, confirmed or rejected")
+	}
+	args := srv.getQueryContext(r)
+	reviews, err := srv.Db.GetUserMatchReviews(&args, uint(providerID), status)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	return writePaginatedResponse(w, http.StatusOK, reviews, args.IntoMeta())
+}
+
+/*
+* PUT: /api/actions/provider-platforms/{id}/match-reviews/{review_id}
+* Resolves a queued match: confirming maps the provider user to the suggested resident, or to user_id when the admin
+* picked someone else; rejecting closes the review without mapping anyone.
+ */
+func (srv *Server) handleResolveUserMatchReview(w http.ResponseWriter, r *http.Request, log sLog) error {
+	providerID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "provider platform ID")
+	}
+	reviewID, err := strconv.Atoi(r.PathValue("review_id"))
+	if err != nil {
+		return newInvalidIdServiceError(err, "match review ID")
+	}
+	var form struct {
+		Status models.UserMatchReviewStatus `json:"status"`
+		UserID *uint                        `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return newJSONReqBodyServiceError(err)
+	}
+	defer r.Body.Close()
+	if form.Status != models.UserMatchConfirmed && form.Status != models.UserMatchRejected {
+		return newBadRequestServiceError(fmt.Errorf("unknown status %q", form.Status), "status must be confirmed or rejected")
+	}
+	claims := r.Context().Value(ClaimsKey).(*Claims)
+	facilityID := srv.getQueryContext(r).FacilityID
+	review, err := srv.Db.GetUserMatchReview(r.Context(), uint(providerID), uint(reviewID))
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if review.FacilityID != facilityID {
+		return newForbiddenServiceError(errors.New("match review belongs to another facility"), "the match review belongs to another facility")
+	}
+	if review.Status != models.UserMatchPending {
+		return newBadRequestServiceError(errors.New("match review already resolved"), "the match has already been "+string(review.Status))
+	}
+	log.add("match_review_id", reviewID)
+
+	if form.Status == models.UserMatchRejected {
+		if err := srv.Db.ResolveUserMatchReview(r.Context(), review, models.UserMatchRejected, nil, &claims.UserID); err != nil {
+			return newDatabaseServiceError(err)
+		}
+		return writeJsonResponse(w, http.StatusOK, review)
+	}
+
+	userID := form.UserID
+	if userID == nil {
+		userID = review.SuggestedUserID
+	}
+	if userID == nil {
+		return newBadRequestServiceError(errors.New("no resident to map"), "user_id is required, the suggested resident no longer exists")
+	}
+	user, err := srv.Db.GetUserByID(*userID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if user.FacilityID != facilityID || user.Role != models.Student {
+		return newBadRequestServiceError(errors.New("user is not a resident at the facility"), "the resident must be at the review's facility")
+	}
+	if existing, _ := srv.Db.GetProviderUserMapping(int(user.ID), providerID); existing != nil {
+		return newBadRequestServiceError(errors.New("resident already mapped"), "the resident is already mapped to a user on this provider")
+	}
+	provider, err := srv.Db.GetProviderPlatformByID(providerID)
+	if err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if err := srv.mapProviderUser(provider, user, review.ImportUser, log); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	if err := srv.Db.ResolveUserMatchReview(r.Context(), review, models.UserMatchConfirmed, &user.ID, &claims.UserID); err != nil {
+		return newDatabaseServiceError(err)
+	}
+	log.info("match review confirmed")
+	return writeJsonResponse(w, http.StatusOK, review)
+}
+
+// mapProviderUser maps the provider user to the resident and registers the resident's login with the provider
+func (srv *Server) mapProviderUser(provider *models.ProviderPlatform, user *models.User, importUser models.ImportUser, log sLog) error {
+	mapping := models.ProviderUserMapping{
+		UserID:             user.ID,
+		ProviderPlatformID: provider.ID,
+		ExternalUsername:   importUser.ExternalUsername,
+		ExternalUserID:     importUser.ExternalUserID,
+	}
+	if err := srv.Db.CreateProviderUserMapping(&mapping); err != nil {
+		return err
+	}
+	srv.invalidateCanvasProgramCache(provider.ID, int(user.ID))
+	if provider.OidcID != 0 {
+		if err := srv.registerProviderLogin(provider, user); err != nil {
+			log.errorf("error registering provider login for user %d: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
 func (srv *Server) handleApplyMatches(w http.ResponseWriter, r *http.Request, log sLog) error {
 	var req ApplyMatchesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -188,21 +420,12 @@ func (srv *Server) handleApplyMatches(w http.ResponseWriter, r *http.Request, lo
 			applied++
 			continue
 		}
-		mapping := models.ProviderUserMapping{
-			UserID:             c.UnlockEdUserID,
-			ProviderPlatformID: service.ProviderPlatformID,
-			ExternalUsername:   c.CanvasUser.ExternalUsername,
-			ExternalUserID:     c.CanvasUser.ExternalUserID,
-		}
-		if err := srv.Db.CreateProviderUserMapping(&mapping); err != nil {
+		if err := srv.mapProviderUser(provider, user, c.CanvasUser, log); err != nil {
 			failed = append(failed, c.CanvasUser.Username)
 			continue
 		}
-		srv.invalidateCanvasProgramCache(service.ProviderPlatformID, int(c.UnlockEdUserID))
-		if provider.OidcID != 0 {
-			if err := srv.registerProviderLogin(provider, user); err != nil {
-				log.errorf("error registering provider login for user %d: %v", c.UnlockEdUserID, err)
-			}
+		if err := srv.Db.ConfirmPendingUserMatchReview(r.Context(), provider.ID, c.CanvasUser.ExternalUserID, user.ID, &claims.UserID); err != nil {
+			log.errorf("error closing the match review for %s: %v", c.CanvasUser.ExternalUserID, err)
 		}
 		applied++
 	}
@@ -236,21 +459,12 @@ func (srv *Server) handleApplyMatches(w http.ResponseWriter, r *http.Request, lo
 		if err := srv.HandleCreateUserKratos(newUser.Username, tempPw); err != nil {
 			log.errorf("error creating kratos user for %s: %v", cu.Username, err)
 		}
-		mapping := models.ProviderUserMapping{
-			UserID:             newUser.ID,
-			ProviderPlatformID: service.ProviderPlatformID,
-			ExternalUsername:   cu.ExternalUsername,
-			ExternalUserID:     cu.ExternalUserID,
-		}
-		if err := srv.Db.CreateProviderUserMapping(&mapping); err != nil {
+		if err := srv.mapProviderUser(provider, &newUser, cu, log); err != nil {
 			failed = append(failed, cu.Username)
 			continue
 		}
-		srv.invalidateCanvasProgramCache(service.ProviderPlatformID, int(newUser.ID))
-		if provider.OidcID != 0 {
-			if err := srv.registerProviderLogin(provider, &newUser); err != nil {
-				log.errorf("error registering provider login for %s: %v", cu.Username, err)
-			}
+		if err := srv.Db.ConfirmPendingUserMatchReview(r.Context(), provider.ID, cu.ExternalUserID, newUser.ID, &claims.UserID); err != nil {
+			log.errorf("error closing the match review for %s: %v", cu.ExternalUserID, err)
 		}
 		created++
 	}
@@ -27,11 +27,20 @@ const (
 	ClientGoodbye WsEventType = "client_goodbye"
 	VisitEvent    WsEventType = "visits"
 	BookmarkEvent WsEventType = "bookmarks"
+	// ReportJobEvent tells an admin that a report they queued has finished or failed
+	ReportJobEvent WsEventType = "report_jobs"
+	// ContentRequestEvent tells a resident that content they requested was added, or that the request was declined
+	ContentRequestEvent WsEventType = "content_requests"
+	// ReleaseDateEvent warns an admin that a resident at their facility is about to be released
+	ReleaseDateEvent WsEventType = "release_dates"
 )
 
 type MsgContent struct {
-	ActivityID int64  `json:"activity_id"`
-	Msg        string `json:"msg"`
+	ActivityID       int64  `json:"activity_id"`
+	Msg              string `json:"msg"`
+	ReportJobID      uint   `json:"report_job_id,omitempty"`
+	ContentRequestID uint   `json:"content_request_id,omitempty"`
+	ContentPath      string `json:"content_path,omitempty"`
 }
 
 type WsMsg struct {
@@ -0,0 +1,120 @@
+/*
+Package i18n holds the message catalog for text the server generates: report headers and titles, PDF labels, CSV
+error files and emails. Messages are looked up by their English text, so English needs no catalog and a message
+missing from a translation falls back to English rather than to a key.
+*/
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Locale string
+
+const (
+	English Locale = "en"
+	Spanish Locale = "es"
+)
+
+var Supported = []Locale{English, Spanish}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[Locale]map[string]string {
+	loaded := make(map[Locale]map[string]string, len(Supported))
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		logrus.Errorf("unable to read message catalogs: %v", err)
+		return loaded
+	}
+	for _, entry := range entries {
+		locale := Locale(strings.TrimSuffix(entry.Name(), ".json"))
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			logrus.Errorf("unable to read %s message catalog: %v", locale, err)
+			continue
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			logrus.Errorf("invalid %s message catalog: %v", locale, err)
+			continue
+		}
+		loaded[locale] = messages
+	}
+	return loaded
+}
+
+// Parse reads a language tag such as "es", "es-MX" or "es_US" into a supported locale
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if idx := strings.IndexAny(tag, "-_"); idx != -1 {
+		tag = tag[:idx]
+	}
+	locale := Locale(tag)
+	return locale, slices.Contains(Supported, locale)
+}
+
+// Default is the locale used for users without a preference and for text not addressed to a user, set by DEFAULT_LOCALE
+func Default() Locale {
+	if locale, ok := Parse(os.Getenv("DEFAULT_LOCALE")); ok {
+		return locale
+	}
+	return English
+}
+
+// OrDefault returns the locale, or the default locale when it is empty or unsupported
+func (l Locale) OrDefault() Locale {
+	if locale, ok := Parse(string(l)); ok {
+		return locale
+	}
+	return Default()
+}
+
+// T translates a message, returning it unchanged when the locale has no translation for it
+func (l Locale) T(msg string) string {
+	if translated, ok := catalogs[l][msg]; ok && translated != "" {
+		return translated
+	}
+	return msg
+}
+
+// Tf translates a format string before formatting it, so translations can reorder or reword around the arguments
+func (l Locale) Tf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// TAll translates each message
+func (l Locale) TAll(msgs []string) []string {
+	translated := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		translated = append(translated, l.T(msg))
+	}
+	return translated
+}
+
+// FormatDate formats the time with the locale's version of the layout, translating month and weekday names
+func (l Locale) FormatDate(t time.Time, layout string) string {
+	formatted := t.Format(l.T(layout))
+	if l == English {
+		return formatted
+	}
+	if strings.Contains(layout, "January") {
+		formatted = strings.Replace(formatted, t.Month().String(), l.T(t.Month().String()), 1)
+	}
+	if strings.Contains(layout, "Monday") {
+		formatted = strings.Replace(formatted, t.Weekday().String(), l.T(t.Weekday().String()), 1)
+	}
+	return formatted
+}
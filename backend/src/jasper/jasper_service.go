@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/evertonvps/go-jasper"
@@ -137,10 +138,7 @@ func (js *jasperService) generateUsageReportPDF(userID int) ([]byte, error) {
 		gj.Executable = "/opt/jasperstarter/bin/jasperstarter"
 	}
 
-	templateDir := os.Getenv("JASPER_TEMPLATE_DIR")
-	if templateDir == "" {
-		templateDir = "/templates"
-	}
+	templateDir := templatesDir()
 	compiledTemplatePath := filepath.Join(templateDir, "user_usage_report.jasper")
 	//TODO keep this here for now, we will figure out how to compile reports during build time
 	// template := "/app/backend/src/templates/user_usage_report.jrxml"
@@ -184,19 +182,66 @@ func GenerateUsageReportPDF(db *database.DB, queryCtx *models.QueryContext, hasP
 	return service.generateUsageReportPDF(userID)
 }
 
+// templatesDir is where the .jrxml sources and their compiled .jasper files are found
+func templatesDir() string {
+	if dir := os.Getenv("JASPER_TEMPLATE_DIR"); dir != "" {
+		return dir
+	}
+	return "/templates"
+}
+
+// TemplateExists reports whether a template of that name is in the templates directory, compiled or as .jrxml source
+func TemplateExists(templateName string) bool {
+	for _, ext := range []string{".jasper", ".jrxml"} {
+		if info, err := os.Stat(filepath.Join(templatesDir(), templateName+ext)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// templateCompileLocks holds a mutex per template, so reports that need the same template compile it only once at a time
+var templateCompileLocks sync.Map
+
+func lockTemplate(baseTemplateName string) func() {
+	mu, _ := templateCompileLocks.LoadOrStore(baseTemplateName, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// compileMissingTemplate compiles a template that has no .jasper file, unless a concurrent report compiled it first
+func compileMissingTemplate(templateDir, baseTemplateName string) error {
+	unlock := lockTemplate(baseTemplateName)
+	defer unlock()
+	if _, err := os.Stat(filepath.Join(templateDir, baseTemplateName+".jasper")); err == nil {
+		return nil
+	}
+	return compileTemplate(templateDir, baseTemplateName)
+}
+
 // recompileTemplate compiles a .jrxml file using JasperStarter
-// This is called when a .jasper file is missing or corrupt (e.g., Studio-compiled with UUID suffix)
+// This is called when a .jasper file is corrupt (e.g., Studio-compiled with UUID suffix)
 func recompileTemplate(templateDir, baseTemplateName string) error {
+	unlock := lockTemplate(baseTemplateName)
+	defer unlock()
+	return compileTemplate(templateDir, baseTemplateName)
+}
+
+// compileTemplate compiles into a temporary file that is renamed over the .jasper file, so a report running at the
+// same time never reads a half written template
+func compileTemplate(templateDir, baseTemplateName string) error {
 	jrxmlPath := filepath.Join(templateDir, baseTemplateName+".jrxml")
+	tempBase := filepath.Join(templateDir, fmt.Sprintf("%s_%s", baseTemplateName, uuid.New().String()))
 
 	logrus.WithFields(logrus.Fields{
 		"template": baseTemplateName,
 	}).Info("Recompiling template from .jrxml source with JasperStarter")
 
-	cmd := exec.Command("/opt/jasperstarter/bin/jasperstarter", "compile", jrxmlPath)
+	cmd := exec.Command("/opt/jasperstarter/bin/jasperstarter", "compile", jrxmlPath, "-o", tempBase)
 	cmd.Dir = templateDir
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		_ = os.Remove(tempBase + ".jasper")
 		logrus.WithFields(logrus.Fields{
 			"template": baseTemplateName,
 			"error":    err,
@@ -204,6 +249,10 @@ func recompileTemplate(templateDir, baseTemplateName string) error {
 		}).Error("Failed to recompile template")
 		return fmt.Errorf("failed to recompile template %s: %w", baseTemplateName, err)
 	}
+	if err := os.Rename(tempBase+".jasper", filepath.Join(templateDir, baseTemplateName+".jasper")); err != nil {
+		_ = os.Remove(tempBase + ".jasper")
+		return fmt.Errorf("failed to replace compiled template %s: %w", baseTemplateName, err)
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"template": baseTemplateName,
@@ -254,6 +303,7 @@ func GenerateReportPDF(config models.PDFConfig, filterSummary []models.PDFFilter
 	if title == "" {
 		title = "Report"
 	}
+	locale := config.Locale.OrDefault()
 
 	// Build individual filter label/value parameters for proper spacing in JRXML.
 	// Each filter line is passed as FilterLabelN and FilterValueN pairs.
@@ -261,12 +311,29 @@ func GenerateReportPDF(config models.PDFConfig, filterSummary []models.PDFFilter
 	maxFilters := 6
 
 	params := []jasper.Parameter{
-		{Key: "ReportTitle", Value: title},
-		{Key: "GeneratedDate", Value: time.Now().Format("January 2, 2006 at 3:04 PM")},
+		{Key: "GeneratedDate", Value: locale.FormatDate(time.Now(), "January 2, 2006 at 3:04 PM")},
 		{Key: "LogoImage", Value: base64.StdEncoding.EncodeToString(src.UnlockedLogoImg)},
 		{Key: "FilterCount", Value: fmt.Sprintf("%d", filterCount)},
 	}
 
+	// Report templates take their title, labels and column headers as parameters so they can be translated, other
+	// templates (e.g. certificates) lay out their own text around the title.
+	if len(config.Columns) > 0 {
+		params = append(params,
+			jasper.Parameter{Key: "ReportTitle", Value: locale.T(title + " Report")},
+			jasper.Parameter{Key: "GeneratedLabel", Value: locale.T("Generated: ")},
+			jasper.Parameter{Key: "NoRecordsLabel", Value: locale.T("No Records Found")},
+		)
+		for i, column := range config.Columns {
+			params = append(params, jasper.Parameter{Key: fmt.Sprintf("Column%d", i), Value: column})
+		}
+		for i, column := range config.SubColumns {
+			params = append(params, jasper.Parameter{Key: fmt.Sprintf("SubColumn%d", i), Value: column})
+		}
+	} else {
+		params = append(params, jasper.Parameter{Key: "ReportTitle", Value: title})
+	}
+
 	// Only pass SubRowCount when the report actually has a sub-table. JasperStarter
 	// errors on any parameter a template doesn't declare, and only templates with a
 	// sub-table (e.g. program_outcomes breakdown) declare SubRowCount.
@@ -323,6 +390,7 @@ func GenerateReportPDF(config models.PDFConfig, filterSummary []models.PDFFilter
 
 	gj := jasper.NewGoJasperJsonData(jsonFilePath, "", params, "pdf", outputFilePath)
 	gj.Output = outputFilePath
+	gj.Locale = string(locale)
 
 	if path, err := exec.LookPath("jasperstarter"); err == nil {
 		gj.Executable = path
@@ -331,13 +399,17 @@ func GenerateReportPDF(config models.PDFConfig, filterSummary []models.PDFFilter
 		gj.Executable = "/opt/jasperstarter/bin/jasperstarter"
 	}
 
-	templateDir := os.Getenv("JASPER_TEMPLATE_DIR")
-	if templateDir == "" {
-		templateDir = "/templates"
-	}
+	templateDir := templatesDir()
 	compiledTemplatePath := filepath.Join(templateDir, templateName+".jasper")
 	jrxmlTemplatePath := filepath.Join(templateDir, templateName+".jrxml")
 
+	// a template without a compiled .jasper file is compiled from its .jrxml source the first time it is used
+	if _, err := os.Stat(compiledTemplatePath); os.IsNotExist(err) {
+		if err := compileMissingTemplate(templateDir, templateName); err != nil {
+			return nil, err
+		}
+	}
+
 	pdfBytes, err := gj.Process(compiledTemplatePath)
 	if err != nil {
 		// Check if this is a Jaspersoft Studio compilation error (NoClassDefFoundError with UUID suffix)
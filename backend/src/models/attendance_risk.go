@@ -0,0 +1,170 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AttendanceRiskRule string
+
+const (
+	ConsecutiveAbsencesRisk AttendanceRiskRule = "consecutive_absences"
+	LowAttendanceRateRisk   AttendanceRiskRule = "low_attendance_rate"
+)
+
+type AttendanceRiskAlertStatus string
+
+const (
+	RiskAlertOpen         AttendanceRiskAlertStatus = "open"
+	RiskAlertAcknowledged AttendanceRiskAlertStatus = "acknowledged"
+	RiskAlertSnoozed      AttendanceRiskAlertStatus = "snoozed"
+	RiskAlertResolved     AttendanceRiskAlertStatus = "resolved"
+)
+
+// AttendanceRiskSettings are a facility's thresholds for the nightly attendance-risk evaluation.
+// A zero threshold turns its rule off.
+type AttendanceRiskSettings struct {
+	FacilityID          uint      `json:"facility_id" gorm:"primaryKey"`
+	Enabled             bool      `json:"enabled" gorm:"not null"`
+	ConsecutiveAbsences int       `json:"consecutive_absences" gorm:"not null"`
+	MinAttendanceRate   int       `json:"min_attendance_rate" gorm:"not null"`  // percent
+	RateWindowSessions  int       `json:"rate_window_sessions" gorm:"not null"` // most recent sessions the rate is measured over
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (AttendanceRiskSettings) TableName() string { return "attendance_risk_settings" }
+
+// DefaultAttendanceRiskSettings are used for facilities that have not saved their own thresholds
+func DefaultAttendanceRiskSettings(facilityID uint) AttendanceRiskSettings {
+	return AttendanceRiskSettings{
+		FacilityID:          facilityID,
+		Enabled:             true,
+		ConsecutiveAbsences: 3,
+		MinAttendanceRate:   70,
+		RateWindowSessions:  10,
+	}
+}
+
+func (settings *AttendanceRiskSettings) Validate() error {
+	switch {
+	case settings.ConsecutiveAbsences < 0:
+		return fmt.Errorf("consecutive_absences cannot be negative")
+	case settings.MinAttendanceRate < 0 || settings.MinAttendanceRate > 100:
+		return fmt.Errorf("min_attendance_rate must be between 0 and 100")
+	case settings.RateWindowSessions < 0:
+		return fmt.Errorf("rate_window_sessions cannot be negative")
+	case settings.MinAttendanceRate > 0 && settings.RateWindowSessions == 0:
+		return fmt.Errorf("rate_window_sessions is required when min_attendance_rate is set")
+	}
+	return nil
+}
+
+// AttendanceRiskFinding is one rule a resident's attendance in a class currently breaks
+type AttendanceRiskFinding struct {
+	Rule                AttendanceRiskRule
+	ConsecutiveAbsences int
+	AttendanceRate      int
+	SessionsConsidered  int
+	Detail              string
+}
+
+/*
+Evaluate checks a resident's attendance in one class against the thresholds. history holds the
+resident's recorded attendance statuses for the class, most recent session first. Excused and
+unexcused absences both count as absences; the rate rule waits for a full window of sessions.
+*/
+func (settings *AttendanceRiskSettings) Evaluate(history []Attendance) []AttendanceRiskFinding {
+	findings := make([]AttendanceRiskFinding, 0, 2)
+	if !settings.Enabled {
+		return findings
+	}
+	if settings.ConsecutiveAbsences > 0 {
+		streak := 0
+		for _, status := range history {
+			if status != Absent_Excused && status != Absent_Unexcused {
+				break
+			}
+			streak++
+		}
+		if streak >= settings.ConsecutiveAbsences {
+			findings = append(findings, AttendanceRiskFinding{
+				Rule:                ConsecutiveAbsencesRisk,
+				ConsecutiveAbsences: streak,
+				SessionsConsidered:  streak,
+				Detail:              fmt.Sprintf("absent from the last %d sessions", streak),
+			})
+		}
+	}
+	if settings.MinAttendanceRate > 0 && settings.RateWindowSessions > 0 && len(history) >= settings.RateWindowSessions {
+		attended := 0
+		for _, status := range history[:settings.RateWindowSessions] {
+			if status == Present || status == Partial {
+				attended++
+			}
+		}
+		rate := attended * 100 / settings.RateWindowSessions
+		if rate < settings.MinAttendanceRate {
+			findings = append(findings, AttendanceRiskFinding{
+				Rule:               LowAttendanceRateRisk,
+				AttendanceRate:     rate,
+				SessionsConsidered: settings.RateWindowSessions,
+				Detail:             fmt.Sprintf("attended %d%% of the last %d sessions (threshold %d%%)", rate, settings.RateWindowSessions, settings.MinAttendanceRate),
+			})
+		}
+	}
+	return findings
+}
+
+// AttendanceRiskAlert is raised by the nightly evaluation when a resident breaks one of their facility's attendance-risk rules
+// in a class. Only one unresolved alert exists per resident, class and rule; it is refreshed on each run and resolves itself
+// once the rule is no longer broken. A resolved alert is only raised again after attendance newer than it is taken.
+type AttendanceRiskAlert struct {
+	DatabaseFields
+	FacilityID          uint                      `json:"facility_id" gorm:"not null"`
+	UserID              uint                      `json:"user_id" gorm:"not null"`
+	ClassID             uint                      `json:"class_id" gorm:"not null"`
+	Rule                AttendanceRiskRule        `json:"rule" gorm:"size:32;not null"`
+	Status              AttendanceRiskAlertStatus `json:"status" gorm:"size:16;not null;default:open"`
+	Detail              string                    `json:"detail" gorm:"size:255"`
+	ConsecutiveAbsences int                       `json:"consecutive_absences"`
+	AttendanceRate      int                       `json:"attendance_rate"`
+	SessionsConsidered  int                       `json:"sessions_considered"`
+	LastSessionDate     string                    `json:"last_session_date" gorm:"size:10"` // most recent attendance date the alert was evaluated on
+	LastEvaluatedAt     time.Time                 `json:"last_evaluated_at"`
+	SnoozedUntil        *time.Time                `json:"snoozed_until"`
+	AcknowledgedAt      *time.Time                `json:"acknowledged_at"`
+	AcknowledgedByID    *uint                     `json:"acknowledged_by_id"`
+	ResolvedAt          *time.Time                `json:"resolved_at"`
+	ResolvedByID        *uint                     `json:"resolved_by_id"`
+	NoteID              *uint                     `json:"note_id"` // the latest UserNote written while handling the alert
+	NotesPath           string                    `json:"notes_path" gorm:"-"`
+
+	User  *User         `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Class *ProgramClass `json:"class,omitempty" gorm:"foreignKey:ClassID;references:ID"`
+	Note  *UserNote     `json:"note,omitempty" gorm:"foreignKey:NoteID;references:ID"`
+}
+
+func (AttendanceRiskAlert) TableName() string { return "attendance_risk_alerts" }
+
+// AfterFind links the alert to the resident's note timeline
+func (alert *AttendanceRiskAlert) AfterFind(tx *gorm.DB) error {
+	alert.NotesPath = fmt.Sprintf("/api/users/%d/notes", alert.UserID)
+	return nil
+}
+
+type AttendanceRiskAlertAction string
+
+const (
+	AcknowledgeRiskAlert AttendanceRiskAlertAction = "acknowledge"
+	SnoozeRiskAlert      AttendanceRiskAlertAction = "snooze"
+	ResolveRiskAlert     AttendanceRiskAlertAction = "resolve"
+)
+
+type AttendanceRiskAlertActionRequest struct {
+	Action       AttendanceRiskAlertAction `json:"action"`
+	Note         string                    `json:"note"`
+	SnoozedUntil string                    `json:"snoozed_until"` // YYYY-MM-DD, required to snooze
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type AttendanceSyncResult string
+
+const (
+	// AttendanceSyncApplied marks were written to attendance, or already matched what was recorded
+	AttendanceSyncApplied AttendanceSyncResult = "applied"
+	// AttendanceSyncConflict marks were not written because someone else recorded a different status for the session
+	AttendanceSyncConflict AttendanceSyncResult = "conflict"
+	// AttendanceSyncRejected marks can never be applied, e.g. for a cancelled session or a date in the future
+	AttendanceSyncRejected AttendanceSyncResult = "rejected"
+)
+
+// MaxAttendanceSyncBatch is the most marks a single sync request may carry
+const MaxAttendanceSyncBatch = 500
+
+var validAttendanceStatuses = []Attendance{Present, Partial, Absent_Excused, Absent_Unexcused}
+
+func (a Attendance) IsValid() bool {
+	return slices.Contains(validAttendanceStatuses, a)
+}
+
+/*
+AttendanceSyncMark is an attendance mark captured on a device that may have been offline. ClientID is generated by the
+device and identifies the mark across retries, CapturedAt is when the instructor took it.
+*/
+type AttendanceSyncMark struct {
+	ClientID         string     `json:"client_id"`
+	EventID          uint       `json:"event_id"`
+	UserID           uint       `json:"user_id"`
+	Date             string     `json:"date"`
+	AttendanceStatus Attendance `json:"attendance_status"`
+	Note             string     `json:"note"`
+	ReasonCategory   string     `json:"reason_category"`
+	CheckInAt        *string    `json:"check_in_at"`
+	CheckOutAt       *string    `json:"check_out_at"`
+	CapturedAt       time.Time  `json:"captured_at"`
+}
+
+func (mark *AttendanceSyncMark) Attendance() ProgramClassEventAttendance {
+	return ProgramClassEventAttendance{
+		EventID:          mark.EventID,
+		UserID:           mark.UserID,
+		Date:             mark.Date,
+		AttendanceStatus: mark.AttendanceStatus,
+		Note:             mark.Note,
+		ReasonCategory:   mark.ReasonCategory,
+		CheckInAt:        mark.CheckInAt,
+		CheckOutAt:       mark.CheckOutAt,
+	}
+}
+
+/*
+AttendanceSyncRecord is the outcome of syncing one offline mark, kept by the class, resident and mark's client ID so a
+device that resends a batch after a dropped response gets the same outcome back instead of the mark being applied twice.
+*/
+type AttendanceSyncRecord struct {
+	DatabaseFields
+	ClientID         string               `json:"client_id" gorm:"size:64;not null;uniqueIndex:idx_attendance_sync_records_mark,priority:3"`
+	ClassID          uint                 `json:"class_id" gorm:"not null;uniqueIndex:idx_attendance_sync_records_mark,priority:1"`
+	EventID          uint                 `json:"event_id" gorm:"not null"`
+	UserID           uint                 `json:"user_id" gorm:"not null;uniqueIndex:idx_attendance_sync_records_mark,priority:2"`
+	Date             string               `json:"date" gorm:"size:10;not null"`
+	AttendanceStatus Attendance           `json:"attendance_status" gorm:"size:32;not null"`
+	CapturedAt       time.Time            `json:"captured_at" gorm:"not null"`
+	SyncedByID       uint                 `json:"synced_by_id" gorm:"not null"`
+	Result           AttendanceSyncResult `json:"result" gorm:"size:20;not null"`
+	Message          string               `json:"message,omitempty" gorm:"type:text"`
+	// RecordedStatus is what attendance holds for the session after the sync, the other recorder's status on a conflict
+	RecordedStatus Attendance `json:"recorded_status,omitempty" gorm:"size:32"`
+	AttendanceID   *uint      `json:"attendance_id"`
+
+	// Replayed is set when the mark was synced by an earlier request and this is its stored outcome
+	Replayed bool `json:"replayed" gorm:"-"`
+}
+
+func (AttendanceSyncRecord) TableName() string { return "attendance_sync_records" }
+
+func NewAttendanceSyncRecord(classID uint, mark *AttendanceSyncMark, syncedByID uint) *AttendanceSyncRecord {
+	return &AttendanceSyncRecord{
+		ClientID:         mark.ClientID,
+		ClassID:          classID,
+		EventID:          mark.EventID,
+		UserID:           mark.UserID,
+		Date:             mark.Date,
+		AttendanceStatus: mark.AttendanceStatus,
+		CapturedAt:       mark.CapturedAt,
+		SyncedByID:       syncedByID,
+	}
+}
+
+func (rec *AttendanceSyncRecord) Reject(msg string) *AttendanceSyncRecord {
+	rec.Result, rec.Message = AttendanceSyncRejected, msg
+	return rec
+}
@@ -38,3 +38,48 @@ const (
 	OverrideForwards string = "forwards"
 	OverrideSelf     string = "self"
 )
+
+// CalendarImportMapping lets an admin point an uploaded VEVENT (by UID) at a class, room or instructor
+// the importer could not match on its own, or leave it out of the import
+type CalendarImportMapping struct {
+	ClassID      *uint `json:"class_id"`
+	RoomID       *uint `json:"room_id"`
+	InstructorID *uint `json:"instructor_id"`
+	Skip         bool  `json:"skip"`
+}
+
+// CalendarImportItem is one VEVENT from an uploaded .ics file and the class event it would become
+type CalendarImportItem struct {
+	UID            string         `json:"uid"`
+	Summary        string         `json:"summary"`
+	Location       string         `json:"location"`
+	Organizer      string         `json:"organizer"`
+	ClassID        *uint          `json:"class_id"`
+	ClassName      string         `json:"class_name"`
+	RoomID         *uint          `json:"room_id"`
+	RoomName       string         `json:"room_name"`
+	InstructorID   *uint          `json:"instructor_id"`
+	InstructorName string         `json:"instructor_name"`
+	RecurrenceRule string         `json:"recurrence_rule"`
+	Duration       string         `json:"duration"`
+	CancelledDates []string       `json:"cancelled_dates"`
+	Conflicts      []RoomConflict `json:"conflicts"`
+	Errors         []string       `json:"errors"`
+	Skipped        bool           `json:"skipped"`
+	EventID        *uint          `json:"event_id,omitempty"`
+
+	// CancelledAt holds the excluded occurrences themselves, CancelledDates is their display form
+	CancelledAt []time.Time `json:"-"`
+}
+
+func (item *CalendarImportItem) IsImportable() bool {
+	return !item.Skipped && len(item.Errors) == 0 && len(item.Conflicts) == 0
+}
+
+type CalendarImportResult struct {
+	DryRun   bool                 `json:"dry_run"`
+	Items    []CalendarImportItem `json:"items"`
+	Created  int                  `json:"created"`
+	Skipped  int                  `json:"skipped"`
+	Rejected int                  `json:"rejected"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+)
+
+type CalendarFeedScope string
+
+const (
+	FacilityCalendarFeed   CalendarFeedScope = "facility"
+	ClassCalendarFeed      CalendarFeedScope = "class"
+	InstructorCalendarFeed CalendarFeedScope = "instructor"
+	ResidentCalendarFeed   CalendarFeedScope = "resident"
+)
+
+func (s CalendarFeedScope) IsValid() bool {
+	switch s {
+	case FacilityCalendarFeed, ClassCalendarFeed, InstructorCalendarFeed, ResidentCalendarFeed:
+		return true
+	}
+	return false
+}
+
+var ErrInvalidCalendarFeedToken = errors.New("invalid calendar feed token")
+
+/*
+CalendarFeedToken grants read-only access to an iCalendar (.ics) feed without a session cookie, so a
+calendar client can subscribe to it. The token handed out is signed with the APP_KEY and carries the
+row ID and a random nonce; revoking the row (or rotating APP_KEY) invalidates every copy of the URL.
+*/
+type CalendarFeedToken struct {
+	DatabaseFields
+	UserID         uint              `json:"user_id" gorm:"not null"`
+	FacilityID     uint              `json:"facility_id" gorm:"not null"`
+	Scope          CalendarFeedScope `json:"scope" gorm:"size:32;not null" validate:"required,oneof=facility class instructor resident"`
+	ScopeID        uint              `json:"scope_id" gorm:"not null"`
+	Name           string            `json:"name" gorm:"size:255"`
+	Nonce          string            `json:"-" gorm:"size:64;not null"`
+	LastAccessedAt *time.Time        `json:"last_accessed_at"`
+	RevokedAt      *time.Time        `json:"revoked_at"`
+
+	User     *User     `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Facility *Facility `json:"facility,omitempty" gorm:"foreignKey:FacilityID;references:ID"`
+}
+
+func (CalendarFeedToken) TableName() string { return "calendar_feed_tokens" }
+
+func (t *CalendarFeedToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// NewCalendarFeedNonce returns the random value stored with a feed token and embedded in its signed form
+func NewCalendarFeedNonce() (string, error) {
+	return newTokenNonce()
+}
+
+// SignedToken returns the opaque token placed in the subscription URL
+func (t *CalendarFeedToken) SignedToken() string {
+	return signToken("calendar-feed", t.ID, t.Nonce)
+}
+
+/*
+ParseCalendarFeedToken checks the signature of a token from a subscription URL and returns the token ID
+and nonce it carries. The caller still has to load the row, compare the nonce and check for revocation.
+*/
+func ParseCalendarFeedToken(token string) (uint, string, error) {
+	id, nonce, ok := parseSignedToken("calendar-feed", token)
+	if !ok {
+		return 0, "", ErrInvalidCalendarFeedToken
+	}
+	return id, nonce, nil
+}
+
+// MatchesNonce compares the nonce from a parsed token against the stored one in constant time
+func (t *CalendarFeedToken) MatchesNonce(nonce string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.Nonce), []byte(nonce)) == 1
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type CanvasEnrollmentState string
+
+const (
+	// CanvasEnrollmentActive residents are enrolled in the class and should be active students in the Canvas course
+	CanvasEnrollmentActive CanvasEnrollmentState = "active"
+	// CanvasEnrollmentInactive residents have left the class and their Canvas enrollment should be deactivated
+	CanvasEnrollmentInactive CanvasEnrollmentState = "inactive"
+)
+
+type CanvasSyncStatus string
+
+const (
+	CanvasSyncSynced CanvasSyncStatus = "synced"
+	CanvasSyncFailed CanvasSyncStatus = "failed"
+)
+
+// MaxCanvasSyncAttempts is how many runs in a row a push can fail before it waits for an admin to retry it
+const MaxCanvasSyncAttempts = 5
+
+/*
+CanvasEnrollmentSync is what was last pushed to Canvas for one resident's enrollment in a class linked to a Canvas course.
+State is the Canvas enrollment state the resident should have, Status whether Canvas has it yet. A failed push keeps the
+error for the class page and is tried again on the next run, a synced one is left alone until the enrollment changes.
+*/
+type CanvasEnrollmentSync struct {
+	DatabaseFields
+	ClassID            uint                  `json:"class_id" gorm:"not null;uniqueIndex:idx_canvas_enrollment_syncs_class_user"`
+	UserID             uint                  `json:"user_id" gorm:"not null;uniqueIndex:idx_canvas_enrollment_syncs_class_user"`
+	ProviderPlatformID uint                  `json:"provider_platform_id" gorm:"not null"`
+	CanvasCourseID     string                `json:"canvas_course_id" gorm:"size:255;not null"`
+	CanvasUserID       string                `json:"canvas_user_id" gorm:"size:255"`
+	CanvasEnrollmentID string                `json:"canvas_enrollment_id" gorm:"size:255"`
+	State              CanvasEnrollmentState `json:"state" gorm:"size:20;not null"`
+	Status             CanvasSyncStatus      `json:"status" gorm:"size:20;not null"`
+	Attempts           int                   `json:"attempts" gorm:"not null;default:0"`
+	Error              string                `json:"error"`
+	SyncedAt           *time.Time            `json:"synced_at"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (CanvasEnrollmentSync) TableName() string { return "canvas_enrollment_syncs" }
+
+// CanvasEnrollmentStateFor is the Canvas enrollment state a resident with the class enrollment status should have
+func CanvasEnrollmentStateFor(status ProgramEnrollmentStatus) CanvasEnrollmentState {
+	if status == Enrolled {
+		return CanvasEnrollmentActive
+	}
+	return CanvasEnrollmentInactive
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const DefaultCertificateTemplate = "certificate_of_completion"
+
+// certificate template names are jrxml files in the templates directory
+var certificateTemplateName = regexp.MustCompile(`^certificate_[a-z0-9_]{1,60}$`)
+
+// verification codes leave out characters that are easily misread on paper (0/O, 1/I/L)
+const verificationAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+/*
+CertificateTemplate picks the jasper template and wording used for certificates of a program or of a credit type.
+A template for the program wins over one for any of the program's credit types; programs matching neither use
+DefaultCertificateTemplate.
+*/
+type CertificateTemplate struct {
+	DatabaseFields
+	ProgramID    *uint      `json:"program_id"`
+	CreditType   CreditType `json:"credit_type" gorm:"size:50"`
+	TemplateName string     `json:"template_name" gorm:"size:64;not null"`
+	Heading      string     `json:"heading" gorm:"size:255"`
+	Signatory    string     `json:"signatory" gorm:"size:255"`
+
+	Program *Program `json:"program,omitempty" gorm:"foreignKey:ProgramID;references:ID"`
+}
+
+func (CertificateTemplate) TableName() string { return "certificate_templates" }
+
+func (template *CertificateTemplate) Validate() error {
+	if (template.ProgramID == nil) == (template.CreditType == "") {
+		return fmt.Errorf("a template applies to either a program_id or a credit_type")
+	}
+	if !certificateTemplateName.MatchString(template.TemplateName) {
+		return fmt.Errorf("template_name must start with certificate_ and contain only lowercase letters, digits and underscores")
+	}
+	return nil
+}
+
+// CompletionCertificate is a certificate of completion issued for a ProgramCompletion. The printed details are copied from the
+// completion at issuance so a certificate always renders, and verifies, exactly as it was issued.
+type CompletionCertificate struct {
+	DatabaseFields
+	ProgramCompletionID uint       `json:"program_completion_id" gorm:"not null;uniqueIndex"`
+	UserID              uint       `json:"user_id" gorm:"not null"`
+	ProgramID           uint       `json:"program_id" gorm:"not null"`
+	ProgramClassID      uint       `json:"program_class_id" gorm:"not null"`
+	VerificationCode    string     `json:"verification_code" gorm:"size:14;not null;uniqueIndex"`
+	TemplateName        string     `json:"template_name" gorm:"size:64;not null"`
+	Heading             string     `json:"heading" gorm:"size:255"`
+	Signatory           string     `json:"signatory" gorm:"size:255"`
+	ResidentName        string     `json:"resident_name" gorm:"size:255;not null"`
+	ProgramName         string     `json:"program_name" gorm:"size:255;not null"`
+	ClassName           string     `json:"class_name" gorm:"size:255"`
+	FacilityName        string     `json:"facility_name" gorm:"size:255"`
+	CreditType          string     `json:"credit_type" gorm:"size:255"`
+	IssuedAt            time.Time  `json:"issued_at" gorm:"not null"`
+	RevokedAt           *time.Time `json:"revoked_at"`
+}
+
+func (CompletionCertificate) TableName() string { return "certificates" }
+
+func (certificate *CompletionCertificate) BeforeCreate(tx *gorm.DB) error {
+	if err := certificate.DatabaseFields.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if certificate.VerificationCode == "" {
+		code, err := NewVerificationCode()
+		if err != nil {
+			return err
+		}
+		certificate.VerificationCode = code
+	}
+	return nil
+}
+
+// NewVerificationCode returns a random code formatted as XXXX-XXXX-XXXX
+func NewVerificationCode() (string, error) {
+	var code strings.Builder
+	max := big.NewInt(int64(len(verificationAlphabet)))
+	for i := range 12 {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(verificationAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// NormalizeVerificationCode accepts a code as typed by a person: any case, with or without dashes or spaces
+func NormalizeVerificationCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 12 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12]
+}
+
+// ToPDF lays the certificate out as the single row the certificate templates read
+func (certificate *CompletionCertificate) ToPDF(verifyURL string) PDFConfig {
+	return PDFConfig{
+		Title: certificate.Heading,
+		Data: [][]string{{
+			certificate.ResidentName,
+			certificate.ProgramName,
+			certificate.ClassName,
+			certificate.FacilityName,
+			certificate.CreditType,
+			certificate.IssuedAt.Format("January 2, 2006"),
+			certificate.VerificationCode,
+			certificate.Signatory,
+			verifyURL,
+		}},
+	}
+}
+
+// CertificateVerification is all the public verification endpoint reveals about a certificate
+type CertificateVerification struct {
+	Valid        bool       `json:"valid"`
+	Revoked      bool       `json:"revoked"`
+	ResidentName string     `json:"resident_name,omitempty"`
+	ProgramName  string     `json:"program_name,omitempty"`
+	FacilityName string     `json:"facility_name,omitempty"`
+	IssuedAt     *time.Time `json:"issued_at,omitempty"`
+}
+
+// BulkCertificateResult reports a class-wide issuance
+type BulkCertificateResult struct {
+	Issued        []CompletionCertificate `json:"issued"`
+	AlreadyIssued int                     `json:"already_issued"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type ContentRequestStatus string
+
+const (
+	ContentRequestSubmitted   ContentRequestStatus = "submitted"
+	ContentRequestUnderReview ContentRequestStatus = "under_review"
+	ContentRequestApproved    ContentRequestStatus = "approved"
+	ContentRequestFulfilled   ContentRequestStatus = "fulfilled"
+	ContentRequestDeclined    ContentRequestStatus = "declined"
+)
+
+// OpenContentRequestStatuses are the statuses of requests that are still being worked on
+var OpenContentRequestStatuses = []ContentRequestStatus{ContentRequestSubmitted, ContentRequestUnderReview, ContentRequestApproved}
+
+// contentRequestTransitions lists where a request can go from each status, fulfilled and declined are final
+var contentRequestTransitions = map[ContentRequestStatus][]ContentRequestStatus{
+	ContentRequestSubmitted:   {ContentRequestUnderReview, ContentRequestApproved, ContentRequestDeclined},
+	ContentRequestUnderReview: {ContentRequestApproved, ContentRequestDeclined},
+	ContentRequestApproved:    {ContentRequestFulfilled, ContentRequestDeclined},
+}
+
+/*
+ContentRequest is a resident's request for a library or video that is not available yet. A request for the same
+content as another resident's open request is kept as a duplicate of it: admins review the first request, and every
+duplicate follows it to approval and fulfillment so each resident who asked is linked to the content and notified.
+*/
+type ContentRequest struct {
+	DatabaseFields
+	UserID                uint                 `json:"user_id" gorm:"not null"`
+	FacilityID            uint                 `json:"facility_id" gorm:"not null"`
+	Content               string               `json:"content" gorm:"type:text;not null"`
+	NormalizedContent     string               `json:"-" gorm:"type:text;not null"`
+	Status                ContentRequestStatus `json:"status" gorm:"size:20;not null"`
+	DuplicateOfID         *uint                `json:"duplicate_of_id"`
+	ReviewNote            string               `json:"review_note" gorm:"type:text"`
+	ReviewedByID          *uint                `json:"reviewed_by_id"`
+	ReviewedAt            *time.Time           `json:"reviewed_at"`
+	OpenContentProviderID *uint                `json:"open_content_provider_id"`
+	ContentID             *uint                `json:"content_id"`
+	ContentType           string               `json:"content_type,omitempty" gorm:"size:20"`
+	FulfilledAt           *time.Time           `json:"fulfilled_at"`
+
+	RequesterCount int64            `json:"requester_count,omitempty" gorm:"->;-:migration"`
+	User           *User            `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Facility       *Facility        `json:"facility,omitempty" gorm:"foreignKey:FacilityID;references:ID"`
+	Duplicates     []ContentRequest `json:"duplicates,omitempty" gorm:"foreignKey:DuplicateOfID"`
+}
+
+func (ContentRequest) TableName() string { return "content_requests" }
+
+func (req *ContentRequest) IsOpen() bool {
+	return slices.Contains(OpenContentRequestStatuses, req.Status)
+}
+
+func (req *ContentRequest) CanTransitionTo(status ContentRequestStatus) error {
+	if !slices.Contains(contentRequestTransitions[req.Status], status) {
+		return fmt.Errorf("a %s request cannot be %s", req.Status, status)
+	}
+	return nil
+}
+
+// ContentPath is where a resident opens the content that fulfilled the request
+func (req *ContentRequest) ContentPath() string {
+	if req.ContentID == nil {
+		return ""
+	}
+	return fmt.Sprintf("/viewer/%ss/%d", req.ContentType, *req.ContentID)
+}
+
+// NormalizeContentRequest reduces a request to lower case words so the same title asked for with different casing,
+// punctuation or spacing is recognized as a duplicate
+func NormalizeContentRequest(content string) string {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
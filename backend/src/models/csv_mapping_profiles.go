@@ -0,0 +1,199 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+CSVMappingProfile is a saved way of reading one facility's resident export in a bulk upload: which column holds each
+field and how its values are cleaned up. Profiles without a facility can be used at every facility.
+*/
+type CSVMappingProfile struct {
+	DatabaseFields
+	Name       string             `json:"name" gorm:"size:100;not null" validate:"required,max=100"`
+	FacilityID *uint              `json:"facility_id"`
+	Columns    []CSVColumnMapping `json:"columns" gorm:"serializer:json;type:jsonb;not null"`
+
+	Facility *Facility `json:"facility,omitempty" gorm:"foreignKey:FacilityID;constraint:OnDelete:CASCADE"`
+}
+
+func (CSVMappingProfile) TableName() string { return "csv_mapping_profiles" }
+
+// CSVColumnMapping reads one field from the column with the given header, Column is matched ignoring case and spacing
+type CSVColumnMapping struct {
+	Field      CSVField       `json:"field"`
+	Column     string         `json:"column"`
+	Transforms []CSVTransform `json:"transforms,omitempty"`
+	// DateFormat is one of CSVDateFormats, for date fields written some other way than YYYY-MM-DD or MM/DD/YYYY
+	DateFormat string `json:"date_format,omitempty"`
+}
+
+type CSVField string
+
+const (
+	CSVFieldLastName   CSVField = "last_name"
+	CSVFieldFirstName  CSVField = "first_name"
+	CSVFieldFullName   CSVField = "full_name"
+	CSVFieldResidentID CSVField = "resident_id"
+	CSVFieldUsername   CSVField = "username"
+	CSVFieldHousing    CSVField = "housing_unit"
+	CSVFieldRelease    CSVField = "release_date"
+	CSVFieldBirthDate  CSVField = "date_of_birth"
+	CSVFieldLanguage   CSVField = "preferred_language"
+)
+
+var csvFields = []CSVField{CSVFieldLastName, CSVFieldFirstName, CSVFieldFullName, CSVFieldResidentID, CSVFieldUsername,
+	CSVFieldHousing, CSVFieldRelease, CSVFieldBirthDate, CSVFieldLanguage}
+
+func (f CSVField) isDate() bool {
+	return f == CSVFieldRelease || f == CSVFieldBirthDate
+}
+
+type CSVTransform string
+
+const (
+	// CSVSplitLastFirst and CSVSplitFirstLast split a full_name written "Last, First" or "First Last"
+	CSVSplitLastFirst   CSVTransform = "split_last_first"
+	CSVSplitFirstLast   CSVTransform = "split_first_last"
+	CSVTrimLeadingZeros CSVTransform = "trim_leading_zeros"
+	CSVUppercase        CSVTransform = "uppercase"
+	CSVLowercase        CSVTransform = "lowercase"
+)
+
+var csvTransforms = []CSVTransform{CSVSplitLastFirst, CSVSplitFirstLast, CSVTrimLeadingZeros, CSVUppercase, CSVLowercase}
+
+// CSVDateFormats are the date formats a profile can read, keyed by how they're shown to admins
+var CSVDateFormats = map[string]string{
+	"YYYY-MM-DD":  "2006-01-02",
+	"MM/DD/YYYY":  "01/02/2006",
+	"M/D/YYYY":    "1/2/2006",
+	"DD/MM/YYYY":  "02/01/2006",
+	"MM-DD-YYYY":  "01-02-2006",
+	"YYYYMMDD":    "20060102",
+	"DD-MON-YYYY": "02-Jan-2006",
+	"MM/DD/YY":    "01/02/06",
+}
+
+// Validate checks the profile can be applied: every field known and mapped once, a resident ID and a name
+func (p *CSVMappingProfile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile name is required")
+	}
+	mapped := make(map[CSVField]bool, len(p.Columns))
+	for idx := range p.Columns {
+		column := &p.Columns[idx]
+		column.Column = strings.TrimSpace(column.Column)
+		if !slices.Contains(csvFields, column.Field) {
+			return fmt.Errorf("unknown field %q", column.Field)
+		}
+		if column.Column == "" {
+			return fmt.Errorf("%s has no column", column.Field)
+		}
+		if mapped[column.Field] {
+			return fmt.Errorf("%s is mapped more than once", column.Field)
+		}
+		mapped[column.Field] = true
+		splits := 0
+		for _, transform := range column.Transforms {
+			if !slices.Contains(csvTransforms, transform) {
+				return fmt.Errorf("unknown transform %q", transform)
+			}
+			if transform == CSVSplitLastFirst || transform == CSVSplitFirstLast {
+				splits++
+			}
+		}
+		if (column.Field == CSVFieldFullName && splits != 1) || (column.Field != CSVFieldFullName && splits > 0) {
+			return fmt.Errorf("%s must be split by exactly one of %s or %s, and no other field can be", CSVFieldFullName, CSVSplitLastFirst, CSVSplitFirstLast)
+		}
+		if column.DateFormat != "" {
+			if !column.Field.isDate() {
+				return fmt.Errorf("%s is not a date", column.Field)
+			}
+			if _, ok := CSVDateFormats[column.DateFormat]; !ok {
+				return fmt.Errorf("unknown date format %q", column.DateFormat)
+			}
+		}
+	}
+	if !mapped[CSVFieldResidentID] {
+		return errors.New("a column must be mapped to resident_id")
+	}
+	if mapped[CSVFieldFullName] {
+		if mapped[CSVFieldLastName] || mapped[CSVFieldFirstName] {
+			return errors.New("full_name can't be mapped along with last_name or first_name")
+		}
+	} else if !mapped[CSVFieldLastName] || !mapped[CSVFieldFirstName] {
+		return errors.New("columns must be mapped to last_name and first_name, or to full_name")
+	}
+	return nil
+}
+
+// Column returns the mapping for the field, nil when the profile doesn't read it
+func (p *CSVMappingProfile) Column(field CSVField) *CSVColumnMapping {
+	for idx := range p.Columns {
+		if p.Columns[idx].Field == field {
+			return &p.Columns[idx]
+		}
+	}
+	return nil
+}
+
+// Apply runs the column's transforms on a trimmed cell value, splitting names is left to SplitName
+func (c *CSVColumnMapping) Apply(value string) string {
+	value = strings.TrimSpace(value)
+	for _, transform := range c.Transforms {
+		switch transform {
+		case CSVTrimLeadingZeros:
+			if trimmed := strings.TrimLeft(value, "0"); trimmed != "" {
+				value = trimmed
+			} else if value != "" {
+				value = "0"
+			}
+		case CSVUppercase:
+			value = strings.ToUpper(value)
+		case CSVLowercase:
+			value = strings.ToLower(value)
+		}
+	}
+	return value
+}
+
+// ParseDate reads a date cell in the column's date format and returns it in ReleaseDateLayout
+func (c *CSVColumnMapping) ParseDate(value string) (string, error) {
+	if c.DateFormat == "" {
+		return ParseReleaseDate(value)
+	}
+	layout := CSVDateFormats[c.DateFormat]
+	date, err := time.Parse(layout, strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("date must be written as %s", c.DateFormat)
+	}
+	// two digit years are read as 1969 through 2068, a birth date that lands in the future is from the century before
+	if c.Field == CSVFieldBirthDate && !strings.Contains(layout, "2006") && date.After(time.Now()) {
+		date = date.AddDate(-100, 0, 0)
+	}
+	return date.Format(ReleaseDateLayout), nil
+}
+
+// SplitName splits a full_name cell into the last and first name the way the column says it's written
+func (c *CSVColumnMapping) SplitName(value string) (last, first string, ok bool) {
+	value = c.Apply(value)
+	if slices.Contains(c.Transforms, CSVSplitFirstLast) {
+		idx := strings.LastIndex(value, " ")
+		if idx == -1 {
+			return "", "", false
+		}
+		first, last = strings.TrimSpace(value[:idx]), strings.TrimSpace(value[idx+1:])
+	} else {
+		var found bool
+		last, first, found = strings.Cut(value, ",")
+		if !found {
+			return "", "", false
+		}
+		last, first = strings.TrimSpace(last), strings.TrimSpace(first)
+	}
+	return last, first, last != "" && first != ""
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type FacilityWithStats struct {
 	ID             uint      `json:"id"`
@@ -33,18 +36,90 @@ func (Facility) TableName() string {
 	return "facilities"
 }
 
+// Today is the date it currently is at the facility, which release dates are written in
+func (facility *Facility) Today(now time.Time) string {
+	location, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return now.In(location).Format(ReleaseDateLayout)
+}
+
 type Room struct {
 	DatabaseFields
 	FacilityID uint   `json:"facility_id" gorm:"not null"`
 	Name       string `json:"name" gorm:"size:255;not null" validate:"required,max=255"`
+	// Capacity is the number of seats, nil when it has not been recorded
+	Capacity *int64 `json:"capacity" validate:"omitempty,min=1"`
+	IsActive bool   `json:"is_active" gorm:"not null;default:true"`
 
-	Facility *Facility `json:"facility,omitempty" gorm:"foreignKey:FacilityID;references:ID"`
+	Facility   *Facility       `json:"facility,omitempty" gorm:"foreignKey:FacilityID;references:ID"`
+	Attributes []RoomAttribute `json:"attributes" gorm:"foreignKey:RoomID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
 func (Room) TableName() string {
 	return "rooms"
 }
 
+func (room *Room) HasAttributes(required []RoomAttributeType) bool {
+	for _, attr := range required {
+		if !slices.ContainsFunc(room.Attributes, func(ra RoomAttribute) bool { return ra.Attribute == attr }) {
+			return false
+		}
+	}
+	return true
+}
+
+type RoomAttributeType string
+
+const (
+	RoomComputerLab RoomAttributeType = "computer_lab"
+	RoomAccessible  RoomAttributeType = "accessible"
+	RoomProjector   RoomAttributeType = "projector"
+	RoomWhiteboard  RoomAttributeType = "whiteboard"
+	RoomKitchen     RoomAttributeType = "kitchen"
+	RoomWorkshop    RoomAttributeType = "workshop"
+)
+
+var AllRoomAttributes = []RoomAttributeType{
+	RoomComputerLab, RoomAccessible, RoomProjector, RoomWhiteboard, RoomKitchen, RoomWorkshop,
+}
+
+func (attr RoomAttributeType) IsValid() bool {
+	return slices.Contains(AllRoomAttributes, attr)
+}
+
+type RoomAttribute struct {
+	RoomID    uint              `json:"-" gorm:"primaryKey;not null"`
+	Attribute RoomAttributeType `json:"attribute" gorm:"primaryKey;size:50" validate:"required"`
+}
+
+func (RoomAttribute) TableName() string { return "room_attributes" }
+
+// RoomCapacityWarning is reported when a class is larger than the room it is scheduled in. It does not block scheduling.
+type RoomCapacityWarning struct {
+	RoomID        uint   `json:"room_id"`
+	RoomName      string `json:"room_name"`
+	RoomCapacity  int64  `json:"room_capacity"`
+	ClassCapacity int64  `json:"class_capacity"`
+	Enrolled      int64  `json:"enrolled"`
+	Message       string `json:"message"`
+}
+
+type ScheduleCheckResult struct {
+	Conflicts []RoomConflict        `json:"conflicts"`
+	Warnings  []RoomCapacityWarning `json:"warnings"`
+}
+
+type RoomFinderRequest struct {
+	FacilityID     uint
+	RecurrenceRule string
+	Duration       string
+	MinCapacity    int64
+	Attributes     []RoomAttributeType
+	ExcludeEventID *uint
+}
+
 type RoomBooking struct {
 	RoomID     uint
 	EventID    uint
@@ -65,8 +140,10 @@ type ConflictCheckRequest struct {
 }
 
 const (
-	ConflictTypeRoom       = "room"
-	ConflictTypeInstructor = "instructor"
+	ConflictTypeRoom                  = "room"
+	ConflictTypeInstructor            = "instructor"
+	ConflictTypeInstructorUnavailable = "instructor_unavailable"
+	ConflictTypeInstructorOverHours   = "instructor_over_hours"
 )
 
 type RoomConflict struct {
@@ -76,4 +153,6 @@ type RoomConflict struct {
 	StartTime          time.Time `json:"start_time"`
 	EndTime            time.Time `json:"end_time"`
 	ConflictType       string    `json:"conflict_type"`
+	// Message explains availability and workload conflicts, which are not caused by another class
+	Message string `json:"message,omitempty"`
 }
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstructorAvailability is a weekly window, in facility local time, during which an instructor can teach.
+// An instructor with no windows is treated as available at any time outside their blackouts.
+type InstructorAvailability struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	InstructorID uint   `json:"instructor_id" gorm:"not null;index"`
+	DayOfWeek    int    `json:"day_of_week" gorm:"not null" validate:"min=0,max=6"` // 0 is Sunday, as in time.Weekday
+	StartTime    string `json:"start_time" gorm:"size:5;not null" validate:"required,datetime=15:04"`
+	EndTime      string `json:"end_time" gorm:"size:5;not null" validate:"required,datetime=15:04"`
+}
+
+func (InstructorAvailability) TableName() string { return "instructor_availabilities" }
+
+// Covers reports whether the session from start to end (facility local time) fits inside the window
+func (window *InstructorAvailability) Covers(start, end time.Time) bool {
+	if int(start.Weekday()) != window.DayOfWeek {
+		return false
+	}
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	from, err := clockOnDay(dayStart, window.StartTime)
+	if err != nil {
+		return false
+	}
+	to, err := clockOnDay(dayStart, window.EndTime)
+	if err != nil {
+		return false
+	}
+	return !start.Before(from) && !end.After(to)
+}
+
+func clockOnDay(day time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location()), nil
+}
+
+// InstructorBlackout is a period, such as leave or training, during which an instructor cannot be scheduled
+type InstructorBlackout struct {
+	DatabaseFields
+	InstructorID uint      `json:"instructor_id" gorm:"not null;index"`
+	StartsAt     time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt       time.Time `json:"ends_at" gorm:"not null"`
+	Reason       string    `json:"reason" gorm:"size:255" validate:"max=255"`
+}
+
+func (InstructorBlackout) TableName() string { return "instructor_blackouts" }
+
+func (blackout *InstructorBlackout) Overlaps(start, end time.Time) bool {
+	return start.Before(blackout.EndsAt) && end.After(blackout.StartsAt)
+}
+
+// InstructorWorkloadLimit caps the hours an instructor may teach in a week (Monday to Sunday, facility local time).
+// Instructors without a row have no limit.
+type InstructorWorkloadLimit struct {
+	InstructorID   uint      `json:"instructor_id" gorm:"primaryKey"`
+	MaxWeeklyHours float64   `json:"max_weekly_hours" gorm:"not null" validate:"gt=0,lte=168"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (InstructorWorkloadLimit) TableName() string { return "instructor_workload_limits" }
+
+type InstructorAvailabilityProfile struct {
+	InstructorID   uint                     `json:"instructor_id"`
+	Windows        []InstructorAvailability `json:"windows"`
+	Blackouts      []InstructorBlackout     `json:"blackouts"`
+	MaxWeeklyHours *float64                 `json:"max_weekly_hours"`
+}
+
+func (profile *InstructorAvailabilityProfile) IsAvailable(start, end time.Time) (bool, string) {
+	for idx := range profile.Blackouts {
+		if profile.Blackouts[idx].Overlaps(start, end) {
+			reason := profile.Blackouts[idx].Reason
+			if reason == "" {
+				reason = "blackout"
+			}
+			return false, fmt.Sprintf("instructor is unavailable (%s)", reason)
+		}
+	}
+	if len(profile.Windows) == 0 {
+		return true, ""
+	}
+	for idx := range profile.Windows {
+		if profile.Windows[idx].Covers(start, end) {
+			return true, ""
+		}
+	}
+	return false, "session is outside the instructor's availability"
+}
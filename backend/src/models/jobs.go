@@ -45,6 +45,22 @@ func (cj *CronJob) BeforeCreate(tx *gorm.DB) error {
 		cj.Schedule = schedule
 	case string(ActivateScheduledClassesJob):
 		cj.Schedule = EveryMorningAt5AM
+	case string(EvaluateAttendanceRiskJob):
+		cj.Schedule = EveryNightAt2AM
+	case string(RunReportSubscriptionsJob), string(ReleaseLifecycleJob):
+		cj.Schedule = EveryHour
+	case string(RosterSyncJob):
+		schedule := os.Getenv("ROSTER_SYNC_CRON_SCHEDULE")
+		if schedule == "" {
+			schedule = EveryNightAt1AM
+		}
+		cj.Schedule = schedule
+	case string(CanvasEnrollmentSyncJob):
+		schedule := os.Getenv("CANVAS_ENROLLMENT_SYNC_CRON_SCHEDULE")
+		if schedule == "" {
+			schedule = EveryFifteenMinutes
+		}
+		cj.Schedule = schedule
 	default:
 		cj.Schedule = os.Getenv("MIDDLEWARE_CRON_SCHEDULE")
 	}
@@ -99,16 +115,25 @@ const (
 	SyncVideoMetadataJob        JobType   = "sync_video_metadata"
 	AddVideosJob                JobType   = "add_videos"
 	ActivateScheduledClassesJob JobType   = "activate_scheduled_classes"
+	EvaluateAttendanceRiskJob   JobType   = "evaluate_attendance_risk"
+	RunReportSubscriptionsJob   JobType   = "run_report_subscriptions"
+	ReleaseLifecycleJob         JobType   = "release_lifecycle"
+	RosterSyncJob               JobType   = "roster_sync"
+	CanvasEnrollmentSyncJob     JobType   = "canvas_enrollment_sync"
 	EveryDaytimeHour            string    = "0 6-20 * * *"
 	EverySundayAt8PM            string    = "0 20 * * 6"
 	EveryMorningAt5AM           string    = "0 5 * * *"
+	EveryNightAt1AM             string    = "0 1 * * *"
+	EveryNightAt2AM             string    = "0 2 * * *"
+	EveryHour                   string    = "0 * * * *"
+	EveryFifteenMinutes         string    = "*/15 * * * *"
 	StatusPending               JobStatus = "pending"
 	StatusRunning               JobStatus = "running"
 )
 
 var AllDefaultProviderJobs = []JobType{GetCoursesJob, GetMilestonesJob, GetActivityJob}
 var AllContentProviderJobs = []JobType{ScrapeKiwixJob, RetryVideoDownloadsJob, SyncVideoMetadataJob}
-var AllSystemJobs = []JobType{ActivateScheduledClassesJob}
+var AllSystemJobs = []JobType{ActivateScheduledClassesJob, EvaluateAttendanceRiskJob, RunReportSubscriptionsJob, ReleaseLifecycleJob, RosterSyncJob, CanvasEnrollmentSyncJob}
 
 func (jt JobType) IsVideoJob() bool {
 	switch jt {
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidKioskToken = errors.New("invalid kiosk session token")
+	ErrInvalidKioskPin   = errors.New("kiosk PIN must be 4 to 8 digits")
+)
+
+const (
+	// KioskSessionLifetime is how long a kiosk session accepts check-ins after it is opened, unless finalized sooner
+	KioskSessionLifetime = 12 * time.Hour
+	// MaxKioskPinAttempts is how many wrong PINs in a row lock a resident out of kiosk check-in until an admin sets a new PIN
+	MaxKioskPinAttempts = 5
+	// MaxKioskSessionFailedAttempts is how many incorrect DOC IDs or PINs a kiosk accepts within KioskAttemptWindow
+	// before it stops taking check-ins for the rest of the window, whichever residents they were for
+	MaxKioskSessionFailedAttempts = 10
+	KioskAttemptWindow            = 5 * time.Minute
+)
+
+var kioskPinPattern = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+/*
+KioskSession lets a shared device check residents in and out of one class session (an event on a date) without
+anyone signing in on it. The token handed to the device is signed with the APP_KEY and carries the row ID and a
+random nonce, so it is good for that event and date only, and stops working once the session expires or the
+instructor finalizes it.
+*/
+type KioskSession struct {
+	DatabaseFields
+	ClassID       uint       `json:"class_id" gorm:"not null"`
+	EventID       uint       `json:"event_id" gorm:"not null"`
+	FacilityID    uint       `json:"facility_id" gorm:"not null"`
+	Date          string     `json:"date" gorm:"size:10;not null"`
+	Nonce         string     `json:"-" gorm:"size:64;not null"`
+	OpenedByID    uint       `json:"opened_by_id" gorm:"not null"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	FinalizedAt   *time.Time `json:"finalized_at"`
+	FinalizedByID *uint      `json:"finalized_by_id"`
+	// FailedAttempts counts the incorrect DOC IDs and PINs entered on the device since FailedAttemptsSince
+	FailedAttempts      int        `json:"-" gorm:"not null;default:0"`
+	FailedAttemptsSince *time.Time `json:"-"`
+
+	Class *ProgramClass      `json:"class,omitempty" gorm:"foreignKey:ClassID;references:ID"`
+	Event *ProgramClassEvent `json:"event,omitempty" gorm:"foreignKey:EventID;references:ID"`
+}
+
+func (KioskSession) TableName() string { return "kiosk_sessions" }
+
+func NewKioskSession(class *ProgramClass, eventID uint, date string, openedByID uint) (*KioskSession, error) {
+	nonce, err := newTokenNonce()
+	if err != nil {
+		return nil, err
+	}
+	return &KioskSession{
+		ClassID:    class.ID,
+		EventID:    eventID,
+		FacilityID: class.FacilityID,
+		Date:       date,
+		Nonce:      nonce,
+		OpenedByID: openedByID,
+		ExpiresAt:  time.Now().Add(KioskSessionLifetime),
+	}, nil
+}
+
+// IsOpen reports whether the device may still check residents in and out
+func (s *KioskSession) IsOpen() bool {
+	return s.FinalizedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// IsRateLimited reports whether the device entered too many incorrect DOC IDs or PINs in the current window
+func (s *KioskSession) IsRateLimited(now time.Time) bool {
+	return s.FailedAttemptsSince != nil && now.Before(s.FailedAttemptsSince.Add(KioskAttemptWindow)) &&
+		s.FailedAttempts >= MaxKioskSessionFailedAttempts
+}
+
+// SignedToken returns the opaque token the kiosk device sends with each check-in
+func (s *KioskSession) SignedToken() string {
+	return signToken("kiosk-session", s.ID, s.Nonce)
+}
+
+// MatchesNonce compares the nonce from a parsed token against the stored one in constant time
+func (s *KioskSession) MatchesNonce(nonce string) bool {
+	return subtle.ConstantTimeCompare([]byte(s.Nonce), []byte(nonce)) == 1
+}
+
+// ParseKioskSessionToken checks the signature of a kiosk token and returns the session ID and nonce it carries
+func ParseKioskSessionToken(token string) (uint, string, error) {
+	id, nonce, ok := parseSignedToken("kiosk-session", token)
+	if !ok {
+		return 0, "", ErrInvalidKioskToken
+	}
+	return id, nonce, nil
+}
+
+// SetKioskPin stores a hash of the resident's kiosk PIN and clears any lockout from earlier wrong PINs
+func (user *User) SetKioskPin(pin string) error {
+	if !kioskPinPattern.MatchString(pin) {
+		return ErrInvalidKioskPin
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.KioskPinHash = string(hash)
+	user.KioskPinFailedAttempts = 0
+	return nil
+}
+
+func (user *User) CheckKioskPin(pin string) bool {
+	return user.KioskPinHash != "" && bcrypt.CompareHashAndPassword([]byte(user.KioskPinHash), []byte(pin)) == nil
+}
+
+func (user *User) IsKioskPinLocked() bool {
+	return user.KioskPinFailedAttempts >= MaxKioskPinAttempts
+}
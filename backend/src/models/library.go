@@ -125,6 +125,22 @@ type OpenContentSearchResult struct {
 	StartIndex   string             `json:"start_index"`
 	ItemsPerPage string             `json:"items_per_page"`
 	Items        []SearchResultItem `json:"items"`
+	Languages    []LanguageFacet    `json:"languages"`
+}
+
+// LibraryLanguage counts the libraries a facility can see in a language and how often its residents open them
+type LibraryLanguage struct {
+	Language  string `json:"language"`
+	Libraries int    `json:"libraries"`
+	Activity  int64  `json:"activity"`
+}
+
+// LanguageFacet is a library language a search could cover, Results is only set for the languages it did cover
+type LanguageFacet struct {
+	Language  string `json:"language"`
+	Libraries int    `json:"libraries"`
+	Results   *int64 `json:"results,omitempty"`
+	Selected  bool   `json:"selected"`
 }
 
 type SearchResultItem struct {
@@ -165,12 +181,15 @@ func (rss *RSS) SerializeSearchResults(libraries []Library) *OpenContentSearchRe
 	}
 	for _, item := range rss.Channel.Items {
 		library := getLibrary(libraries, item.Link)
-		thumbnail := ""
+		thumbnail, language := "", ""
 		if library == nil {
 			continue
 		} else if library.ThumbnailUrl != nil {
 			thumbnail = *library.ThumbnailUrl
 		}
+		if library.Language != nil {
+			language = *library.Language
+		}
 		resultItem := SearchResultItem{
 			OpenContentItem: OpenContentItem{
 				ContentId:    library.ID,
@@ -179,6 +198,7 @@ func (rss *RSS) SerializeSearchResults(libraries []Library) *OpenContentSearchRe
 				Description:  item.Description.RawText,
 				Title:        item.Book.Title,
 				ContentType:  "library",
+				Language:     language,
 			},
 			PageTitle: item.Title,
 		}
@@ -102,6 +102,7 @@ type OpenContentItem struct {
 	ContentType           string `json:"content_type"`
 	ProviderName          string `json:"provider_name,omitempty"`
 	ChannelTitle          string `json:"channel_title,omitempty"`
+	Language              string `json:"language,omitempty"`
 }
 
 const (
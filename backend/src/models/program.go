@@ -256,3 +256,54 @@ func (p *Program) GetUniqueCreditTypeString() string {
 	}
 	return strings.Join(keys, ",")
 }
+
+/*
+ProgramPrerequisite is an edge in the prerequisite graph between programs: a resident must
+have a ProgramCompletion for PrerequisiteProgramID before enrolling in a class of ProgramID
+*/
+type ProgramPrerequisite struct {
+	ProgramID             uint      `json:"program_id" gorm:"primaryKey;not null"`
+	PrerequisiteProgramID uint      `json:"prerequisite_program_id" gorm:"primaryKey;not null"`
+	CreatedAt             time.Time `json:"created_at"`
+	CreateUserID          *uint     `json:"create_user_id"`
+
+	PrerequisiteProgram *Program `json:"prerequisite_program,omitempty" gorm:"foreignKey:PrerequisiteProgramID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (ProgramPrerequisite) TableName() string { return "program_prerequisites" }
+
+type MissingPrerequisite struct {
+	ProgramID   uint   `json:"program_id"`
+	ProgramName string `json:"program_name"`
+}
+
+// EnrollmentIneligibility lists the prerequisite programs a resident has not completed for a class
+type EnrollmentIneligibility struct {
+	UserID               uint                  `json:"user_id"`
+	UserName             string                `json:"user_name"`
+	MissingPrerequisites []MissingPrerequisite `json:"missing_prerequisites"`
+}
+
+/*
+ProgramEligibilityOverride records an admin's decision to enroll a resident in a class
+despite missing prerequisites, along with the reason given
+*/
+type ProgramEligibilityOverride struct {
+	DatabaseFields
+	UserID    uint   `json:"user_id" gorm:"not null"`
+	ClassID   uint   `json:"class_id" gorm:"not null"`
+	ProgramID uint   `json:"program_id" gorm:"not null"`
+	Reason    string `json:"reason" gorm:"not null" validate:"required,max=255"`
+
+	User  *User         `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Class *ProgramClass `json:"class,omitempty" gorm:"foreignKey:ClassID;references:ID"`
+}
+
+func (ProgramEligibilityOverride) TableName() string { return "program_eligibility_overrides" }
+
+func (pp *ProgramPrerequisite) BeforeCreate(tx *gorm.DB) error {
+	if userID, ok := tx.Statement.Context.Value(UserIDKey).(uint); ok {
+		pp.CreateUserID = &userID
+	}
+	return nil
+}
@@ -41,6 +41,11 @@ type ProgramClass struct {
 	Completed        int64       `json:"completed" gorm:"-"`
 	IsCanvas         bool        `json:"is_canvas" gorm:"-"`
 	CanvasTimezone   string      `json:"canvas_timezone,omitempty" gorm:"-"`
+	// RoomCapacityWarnings is set when the class is saved into a room too small for it
+	RoomCapacityWarnings []RoomCapacityWarning `json:"room_capacity_warnings,omitempty" gorm:"-"`
+	// CanvasProviderID and CanvasCourseID link the class to a Canvas course its enrollments are pushed to
+	CanvasProviderID *uint   `json:"canvas_provider_id,omitempty"`
+	CanvasCourseID   *string `json:"canvas_course_id,omitempty" gorm:"size:255"`
 
 	Program      *Program                 `json:"program" gorm:"foreignKey:ProgramID;references:ID"`
 	Enrollments  []ProgramClassEnrollment `json:"enrollments" gorm:"foreignKey:ClassID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -133,12 +138,14 @@ meaning they will need to attend the ClassEvents for that class: tracked by Clas
 */
 type ProgramClassEnrollment struct {
 	DatabaseFields
-	ClassID           uint                    `json:"class_id" gorm:"not null"`
-	UserID            uint                    `json:"user_id" gorm:"not null"`
+	// a resident has at most one enrolled or waitlisted enrollment in a class
+	ClassID           uint                    `json:"class_id" gorm:"not null;uniqueIndex:idx_program_class_enrollments_active_class_user,where:(enrollment_status = 'Enrolled' OR enrollment_status = 'Waitlisted') AND deleted_at IS NULL"`
+	UserID            uint                    `json:"user_id" gorm:"not null;uniqueIndex:idx_program_class_enrollments_active_class_user"`
 	EnrollmentStatus  ProgramEnrollmentStatus `json:"enrollment_status" gorm:"size:255" validate:"max=255"`
 	ChangeReason      string                  `json:"change_reason" gorm:"size:255" validate:"max=255"`
 	EnrolledAt        *time.Time              `json:"enrolled_at"`
 	EnrollmentEndedAt *time.Time              `json:"enrollment_ended_at"`
+	WaitlistPosition  *int                    `json:"waitlist_position"`
 
 	User  *User         `json:"user" gorm:"foreignKey:UserID;references:ID"`
 	Class *ProgramClass `json:"class" gorm:"foreignKey:ClassID;references:ID"`
@@ -217,7 +224,7 @@ func (e *ProgramClassEnrollment) BeforeUpdate(tx *gorm.DB) (err error) {
 		}
 	}
 
-	// This likely gets hit when we introduce "Waitlist" as a status
+	// Hit when a waitlisted resident is promoted into an Active class
 	if newEnrollmentStatus == Enrolled && classStatus == Active && !tx.Statement.Changed("enrolled_at") {
 		tx.Statement.SetColumn("enrolled_at", time.Now().UTC())
 		// ? do we need to worry about updating fields to the same value (enrolled -> enrolled)?
@@ -262,8 +269,16 @@ const (
 	EnrollmentIncompleteFailedToComplete ProgramEnrollmentStatus = "Incomplete: Failed to Complete"
 	EnrollmentIncompleteTransfered       ProgramEnrollmentStatus = "Incomplete: Transfered"
 	EnrollmentIncompleteSegregated       ProgramEnrollmentStatus = "Incomplete: Segregated"
+	EnrollmentIncompleteReleased         ProgramEnrollmentStatus = "Incomplete: Released"
+	EnrollmentWaitlisted                 ProgramEnrollmentStatus = "Waitlisted"
 )
 
+// FreesClassSeat reports whether moving an enrollment into this status opens a seat
+// that should be offered to the next resident on the class waitlist.
+func FreesClassSeat(s ProgramEnrollmentStatus) bool {
+	return s == EnrollmentCancelled || strings.HasPrefix(string(s), "Incomplete:")
+}
+
 type ProgramCompletion struct {
 	DatabaseFields
 	UserID              uint      `json:"user_id" gorm:"not null"`
@@ -25,6 +25,7 @@ const (
 	CanvasCloud ProviderPlatformType = "canvas_cloud"
 	Kolibri     ProviderPlatformType = "kolibri"
 	Brightspace ProviderPlatformType = "brightspace"
+	Moodle      ProviderPlatformType = "moodle"
 )
 
 type ProviderPlatformState string
@@ -53,6 +54,10 @@ type ProviderPlatform struct {
 	BaseUrl                string                `gorm:"size:255"                    json:"base_url"`
 	State                  ProviderPlatformState `gorm:"size:100"                    json:"state"`
 	ExternalAuthProviderId string                `gorm:"size:128"                    json:"external_auth_provider_id"`
+	// MatchAutoConfirm and MatchAmbiguous are the user matcher's thresholds for this provider: matches scoring at least
+	// MatchAutoConfirm are confirmed on their own, ones scoring at least MatchAmbiguous are queued for review
+	MatchAutoConfirm float64 `gorm:"not null;default:0.9" json:"match_auto_confirm"`
+	MatchAmbiguous   float64 `gorm:"not null;default:0.5" json:"match_ambiguous"`
 	/* this field needs to be fetched by joining oidc_clients when querying the provider_platforms */
 	OidcID uint `gorm:"-" json:"oidc_id"`
 
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ReleaseDateLayout = "2006-01-02"
+	// defaultReleaseWarningDays is how far ahead of a release date admins are warned when RELEASE_WARNING_DAYS is unset
+	defaultReleaseWarningDays = 14
+	// ReleaseChangeReason is the change reason on enrollments the release lifecycle job withdraws
+	ReleaseChangeReason = "Released"
+)
+
+// release dates from DOC exports are often written month first, both layouts are stored as ReleaseDateLayout
+var releaseDateInputLayouts = []string{ReleaseDateLayout, "1/2/2006", "01/02/2006"}
+
+// ReleaseWarningDays is how many days ahead of a resident's release date admins are warned
+func ReleaseWarningDays() int {
+	days, err := strconv.Atoi(os.Getenv("RELEASE_WARNING_DAYS"))
+	if err != nil || days < 0 {
+		return defaultReleaseWarningDays
+	}
+	return days
+}
+
+// ParseReleaseDate accepts a release date as written in a roster export and returns it in ReleaseDateLayout
+func ParseReleaseDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range releaseDateInputLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(ReleaseDateLayout), nil
+		}
+	}
+	return "", errors.New("release date must be written as YYYY-MM-DD or MM/DD/YYYY")
+}
+
+// UpcomingRelease is a resident whose release date falls within the warning window
+type UpcomingRelease struct {
+	UserID      uint   `json:"user_id"`
+	NameFirst   string `json:"name_first"`
+	NameLast    string `json:"name_last"`
+	DocID       string `json:"doc_id"`
+	ReleaseDate string `json:"release_date"`
+	DaysUntil   int    `json:"days_until"`
+	// OpenEnrollments counts the classes the resident will be withdrawn from on their release date
+	OpenEnrollments int `json:"open_enrollments"`
+}
+
+// DaysUntilRelease counts the whole days from today to the release date, both in the facility's time zone
+func DaysUntilRelease(releaseDate, today string) int {
+	release, err := time.Parse(ReleaseDateLayout, releaseDate)
+	if err != nil {
+		return 0
+	}
+	now, err := time.Parse(ReleaseDateLayout, today)
+	if err != nil {
+		return 0
+	}
+	return int(release.Sub(now).Hours() / 24)
+}
@@ -0,0 +1,350 @@
+package models
+
+import (
+	"UnlockEdv2/src/i18n"
+	"archive/zip"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ReleasePacket records a re-entry release packet: a signed ZIP of everything the resident learned while with us, kept
+in the artifact store so an admin can hand it to the resident or their re-entry case manager after release.
+*/
+type ReleasePacket struct {
+	DatabaseFields
+	UserID        uint   `json:"user_id" gorm:"not null;index"`
+	FacilityID    uint   `json:"facility_id" gorm:"not null"`
+	GeneratedByID *uint  `json:"generated_by_id"`
+	Filename      string `json:"filename" gorm:"size:255;not null"`
+	ArtifactKey   string `json:"-" gorm:"size:255;not null"`
+	SizeBytes     int64  `json:"size_bytes"`
+	// Checksum is the hex SHA-256 of the ZIP, so a copy handed off can be matched against the one we produced
+	Checksum string `json:"checksum" gorm:"size:64;not null"`
+
+	GeneratedBy *User `json:"generated_by,omitempty" gorm:"foreignKey:GeneratedByID;constraint:OnDelete:SET NULL"`
+}
+
+func (ReleasePacket) TableName() string { return "release_packets" }
+
+const (
+	releasePacketSignaturePurpose = "release_packet"
+	ReleasePacketManifestFile     = "manifest.json"
+	ReleasePacketSignatureFile    = "manifest.sig"
+)
+
+// ReleasePacketCompletion is a program completion with the credit hours its class carried
+type ReleasePacketCompletion struct {
+	ProgramName     string    `json:"program_name"`
+	ClassName       string    `json:"class_name"`
+	FacilityName    string    `json:"facility_name"`
+	CreditType      string    `json:"credit_type"`
+	CreditHours     *int64    `json:"credit_hours"`
+	EnrolledOn      time.Time `json:"enrolled_on"`
+	CompletedAt     time.Time `json:"completed_at"`
+	CertificateCode *string   `json:"certificate_code"`
+}
+
+// ReleasePacketFavorite is a library or video the resident saved
+type ReleasePacketFavorite struct {
+	ContentType  string    `json:"content_type"`
+	Title        string    `json:"title"`
+	Url          string    `json:"url"`
+	ProviderName string    `json:"provider_name"`
+	FavoritedAt  time.Time `json:"favorited_at"`
+}
+
+// ReleasePacketCourse is the resident's progress in a course on a connected provider platform
+type ReleasePacketCourse struct {
+	ProviderName        string     `json:"provider_name"`
+	CourseName          string     `json:"course_name"`
+	EnrolledAt          *time.Time `json:"enrolled_at"`
+	MilestonesCompleted int64      `json:"milestones_completed"`
+	TotalMilestones     int64      `json:"total_milestones"`
+	Outcomes            string     `json:"outcomes"`
+}
+
+type ReleasePacketResident struct {
+	UserID        uint       `json:"user_id"`
+	Name          string     `json:"name"`
+	DocID         string     `json:"doc_id"`
+	FacilityName  string     `json:"facility_name"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+}
+
+// ReleasePacketData is everything that goes into a resident's release packet
+type ReleasePacketData struct {
+	Resident        ReleasePacketResident
+	Completions     []ReleasePacketCompletion
+	Classes         []TranscriptClass
+	LearningRecords []LearningRecordEntry
+	Favorites       []ReleasePacketFavorite
+	CourseProgress  []ReleasePacketCourse
+	GeneratedAt     time.Time
+	Locale          i18n.Locale
+}
+
+// ReleasePacketManifest lists every file in the packet with its digest, it is the part of the packet that is signed
+type ReleasePacketManifest struct {
+	UserID      uint                    `json:"user_id"`
+	DocID       string                  `json:"doc_id"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Files       []ReleasePacketFileHash `json:"files"`
+}
+
+// ReleasePacketVerification is all the public verification endpoint reveals about a packet
+type ReleasePacketVerification struct {
+	Valid       bool       `json:"valid"`
+	Reason      string     `json:"reason,omitempty"`
+	DocID       string     `json:"doc_id,omitempty"`
+	GeneratedAt *time.Time `json:"generated_at,omitempty"`
+}
+
+type ReleasePacketFileHash struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+func NewReleasePacketData(user *User, locale i18n.Locale) *ReleasePacketData {
+	data := &ReleasePacketData{
+		Resident: ReleasePacketResident{
+			UserID:        user.ID,
+			Name:          formatResidentName(user.NameLast, user.NameFirst),
+			DocID:         user.DocID,
+			DeactivatedAt: user.DeactivatedAt,
+		},
+		GeneratedAt: time.Now(),
+		Locale:      locale,
+	}
+	if user.Facility != nil {
+		data.Resident.FacilityName = user.Facility.Name
+	}
+	return data
+}
+
+// Filename is the name the packet is downloaded as
+func (data *ReleasePacketData) Filename() string {
+	name := data.Resident.DocID
+	if name == "" {
+		name = strconv.FormatUint(uint64(data.Resident.UserID), 10)
+	}
+	return fmt.Sprintf("release-packet-%s-%s.zip", name, data.GeneratedAt.Format("2006-01-02"))
+}
+
+func (data *ReleasePacketData) completionsCSV() [][]string {
+	locale := data.Locale
+	rows := [][]string{locale.TAll([]string{
+		"Program Name", "Class Name", "Facility", "Credit Type", "Credit Hours", "Enrolled Date", "Completed Date", "Certificate Code",
+	})}
+	for _, completion := range data.Completions {
+		creditHours, code := "", ""
+		if completion.CreditHours != nil {
+			creditHours = strconv.FormatInt(*completion.CreditHours, 10)
+		}
+		if completion.CertificateCode != nil {
+			code = *completion.CertificateCode
+		}
+		rows = append(rows, []string{
+			completion.ProgramName,
+			completion.ClassName,
+			completion.FacilityName,
+			completion.CreditType,
+			creditHours,
+			formatReportDate(&completion.EnrolledOn),
+			formatReportDate(&completion.CompletedAt),
+			code,
+		})
+	}
+	return rows
+}
+
+func (data *ReleasePacketData) enrollmentsCSV() [][]string {
+	transcript := &TranscriptData{Locale: data.Locale}
+	rows := [][]string{data.Locale.TAll(slices.Concat(transcriptClassHeaders, []string{"Sessions Attended", "Total Sessions"}))}
+	for _, class := range data.Classes {
+		rows = append(rows, append(transcript.classValues(class),
+			strconv.Itoa(class.SessionsAttended), strconv.Itoa(class.TotalSessions)))
+	}
+	return rows
+}
+
+func (data *ReleasePacketData) favoritesCSV() [][]string {
+	rows := [][]string{data.Locale.TAll([]string{"Type", "Title", "URL", "Provider", "Favorited Date"})}
+	for _, favorite := range data.Favorites {
+		rows = append(rows, []string{
+			data.Locale.T(favoriteTypeDisplay(favorite.ContentType)),
+			favorite.Title,
+			favorite.Url,
+			favorite.ProviderName,
+			formatReportDate(&favorite.FavoritedAt),
+		})
+	}
+	return rows
+}
+
+func favoriteTypeDisplay(contentType string) string {
+	switch contentType {
+	case "library":
+		return "Library"
+	case "video":
+		return "Video"
+	default:
+		return contentType
+	}
+}
+
+func (data *ReleasePacketData) courseProgressCSV() [][]string {
+	rows := [][]string{data.Locale.TAll([]string{
+		"Provider", "Course Name", "Enrolled Date", "Milestones Completed", "Total Milestones", "Outcomes",
+	})}
+	for _, course := range data.CourseProgress {
+		rows = append(rows, []string{
+			course.ProviderName,
+			course.CourseName,
+			formatReportDate(course.EnrolledAt),
+			strconv.FormatInt(course.MilestonesCompleted, 10),
+			strconv.FormatInt(course.TotalMilestones, 10),
+			course.Outcomes,
+		})
+	}
+	return rows
+}
+
+/*
+ToZip writes the packet: the resident's details and learning records as JSON, completions, enrollment outcomes,
+favorites and course progress as CSV, and a manifest holding the SHA-256 of each file. The manifest is signed with
+the APP_KEY so VerifyReleasePacket can tell a packet we produced from one that was edited after handoff.
+*/
+func (data *ReleasePacketData) ToZip() ([]byte, error) {
+	files := []struct {
+		name    string
+		content func() ([]byte, error)
+	}{
+		{"resident.json", func() ([]byte, error) { return json.MarshalIndent(data.Resident, "", "  ") }},
+		{"completions.csv", func() ([]byte, error) { return writeCSVBytes(data.completionsCSV()) }},
+		{"enrollments.csv", func() ([]byte, error) { return writeCSVBytes(data.enrollmentsCSV()) }},
+		{"learning_records.json", func() ([]byte, error) { return json.MarshalIndent(data.LearningRecords, "", "  ") }},
+		{"favorites.csv", func() ([]byte, error) { return writeCSVBytes(data.favoritesCSV()) }},
+		{"course_progress.csv", func() ([]byte, error) { return writeCSVBytes(data.courseProgressCSV()) }},
+	}
+	manifest := ReleasePacketManifest{
+		UserID:      data.Resident.UserID,
+		DocID:       data.Resident.DocID,
+		GeneratedAt: data.GeneratedAt,
+		Files:       make([]ReleasePacketFileHash, 0, len(files)),
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		content, err := file.content()
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", file.name, err)
+		}
+		if err := writeZipFile(archive, file.name, content, data.GeneratedAt); err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		manifest.Files = append(manifest.Files, ReleasePacketFileHash{Name: file.name, SHA256: hex.EncodeToString(sum[:]), Size: len(content)})
+	}
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipFile(archive, ReleasePacketManifestFile, manifestBytes, data.GeneratedAt); err != nil {
+		return nil, err
+	}
+	signature := tokenSignature(releasePacketSignaturePurpose, string(manifestBytes))
+	if err := writeZipFile(archive, ReleasePacketSignatureFile, []byte(signature), data.GeneratedAt); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeZipFile(archive *zip.Writer, name string, content []byte, modified time.Time) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("adding %s: %w", name, err)
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func writeCSVBytes(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReleasePacketChecksum is the hex SHA-256 of a packet ZIP
+func ReleasePacketChecksum(packet []byte) string {
+	sum := sha256.Sum256(packet)
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+VerifyReleasePacket checks a packet ZIP's manifest signature and that every file still matches its digest in the
+manifest. Files added after the packet was produced also fail verification.
+*/
+func VerifyReleasePacket(packet []byte) (*ReleasePacketManifest, error) {
+	archive, err := zip.NewReader(bytes.NewReader(packet), int64(len(packet)))
+	if err != nil {
+		return nil, fmt.Errorf("reading release packet: %w", err)
+	}
+	contents := make(map[string][]byte, len(archive.File))
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", file.Name, err)
+		}
+		contents[file.Name] = content
+	}
+	manifestBytes, signature := contents[ReleasePacketManifestFile], contents[ReleasePacketSignatureFile]
+	if manifestBytes == nil || signature == nil {
+		return nil, errors.New("release packet has no signed manifest")
+	}
+	expected := tokenSignature(releasePacketSignaturePurpose, string(manifestBytes))
+	if !hmac.Equal([]byte(strings.TrimSpace(string(signature))), []byte(expected)) {
+		return nil, errors.New("release packet manifest signature does not match")
+	}
+	var manifest ReleasePacketManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing release packet manifest: %w", err)
+	}
+	if len(contents) != len(manifest.Files)+2 {
+		return nil, errors.New("release packet contains files not listed in its manifest")
+	}
+	for _, file := range manifest.Files {
+		content, ok := contents[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("release packet is missing %s", file.Name)
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != file.SHA256 {
+			return nil, fmt.Errorf("%s was modified after the release packet was produced", file.Name)
+		}
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportJobStatus string
+
+const (
+	ReportJobPending   ReportJobStatus = "pending"
+	ReportJobRunning   ReportJobStatus = "running"
+	ReportJobCompleted ReportJobStatus = "completed"
+	ReportJobFailed    ReportJobStatus = "failed"
+	// ReportJobExpired is never stored, it is reported for completed jobs whose artifact is past ExpiresAt
+	ReportJobExpired ReportJobStatus = "expired"
+)
+
+/*
+ReportJob is a report generated outside of the request that asked for it. The request is saved as it was scoped
+for the requesting admin, and the finished file is kept in the report artifact store until ExpiresAt. The runs of a
+ReportSubscription are report jobs too, linked by SubscriptionID.
+*/
+type ReportJob struct {
+	DatabaseFields
+	UserID         uint                  `json:"user_id" gorm:"not null"`
+	SubscriptionID *uint                 `json:"subscription_id"`
+	FacilityID     uint                  `json:"facility_id" gorm:"not null"`
+	Type           ReportType            `json:"type" gorm:"size:50;not null"`
+	Format         ReportFormat          `json:"format" gorm:"size:10;not null"`
+	Request        ReportGenerateRequest `json:"request" gorm:"serializer:json;type:jsonb;not null"`
+	Status         ReportJobStatus       `json:"status" gorm:"size:20;not null"`
+	Error          string                `json:"error,omitempty" gorm:"type:text"`
+	RowCount       int                   `json:"row_count"`
+	Filename       string                `json:"filename,omitempty" gorm:"size:255"`
+	ContentType    string                `json:"content_type,omitempty" gorm:"size:100"`
+	SizeBytes      int64                 `json:"size_bytes"`
+	ArtifactKey    string                `json:"-" gorm:"size:255"`
+	StartedAt      *time.Time            `json:"started_at"`
+	CompletedAt    *time.Time            `json:"completed_at"`
+	ExpiresAt      *time.Time            `json:"expires_at"`
+
+	DownloadPath string `json:"download_path,omitempty" gorm:"-"`
+}
+
+func (ReportJob) TableName() string { return "report_jobs" }
+
+func (job *ReportJob) IsExpired(now time.Time) bool {
+	return job.Status == ReportJobCompleted && job.ExpiresAt != nil && !now.Before(*job.ExpiresAt)
+}
+
+func (job *ReportJob) AfterFind(tx *gorm.DB) error {
+	if job.IsExpired(time.Now()) {
+		job.Status = ReportJobExpired
+	}
+	if job.Status == ReportJobCompleted {
+		job.DownloadPath = fmt.Sprintf("/api/reports/jobs/%d/download", job.ID)
+	}
+	return nil
+}
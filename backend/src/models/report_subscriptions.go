@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ReportDateRange is a date range relative to when a scheduled report runs. Ranges end yesterday at the latest
+// so a run never reports on a day that is still in progress.
+type ReportDateRange string
+
+const (
+	Last7Days       ReportDateRange = "last_7_days"
+	Last30Days      ReportDateRange = "last_30_days"
+	PreviousWeek    ReportDateRange = "previous_week"
+	PreviousMonth   ReportDateRange = "previous_month"
+	PreviousQuarter ReportDateRange = "previous_quarter"
+	YearToDate      ReportDateRange = "year_to_date"
+)
+
+var AllReportDateRanges = []ReportDateRange{Last7Days, Last30Days, PreviousWeek, PreviousMonth, PreviousQuarter, YearToDate}
+
+// Resolve returns the first and last day of the range as of now, in now's location. Both days are inclusive.
+func (dr ReportDateRange) Resolve(now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	switch dr {
+	case Last7Days:
+		return today.AddDate(0, 0, -7), yesterday, nil
+	case Last30Days:
+		return today.AddDate(0, 0, -30), yesterday, nil
+	case PreviousWeek:
+		// weeks start on Monday
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		monday := today.AddDate(0, 0, -daysSinceMonday-7)
+		return monday, monday.AddDate(0, 0, 6), nil
+	case PreviousMonth:
+		firstOfMonth := today.AddDate(0, 0, 1-today.Day())
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1), nil
+	case PreviousQuarter:
+		quarterStart := time.Date(today.Year(), today.Month()-(today.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+		return quarterStart.AddDate(0, -3, 0), quarterStart.AddDate(0, 0, -1), nil
+	case YearToDate:
+		start := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		if yesterday.Before(start) {
+			return start, start, nil
+		}
+		return start, yesterday, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown date range %q", dr)
+	}
+}
+
+/*
+ReportSubscription is a saved report that runs on a cron schedule. The saved request's dates are replaced with
+DateRange on every run, and its facility scope is narrowed again using the owner's role at the time of the run.
+Every run is kept as a ReportJob with the subscription's ID.
+*/
+type ReportSubscription struct {
+	DatabaseFields
+	UserID    uint                  `json:"user_id" gorm:"not null"`
+	Name      string                `json:"name" gorm:"size:255;not null"`
+	Schedule  string                `json:"schedule" gorm:"size:100;not null"`
+	TimeZone  string                `json:"time_zone" gorm:"size:64;not null"`
+	DateRange ReportDateRange       `json:"date_range" gorm:"size:30;not null"`
+	Request   ReportGenerateRequest `json:"request" gorm:"serializer:json;type:jsonb;not null"`
+	Enabled   bool                  `json:"enabled" gorm:"not null"`
+	NextRunAt *time.Time            `json:"next_run_at"`
+	LastRunAt *time.Time            `json:"last_run_at"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (ReportSubscription) TableName() string { return "report_subscriptions" }
+
+func (sub *ReportSubscription) Validate() error {
+	if strings.TrimSpace(sub.Name) == "" {
+		return errors.New("name is required")
+	}
+	if !slices.Contains(AllReportDateRanges, sub.DateRange) {
+		return fmt.Errorf("date_range must be one of %v", AllReportDateRanges)
+	}
+	loc, err := time.LoadLocation(sub.TimeZone)
+	if err != nil {
+		return fmt.Errorf("unknown time_zone %q", sub.TimeZone)
+	}
+	schedule, err := cron.ParseStandard(sub.Schedule)
+	if err != nil {
+		return fmt.Errorf("schedule is not a valid cron expression: %v", err)
+	}
+	// subscriptions are run by an hourly system job, anything more frequent would silently run hourly. Runs of a
+	// schedule like "0,30 9 * * 1" are not evenly spaced, so every gap over a year of runs is checked.
+	prev := schedule.Next(time.Now().In(loc))
+	end := prev.AddDate(1, 0, 0)
+	for next := schedule.Next(prev); !next.IsZero() && next.Before(end); prev, next = next, schedule.Next(next) {
+		if next.Sub(prev) < time.Hour {
+			return errors.New("schedule cannot run more than once an hour")
+		}
+	}
+	return nil
+}
+
+// NextRun returns the first scheduled time after the given time, evaluated in the subscription's time zone
+func (sub *ReportSubscription) NextRun(after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(sub.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	schedule, err := cron.ParseStandard(sub.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(after.In(loc)), nil
+}
+
+// RequestAt returns the saved request with its dates resolved for a run at the given time
+func (sub *ReportSubscription) RequestAt(now time.Time) (ReportGenerateRequest, error) {
+	req := sub.Request
+	loc, err := time.LoadLocation(sub.TimeZone)
+	if err != nil {
+		return req, err
+	}
+	req.StartDate, req.EndDate, err = sub.DateRange.Resolve(now.In(loc))
+	req.Async = false
+	return req, err
+}
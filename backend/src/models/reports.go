@@ -1,6 +1,7 @@
 package models
 
 import (
+	"UnlockEdv2/src/i18n"
 	"fmt"
 	"math"
 	"strconv"
@@ -18,6 +19,7 @@ const (
 	FacilityComparisonReport ReportType = "facility_comparison"
 	ClassRosterReport        ReportType = "class_roster"
 	ResidentProfileReport    ReportType = "resident_profile"
+	RoomUtilizationReport    ReportType = "room_utilization"
 )
 
 type ReportFormat string
@@ -29,7 +31,7 @@ const (
 )
 
 type ReportGenerateRequest struct {
-	Type      ReportType   `json:"type" validate:"required,oneof=attendance program_outcomes facility_comparison class_roster resident_profile"`
+	Type      ReportType   `json:"type" validate:"required,oneof=attendance program_outcomes facility_comparison class_roster resident_profile room_utilization"`
 	Format    ReportFormat `json:"format" validate:"required,oneof=csv pdf excel"`
 	StartDate time.Time    `json:"start_date" validate:"required"`
 	EndDate   time.Time    `json:"end_date" validate:"required,gtefield=StartDate"`
@@ -50,14 +52,28 @@ type ReportGenerateRequest struct {
 	IncludeIncompleteReason bool     `json:"include_incomplete_reason"`
 	IncludeAttendanceRate   bool     `json:"include_attendance_rate"`
 	IncludeEnrollmentDates  bool     `json:"include_enrollment_dates"`
-}
-
+	// RoomHoursPerWeek is how many hours a room can be booked in a week, defaults to DefaultRoomHoursPerWeek
+	RoomHoursPerWeek *float64 `json:"room_hours_per_week" validate:"omitempty,gt=0,lte=168"`
+	// Async queues the report as a ReportJob instead of building it within the request
+	Async bool `json:"async"`
+	// Locale is the language of the report's headers and labels, the requesting user's locale when not set
+	Locale i18n.Locale `json:"locale,omitempty"`
+}
+
+/*
+PDFConfig is what a report passes to its jasper template. Title is the English title, it names the downloaded file
+and is translated into Locale for the PDF itself. Columns and SubColumns are the already translated headers of the
+template's tables, in the template's column order.
+*/
 type PDFConfig struct {
 	Title         string
 	Data          [][]string
 	SubRows       [][]string
 	Params        map[string]string
 	FilterSummary []PDFFilterLine
+	Locale        i18n.Locale
+	Columns       []string
+	SubColumns    []string
 }
 
 type PDFFilterLine struct {
@@ -106,9 +122,12 @@ type FacilityComparisonReportRow struct {
 }
 
 type AttendanceReportData struct {
-	Data []AttendanceReportRow
+	Data   []AttendanceReportRow
+	Locale i18n.Locale
 }
 
+var attendanceHeaders = []string{"Date", "Program Name", "Class Name", "Facility", "Resident Name", "DOC ID", "Attendance Status", "Note"}
+
 type ProgramClassBreakdownRow struct {
 	ClassName         string
 	Status            string
@@ -122,8 +141,14 @@ type ProgramOutcomesReportData struct {
 	Data          []ProgramOutcomesReportRow
 	Classes       []ProgramClassBreakdownRow
 	IncludeStatus bool
+	Locale        i18n.Locale
 }
 
+var (
+	programOutcomesHeaders = []string{"Program Name", "Program Type", "Facilities Active", "Total Classes", "Currently Enrolled", "Enrolled in Range", "Total Capacity", "Utilization %"}
+	classBreakdownHeaders  = []string{"Class", "Status", "Credit Hours", "Capacity", "Currently Enrolled", "Enrolled in Range", "Utilization %"}
+)
+
 func programStatusLabel(isActive bool) string {
 	if isActive {
 		return "Active"
@@ -132,17 +157,22 @@ func programStatusLabel(isActive bool) string {
 }
 
 type FacilityComparisonReportData struct {
-	Data []FacilityComparisonReportRow
+	Data   []FacilityComparisonReportRow
+	Locale i18n.Locale
 }
 
+var (
+	facilityComparisonHeaders = []string{"Facility", "Total Programs", "Active Programs", "Total Enrollments", "Active Enrollments", "Completion Rate (%)", "Attendance Rate (%)", "Top Program Type", "Total Credit Hours", "Certificates Earned", "Last Activity Date"}
+	// the PDF has less room, so its columns are abbreviated
+	facilityComparisonPDFHeaders = []string{"Facility", "Programs", "Active", "Enrollments", "Active", "Comp%", "Attend%", "Top Type", "Credit Hours", "Certificates", "Last Activity"}
+)
+
 func (r AttendanceReportData) Len() int {
 	return len(r.Data)
 }
 
 func (r AttendanceReportData) ToCSV() ([][]string, error) {
-	csvData := [][]string{
-		{"Date", "Program Name", "Class Name", "Facility", "Resident Name", "DOC ID", "Attendance Status", "Note"},
-	}
+	csvData := [][]string{r.Locale.TAll(attendanceHeaders)}
 
 	for _, row := range r.Data {
 		csvData = append(csvData, []string{
@@ -152,7 +182,7 @@ func (r AttendanceReportData) ToCSV() ([][]string, error) {
 			row.FacilityName,
 			formatResidentName(row.StudentLastName, row.StudentFirstName),
 			row.DocID,
-			row.AttendanceStatus.HumanReadable(),
+			r.Locale.T(row.AttendanceStatus.HumanReadable()),
 			FormatNullableString(row.AbsenceReason),
 		})
 	}
@@ -165,12 +195,12 @@ func (r ProgramOutcomesReportData) Len() int {
 }
 
 func (r ProgramOutcomesReportData) headers() []string {
-	headers := []string{"Program Name", "Program Type", "Facilities Active", "Total Classes", "Currently Enrolled", "Enrolled in Range", "Total Capacity", "Utilization %"}
+	headers := r.Locale.TAll(programOutcomesHeaders)
 	// Status is appended (never inserted) so the fixed column positions the PDF
 	// template maps stay stable; the extra trailing column is hidden in the PDF
 	// unless requested.
 	if r.IncludeStatus {
-		headers = append(headers, "Status")
+		headers = append(headers, r.Locale.T("Status"))
 	}
 	return headers
 }
@@ -178,7 +208,7 @@ func (r ProgramOutcomesReportData) headers() []string {
 func (r ProgramOutcomesReportData) rowValues(row ProgramOutcomesReportRow) []string {
 	values := []string{
 		row.ProgramName,
-		humanizeProgramTypes(row.ProgramType),
+		humanizeProgramTypes(r.Locale, row.ProgramType),
 		strconv.Itoa(row.FacilitiesActive),
 		strconv.Itoa(row.TotalClasses),
 		strconv.Itoa(row.ActiveEnrollments),
@@ -187,7 +217,7 @@ func (r ProgramOutcomesReportData) rowValues(row ProgramOutcomesReportRow) []str
 		strconv.Itoa(row.Utilization),
 	}
 	if r.IncludeStatus {
-		values = append(values, programStatusLabel(row.IsActive))
+		values = append(values, r.Locale.T(programStatusLabel(row.IsActive)))
 	}
 	return values
 }
@@ -195,7 +225,7 @@ func (r ProgramOutcomesReportData) rowValues(row ProgramOutcomesReportRow) []str
 func (r ProgramOutcomesReportData) rowCells(row ProgramOutcomesReportRow) []any {
 	cells := []any{
 		row.ProgramName,
-		humanizeProgramTypes(row.ProgramType),
+		humanizeProgramTypes(r.Locale, row.ProgramType),
 		row.FacilitiesActive,
 		row.TotalClasses,
 		row.ActiveEnrollments,
@@ -204,7 +234,7 @@ func (r ProgramOutcomesReportData) rowCells(row ProgramOutcomesReportRow) []any
 		row.Utilization,
 	}
 	if r.IncludeStatus {
-		cells = append(cells, programStatusLabel(row.IsActive))
+		cells = append(cells, r.Locale.T(programStatusLabel(row.IsActive)))
 	}
 	return cells
 }
@@ -218,13 +248,13 @@ func (r ProgramOutcomesReportData) ToCSV() ([][]string, error) {
 	return csvData, nil
 }
 
-func humanizeProgramTypes(agg string) string {
+func humanizeProgramTypes(locale i18n.Locale, agg string) string {
 	if agg == "" || agg == "N/A" {
 		return agg
 	}
 	parts := strings.Split(agg, ",")
 	for i, p := range parts {
-		parts[i] = HumanReadableProgType(strings.TrimSpace(p))
+		parts[i] = locale.T(HumanReadableProgType(strings.TrimSpace(p)))
 	}
 	return strings.Join(parts, ", ")
 }
@@ -234,9 +264,7 @@ func (r FacilityComparisonReportData) Len() int {
 }
 
 func (r FacilityComparisonReportData) ToCSV() ([][]string, error) {
-	csvData := [][]string{
-		{"Facility", "Total Programs", "Active Programs", "Total Enrollments", "Active Enrollments", "Completion Rate (%)", "Attendance Rate (%)", "Top Program Type", "Total Credit Hours", "Certificates Earned", "Last Activity Date"},
-	}
+	csvData := [][]string{r.Locale.TAll(facilityComparisonHeaders)}
 
 	for _, row := range r.Data {
 		lastActivity := ""
@@ -252,7 +280,7 @@ func (r FacilityComparisonReportData) ToCSV() ([][]string, error) {
 			strconv.Itoa(row.ActiveEnrollments),
 			strconv.FormatFloat(row.CompletionRate, 'f', 2, 64),
 			strconv.FormatFloat(row.AttendanceRate, 'f', 2, 64),
-			HumanReadableProgType(row.TopProgramType),
+			r.Locale.T(HumanReadableProgType(row.TopProgramType)),
 			strconv.FormatFloat(row.TotalCreditHours, 'f', 2, 64),
 			strconv.Itoa(row.CertificatesEarned),
 			lastActivity,
@@ -265,15 +293,14 @@ func (r FacilityComparisonReportData) ToCSV() ([][]string, error) {
 //nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
 func (r AttendanceReportData) ToExcel() (*excelize.File, error) {
 	f := excelize.NewFile()
-	sheetName := "Attendance Report"
+	sheetName := r.Locale.T("Attendance Report")
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
 	}
 	f.SetActiveSheet(index)
 
-	headers := []string{"Date", "Program Name", "Class Name", "Facility", "Resident Name", "DOC ID", "Attendance Status", "Note"}
-	for i, header := range headers {
+	for i, header := range r.Locale.TAll(attendanceHeaders) {
 		cell := fmt.Sprintf("%s1", excelColumnName(i))
 		f.SetCellValue(sheetName, cell, header)
 	}
@@ -286,7 +313,7 @@ func (r AttendanceReportData) ToExcel() (*excelize.File, error) {
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", rowNum), row.FacilityName)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), formatResidentName(row.StudentLastName, row.StudentFirstName))
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), row.DocID)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), row.AttendanceStatus.HumanReadable())
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), r.Locale.T(row.AttendanceStatus.HumanReadable()))
 		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), FormatNullableString(row.AbsenceReason))
 	}
 
@@ -296,7 +323,7 @@ func (r AttendanceReportData) ToExcel() (*excelize.File, error) {
 //nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
 func (r ProgramOutcomesReportData) ToExcel() (*excelize.File, error) {
 	f := excelize.NewFile()
-	sheetName := "Program Outcomes"
+	sheetName := r.Locale.T("Program Outcomes")
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
@@ -317,11 +344,10 @@ func (r ProgramOutcomesReportData) ToExcel() (*excelize.File, error) {
 
 	if len(r.Classes) > 0 {
 		titleRow := len(r.Data) + 3 // one blank spacer row after the program rows
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", titleRow), "Class Breakdown")
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", titleRow), r.Locale.T("Class Breakdown"))
 
-		classHeaders := []string{"Class", "Status", "Credit Hours", "Capacity", "Currently Enrolled", "Enrolled in Range", "Utilization %"}
 		headerRow := titleRow + 1
-		for i, h := range classHeaders {
+		for i, h := range r.Locale.TAll(classBreakdownHeaders) {
 			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", excelColumnName(i), headerRow), h)
 		}
 
@@ -339,15 +365,14 @@ func (r ProgramOutcomesReportData) ToExcel() (*excelize.File, error) {
 //nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
 func (r FacilityComparisonReportData) ToExcel() (*excelize.File, error) {
 	f := excelize.NewFile()
-	sheetName := "Facility Comparison"
+	sheetName := r.Locale.T("Facility Comparison")
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
 	}
 	f.SetActiveSheet(index)
 
-	headers := []string{"Facility", "Total Programs", "Active Programs", "Total Enrollments", "Active Enrollments", "Completion Rate (%)", "Attendance Rate (%)", "Top Program Type", "Total Credit Hours", "Certificates Earned", "Last Activity Date"}
-	for i, header := range headers {
+	for i, header := range r.Locale.TAll(facilityComparisonHeaders) {
 		cell := fmt.Sprintf("%s1", excelColumnName(i))
 		f.SetCellValue(sheetName, cell, header)
 	}
@@ -366,7 +391,7 @@ func (r FacilityComparisonReportData) ToExcel() (*excelize.File, error) {
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), row.ActiveEnrollments)
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), fmt.Sprintf("%.2f", row.CompletionRate))
 		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), fmt.Sprintf("%.2f", row.AttendanceRate))
-		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), HumanReadableProgType(row.TopProgramType))
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), r.Locale.T(HumanReadableProgType(row.TopProgramType)))
 		f.SetCellValue(sheetName, fmt.Sprintf("I%d", rowNum), fmt.Sprintf("%.2f", row.TotalCreditHours))
 		f.SetCellValue(sheetName, fmt.Sprintf("J%d", rowNum), row.CertificatesEarned)
 		f.SetCellValue(sheetName, fmt.Sprintf("K%d", rowNum), lastActivity)
@@ -385,14 +410,16 @@ func (r AttendanceReportData) ToPDF() (PDFConfig, error) {
 			row.FacilityName,
 			formatResidentName(row.StudentLastName, row.StudentFirstName),
 			row.DocID,
-			row.AttendanceStatus.HumanReadable(),
+			r.Locale.T(row.AttendanceStatus.HumanReadable()),
 			FormatNullableString(row.AbsenceReason),
 		}
 	}
 
 	return PDFConfig{
-		Title: "Attendance Records",
-		Data:  tableData,
+		Title:   "Attendance Records",
+		Data:    tableData,
+		Locale:  r.Locale,
+		Columns: r.Locale.TAll(attendanceHeaders),
 	}, nil
 }
 
@@ -406,9 +433,14 @@ func (r ProgramOutcomesReportData) ToPDF() (PDFConfig, error) {
 		Title:   "Program Export",
 		Data:    tableData,
 		SubRows: r.subRows(),
+		Locale:  r.Locale,
+		// the template always has the Status column, it is hidden unless requested
+		Columns:    append(r.Locale.TAll(programOutcomesHeaders), r.Locale.T("Status")),
+		SubColumns: r.Locale.TAll(classBreakdownHeaders),
 		// Toggles the trailing Status column in the PDF template.
 		Params: map[string]string{
-			"ShowStatus": strconv.FormatBool(r.IncludeStatus),
+			"ShowStatus":          strconv.FormatBool(r.IncludeStatus),
+			"ClassBreakdownLabel": r.Locale.T("Class Breakdown"),
 		},
 	}, nil
 }
@@ -426,7 +458,7 @@ func (r ProgramOutcomesReportData) subRows() [][]string {
 		}
 		rows[i] = []string{
 			c.ClassName,
-			c.Status,
+			r.Locale.T(c.Status),
 			creditHours,
 			strconv.Itoa(c.Capacity),
 			strconv.Itoa(c.ActiveEnrollments),
@@ -452,7 +484,7 @@ func (r FacilityComparisonReportData) ToPDF() (PDFConfig, error) {
 			fmt.Sprintf("%d", row.ActiveEnrollments),
 			fmt.Sprintf("%.1f", row.CompletionRate),
 			fmt.Sprintf("%.1f", row.AttendanceRate),
-			HumanReadableProgType(row.TopProgramType),
+			r.Locale.T(HumanReadableProgType(row.TopProgramType)),
 			fmt.Sprintf("%.1f", row.TotalCreditHours),
 			fmt.Sprintf("%d", row.CertificatesEarned),
 			lastActivity,
@@ -460,8 +492,13 @@ func (r FacilityComparisonReportData) ToPDF() (PDFConfig, error) {
 	}
 
 	return PDFConfig{
-		Title: "Facility Comparison",
-		Data:  tableData,
+		Title:   "Facility Comparison",
+		Data:    tableData,
+		Locale:  r.Locale,
+		Columns: r.Locale.TAll(facilityComparisonPDFHeaders),
+		Params: map[string]string{
+			"FiltersLabel": r.Locale.T("Report Filters:"),
+		},
 	}, nil
 }
 
@@ -514,28 +551,34 @@ type ClassRosterReportData struct {
 	IncludeIncompleteReason bool
 	IncludeAttendanceRate   bool
 	IncludeEnrollmentDates  bool
+	Locale                  i18n.Locale
 }
 
+// classRosterHeaders are every column of the roster, the optional ones are left out of CSV and Excel unless requested
+var classRosterHeaders = []string{"Resident Name", "DOC ID", "Enrollment Status", "Incomplete Reason", "Avg Attendance Rate", "Enrolled At", "Ended At"}
+
 func (r ClassRosterReportData) Len() int {
 	return len(r.Data)
 }
 
 func (r ClassRosterReportData) headers() []string {
-	headers := []string{"Resident Name", "DOC ID", "Enrollment Status"}
+	all := r.Locale.TAll(classRosterHeaders)
+	headers := all[:3:3]
 	if r.IncludeIncompleteReason {
-		headers = append(headers, "Incomplete Reason")
+		headers = append(headers, all[3])
 	}
 	if r.IncludeAttendanceRate {
-		headers = append(headers, "Avg Attendance Rate")
+		headers = append(headers, all[4])
 	}
 	if r.IncludeEnrollmentDates {
-		headers = append(headers, "Enrolled At", "Ended At")
+		headers = append(headers, all[5], all[6])
 	}
 	return headers
 }
 
 func (r ClassRosterReportData) rowValues(row ClassRosterReportRow) []string {
 	status, reason := splitEnrollmentStatus(row.EnrollmentStatus)
+	status, reason = r.Locale.T(status), r.Locale.T(reason)
 	values := []string{
 		formatResidentName(row.NameLast, row.NameFirst),
 		row.DocID,
@@ -564,7 +607,7 @@ func (r ClassRosterReportData) ToCSV() ([][]string, error) {
 //nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
 func (r ClassRosterReportData) ToExcel() (*excelize.File, error) {
 	f := excelize.NewFile()
-	sheetName := "Class Roster"
+	sheetName := r.Locale.T("Class Roster")
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
@@ -590,8 +633,8 @@ func (r ClassRosterReportData) ToPDF() (PDFConfig, error) {
 		tableData[i] = []string{
 			formatResidentName(row.NameLast, row.NameFirst),
 			row.DocID,
-			status,
-			reason,
+			r.Locale.T(status),
+			r.Locale.T(reason),
 			formatAttendanceRate(row.SessionsAttended, row.TotalSessions),
 			formatReportDate(row.EnrolledAt),
 			formatReportDate(row.EndedAt),
@@ -599,8 +642,10 @@ func (r ClassRosterReportData) ToPDF() (PDFConfig, error) {
 	}
 
 	return PDFConfig{
-		Title: "Class Roster",
-		Data:  tableData,
+		Title:   "Class Roster",
+		Data:    tableData,
+		Locale:  r.Locale,
+		Columns: r.Locale.TAll(classRosterHeaders),
 		// use these to hide/show columns in report
 		Params: map[string]string{
 			"ShowIncompleteReason": strconv.FormatBool(r.IncludeIncompleteReason),
@@ -627,7 +672,8 @@ type ResidentProfileReportRow struct {
 }
 
 type ResidentProfileReportData struct {
-	Data []ResidentProfileReportRow
+	Data   []ResidentProfileReportRow
+	Locale i18n.Locale
 }
 
 var residentProfileHeaders = []string{
@@ -647,18 +693,18 @@ func (r ResidentProfileReportData) rowValues(row ResidentProfileReportRow) []str
 		row.FacilityName,
 		row.ProgramName,
 		row.ClassName,
-		enrollmentStatusDisplay(row.EnrollmentStatus),
+		r.Locale.T(enrollmentStatusDisplay(row.EnrollmentStatus)),
 		formatReportDate(row.EnrolledAt),
 		formatReportDate(row.EndedAt),
 		strconv.Itoa(row.SessionsAttended),
 		strconv.Itoa(row.TotalSessions),
 		formatAttendanceRate(row.SessionsAttended, row.TotalSessions),
-		enrollmentCompletionStatus(row.EnrollmentStatus),
+		r.Locale.T(enrollmentCompletionStatus(row.EnrollmentStatus)),
 	}
 }
 
 func (r ResidentProfileReportData) ToCSV() ([][]string, error) {
-	csvData := [][]string{residentProfileHeaders}
+	csvData := [][]string{r.Locale.TAll(residentProfileHeaders)}
 	for _, row := range r.Data {
 		csvData = append(csvData, r.rowValues(row))
 	}
@@ -668,14 +714,14 @@ func (r ResidentProfileReportData) ToCSV() ([][]string, error) {
 //nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
 func (r ResidentProfileReportData) ToExcel() (*excelize.File, error) {
 	f := excelize.NewFile()
-	sheetName := "Resident Profile"
+	sheetName := r.Locale.T("Resident Profile")
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
 	}
 	f.SetActiveSheet(index)
 
-	for i, header := range residentProfileHeaders {
+	for i, header := range r.Locale.TAll(residentProfileHeaders) {
 		f.SetCellValue(sheetName, fmt.Sprintf("%s1", excelColumnName(i)), header)
 	}
 	for i, row := range r.Data {
@@ -694,8 +740,10 @@ func (r ResidentProfileReportData) ToPDF() (PDFConfig, error) {
 	}
 
 	return PDFConfig{
-		Title: "Resident Profile",
-		Data:  tableData,
+		Title:   "Resident Profile",
+		Data:    tableData,
+		Locale:  r.Locale,
+		Columns: r.Locale.TAll(residentProfileHeaders),
 	}, nil
 }
 
@@ -747,3 +795,101 @@ func enrollmentCompletionStatus(status string) string {
 		return status
 	}
 }
+
+// ---- Room Utilization report ----
+
+// DefaultRoomHoursPerWeek is the bookable time assumed for a room when the request does not set one: 8 hours, 5 days a week
+const DefaultRoomHoursPerWeek = 40.0
+
+type RoomUtilizationReportRow struct {
+	FacilityName      string
+	RoomName          string
+	WeekStart         time.Time
+	Sessions          int
+	CancelledSessions int
+	BookedHours       float64
+	AvailableHours    float64
+}
+
+func (row RoomUtilizationReportRow) Utilization() int {
+	if row.AvailableHours <= 0 {
+		return 0
+	}
+	return int(math.Round(100.0 * row.BookedHours / row.AvailableHours))
+}
+
+type RoomUtilizationReportData struct {
+	Data   []RoomUtilizationReportRow
+	Locale i18n.Locale
+}
+
+var roomUtilizationHeaders = []string{
+	"Week Of", "Facility", "Room", "Sessions", "Cancelled Sessions", "Booked Hours", "Available Hours", "Utilization %",
+}
+
+func (r RoomUtilizationReportData) Len() int {
+	return len(r.Data)
+}
+
+func (r RoomUtilizationReportData) rowValues(row RoomUtilizationReportRow) []string {
+	return []string{
+		row.WeekStart.Format("2006-01-02"),
+		row.FacilityName,
+		row.RoomName,
+		strconv.Itoa(row.Sessions),
+		strconv.Itoa(row.CancelledSessions),
+		strconv.FormatFloat(row.BookedHours, 'f', 1, 64),
+		strconv.FormatFloat(row.AvailableHours, 'f', 1, 64),
+		strconv.Itoa(row.Utilization()),
+	}
+}
+
+func (r RoomUtilizationReportData) ToCSV() ([][]string, error) {
+	csvData := [][]string{r.Locale.TAll(roomUtilizationHeaders)}
+	for _, row := range r.Data {
+		csvData = append(csvData, r.rowValues(row))
+	}
+	return csvData, nil
+}
+
+//nolint:errcheck // Excel cell setting errors are unlikely and checked at sheet creation
+func (r RoomUtilizationReportData) ToExcel() (*excelize.File, error) {
+	f := excelize.NewFile()
+	sheetName := r.Locale.T("Room Utilization")
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Excel sheet: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	for i, header := range r.Locale.TAll(roomUtilizationHeaders) {
+		f.SetCellValue(sheetName, fmt.Sprintf("%s1", excelColumnName(i)), header)
+	}
+	for i, row := range r.Data {
+		rowNum := i + 2
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", rowNum), row.WeekStart.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", rowNum), row.FacilityName)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", rowNum), row.RoomName)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", rowNum), row.Sessions)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), row.CancelledSessions)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), math.Round(row.BookedHours*10)/10)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), math.Round(row.AvailableHours*10)/10)
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), row.Utilization())
+	}
+
+	return f, nil
+}
+
+func (r RoomUtilizationReportData) ToPDF() (PDFConfig, error) {
+	tableData := make([][]string, len(r.Data))
+	for i, row := range r.Data {
+		tableData[i] = r.rowValues(row)
+	}
+
+	return PDFConfig{
+		Title:   "Room Utilization",
+		Data:    tableData,
+		Locale:  r.Locale,
+		Columns: r.Locale.TAll(roomUtilizationHeaders),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+/*
+RosterSyncRun is the report of one nightly roster sync: the DOC's roster file diffed against our residents, with every
+admission, transfer, release and release date change it found. Dry runs are never saved, so every saved run was applied
+unless it failed.
+*/
+type RosterSyncRun struct {
+	DatabaseFields
+	Filename string `json:"filename" gorm:"size:255;not null"`
+	// Checksum is the hex SHA-256 of the roster file, so a run can be matched to the file the DOC dropped
+	Checksum      string          `json:"checksum" gorm:"size:64;not null"`
+	DryRun        bool            `json:"dry_run" gorm:"-"`
+	Status        RosterSyncState `json:"status" gorm:"size:20;not null"`
+	Error         string          `json:"error,omitempty" gorm:"size:512"`
+	RowCount      int             `json:"row_count"`
+	Admitted      int             `json:"admitted"`
+	Transferred   int             `json:"transferred"`
+	Released      int             `json:"released"`
+	Updated       int             `json:"updated"`
+	Skipped       int             `json:"skipped"`
+	Failed        int             `json:"failed"`
+	TriggeredByID *uint           `json:"triggered_by_id"`
+
+	TriggeredBy *User              `json:"triggered_by,omitempty" gorm:"foreignKey:TriggeredByID;constraint:OnDelete:SET NULL"`
+	Changes     []RosterSyncChange `json:"changes,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+func (RosterSyncRun) TableName() string { return "roster_sync_runs" }
+
+// RosterSyncChange is one line of a run's report. RowNumber is 0 for residents released for being absent from the file.
+type RosterSyncChange struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	RunID          uint             `json:"run_id" gorm:"not null;index"`
+	Action         RosterSyncAction `json:"action" gorm:"size:20;not null"`
+	RowNumber      int              `json:"row_number"`
+	DocID          string           `json:"doc_id" gorm:"size:255"`
+	NameFirst      string           `json:"name_first" gorm:"size:255"`
+	NameLast       string           `json:"name_last" gorm:"size:255"`
+	UserID         *uint            `json:"user_id"`
+	FromFacilityID *uint            `json:"from_facility_id"`
+	ToFacilityID   *uint            `json:"to_facility_id"`
+	ReleaseDate    string           `json:"release_date,omitempty" gorm:"size:10"`
+	Detail         string           `json:"detail" gorm:"size:512"`
+	Applied        bool             `json:"applied"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+func (RosterSyncChange) TableName() string { return "roster_sync_changes" }
+
+type RosterSyncState string
+
+const (
+	RosterSyncCompleted RosterSyncState = "completed"
+	RosterSyncFailed    RosterSyncState = "failed"
+)
+
+type RosterSyncAction string
+
+const (
+	RosterSyncAdmit       RosterSyncAction = "admit"
+	RosterSyncTransfer    RosterSyncAction = "transfer"
+	RosterSyncRelease     RosterSyncAction = "release"
+	RosterSyncReleaseDate RosterSyncAction = "release_date"
+	// RosterSyncSkip is a row that needs a person to look at it, e.g. an unknown facility or a readmitted resident
+	RosterSyncSkip RosterSyncAction = "skip"
+)
+
+const defaultRosterSyncMaxReleasePercent = 10
+
+// RosterSyncDir is the directory the DOC's roster files are dropped in, by SFTP or a mounted share
+func RosterSyncDir() string {
+	return os.Getenv("ROSTER_SYNC_DIR")
+}
+
+// RosterSyncDryRun reports whether scheduled syncs should only report what they would change
+func RosterSyncDryRun() bool {
+	dryRun, _ := strconv.ParseBool(os.Getenv("ROSTER_SYNC_DRY_RUN"))
+	return dryRun
+}
+
+/*
+RosterSyncMaxReleasePercent caps the share of active residents one run may release. Residents missing from the file are
+released, so a truncated export would otherwise deactivate most of the population overnight.
+*/
+func RosterSyncMaxReleasePercent() int {
+	percent, err := strconv.Atoi(os.Getenv("ROSTER_SYNC_MAX_RELEASE_PERCENT"))
+	if err != nil || percent <= 0 || percent > 100 {
+		return defaultRosterSyncMaxReleasePercent
+	}
+	return percent
+}
+
+/*
+Tally counts the run's changes by action. Changes that failed to apply are counted apart from their action, except in
+dry runs and runs stopped before applying anything, whose counts are what the run would have done.
+*/
+func (run *RosterSyncRun) Tally() {
+	planned := run.DryRun || run.Status == RosterSyncFailed
+	run.Admitted, run.Transferred, run.Released, run.Updated, run.Skipped, run.Failed = 0, 0, 0, 0, 0, 0
+	for _, change := range run.Changes {
+		if change.Action == RosterSyncSkip {
+			run.Skipped++
+			continue
+		}
+		if !change.Applied && !planned {
+			run.Failed++
+			continue
+		}
+		switch change.Action {
+		case RosterSyncAdmit:
+			run.Admitted++
+		case RosterSyncTransfer:
+			run.Transferred++
+		case RosterSyncRelease:
+			run.Released++
+		case RosterSyncReleaseDate:
+			run.Updated++
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// SessionCoverage records that a substitute taught a single session of a class in place of its scheduled instructor.
+// The substitution itself is the one-off override referenced by OverrideID.
+type SessionCoverage struct {
+	DatabaseFields
+	EventID                uint      `json:"event_id" gorm:"not null"`
+	ClassID                uint      `json:"class_id" gorm:"not null"`
+	OverrideID             uint      `json:"override_id" gorm:"not null"`
+	SessionDate            string    `json:"session_date" gorm:"size:10;not null"` // YYYY-MM-DD in facility local time
+	StartsAt               time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt                 time.Time `json:"ends_at" gorm:"not null"`
+	OriginalInstructorID   *uint     `json:"original_instructor_id"`
+	SubstituteInstructorID uint      `json:"substitute_instructor_id" gorm:"not null"`
+	Reason                 string    `json:"reason" gorm:"size:255"`
+
+	Class      *ProgramClass `json:"class,omitempty" gorm:"foreignKey:ClassID;references:ID"`
+	Substitute *User         `json:"substitute,omitempty" gorm:"foreignKey:SubstituteInstructorID;references:ID"`
+}
+
+func (SessionCoverage) TableName() string { return "session_coverages" }
+
+func (coverage *SessionCoverage) Hours() float64 {
+	return coverage.EndsAt.Sub(coverage.StartsAt).Hours()
+}
+
+type AssignSubstituteRequest struct {
+	Date         string `json:"date"`
+	InstructorID uint   `json:"instructor_id"`
+	Reason       string `json:"reason"`
+}
+
+// InstructorCoverageSummary counts, for payroll, the sessions an instructor taught for others and the sessions of theirs others taught
+type InstructorCoverageSummary struct {
+	InstructorID    uint    `json:"instructor_id"`
+	NameFirst       string  `json:"name_first"`
+	NameLast        string  `json:"name_last"`
+	SessionsCovered int64   `json:"sessions_covered"`
+	HoursCovered    float64 `json:"hours_covered"`
+	SessionsMissed  int64   `json:"sessions_missed"`
+	HoursMissed     float64 `json:"hours_missed"`
+}
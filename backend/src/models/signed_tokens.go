@@ -0,0 +1,56 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func newTokenNonce() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// signToken encodes a row ID and nonce and signs them with the APP_KEY, the purpose keeps a token signed for one
+// kind of row from being accepted for another
+func signToken(purpose string, id uint, nonce string) string {
+	payload := base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%s", id, nonce))
+	return payload + "." + tokenSignature(purpose, payload)
+}
+
+func parseSignedToken(purpose, token string) (uint, string, bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || payload == "" || signature == "" {
+		return 0, "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(tokenSignature(purpose, payload))) {
+		return 0, "", false
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, "", false
+	}
+	rawID, nonce, found := strings.Cut(string(decoded), ":")
+	if !found || nonce == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint(id), nonce, true
+}
+
+func tokenSignature(purpose, payload string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("APP_KEY")))
+	mac.Write([]byte(purpose + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"UnlockEdv2/src/i18n"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+Transcript records a transcript handed to a resident or their case manager. The totals are copied at issuance so the
+verification endpoint can confirm what the printed transcript said, even after later enrollments change the record.
+*/
+type Transcript struct {
+	DatabaseFields
+	UserID           uint      `json:"user_id" gorm:"not null"`
+	VerificationCode string    `json:"verification_code" gorm:"size:14;not null;uniqueIndex"`
+	ResidentName     string    `json:"resident_name" gorm:"size:255;not null"`
+	DocID            string    `json:"doc_id" gorm:"size:25"`
+	FacilityName     string    `json:"facility_name" gorm:"size:255"`
+	ClassesCompleted int       `json:"classes_completed" gorm:"not null;default:0"`
+	TotalCreditHours int64     `json:"total_credit_hours" gorm:"not null;default:0"`
+	IssuedAt         time.Time `json:"issued_at" gorm:"not null"`
+	IssuedByID       uint      `json:"issued_by_id" gorm:"not null"`
+}
+
+func (Transcript) TableName() string { return "transcripts" }
+
+func (transcript *Transcript) BeforeCreate(tx *gorm.DB) error {
+	if err := transcript.DatabaseFields.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if transcript.VerificationCode == "" {
+		code, err := NewVerificationCode()
+		if err != nil {
+			return err
+		}
+		transcript.VerificationCode = code
+	}
+	return nil
+}
+
+// TranscriptClass is one class enrollment on a transcript, with the completion recorded for it when there is one
+type TranscriptClass struct {
+	ProgramName      string     `json:"program_name"`
+	ClassName        string     `json:"class_name"`
+	FacilityName     string     `json:"facility_name"`
+	EnrollmentStatus string     `json:"enrollment_status"`
+	EnrolledAt       *time.Time `json:"enrolled_at"`
+	EndedAt          *time.Time `json:"ended_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	CreditHours      *int64     `json:"credit_hours"`
+	SessionsAttended int        `json:"sessions_attended"`
+	TotalSessions    int        `json:"total_sessions"`
+	AttendanceRate   *float64   `json:"attendance_rate" gorm:"-"`
+}
+
+func (c *TranscriptClass) IsCompleted() bool {
+	return c.CompletedAt != nil || c.EnrollmentStatus == string(EnrollmentCompleted)
+}
+
+// TranscriptReflection is the part of a finished learning record entry a transcript shows
+type TranscriptReflection struct {
+	ProgramName    string   `json:"program_name"`
+	FacilityName   string   `json:"facility_name"`
+	CompletionDate string   `json:"completion_date"`
+	Summary        string   `json:"summary"`
+	TopSkills      []string `json:"top_skills"`
+}
+
+/*
+TranscriptData is a resident's transcript: every class they were enrolled in at any facility, credit hours earned
+for the classes they completed, and the reflections they wrote about them. Transcript is set once it has been issued
+with a verification code.
+*/
+type TranscriptData struct {
+	Transcript       *Transcript            `json:"transcript,omitempty"`
+	VerifyURL        string                 `json:"verify_url,omitempty"`
+	ResidentName     string                 `json:"resident_name"`
+	DocID            string                 `json:"doc_id"`
+	FacilityName     string                 `json:"facility_name"`
+	GeneratedAt      time.Time              `json:"generated_at"`
+	ClassesCompleted int                    `json:"classes_completed"`
+	TotalCreditHours int64                  `json:"total_credit_hours"`
+	Classes          []TranscriptClass      `json:"classes"`
+	Reflections      []TranscriptReflection `json:"reflections"`
+	Locale           i18n.Locale            `json:"-"`
+}
+
+func NewTranscriptData(user *User, classes []TranscriptClass, entries []LearningRecordEntry, locale i18n.Locale) *TranscriptData {
+	data := &TranscriptData{
+		ResidentName: formatResidentName(user.NameLast, user.NameFirst),
+		DocID:        user.DocID,
+		GeneratedAt:  time.Now(),
+		Classes:      classes,
+		Reflections:  make([]TranscriptReflection, 0, len(entries)),
+		Locale:       locale,
+	}
+	if user.Facility != nil {
+		data.FacilityName = user.Facility.Name
+	}
+	for i := range data.Classes {
+		class := &data.Classes[i]
+		if class.TotalSessions > 0 {
+			rate := float64(class.SessionsAttended) / float64(class.TotalSessions) * 100
+			class.AttendanceRate = &rate
+		}
+		if class.IsCompleted() {
+			data.ClassesCompleted++
+			if class.CreditHours != nil {
+				data.TotalCreditHours += *class.CreditHours
+			}
+		}
+	}
+	for _, entry := range entries {
+		facilityName := entry.FacilityName
+		if facilityName == "" {
+			facilityName = entry.FacilityOther
+		}
+		data.Reflections = append(data.Reflections, TranscriptReflection{
+			ProgramName:    entry.ProgramName,
+			FacilityName:   facilityName,
+			CompletionDate: entry.CompletionDate,
+			Summary:        entry.Summary,
+			TopSkills:      entry.TopSkills,
+		})
+	}
+	return data
+}
+
+// Issue attaches the issued transcript, whose verification code and URL then print on every format
+func (data *TranscriptData) Issue(transcript *Transcript, verifyURL string) {
+	data.Transcript, data.VerifyURL = transcript, verifyURL
+	data.GeneratedAt = transcript.IssuedAt
+}
+
+// NewTranscript returns the record kept for a transcript issued from the data
+func (data *TranscriptData) NewTranscript(userID, issuedByID uint) *Transcript {
+	return &Transcript{
+		UserID:           userID,
+		ResidentName:     data.ResidentName,
+		DocID:            data.DocID,
+		FacilityName:     data.FacilityName,
+		ClassesCompleted: data.ClassesCompleted,
+		TotalCreditHours: data.TotalCreditHours,
+		IssuedAt:         time.Now(),
+		IssuedByID:       issuedByID,
+	}
+}
+
+var transcriptClassHeaders = []string{
+	"Program Name", "Class Name", "Facility", "Enrollment Status", "Enrolled Date",
+	"Completed Date", "Credit Hours", "Attendance Rate",
+}
+
+var transcriptReflectionHeaders = []string{"Program Name", "Completion Date", "Summary", "Top Skills"}
+
+func (data *TranscriptData) classValues(class TranscriptClass) []string {
+	creditHours := ""
+	if class.CreditHours != nil {
+		creditHours = strconv.FormatInt(*class.CreditHours, 10)
+	}
+	completedAt := class.CompletedAt
+	if completedAt == nil && class.IsCompleted() {
+		completedAt = class.EndedAt
+	}
+	return []string{
+		class.ProgramName,
+		class.ClassName,
+		class.FacilityName,
+		data.Locale.T(enrollmentStatusDisplay(class.EnrollmentStatus)),
+		formatReportDate(class.EnrolledAt),
+		formatReportDate(completedAt),
+		creditHours,
+		formatAttendanceRate(class.SessionsAttended, class.TotalSessions),
+	}
+}
+
+func (data *TranscriptData) verificationCode() string {
+	if data.Transcript == nil {
+		return ""
+	}
+	return data.Transcript.VerificationCode
+}
+
+/*
+ToCSV lays the transcript out for case managers to load into their own systems: a header block describing the
+resident and the verification code, then one row per class. Reflections are left out, they are written for people
+rather than for import.
+*/
+func (data *TranscriptData) ToCSV() [][]string {
+	locale := data.Locale
+	rows := [][]string{
+		{locale.T("Resident Name"), data.ResidentName},
+		{locale.T("DOC ID"), data.DocID},
+		{locale.T("Facility"), data.FacilityName},
+		{locale.T("Verification Code"), data.verificationCode()},
+		{locale.T("Verify At"), data.VerifyURL},
+		{locale.T("Issued"), data.GeneratedAt.Format("2006-01-02")},
+		{locale.T("Classes Completed"), strconv.Itoa(data.ClassesCompleted)},
+		{locale.T("Total Credit Hours"), strconv.FormatInt(data.TotalCreditHours, 10)},
+		{},
+		locale.TAll(transcriptClassHeaders),
+	}
+	for _, class := range data.Classes {
+		rows = append(rows, data.classValues(class))
+	}
+	return rows
+}
+
+// ToPDF lays the transcript out for the resident_transcript template, classes as rows and reflections as sub rows
+func (data *TranscriptData) ToPDF() PDFConfig {
+	locale := data.Locale
+	rows := make([][]string, 0, len(data.Classes))
+	for _, class := range data.Classes {
+		rows = append(rows, data.classValues(class))
+	}
+	subRows := make([][]string, 0, len(data.Reflections))
+	for _, reflection := range data.Reflections {
+		subRows = append(subRows, []string{
+			reflection.ProgramName,
+			reflection.CompletionDate,
+			reflection.Summary,
+			strings.Join(reflection.TopSkills, ", "),
+		})
+	}
+	return PDFConfig{
+		Title:      "Transcript",
+		Data:       rows,
+		SubRows:    subRows,
+		Locale:     locale,
+		Columns:    locale.TAll(transcriptClassHeaders),
+		SubColumns: locale.TAll(transcriptReflectionHeaders),
+		Params: map[string]string{
+			"ResidentLabel":     locale.T("Resident Name"),
+			"ResidentName":      data.ResidentName,
+			"DocIDLabel":        locale.T("DOC ID"),
+			"DocID":             data.DocID,
+			"FacilityLabel":     locale.T("Facility"),
+			"FacilityName":      data.FacilityName,
+			"SummaryLine":       locale.Tf("%d classes completed, %d credit hours earned", data.ClassesCompleted, data.TotalCreditHours),
+			"ReflectionsLabel":  locale.T("Reflections"),
+			"VerificationLabel": locale.T("Verification Code"),
+			"VerificationCode":  data.verificationCode(),
+			"VerifyURL":         data.VerifyURL,
+		},
+	}
+}
+
+// TranscriptVerification is all the public verification endpoint reveals about a transcript
+type TranscriptVerification struct {
+	Valid            bool       `json:"valid"`
+	ResidentName     string     `json:"resident_name,omitempty"`
+	FacilityName     string     `json:"facility_name,omitempty"`
+	ClassesCompleted int        `json:"classes_completed,omitempty"`
+	TotalCreditHours int64      `json:"total_credit_hours,omitempty"`
+	IssuedAt         *time.Time `json:"issued_at,omitempty"`
+}
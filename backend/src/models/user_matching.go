@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultMatchAutoConfirm = 0.90
+	DefaultMatchAmbiguous   = 0.50
+)
+
+// MatchThresholds returns the provider's user matcher thresholds, the defaults for providers that never set them
+func (provider *ProviderPlatform) MatchThresholds() (autoConfirm, ambiguous float64) {
+	autoConfirm, ambiguous = provider.MatchAutoConfirm, provider.MatchAmbiguous
+	if ValidateMatchThresholds(autoConfirm, ambiguous) != nil {
+		return DefaultMatchAutoConfirm, DefaultMatchAmbiguous
+	}
+	return autoConfirm, ambiguous
+}
+
+func ValidateMatchThresholds(autoConfirm, ambiguous float64) error {
+	if ambiguous <= 0 || autoConfirm > 1 || ambiguous >= autoConfirm {
+		return errors.New("match thresholds must satisfy 0 < match_ambiguous < match_auto_confirm <= 1")
+	}
+	return nil
+}
+
+// UserMatchSignal is something a provider user and a resident were found to have in common
+type UserMatchSignal string
+
+const (
+	MatchSignalDocID    UserMatchSignal = "doc_id"
+	MatchSignalUsername UserMatchSignal = "username"
+	MatchSignalEmail    UserMatchSignal = "email"
+	MatchSignalName     UserMatchSignal = "name"
+)
+
+type UserMatchReviewStatus string
+
+const (
+	UserMatchPending   UserMatchReviewStatus = "pending"
+	UserMatchConfirmed UserMatchReviewStatus = "confirmed"
+	UserMatchRejected  UserMatchReviewStatus = "rejected"
+)
+
+/*
+UserMatchReview is a provider user the matcher couldn't confirm on its own, kept until an admin confirms the suggested
+resident (or another one) or rejects the suggestion. A rejected review stays closed until the matcher suggests someone
+else for the provider user.
+*/
+type UserMatchReview struct {
+	DatabaseFields
+	ProviderPlatformID uint                  `gorm:"not null;uniqueIndex:idx_user_match_reviews_provider_external" json:"provider_platform_id"`
+	FacilityID         uint                  `gorm:"not null" json:"facility_id"`
+	ExternalUserID     string                `gorm:"size:255;not null;uniqueIndex:idx_user_match_reviews_provider_external" json:"external_user_id"`
+	ImportUser         ImportUser            `gorm:"serializer:json;type:jsonb;not null" json:"import_user"`
+	SuggestedUserID    *uint                 `json:"suggested_user_id"`
+	Score              float64               `gorm:"not null" json:"score"`
+	Signals            []UserMatchSignal     `gorm:"serializer:json;type:jsonb;not null" json:"signals"`
+	Status             UserMatchReviewStatus `gorm:"size:20;not null;default:pending" json:"status"`
+	// ResolvedUserID is the resident the provider user was mapped to, which the admin may have picked over the suggestion
+	ResolvedUserID *uint      `json:"resolved_user_id,omitempty"`
+	ResolvedByID   *uint      `json:"resolved_by_id,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+
+	ProviderPlatform *ProviderPlatform `json:"-" gorm:"foreignKey:ProviderPlatformID;constraint:OnDelete:CASCADE"`
+	SuggestedUser    *User             `json:"suggested_user,omitempty" gorm:"foreignKey:SuggestedUserID;constraint:OnDelete:SET NULL"`
+	ResolvedUser     *User             `json:"resolved_user,omitempty" gorm:"foreignKey:ResolvedUserID;constraint:OnDelete:SET NULL"`
+}
+
+func (UserMatchReview) TableName() string { return "user_match_reviews" }
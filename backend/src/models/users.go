@@ -1,6 +1,7 @@
 package models
 
 import (
+	"UnlockEdv2/src/i18n"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -66,6 +67,22 @@ type User struct {
 	FacilityID    uint       `json:"facility_id"`
 	DocID         string     `json:"doc_id" gorm:"column:doc_id;size:25"`
 	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	// Locale is the language of the reports, exports and messages the server writes for the user
+	Locale i18n.Locale `gorm:"size:10;not null;default:en" json:"locale"`
+	// KioskPinHash is the bcrypt hash of the PIN the resident checks in with on a shared class device
+	KioskPinHash           string `gorm:"size:255" json:"-"`
+	KioskPinFailedAttempts int    `gorm:"not null;default:0" json:"-"`
+	// ReleaseDate is the resident's projected release date in their facility's time zone, the release lifecycle job
+	// warns admins ahead of it and withdraws and deactivates the resident on it
+	ReleaseDate *string `gorm:"size:10" json:"release_date"`
+	// ReleaseWarnedFor is the release date admins were last warned about, so a changed date is warned about again
+	ReleaseWarnedFor *string `gorm:"size:10" json:"-"`
+	// ReleasePacketPending is set when the release lifecycle deactivated the resident but could not produce their
+	// release packet yet, the next run tries again
+	ReleasePacketPending bool    `gorm:"not null;default:false" json:"-"`
+	HousingUnit          *string `gorm:"size:50" json:"housing_unit,omitempty"`
+	// DateOfBirth is kept in ReleaseDateLayout so it never shifts with a time zone
+	DateOfBirth *string `gorm:"size:10" json:"date_of_birth,omitempty"`
 
 	/* foreign keys */
 	Mappings             []ProviderUserMapping `json:"mappings,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete CASCADE"`
@@ -83,6 +100,8 @@ type ImportUser struct {
 	Email            string `json:"email"`
 	ExternalUserID   string `json:"external_user_id"`
 	ExternalUsername string `json:"external_username"`
+	// DocID is the resident ID the provider keeps for the user (an ID number or org-defined ID), empty when it has none
+	DocID string `json:"doc_id,omitempty"`
 }
 
 func (User) TableName() string {
@@ -155,14 +174,19 @@ func (UserAccountHistory) TableName() string {
 type ActivityHistoryAction string
 
 const (
-	AccountCreation       ActivityHistoryAction = "account_creation"
-	FacilityTransfer      ActivityHistoryAction = "facility_transfer"
-	SetPassword           ActivityHistoryAction = "set_password"
-	ResetPassword         ActivityHistoryAction = "reset_password"
-	ProgClassHistory      ActivityHistoryAction = "progclass_history"
-	UserDeactivated       ActivityHistoryAction = "user_deactivated"
-	AttendanceRecorded    ActivityHistoryAction = "attendance_recorded"
-	LearningRecordDeleted ActivityHistoryAction = "learning_record_deleted"
+	AccountCreation             ActivityHistoryAction = "account_creation"
+	FacilityTransfer            ActivityHistoryAction = "facility_transfer"
+	SetPassword                 ActivityHistoryAction = "set_password"
+	ResetPassword               ActivityHistoryAction = "reset_password"
+	ProgClassHistory            ActivityHistoryAction = "progclass_history"
+	UserDeactivated             ActivityHistoryAction = "user_deactivated"
+	AttendanceRecorded          ActivityHistoryAction = "attendance_recorded"
+	LearningRecordDeleted       ActivityHistoryAction = "learning_record_deleted"
+	WaitlistPromoted            ActivityHistoryAction = "waitlist_promoted"
+	ReleasePacketGenerated      ActivityHistoryAction = "release_packet_generated"
+	ReleaseDateSet              ActivityHistoryAction = "release_date_set"
+	ReleaseWarningSent          ActivityHistoryAction = "release_warning_sent"
+	ReleaseEnrollmentsWithdrawn ActivityHistoryAction = "release_enrollments_withdrawn"
 )
 
 type ActivityHistoryResponse struct {
@@ -269,11 +293,19 @@ type ValidatedUserRow struct {
 	FirstName  string `json:"first_name"`
 	ResidentID string `json:"resident_id"`
 	Username   string `json:"username"`
+	// ReleaseDate is the projected release date in ReleaseDateLayout, empty when the file has none for the resident
+	ReleaseDate string `json:"release_date,omitempty"`
+	HousingUnit string `json:"housing_unit,omitempty"`
+	DateOfBirth string `json:"date_of_birth,omitempty"`
+	// PreferredLanguage is the locale the resident's reports and messages are written in
+	PreferredLanguage string `json:"preferred_language,omitempty"`
 }
 
 type InvalidUserRow struct {
 	ValidatedUserRow
 	ErrorReasons []string `json:"error_reasons"`
+	// Row is the row as it was uploaded, written back out in the error CSV of an upload read with a mapping profile
+	Row []string `json:"-"`
 }
 
 type BulkUploadResponse struct {
@@ -0,0 +1,190 @@
+package src
+
+import (
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// rosterReleaseStatuses are the status column values DOC exports use for someone who has left custody
+var rosterReleaseStatuses = []string{"released", "release", "discharged", "paroled", "parole", "inactive"}
+
+/*
+PlanRosterSync diffs a DOC roster file against our residents. The file is the whole population: a new DOC ID is an
+admission, a different facility is a transfer, and an active resident missing from the file, or whose status says they
+left custody, is a release. Rows that can't be acted on without a person, like an unknown facility or a deactivated
+resident reappearing, are reported as skipped. Release dates already past at the resident's facility are reported as
+skipped rather than set, only the status column or a missing row releases someone. Nothing is written, the returned
+changes are applied by the caller.
+*/
+func PlanRosterSync(records [][]string, residents []models.User, facilities []models.Facility, checkIdentity UserIdentityChecker, now time.Time) ([]models.RosterSyncChange, error) {
+	if len(records) == 0 {
+		return nil, errors.New("roster file is empty")
+	}
+	headerMap, err := ValidateCSVHeaders(records[0])
+	if err != nil {
+		return nil, err
+	}
+	if headerMap.FacilityIdx == -1 {
+		return nil, errors.New("roster file has no facility column")
+	}
+	// residents get their DOC ID as their username, as they do when no username is given in a bulk upload
+	validationMap := *headerMap
+	validationMap.UsernameIdx = -1
+
+	facilityIDs := make(map[string]uint, len(facilities))
+	facilityToday := make(map[uint]string, len(facilities))
+	for idx := range facilities {
+		facilityIDs[normalizeHeaderName(facilities[idx].Name)] = facilities[idx].ID
+		facilityToday[facilities[idx].ID] = facilities[idx].Today(now)
+	}
+	byDocID := make(map[string]*models.User, len(residents))
+	for idx := range residents {
+		byDocID[residents[idx].DocID] = &residents[idx]
+	}
+
+	changes := make([]models.RosterSyncChange, 0)
+	seenRows := make(map[string]int)
+	existingResidentIDs := make(map[string]int)
+	existingUsernames := make(map[string]int)
+	column := func(row []string, idx int) string {
+		if idx == -1 || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+	for i, row := range records[1:] {
+		rowNum := i + 2
+		change := models.RosterSyncChange{
+			RowNumber: rowNum,
+			DocID:     column(row, headerMap.ResidentIdx),
+			NameFirst: column(row, headerMap.FirstNameIdx),
+			NameLast:  column(row, headerMap.LastNameIdx),
+		}
+		if change.DocID == "" && change.NameFirst == "" && change.NameLast == "" && column(row, headerMap.FacilityIdx) == "" {
+			continue
+		}
+		skip := func(detail string) {
+			change.Action = models.RosterSyncSkip
+			change.Detail = detail
+			changes = append(changes, change)
+		}
+		if change.DocID == "" {
+			skip("missing DOC ID")
+			continue
+		}
+		// a resident on a row that can't be used has still been seen, so they aren't released for being missing
+		if firstRow, exists := seenRows[change.DocID]; exists {
+			skip(fmt.Sprintf("DOC ID is also on row %d", firstRow))
+			continue
+		}
+		seenRows[change.DocID] = rowNum
+		facilityName := column(row, headerMap.FacilityIdx)
+		facilityID, known := facilityIDs[normalizeHeaderName(facilityName)]
+		if !known {
+			skip(fmt.Sprintf("unknown facility %q", facilityName))
+			continue
+		}
+		change.ToFacilityID = &facilityID
+		releaseDate := column(row, headerMap.ReleaseDateIdx)
+		if releaseDate != "" {
+			parsed, err := models.ParseReleaseDate(releaseDate)
+			if err != nil {
+				skip(err.Error())
+				continue
+			}
+			change.ReleaseDate = parsed
+		}
+		leftCustody := slices.Contains(rosterReleaseStatuses, strings.ToLower(column(row, headerMap.StatusIdx)))
+		// a past date would have the release lifecycle release a resident DOC still has in custody
+		pastReleaseDate := ""
+		if change.ReleaseDate != "" && change.ReleaseDate < facilityToday[facilityID] {
+			pastReleaseDate, change.ReleaseDate = change.ReleaseDate, ""
+		}
+		skipPastReleaseDate := func() {
+			skipped := change
+			skipped.Action = models.RosterSyncSkip
+			skipped.Detail = fmt.Sprintf("release date %s is in the past, it was not set", pastReleaseDate)
+			changes = append(changes, skipped)
+		}
+
+		existing, found := byDocID[change.DocID]
+		if !found {
+			if leftCustody {
+				continue
+			}
+			_, invalid := ValidateUserRow(row, rowNum, &validationMap, existingResidentIDs, checkIdentity, existingUsernames, i18n.Default())
+			if invalid != nil {
+				skip(strings.Join(invalid.ErrorReasons, "; "))
+				continue
+			}
+			change.Action = models.RosterSyncAdmit
+			change.Detail = "new resident"
+			changes = append(changes, change)
+			if pastReleaseDate != "" {
+				skipPastReleaseDate()
+			}
+			continue
+		}
+		change.UserID = &existing.ID
+		if existing.DeactivatedAt != nil {
+			if !leftCustody {
+				skip("resident is deactivated but back on the roster, reactivate them by hand")
+			}
+			continue
+		}
+		if leftCustody {
+			change.Action = models.RosterSyncRelease
+			change.FromFacilityID = &existing.FacilityID
+			change.ToFacilityID = nil
+			change.Detail = "status is " + strings.ToLower(column(row, headerMap.StatusIdx))
+			changes = append(changes, change)
+			continue
+		}
+		if existing.FacilityID != facilityID {
+			transfer := change
+			transfer.Action = models.RosterSyncTransfer
+			transfer.FromFacilityID = &existing.FacilityID
+			transfer.ReleaseDate = ""
+			transfer.Detail = "moved to " + facilityName
+			changes = append(changes, transfer)
+		}
+		if pastReleaseDate != "" && (existing.ReleaseDate == nil || *existing.ReleaseDate != pastReleaseDate) {
+			skipPastReleaseDate()
+		}
+		if change.ReleaseDate != "" && (existing.ReleaseDate == nil || *existing.ReleaseDate != change.ReleaseDate) {
+			dateChange := change
+			dateChange.Action = models.RosterSyncReleaseDate
+			dateChange.ToFacilityID = nil
+			dateChange.Detail = "release date set"
+			if existing.ReleaseDate != nil {
+				dateChange.Detail = "release date moved from " + *existing.ReleaseDate
+			}
+			changes = append(changes, dateChange)
+		}
+	}
+
+	for idx := range residents {
+		resident := &residents[idx]
+		if resident.DeactivatedAt != nil {
+			continue
+		}
+		if _, seen := seenRows[resident.DocID]; seen {
+			continue
+		}
+		changes = append(changes, models.RosterSyncChange{
+			Action:         models.RosterSyncRelease,
+			DocID:          resident.DocID,
+			NameFirst:      resident.NameFirst,
+			NameLast:       resident.NameLast,
+			UserID:         &resident.ID,
+			FromFacilityID: &resident.FacilityID,
+			Detail:         "not on the roster",
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/models"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrUnreadableCalendar wraps errors from parsing the uploaded file so handlers can report them as bad input
+var ErrUnreadableCalendar = errors.New("unable to read calendar file")
+
+type CalendarImportService struct {
+	db *database.DB
+}
+
+func NewCalendarImportService(db *database.DB) *CalendarImportService {
+	return &CalendarImportService{db: db}
+}
+
+/*
+Import reads an uploaded .ics file and maps each VEVENT to a class (by SUMMARY), room (by LOCATION) and
+instructor (by ORGANIZER email or name, falling back to the class's current instructor); the caller's
+mappings keyed by UID take precedence. Every mapped series is checked for room and instructor conflicts.
+With dryRun nothing is written. Otherwise the series are created only when every item that is not skipped
+is free of errors and conflicts, so an import is all or nothing.
+*/
+func (svc *CalendarImportService) Import(facilityID uint, file io.Reader, mappings map[string]models.CalendarImportMapping, dryRun bool) (*models.CalendarImportResult, error) {
+	facility, err := svc.db.GetFacilityByID(int(facilityID))
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	events, err := ParseICalendar(file, location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnreadableCalendar, err)
+	}
+	lookups, err := svc.loadLookups(facilityID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CalendarImportResult{DryRun: dryRun, Items: make([]models.CalendarImportItem, 0, len(events))}
+	for idx, event := range events {
+		if event.UID == "" {
+			event.UID = fmt.Sprintf("event-%d", idx+1)
+		}
+		item := lookups.resolve(event, mappings[event.UID], location)
+		if item.IsImportable() {
+			conflicts, err := svc.db.CheckConflicts(&models.ConflictCheckRequest{
+				FacilityID:     facilityID,
+				RoomID:         *item.RoomID,
+				InstructorID:   *item.InstructorID,
+				RecurrenceRule: item.RecurrenceRule,
+				Duration:       item.Duration,
+			})
+			if err != nil {
+				item.Errors = append(item.Errors, "unable to check the schedule for conflicts: "+err.Error())
+			}
+			item.Conflicts = conflicts
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	for idx := range result.Items {
+		item := &result.Items[idx]
+		switch {
+		case item.Skipped:
+			result.Skipped++
+		case !item.IsImportable():
+			result.Rejected++
+		}
+	}
+	if dryRun || result.Rejected > 0 {
+		return result, nil
+	}
+	committed, err := svc.db.ImportClassEvents(facilityID, result.Items)
+	if err != nil {
+		return nil, err
+	}
+	if !committed {
+		result.Rejected = 0
+		for idx := range result.Items {
+			if !result.Items[idx].Skipped && !result.Items[idx].IsImportable() {
+				result.Rejected++
+			}
+		}
+		return result, nil
+	}
+	for _, item := range result.Items {
+		if item.EventID != nil {
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+type calendarImportLookups struct {
+	classesByName      map[string][]models.ProgramClass
+	classesByID        map[uint]models.ProgramClass
+	roomsByName        map[string]models.Room
+	roomsByID          map[uint]models.Room
+	instructorsByEmail map[string]models.Instructor
+	instructorsByName  map[string]models.Instructor
+	instructorsByID    map[uint]models.Instructor
+}
+
+func (svc *CalendarImportService) loadLookups(facilityID uint) (*calendarImportLookups, error) {
+	classes, err := svc.db.GetSchedulableClassesForFacility(facilityID)
+	if err != nil {
+		return nil, err
+	}
+	rooms, err := svc.db.GetRoomsForFacility(facilityID, true)
+	if err != nil {
+		return nil, err
+	}
+	instructors, err := svc.db.GetFacilityInstructors(int(facilityID))
+	if err != nil {
+		return nil, err
+	}
+	lookups := &calendarImportLookups{
+		classesByName:      make(map[string][]models.ProgramClass, len(classes)),
+		classesByID:        make(map[uint]models.ProgramClass, len(classes)),
+		roomsByName:        make(map[string]models.Room, len(rooms)),
+		roomsByID:          make(map[uint]models.Room, len(rooms)),
+		instructorsByEmail: make(map[string]models.Instructor, len(instructors)),
+		instructorsByName:  make(map[string]models.Instructor, len(instructors)),
+		instructorsByID:    make(map[uint]models.Instructor, len(instructors)),
+	}
+	for _, class := range classes {
+		key := normalizeImportName(class.Name)
+		lookups.classesByName[key] = append(lookups.classesByName[key], class)
+		lookups.classesByID[class.ID] = class
+	}
+	for _, room := range rooms {
+		lookups.roomsByName[normalizeImportName(room.Name)] = room
+		lookups.roomsByID[room.ID] = room
+	}
+	for _, instructor := range instructors {
+		if instructor.ID == 0 {
+			continue // the "Unassigned" placeholder
+		}
+		if instructor.Email != "" {
+			lookups.instructorsByEmail[strings.ToLower(instructor.Email)] = instructor
+		}
+		lookups.instructorsByName[normalizeImportName(instructor.NameFirst+" "+instructor.NameLast)] = instructor
+		lookups.instructorsByID[uint(instructor.ID)] = instructor
+	}
+	return lookups, nil
+}
+
+func (lookups *calendarImportLookups) resolve(event ICalEvent, mapping models.CalendarImportMapping, location *time.Location) models.CalendarImportItem {
+	item := models.CalendarImportItem{
+		UID:            event.UID,
+		Summary:        event.Summary,
+		Location:       event.Location,
+		Organizer:      event.OrganizerEmail,
+		CancelledDates: []string{},
+		Conflicts:      []models.RoomConflict{},
+		Errors:         []string{},
+	}
+	if item.Organizer == "" {
+		item.Organizer = event.OrganizerName
+	}
+	if mapping.Skip {
+		item.Skipped = true
+		return item
+	}
+	switch {
+	case event.RecurrenceID != "":
+		item.Errors = append(item.Errors, "changes to a single occurrence (RECURRENCE-ID) are not imported")
+		return item
+	case event.Status == "CANCELLED":
+		item.Errors = append(item.Errors, "event is cancelled in the calendar")
+		return item
+	case event.Start.IsZero():
+		item.Errors = append(item.Errors, "event has no DTSTART")
+		return item
+	case event.AllDay:
+		item.Errors = append(item.Errors, "all-day events cannot be scheduled as class sessions")
+		return item
+	case event.Duration <= 0:
+		item.Errors = append(item.Errors, "event has no duration")
+		return item
+	}
+
+	var class *models.ProgramClass
+	if mapping.ClassID != nil {
+		if found, ok := lookups.classesByID[*mapping.ClassID]; ok {
+			class = &found
+		} else {
+			item.Errors = append(item.Errors, fmt.Sprintf("class %d is not open for scheduling at this facility", *mapping.ClassID))
+		}
+	} else {
+		switch matches := lookups.classesByName[normalizeImportName(event.Summary)]; len(matches) {
+		case 0:
+			item.Errors = append(item.Errors, fmt.Sprintf("no open class named %q", event.Summary))
+		case 1:
+			class = &matches[0]
+		default:
+			item.Errors = append(item.Errors, fmt.Sprintf("%d classes are named %q, choose one", len(matches), event.Summary))
+		}
+	}
+	if class != nil {
+		item.ClassID = &class.ID
+		item.ClassName = class.Name
+	}
+
+	if mapping.RoomID != nil {
+		if room, ok := lookups.roomsByID[*mapping.RoomID]; ok {
+			item.RoomID, item.RoomName = &room.ID, room.Name
+		} else {
+			item.Errors = append(item.Errors, fmt.Sprintf("room %d is not at this facility", *mapping.RoomID))
+		}
+	} else if room, ok := lookups.roomsByName[normalizeImportName(event.Location)]; ok {
+		item.RoomID, item.RoomName = &room.ID, room.Name
+	} else if event.Location == "" {
+		item.Errors = append(item.Errors, "event has no LOCATION, choose a room")
+	} else {
+		item.Errors = append(item.Errors, fmt.Sprintf("no room named %q", event.Location))
+	}
+
+	instructor, found := lookups.matchInstructor(event, mapping, class)
+	if found {
+		id := uint(instructor.ID)
+		item.InstructorID = &id
+		item.InstructorName = strings.TrimSpace(instructor.NameFirst + " " + instructor.NameLast)
+	} else {
+		item.Errors = append(item.Errors, "no instructor could be matched, choose one")
+	}
+
+	localStart := event.Start.In(location)
+	rule := event.RRule
+	if rule == "" {
+		rule = "FREQ=DAILY;COUNT=1"
+	}
+	item.RecurrenceRule = fmt.Sprintf("DTSTART;TZID=Local:%s\nRRULE:%s", localStart.Format("20060102T150405"), rule)
+	item.Duration = event.Duration.String()
+	probe := models.ProgramClassEvent{RecurrenceRule: item.RecurrenceRule}
+	if _, err := probe.GetRRuleWithTimezone(location.String()); err != nil {
+		item.Errors = append(item.Errors, "recurrence rule is not supported: "+err.Error())
+	}
+	for _, exdate := range event.ExDates {
+		cancelled := exdate.In(location)
+		if exdate.Hour() == 0 && exdate.Minute() == 0 && exdate.Second() == 0 && (localStart.Hour() != 0 || localStart.Minute() != 0) {
+			// EXDATE;VALUE=DATE names a day, the session on that day starts at the series' time
+			cancelled = time.Date(exdate.Year(), exdate.Month(), exdate.Day(), localStart.Hour(), localStart.Minute(), localStart.Second(), 0, location)
+		}
+		item.CancelledAt = append(item.CancelledAt, cancelled)
+		item.CancelledDates = append(item.CancelledDates, cancelled.Format("2006-01-02"))
+	}
+	return item
+}
+
+func (lookups *calendarImportLookups) matchInstructor(event ICalEvent, mapping models.CalendarImportMapping, class *models.ProgramClass) (models.Instructor, bool) {
+	if mapping.InstructorID != nil {
+		instructor, ok := lookups.instructorsByID[*mapping.InstructorID]
+		return instructor, ok
+	}
+	if instructor, ok := lookups.instructorsByEmail[event.OrganizerEmail]; ok && event.OrganizerEmail != "" {
+		return instructor, true
+	}
+	if instructor, ok := lookups.instructorsByName[normalizeImportName(event.OrganizerName)]; ok && event.OrganizerName != "" {
+		return instructor, true
+	}
+	if class != nil && class.InstructorID != nil {
+		instructor, ok := lookups.instructorsByID[*class.InstructorID]
+		return instructor, ok
+	}
+	return models.Instructor{}, false
+}
+
+func normalizeImportName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/models"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type CertificateService struct {
+	db *database.DB
+}
+
+func NewCertificateService(db *database.DB) *CertificateService {
+	return &CertificateService{db: db}
+}
+
+/*
+IssueClassCertificates issues a certificate for every program completion in the class that does not have one yet,
+so it can be run when the class is completed and again after late completions without duplicating certificates.
+*/
+func (svc *CertificateService) IssueClassCertificates(classID uint) (*models.BulkCertificateResult, error) {
+	completions, err := svc.db.GetClassCompletions(classID)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.BulkCertificateResult{Issued: make([]models.CompletionCertificate, 0, len(completions))}
+	if len(completions) == 0 {
+		return result, nil
+	}
+	completionIDs := make([]uint, 0, len(completions))
+	for _, completion := range completions {
+		completionIDs = append(completionIDs, completion.ID)
+	}
+	existing, err := svc.db.GetCertificatesForCompletions(completionIDs)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := svc.db.GetCertificateTemplates()
+	if err != nil {
+		return nil, err
+	}
+	issuedAt := time.Now()
+	for idx := range completions {
+		completion := &completions[idx]
+		if _, ok := existing[completion.ID]; ok {
+			result.AlreadyIssued++
+			continue
+		}
+		result.Issued = append(result.Issued, newCertificate(completion, templates, issuedAt))
+	}
+	if err := svc.db.CreateCertificates(result.Issued); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func newCertificate(completion *models.ProgramCompletion, templates []models.CertificateTemplate, issuedAt time.Time) models.CompletionCertificate {
+	template := resolveCertificateTemplate(completion, templates)
+	certificate := models.CompletionCertificate{
+		ProgramCompletionID: completion.ID,
+		UserID:              completion.UserID,
+		ProgramID:           completion.ProgramID,
+		ProgramClassID:      completion.ProgramClassID,
+		TemplateName:        models.DefaultCertificateTemplate,
+		Heading:             "Certificate of Completion",
+		Signatory:           completion.ProgramOwner,
+		ProgramName:         completion.ProgramName,
+		ClassName:           completion.ProgramClassName,
+		FacilityName:        completion.FacilityName,
+		CreditType:          completion.CreditType,
+		IssuedAt:            issuedAt,
+	}
+	if completion.User != nil {
+		certificate.ResidentName = strings.TrimSpace(fmt.Sprintf("%s %s", completion.User.NameFirst, completion.User.NameLast))
+	}
+	if template != nil {
+		certificate.TemplateName = template.TemplateName
+		if template.Heading != "" {
+			certificate.Heading = template.Heading
+		}
+		if template.Signatory != "" {
+			certificate.Signatory = template.Signatory
+		}
+	}
+	return certificate
+}
+
+// resolveCertificateTemplate prefers a template for the completion's program over one for any of its credit types
+func resolveCertificateTemplate(completion *models.ProgramCompletion, templates []models.CertificateTemplate) *models.CertificateTemplate {
+	creditTypes := strings.Split(completion.CreditType, ",")
+	var byCreditType *models.CertificateTemplate
+	for idx := range templates {
+		template := &templates[idx]
+		if template.ProgramID != nil && *template.ProgramID == completion.ProgramID {
+			return template
+		}
+		if byCreditType == nil && template.CreditType != "" && slices.Contains(creditTypes, string(template.CreditType)) {
+			byCreditType = template
+		}
+	}
+	return byCreditType
+}
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"UnlockEdv2/src/models"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	icalProdID     = "-//UnlockEd//UnlockEdv2 Calendar//EN"
+	icalUIDDomain  = "unlockedv2"
+	icalTimeLayout = "20060102T150405Z"
+	icalLineLimit  = 75
+)
+
+/*
+WriteICalendar renders expanded class event instances (as returned by GetFacilityCalendar) as an
+RFC 5545 calendar. Each class event series becomes one VEVENT whose instances are listed as RDATEs,
+cancelled sessions are excluded with EXDATE, and rescheduled or room/instructor overrides are written
+as separate VEVENTs sharing the series UID with a RECURRENCE-ID pointing at the session they replace.
+All times are written in UTC, since the instances have already been resolved in the facility timezone.
+*/
+func WriteICalendar(w io.Writer, calendarName, timezone string, events []models.FacilityProgramClassEvent, stamp time.Time) error {
+	ic := &icalWriter{w: bufio.NewWriter(w), stamp: stamp.UTC()}
+	ic.line("BEGIN:VCALENDAR")
+	ic.line("VERSION:2.0")
+	ic.line("PRODID:" + icalProdID)
+	ic.line("CALSCALE:GREGORIAN")
+	ic.line("METHOD:PUBLISH")
+	ic.line("X-WR-CALNAME:" + escapeICalText(calendarName))
+	if timezone != "" {
+		ic.line("X-WR-TIMEZONE:" + timezone)
+	}
+	ic.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	ic.line("X-PUBLISHED-TTL:PT1H")
+
+	order := make([]uint, 0)
+	byEvent := make(map[uint][]models.FacilityProgramClassEvent)
+	for _, event := range events {
+		if event.IsCanvasEvent || event.StartTime == nil || event.EndTime == nil {
+			continue
+		}
+		if _, ok := byEvent[event.ID]; !ok {
+			order = append(order, event.ID)
+		}
+		byEvent[event.ID] = append(byEvent[event.ID], event)
+	}
+	for _, eventID := range order {
+		ic.writeSeries(byEvent[eventID])
+	}
+	ic.line("END:VCALENDAR")
+	if ic.err != nil {
+		return ic.err
+	}
+	return ic.w.Flush()
+}
+
+type icalWriter struct {
+	w     *bufio.Writer
+	stamp time.Time
+	err   error
+}
+
+type icalChangedInstance struct {
+	event        models.FacilityProgramClassEvent
+	recurrenceID time.Time
+}
+
+func (ic *icalWriter) writeSeries(instances []models.FacilityProgramClassEvent) {
+	slices.SortFunc(instances, func(a, b models.FacilityProgramClassEvent) int {
+		return a.StartTime.Compare(*b.StartTime)
+	})
+	first := instances[0]
+	seriesCancelled := first.ProgramClassEvent.IsCancelled
+	uid := fmt.Sprintf("program-class-event-%d@%s", first.ID, icalUIDDomain)
+
+	var (
+		template   *models.FacilityProgramClassEvent
+		rdates     []time.Time
+		exdates    []time.Time
+		changed    []icalChangedInstance
+		standalone []models.FacilityProgramClassEvent
+	)
+	for idx := range instances {
+		instance := instances[idx]
+		switch {
+		case instance.IsCancelled && !seriesCancelled:
+			if original, ok := originalInstanceStart(instance); ok {
+				rdates = append(rdates, original)
+				exdates = append(exdates, original)
+			}
+		case instance.IsOverride:
+			original, ok := originalInstanceStart(instance)
+			if !ok {
+				standalone = append(standalone, instance)
+				continue
+			}
+			rdates = append(rdates, original)
+			changed = append(changed, icalChangedInstance{event: instance, recurrenceID: original})
+		default:
+			if template == nil {
+				template = &instances[idx]
+			}
+			rdates = append(rdates, *instance.StartTime)
+		}
+	}
+
+	if len(rdates) == 0 {
+		for _, instance := range standalone {
+			ic.writeStandalone(instance)
+		}
+		return
+	}
+	rdates = uniqueSortedTimes(rdates)
+	exdates = uniqueSortedTimes(exdates)
+
+	if template == nil {
+		// no session in range runs as scheduled, so describe the series from the first one
+		template = &instances[0]
+	}
+	ic.line("BEGIN:VEVENT")
+	ic.line("UID:" + uid)
+	ic.line("DTSTAMP:" + formatICalTime(ic.stamp))
+	ic.line("DTSTART:" + formatICalTime(rdates[0]))
+	ic.line("DURATION:" + formatICalDuration(template.EndTime.Sub(*template.StartTime)))
+	if len(rdates) > 1 {
+		ic.line("RDATE:" + joinICalTimes(rdates[1:]))
+	}
+	if len(exdates) > 0 && !seriesCancelled {
+		ic.line("EXDATE:" + joinICalTimes(exdates))
+	}
+	seriesRoom := template.Room
+	if template.OriginalRoom != "" {
+		seriesRoom = template.OriginalRoom
+	}
+	ic.writeDetails(template, seriesRoom, seriesCancelled)
+	ic.line("END:VEVENT")
+
+	for _, instance := range changed {
+		ic.line("BEGIN:VEVENT")
+		ic.line("UID:" + uid)
+		ic.line("DTSTAMP:" + formatICalTime(ic.stamp))
+		ic.line("RECURRENCE-ID:" + formatICalTime(instance.recurrenceID))
+		ic.line("DTSTART:" + formatICalTime(*instance.event.StartTime))
+		ic.line("DTEND:" + formatICalTime(*instance.event.EndTime))
+		ic.writeDetails(&instance.event, instance.event.Room, instance.event.IsCancelled)
+		ic.line("END:VEVENT")
+	}
+	for _, instance := range standalone {
+		ic.writeStandalone(instance)
+	}
+}
+
+func (ic *icalWriter) writeStandalone(instance models.FacilityProgramClassEvent) {
+	ic.line("BEGIN:VEVENT")
+	if instance.OverrideID != 0 {
+		ic.line(fmt.Sprintf("UID:program-class-event-%d-override-%d@%s", instance.ID, instance.OverrideID, icalUIDDomain))
+	} else {
+		ic.line(fmt.Sprintf("UID:program-class-event-%d-%s@%s", instance.ID, formatICalTime(*instance.StartTime), icalUIDDomain))
+	}
+	ic.line("DTSTAMP:" + formatICalTime(ic.stamp))
+	ic.line("DTSTART:" + formatICalTime(*instance.StartTime))
+	ic.line("DTEND:" + formatICalTime(*instance.EndTime))
+	ic.writeDetails(&instance, instance.Room, instance.IsCancelled)
+	ic.line("END:VEVENT")
+}
+
+func (ic *icalWriter) writeDetails(event *models.FacilityProgramClassEvent, room string, cancelled bool) {
+	ic.line("SUMMARY:" + escapeICalText(event.ClassName))
+	if room != "" {
+		ic.line("LOCATION:" + escapeICalText(room))
+	}
+	description := make([]string, 0, 3)
+	if event.ProgramName != "" {
+		description = append(description, "Program: "+event.ProgramName)
+	}
+	if event.InstructorName != "" {
+		description = append(description, "Instructor: "+event.InstructorName)
+	}
+	if cancelled && event.Reason != nil && *event.Reason != "" {
+		description = append(description, "Cancelled: "+*event.Reason)
+	}
+	if len(description) > 0 {
+		ic.line("DESCRIPTION:" + escapeICalText(strings.Join(description, "\n")))
+	}
+	if cancelled {
+		ic.line("STATUS:CANCELLED")
+	} else {
+		ic.line("STATUS:CONFIRMED")
+	}
+	ic.line("TRANSP:OPAQUE")
+}
+
+// line writes a content line, folding it at 75 octets as required by RFC 5545 section 3.1
+func (ic *icalWriter) line(content string) {
+	if ic.err != nil {
+		return
+	}
+	var sb strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > icalLineLimit {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += size
+	}
+	sb.WriteString("\r\n")
+	_, ic.err = ic.w.WriteString(sb.String())
+}
+
+// originalInstanceStart returns the scheduled start of the session an instance stands in for
+func originalInstanceStart(instance models.FacilityProgramClassEvent) (time.Time, bool) {
+	if instance.LinkedOverrideEvent != nil {
+		if instance.LinkedOverrideEvent.StartTime == nil {
+			return time.Time{}, false
+		}
+		return *instance.LinkedOverrideEvent.StartTime, true
+	}
+	return *instance.StartTime, true
+}
+
+func uniqueSortedTimes(times []time.Time) []time.Time {
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(times, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+func joinICalTimes(times []time.Time) string {
+	formatted := make([]string, 0, len(times))
+	for _, t := range times {
+		formatted = append(formatted, formatICalTime(t))
+	}
+	return strings.Join(formatted, ",")
+}
+
+func formatICalTime(t time.Time) string {
+	return t.UTC().Format(icalTimeLayout)
+}
+
+func formatICalDuration(d time.Duration) string {
+	if d <= 0 {
+		return "PT0S"
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	var sb strings.Builder
+	sb.WriteString("PT")
+	if hours > 0 {
+		fmt.Fprintf(&sb, "%dH", hours)
+	}
+	if minutes > 0 || hours == 0 {
+		fmt.Fprintf(&sb, "%dM", minutes)
+	}
+	return sb.String()
+}
+
+func escapeICalText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+/*
+ICalEvent is a VEVENT read from an uploaded calendar. Times carrying a TZID are resolved in that zone,
+floating times in the location passed to ParseICalendar, and DATE values are flagged as all-day.
+*/
+type ICalEvent struct {
+	UID            string
+	Summary        string
+	Location       string
+	OrganizerEmail string
+	OrganizerName  string
+	Status         string
+	Start          time.Time
+	End            time.Time
+	Duration       time.Duration
+	AllDay         bool
+	RRule          string
+	ExDates        []time.Time
+	RecurrenceID   string
+}
+
+// ParseICalendar reads every VEVENT from an RFC 5545 calendar, ignoring other components such as VTIMEZONE
+func ParseICalendar(r io.Reader, floating *time.Location) ([]ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		events  []ICalEvent
+		current *ICalEvent
+		depth   int
+		sawCal  bool
+	)
+	for idx, raw := range lines {
+		name, params, value, ok := parseICalLine(raw)
+		if !ok {
+			return nil, fmt.Errorf("line %d is not a valid content line", idx+1)
+		}
+		switch name {
+		case "BEGIN":
+			depth++
+			if strings.EqualFold(value, "VCALENDAR") {
+				sawCal = true
+			}
+			if strings.EqualFold(value, "VEVENT") {
+				current = &ICalEvent{}
+			}
+			continue
+		case "END":
+			depth--
+			if strings.EqualFold(value, "VEVENT") && current != nil {
+				if current.End.IsZero() && current.Duration == 0 && !current.AllDay {
+					current.End = current.Start
+				}
+				if current.Duration == 0 && !current.End.IsZero() {
+					current.Duration = current.End.Sub(current.Start)
+				}
+				events = append(events, *current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeICalText(value)
+		case "LOCATION":
+			current.Location = unescapeICalText(value)
+		case "STATUS":
+			current.Status = strings.ToUpper(value)
+		case "ORGANIZER":
+			current.OrganizerName = strings.Trim(params["CN"], `"`)
+			if email, found := strings.CutPrefix(strings.ToLower(value), "mailto:"); found {
+				current.OrganizerEmail = email
+			}
+		case "DTSTART":
+			start, allDay, err := parseICalTime(value, params, floating)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTSTART: %w", idx+1, err)
+			}
+			current.Start, current.AllDay = start, allDay
+		case "DTEND":
+			end, _, err := parseICalTime(value, params, floating)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTEND: %w", idx+1, err)
+			}
+			current.End = end
+		case "DURATION":
+			duration, err := parseICalDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DURATION: %w", idx+1, err)
+			}
+			current.Duration = duration
+		case "RRULE":
+			current.RRule = value
+		case "EXDATE":
+			for _, part := range strings.Split(value, ",") {
+				exdate, _, err := parseICalTime(part, params, floating)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid EXDATE: %w", idx+1, err)
+				}
+				current.ExDates = append(current.ExDates, exdate)
+			}
+		case "RECURRENCE-ID":
+			current.RecurrenceID = value
+		}
+	}
+	if !sawCal {
+		return nil, errors.New("file is not an iCalendar file")
+	}
+	if depth != 0 || current != nil {
+		return nil, errors.New("calendar has unbalanced BEGIN/END components")
+	}
+	return events, nil
+}
+
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICalLine splits "NAME;PARAM=VALUE:value" into its parts, upper-casing the name and parameter keys
+func parseICalLine(line string) (string, map[string]string, string, bool) {
+	inQuotes := false
+	colon := -1
+	for idx, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = idx
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+	head, value := line[:colon], line[colon+1:]
+	segments := strings.Split(head, ";")
+	params := make(map[string]string, len(segments)-1)
+	for _, segment := range segments[1:] {
+		key, val, _ := strings.Cut(segment, "=")
+		params[strings.ToUpper(key)] = val
+	}
+	return strings.ToUpper(segments[0]), params, value, true
+}
+
+func parseICalTime(value string, params map[string]string, floating *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, floating)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalTimeLayout, value)
+		return t, false, err
+	}
+	loc := floating
+	if tzid := strings.Trim(params["TZID"], `"`); tzid != "" {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseICalDuration handles the dur-value grammar from RFC 5545 section 3.3.6, e.g. PT1H30M or P1W
+func parseICalDuration(value string) (time.Duration, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("duration %q must start with P", value)
+	}
+	var (
+		total  time.Duration
+		number int
+		digits bool
+		inTime bool
+	)
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			digits = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !digits {
+			return 0, fmt.Errorf("duration %q is malformed", value)
+		}
+		switch {
+		case r == 'W':
+			total += time.Duration(number) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(number) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(number) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(number) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(number) * time.Second
+		default:
+			return 0, fmt.Errorf("duration %q is malformed", value)
+		}
+		number, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("duration %q is malformed", value)
+	}
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+func unescapeICalText(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return replacer.Replace(text)
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+var (
+	ErrNoSessionOnDate    = errors.New("the event has no session on that date")
+	ErrSessionCancelled   = errors.New("the session on that date is cancelled")
+	ErrNotASubstitute     = errors.New("instructor cannot substitute at this facility")
+	ErrAlreadyTeaching    = errors.New("instructor is already teaching this session")
+	ErrEventNotInClass    = errors.New("event does not belong to this class")
+	ErrInvalidSessionDate = errors.New("session date must be in YYYY-MM-DD format")
+)
+
+type SubstituteService struct {
+	db *database.DB
+}
+
+func NewSubstituteService(db *database.DB) *SubstituteService {
+	return &SubstituteService{db: db}
+}
+
+// classSession is one occurrence of an event, with the one-off override that already replaces it, if any
+type classSession struct {
+	event        *models.ProgramClassEvent
+	facilityID   uint
+	timezone     string
+	start        time.Time
+	duration     time.Duration
+	rrule        string
+	roomID       *uint
+	instructorID *uint
+	override     *models.ProgramClassEventOverride
+}
+
+func (session *classSession) conflictRequest(instructorID uint) *models.ConflictCheckRequest {
+	return &models.ConflictCheckRequest{
+		FacilityID:     session.facilityID,
+		InstructorID:   instructorID,
+		RecurrenceRule: session.rrule,
+		Duration:       session.duration.String(),
+		ExcludeEventID: &session.event.ID,
+	}
+}
+
+/*
+FindSubstitutes lists the instructors at the class's facility who could teach the event's session on date
+(YYYY-MM-DD): everyone except the session's current instructor who has no double-booking, availability or
+weekly hour conflict, as reported by the same checks that guard scheduling.
+*/
+func (svc *SubstituteService) FindSubstitutes(classID, eventID uint, date string) ([]models.Instructor, error) {
+	session, err := svc.findSession(classID, eventID, date)
+	if err != nil {
+		return nil, err
+	}
+	instructors, err := svc.db.GetFacilityInstructors(int(session.facilityID))
+	if err != nil {
+		return nil, err
+	}
+	available := make([]models.Instructor, 0, len(instructors))
+	for _, instructor := range instructors {
+		if instructor.ID == 0 || (session.instructorID != nil && uint(instructor.ID) == *session.instructorID) {
+			continue
+		}
+		conflicts, err := svc.db.CheckConflicts(session.conflictRequest(uint(instructor.ID)))
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			available = append(available, instructor)
+		}
+	}
+	return available, nil
+}
+
+/*
+AssignSubstitute hands the event's session on req.Date to req.InstructorID as a one-off override, keeping any room
+or time already overridden for that session, and records the coverage against the session's original instructor.
+When the substitute has conflicts nothing is saved and the conflicts are returned. Assigning the original instructor
+hands the session back and the returned coverage is not saved (its ID is zero).
+*/
+func (svc *SubstituteService) AssignSubstitute(args *models.QueryContext, classID, eventID uint, req *models.AssignSubstituteRequest) (*models.SessionCoverage, []models.RoomConflict, error) {
+	session, err := svc.findSession(classID, eventID, req.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.instructorID != nil && *session.instructorID == req.InstructorID {
+		return nil, nil, ErrAlreadyTeaching
+	}
+	if name, err := svc.db.GetInstructorNameByID(req.InstructorID, session.facilityID); err != nil || name == "" {
+		return nil, nil, ErrNotASubstitute
+	}
+	conflicts, err := svc.db.CheckConflicts(session.conflictRequest(req.InstructorID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, conflicts, nil
+	}
+	existing, err := svc.db.GetSessionCoverage(eventID, req.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+	originalInstructorID := session.instructorID
+	if existing != nil {
+		originalInstructorID = existing.OriginalInstructorID
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	override := &models.ProgramClassEventOverride{
+		EventID:       eventID,
+		ClassID:       classID,
+		Duration:      session.duration.String(),
+		OverrideRrule: session.rrule,
+		RoomID:        session.roomID,
+		Reason:        reason,
+		InstructorID:  &req.InstructorID,
+	}
+	if session.override != nil {
+		override.ID = session.override.ID
+		override.LinkedOverrideEventID = session.override.LinkedOverrideEventID
+		if reason == "" {
+			override.Reason = session.override.Reason
+		}
+	}
+	overrideArgs := *args
+	overrideArgs.Timezone = session.timezone
+	coverage := &models.SessionCoverage{
+		EventID:                eventID,
+		ClassID:                classID,
+		SessionDate:            req.Date,
+		StartsAt:               session.start,
+		EndsAt:                 session.start.Add(session.duration),
+		OriginalInstructorID:   originalInstructorID,
+		SubstituteInstructorID: req.InstructorID,
+		Reason:                 reason,
+	}
+	if err := svc.db.AssignSessionSubstitute(&overrideArgs, override, coverage); err != nil {
+		return nil, nil, err
+	}
+	return coverage, nil, nil
+}
+
+// findSession resolves the event's session on date, preferring an override that moves a session onto that day
+func (svc *SubstituteService) findSession(classID, eventID uint, date string) (*classSession, error) {
+	event, err := svc.db.GetEventById(int(eventID))
+	if err != nil {
+		return nil, err
+	}
+	if event.ClassID != classID {
+		return nil, ErrEventNotInClass
+	}
+	class, err := svc.db.GetClassByID(int(classID))
+	if err != nil {
+		return nil, err
+	}
+	facility, err := svc.db.GetFacilityByID(int(class.FacilityID))
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(facility.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	dayStart, err := time.ParseInLocation("2006-01-02", date, location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSessionDate, err)
+	}
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	session := &classSession{
+		event:        event,
+		facilityID:   class.FacilityID,
+		timezone:     facility.Timezone,
+		roomID:       event.RoomID,
+		instructorID: event.InstructorID,
+	}
+
+	cancelled := false
+	for idx := range event.Overrides {
+		override := &event.Overrides[idx]
+		rule, err := rrule.StrToRRule(override.OverrideRrule)
+		if err != nil {
+			continue
+		}
+		occurrences := rule.Between(dayStart, dayEnd.Add(-time.Second), true)
+		if len(occurrences) == 0 {
+			continue
+		}
+		if override.IsCancelled {
+			cancelled = true
+			continue
+		}
+		duration, err := time.ParseDuration(override.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for override %d: %w", override.ID, err)
+		}
+		session.start = occurrences[0].In(location)
+		session.duration = duration
+		session.rrule = override.OverrideRrule
+		session.override = override
+		if override.RoomID != nil {
+			session.roomID = override.RoomID
+		}
+		if override.InstructorID != nil {
+			session.instructorID = override.InstructorID
+		}
+		return session, nil
+	}
+	if cancelled {
+		return nil, ErrSessionCancelled
+	}
+
+	rule, err := event.GetRRuleWithTimezone(facility.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	occurrences := rule.Between(dayStart, dayEnd.Add(-time.Second), true)
+	if len(occurrences) == 0 {
+		return nil, ErrNoSessionOnDate
+	}
+	duration, err := time.ParseDuration(event.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration for event %d: %w", event.ID, err)
+	}
+	session.start = occurrences[0].In(location)
+	session.duration = duration
+	// the same single-occurrence form the session edit endpoint writes, so a later edit updates this override
+	session.rrule = fmt.Sprintf("DTSTART;TZID=%s:%s\nRRULE:FREQ=DAILY;COUNT=1", facility.Timezone, session.start.Format("20060102T150405"))
+	return session, nil
+}
@@ -0,0 +1,179 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttendanceRiskRules(t *testing.T) {
+	settings := models.DefaultAttendanceRiskSettings(1)
+	settings.ConsecutiveAbsences = 3
+	settings.MinAttendanceRate = 60
+	settings.RateWindowSessions = 5
+	present, absent, excused := models.Present, models.Absent_Unexcused, models.Absent_Excused
+	rules := func(history ...models.Attendance) []models.AttendanceRiskRule {
+		found := make([]models.AttendanceRiskRule, 0)
+		for _, finding := range settings.Evaluate(history) {
+			found = append(found, finding.Rule)
+		}
+		return found
+	}
+
+	require.Empty(t, rules(absent, absent, present, present, present))
+	require.Equal(t, []models.AttendanceRiskRule{models.ConsecutiveAbsencesRisk}, rules(absent, excused, absent),
+		"excused absences count towards the streak and the rate waits for a full window")
+	require.Equal(t, []models.AttendanceRiskRule{models.LowAttendanceRateRisk}, rules(present, absent, absent, present, absent, present))
+	require.Equal(t, []models.AttendanceRiskRule{models.ConsecutiveAbsencesRisk, models.LowAttendanceRateRisk}, rules(absent, absent, absent, present, present))
+
+	settings.ConsecutiveAbsences = 0
+	require.Equal(t, []models.AttendanceRiskRule{models.LowAttendanceRateRisk}, rules(absent, absent, absent, present, present))
+	settings.Enabled = false
+	require.Empty(t, rules(absent, absent, absent, absent, absent))
+}
+
+func TestAttendanceRiskAlerts(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Risk Facility")
+	require.NoError(t, err)
+	otherFacility, err := env.CreateTestFacility("Other Risk Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("riskadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	otherAdmin, err := env.CreateTestUser("otherriskadmin", models.FacilityAdmin, otherFacility.ID, "")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("riskresident", models.Student, facility.ID, "")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID, TimeZone: facility.Timezone}
+	otherClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: otherAdmin.ID, FacilityID: otherFacility.ID, TimeZone: otherFacility.Timezone}
+
+	program, err := env.CreateTestProgram("Risk Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, nil)
+	require.NoError(t, err)
+
+	t.Run("Thresholds default until a facility saves its own", func(t *testing.T) {
+		settings := NewRequest[models.AttendanceRiskSettings](env.Client, t, http.MethodGet, "/api/attendance-risk/settings", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.DefaultAttendanceRiskSettings(facility.ID), settings)
+
+		NewRequest[any](env.Client, t, http.MethodPut, "/api/attendance-risk/settings", map[string]any{
+			"enabled":              true,
+			"consecutive_absences": 2,
+			"min_attendance_rate":  120,
+			"rate_window_sessions": 8,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		NewRequest[models.AttendanceRiskSettings](env.Client, t, http.MethodPut, "/api/attendance-risk/settings", map[string]any{
+			"enabled":              false,
+			"consecutive_absences": 2,
+			"min_attendance_rate":  50,
+			"rate_window_sessions": 8,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		settings = NewRequest[models.AttendanceRiskSettings](env.Client, t, http.MethodGet, "/api/attendance-risk/settings", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.False(t, settings.Enabled)
+		require.Equal(t, 2, settings.ConsecutiveAbsences)
+		require.Equal(t, 8, settings.RateWindowSessions)
+
+		other := NewRequest[models.AttendanceRiskSettings](env.Client, t, http.MethodGet, "/api/attendance-risk/settings", nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.True(t, other.Enabled, "thresholds are per facility")
+	})
+
+	newAlert := func(rule models.AttendanceRiskRule) *models.AttendanceRiskAlert {
+		alert := &models.AttendanceRiskAlert{
+			FacilityID:          facility.ID,
+			UserID:              resident.ID,
+			ClassID:             class.ID,
+			Rule:                rule,
+			Status:              models.RiskAlertOpen,
+			Detail:              "absent from the last 3 sessions",
+			ConsecutiveAbsences: 3,
+			LastSessionDate:     "2026-10-01",
+			LastEvaluatedAt:     time.Now(),
+		}
+		require.NoError(t, env.DB.Create(alert).Error)
+		return alert
+	}
+	streakAlert := newAlert(models.ConsecutiveAbsencesRisk)
+	rateAlert := newAlert(models.LowAttendanceRateRisk)
+	alertPath := func(alert *models.AttendanceRiskAlert) string {
+		return fmt.Sprintf("/api/attendance-risk/alerts/%d", alert.ID)
+	}
+	listAlerts := func(query string) []models.AttendanceRiskAlert {
+		return NewRequest[[]models.AttendanceRiskAlert](env.Client, t, http.MethodGet, "/api/attendance-risk/alerts"+query, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+	}
+
+	t.Run("Alerts link to the resident's note timeline", func(t *testing.T) {
+		alerts := listAlerts("")
+		require.Len(t, alerts, 2)
+		require.Equal(t, fmt.Sprintf("/api/users/%d/notes", resident.ID), alerts[0].NotesPath)
+		require.NotNil(t, alerts[0].User)
+		require.Equal(t, resident.ID, alerts[0].User.ID)
+
+		NewRequest[any](env.Client, t, http.MethodGet, "/api/attendance-risk/alerts?status=bogus", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Admins from another facility cannot handle the alert", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPatch, alertPath(streakAlert), map[string]any{"action": "acknowledge"}).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("Acknowledging and snoozing", func(t *testing.T) {
+		alert := NewRequest[models.AttendanceRiskAlert](env.Client, t, http.MethodPatch, alertPath(streakAlert), map[string]any{
+			"action": "acknowledge",
+			"note":   "Spoke with the resident about missed sessions",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.RiskAlertAcknowledged, alert.Status)
+		require.NotNil(t, alert.NoteID)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, alertPath(rateAlert), map[string]any{
+			"action":        "snooze",
+			"snoozed_until": "2020-01-01",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		alert = NewRequest[models.AttendanceRiskAlert](env.Client, t, http.MethodPatch, alertPath(rateAlert), map[string]any{
+			"action":        "snooze",
+			"snoozed_until": time.Now().AddDate(0, 0, 7).Format("2006-01-02"),
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.RiskAlertSnoozed, alert.Status)
+		require.NotNil(t, alert.SnoozedUntil)
+		require.Nil(t, alert.NoteID)
+
+		require.Len(t, listAlerts("?status=snoozed"), 1)
+	})
+
+	t.Run("Resolving requires a note that lands on the resident's timeline", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPatch, alertPath(rateAlert), map[string]any{"action": "resolve"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		alert := NewRequest[models.AttendanceRiskAlert](env.Client, t, http.MethodPatch, alertPath(rateAlert), map[string]any{
+			"action": "resolve",
+			"note":   "Schedule conflict with work detail, now resolved",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.RiskAlertResolved, alert.Status)
+		require.Nil(t, alert.SnoozedUntil)
+		require.NotNil(t, alert.ResolvedByID)
+		require.Equal(t, admin.ID, *alert.ResolvedByID)
+
+		notes := NewRequest[[]models.UserNoteResponse](env.Client, t, http.MethodGet, alert.NotesPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, notes, 2)
+		require.Equal(t, "Schedule conflict with work detail, now resolved", notes[0].Note)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, alertPath(rateAlert), map[string]any{"action": "acknowledge"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		require.Len(t, listAlerts(""), 1)
+		require.Len(t, listAlerts("?status=all"), 2)
+	})
+}
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineAttendanceSync(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Sync Facility")
+	require.NoError(t, err)
+	instructor, err := env.CreateTestUser("syncinstructor", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	otherAdmin, err := env.CreateTestUser("syncotheradmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	first, err := env.CreateTestUser("syncfirst", models.Student, facility.ID, "SYNC001")
+	require.NoError(t, err)
+	second, err := env.CreateTestUser("syncsecond", models.Student, facility.ID, "SYNC002")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Sync Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	teacher, err := env.CreateTestInstructor(facility.ID, "sync")
+	require.NoError(t, err)
+	class, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	otherClass, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	event, err := env.CreateTestEvent(class.ID, "", teacher.ID)
+	require.NoError(t, err)
+	otherEvent, err := env.CreateTestEvent(otherClass.ID, "", teacher.ID)
+	require.NoError(t, err)
+	for _, student := range []*models.User{first, second} {
+		_, err = env.CreateTestEnrollment(class.ID, student.ID, models.Enrolled)
+		require.NoError(t, err)
+	}
+
+	instructorClaims := &handlers.Claims{UserID: instructor.ID, Role: models.FacilityAdmin, FacilityID: facility.ID}
+	otherClaims := &handlers.Claims{UserID: otherAdmin.ID, Role: models.FacilityAdmin, FacilityID: facility.ID}
+	today := time.Now().Format("2006-01-02")
+	capturedAt := time.Now().Add(-time.Hour)
+	syncPath := fmt.Sprintf("/api/program-classes/%d/attendance/sync", class.ID)
+
+	// someone else records the second resident online while the instructor is offline
+	NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events/%d/attendance", class.ID, event.ID),
+		[]models.ProgramClassEventAttendance{{UserID: second.ID, Date: today, AttendanceStatus: models.Absent_Unexcused}}).
+		WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusOK)
+
+	marks := map[string]any{"marks": []models.AttendanceSyncMark{
+		{ClientID: "mark-1", EventID: event.ID, UserID: first.ID, Date: today, AttendanceStatus: models.Present, CapturedAt: capturedAt},
+		{ClientID: "mark-2", EventID: event.ID, UserID: second.ID, Date: today, AttendanceStatus: models.Present, CapturedAt: capturedAt},
+		{ClientID: "mark-3", EventID: event.ID, UserID: first.ID, Date: time.Now().AddDate(0, 0, 2).Format("2006-01-02"), AttendanceStatus: models.Present, CapturedAt: capturedAt},
+		{ClientID: "mark-4", EventID: otherEvent.ID, UserID: first.ID, Date: today, AttendanceStatus: models.Present, CapturedAt: capturedAt},
+	}}
+	sync := func() []models.AttendanceSyncRecord {
+		return NewRequest[[]models.AttendanceSyncRecord](env.Client, t, http.MethodPost, syncPath, marks).
+			WithTestClaims(instructorClaims).Do().ExpectStatus(http.StatusOK).GetData()
+	}
+	historyFor := func(userID uint) int64 {
+		var count int64
+		require.NoError(t, env.DB.Model(&models.UserAccountHistory{}).
+			Where("user_id = ? AND action = ?", userID, models.AttendanceRecorded).Count(&count).Error)
+		return count
+	}
+
+	t.Run("Each mark reports its own outcome", func(t *testing.T) {
+		results := sync()
+		require.Len(t, results, 4)
+		require.Equal(t, models.AttendanceSyncApplied, results[0].Result)
+		require.NotNil(t, results[0].AttendanceID)
+		require.Equal(t, models.AttendanceSyncConflict, results[1].Result)
+		require.Equal(t, models.Absent_Unexcused, results[1].RecordedStatus)
+		require.Equal(t, models.AttendanceSyncRejected, results[2].Result)
+		require.Equal(t, models.AttendanceSyncRejected, results[3].Result)
+		for _, result := range results {
+			require.False(t, result.Replayed)
+		}
+
+		var stored models.ProgramClassEventAttendance
+		require.NoError(t, env.DB.Where("event_id = ? AND user_id = ? AND date = ?", event.ID, second.ID, today).First(&stored).Error)
+		require.Equal(t, models.Absent_Unexcused, stored.AttendanceStatus, "the conflicting mark is not applied")
+		require.Equal(t, int64(1), historyFor(first.ID))
+	})
+
+	t.Run("Resending a batch replays the saved outcomes", func(t *testing.T) {
+		results := sync()
+		require.Len(t, results, 4)
+		require.Equal(t, models.AttendanceSyncApplied, results[0].Result)
+		require.Equal(t, models.AttendanceSyncConflict, results[1].Result)
+		for _, result := range results {
+			require.True(t, result.Replayed)
+		}
+		require.Equal(t, int64(1), historyFor(first.ID), "a replayed mark is not recorded again")
+	})
+
+	t.Run("Client IDs are only replayed for the same class and resident", func(t *testing.T) {
+		results := NewRequest[[]models.AttendanceSyncRecord](env.Client, t, http.MethodPost, syncPath, map[string]any{"marks": []models.AttendanceSyncMark{
+			{ClientID: "mark-1", EventID: event.ID, UserID: second.ID, Date: today, AttendanceStatus: models.Absent_Unexcused, CapturedAt: capturedAt},
+		}}).WithTestClaims(instructorClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.False(t, results[0].Replayed)
+		require.Equal(t, second.ID, results[0].UserID)
+		require.Equal(t, models.AttendanceSyncApplied, results[0].Result)
+	})
+
+	t.Run("Instructors can change their own marks", func(t *testing.T) {
+		results := NewRequest[[]models.AttendanceSyncRecord](env.Client, t, http.MethodPost, syncPath, map[string]any{"marks": []models.AttendanceSyncMark{
+			{ClientID: "mark-5", EventID: event.ID, UserID: first.ID, Date: today, AttendanceStatus: models.Absent_Excused, CapturedAt: time.Now()},
+		}}).WithTestClaims(instructorClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.AttendanceSyncApplied, results[0].Result)
+		require.Equal(t, models.Absent_Excused, results[0].RecordedStatus)
+		require.Equal(t, int64(2), historyFor(first.ID))
+	})
+
+	t.Run("Marks need a client ID", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, syncPath, map[string]any{"marks": []models.AttendanceSyncMark{
+			{EventID: event.ID, UserID: first.ID, Date: today, AttendanceStatus: models.Present, CapturedAt: capturedAt},
+		}}).WithTestClaims(instructorClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+}
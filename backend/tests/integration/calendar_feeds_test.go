@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+type calendarFeed struct {
+	ID      uint   `json:"id"`
+	Scope   string `json:"scope"`
+	ScopeID uint   `json:"scope_id"`
+	URL     string `json:"url"`
+}
+
+func (feed calendarFeed) path() string {
+	_, token, _ := strings.Cut(feed.URL, "/api/ical/")
+	return "/api/ical/" + token
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Calendar Feed Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("icaladmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	instructor, err := env.CreateTestInstructor(facility.ID, "ical")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("icalresident", models.Student, facility.ID, "ICAL1")
+	require.NoError(t, err)
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+	residentClaims := &handlers.Claims{Role: models.Student, UserID: resident.ID, FacilityID: facility.ID}
+
+	program, err := env.CreateTestProgram("Calendar Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, &instructor.ID)
+	require.NoError(t, err)
+	start := time.Now().AddDate(0, 0, 1)
+	event, err := env.CreateTestEvent(class.ID, fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=5", start.Format("20060102T150000Z")), instructor.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(class.ID, resident.ID, models.Enrolled)
+	require.NoError(t, err)
+	cancelledOn := start.AddDate(0, 0, 2)
+	_, err = env.DB.NewEventOverride(int(event.ID), &models.OverrideForm{Date: cancelledOn.Format("2006-01-02"), IsCancelled: true, OverrideType: models.OverrideSelf})
+	require.NoError(t, err)
+
+	t.Run("Facility feed renders the series with cancellations excluded", func(t *testing.T) {
+		feed := NewRequest[calendarFeed](env.Client, t, http.MethodPost, "/api/calendar-feeds", map[string]any{
+			"scope": models.FacilityCalendarFeed,
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Equal(t, facility.ID, feed.ScopeID)
+
+		resp := NewRequest[any](env.Client, t, http.MethodGet, feed.path(), nil).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", "text/calendar; charset=utf-8").
+			ExpectBodyContains("BEGIN:VCALENDAR").
+			ExpectBodyContains(fmt.Sprintf("UID:program-class-event-%d@unlockedv2", event.ID)).
+			ExpectBodyContains("LOCATION:Test Room").
+			ExpectBodyContains("EXDATE:" + cancelledOn.UTC().Format("20060102T150000Z"))
+		require.Contains(t, resp.rawBody, "\r\nEND:VCALENDAR\r\n")
+	})
+
+	t.Run("Residents can only subscribe to their own calendar", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/calendar-feeds", map[string]any{
+			"scope": models.FacilityCalendarFeed,
+		}).WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusForbidden)
+
+		feed := NewRequest[calendarFeed](env.Client, t, http.MethodPost, "/api/calendar-feeds", map[string]any{
+			"scope": models.ResidentCalendarFeed,
+		}).WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Equal(t, resident.ID, feed.ScopeID)
+
+		NewRequest[any](env.Client, t, http.MethodGet, feed.path(), nil).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectBodyContains("SUMMARY:" + class.Name)
+	})
+
+	t.Run("Instructor feed only includes their sessions", func(t *testing.T) {
+		feed := NewRequest[calendarFeed](env.Client, t, http.MethodPost, "/api/calendar-feeds", map[string]any{
+			"scope":    models.InstructorCalendarFeed,
+			"scope_id": facilityAdmin.ID,
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+
+		resp := NewRequest[any](env.Client, t, http.MethodGet, feed.path(), nil).AsRaw().Do().ExpectStatus(http.StatusOK)
+		require.NotContains(t, resp.rawBody, "BEGIN:VEVENT")
+	})
+
+	t.Run("Revoked and tampered tokens are rejected", func(t *testing.T) {
+		feed := NewRequest[calendarFeed](env.Client, t, http.MethodPost, "/api/calendar-feeds", map[string]any{
+			"scope":    models.ClassCalendarFeed,
+			"scope_id": class.ID,
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		NewRequest[any](env.Client, t, http.MethodGet, feed.path(), nil).AsRaw().Do().ExpectStatus(http.StatusOK)
+
+		NewRequest[any](env.Client, t, http.MethodGet, feed.path()+"x", nil).AsRaw().Do().ExpectStatus(http.StatusNotFound)
+
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/calendar-feeds/%d", feed.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK)
+		NewRequest[any](env.Client, t, http.MethodGet, feed.path(), nil).AsRaw().Do().ExpectStatus(http.StatusNotFound)
+
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/calendar-feeds/%d", feed.ID), nil).
+			WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusForbidden)
+	})
+}
@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestICS(events ...string) []byte {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Test//Import//EN"}
+	for _, event := range events {
+		lines = append(lines, "BEGIN:VEVENT", event, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR", "")
+	return []byte(strings.Join(lines, "\r\n"))
+}
+
+func TestCalendarImport(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Calendar Import Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("importadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	instructor, err := env.CreateTestInstructor(facility.ID, "import")
+	require.NoError(t, err)
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+
+	program, err := env.CreateTestProgram("Import Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, &instructor.ID)
+	require.NoError(t, err)
+	busyStart := time.Now().UTC().AddDate(0, 0, 1).Truncate(24 * time.Hour).Add(15 * time.Hour)
+	_, err = env.CreateTestEvent(class.ID, fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=5", busyStart.Format("20060102T150405Z")), instructor.ID)
+	require.NoError(t, err)
+	library := &models.Room{FacilityID: facility.ID, Name: "Library"}
+	require.NoError(t, env.DB.Create(library).Error)
+
+	seriesStart := time.Now().AddDate(0, 0, 14)
+	seriesDay := seriesStart.Format("20060102")
+	skippedDay := seriesStart.AddDate(0, 0, 7).Format("20060102")
+	mapped := strings.Join([]string{
+		"UID:series@import.test",
+		"SUMMARY:" + class.Name,
+		"LOCATION:Library",
+		"ORGANIZER;CN=Test User:mailto:" + instructor.Email,
+		"DTSTART;TZID=America/Chicago:" + seriesDay + "T100000",
+		"DURATION:PT1H30M",
+		"RRULE:FREQ=WEEKLY;COUNT=4",
+		"EXDATE;TZID=America/Chicago:" + skippedDay + "T100000",
+	}, "\r\n")
+	unmapped := strings.Join([]string{
+		"UID:unknown@import.test",
+		"SUMMARY:Basket Weaving",
+		"LOCATION:Library",
+		"DTSTART:" + seriesDay + "T200000Z",
+		"DTEND:" + seriesDay + "T210000Z",
+	}, "\r\n")
+	upload := buildTestICS(mapped, unmapped)
+
+	t.Run("Dry run maps events and reports what cannot be imported", func(t *testing.T) {
+		result := NewRequest[models.CalendarImportResult](env.Client, t, http.MethodPost, "/api/calendar-imports?dry_run=true", nil).
+			WithTestClaims(adminClaims).
+			WithMultipartFile("file", "schedule.ics", upload, nil).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.True(t, result.DryRun)
+		require.Len(t, result.Items, 2)
+		require.Equal(t, 1, result.Rejected)
+
+		series := result.Items[0]
+		require.Empty(t, series.Errors)
+		require.Empty(t, series.Conflicts)
+		require.Equal(t, class.ID, *series.ClassID)
+		require.Equal(t, library.ID, *series.RoomID)
+		require.Equal(t, instructor.ID, *series.InstructorID)
+		require.Equal(t, "1h30m0s", series.Duration)
+		require.Equal(t, []string{seriesStart.AddDate(0, 0, 7).Format("2006-01-02")}, series.CancelledDates)
+		require.Contains(t, series.RecurrenceRule, "DTSTART;TZID=Local:"+seriesDay+"T100000")
+
+		require.NotEmpty(t, result.Items[1].Errors)
+		require.Contains(t, result.Items[1].Errors[0], "Basket Weaving")
+
+		var count int64
+		require.NoError(t, env.DB.Model(&models.ProgramClassEvent{}).Where("room_id = ?", library.ID).Count(&count).Error)
+		require.Zero(t, count)
+	})
+
+	t.Run("Dry run flags conflicts with the existing schedule", func(t *testing.T) {
+		clash := strings.Join([]string{
+			"UID:clash@import.test",
+			"SUMMARY:" + class.Name,
+			"LOCATION:Test Room",
+			"DTSTART:" + busyStart.AddDate(0, 0, 1).Format("20060102T150405Z"),
+			"DURATION:PT1H",
+		}, "\r\n")
+		result := NewRequest[models.CalendarImportResult](env.Client, t, http.MethodPost, "/api/calendar-imports?dry_run=true", nil).
+			WithTestClaims(adminClaims).
+			WithMultipartFile("file", "clash.ics", buildTestICS(clash), nil).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, result.Items, 1)
+		require.NotEmpty(t, result.Items[0].Conflicts)
+		require.Equal(t, 1, result.Rejected)
+	})
+
+	t.Run("Import is rejected until every event is mapped or skipped", func(t *testing.T) {
+		result := NewRequest[models.CalendarImportResult](env.Client, t, http.MethodPost, "/api/calendar-imports", nil).
+			WithTestClaims(adminClaims).
+			WithMultipartFile("file", "schedule.ics", upload, nil).
+			Do().ExpectStatus(http.StatusUnprocessableEntity).GetData()
+		require.Zero(t, result.Created)
+
+		result = NewRequest[models.CalendarImportResult](env.Client, t, http.MethodPost, "/api/calendar-imports", nil).
+			WithTestClaims(adminClaims).
+			WithMultipartFile("file", "schedule.ics", upload, map[string]string{
+				"mappings": `{"unknown@import.test": {"skip": true}}`,
+			}).
+			Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Equal(t, 1, result.Created)
+		require.Equal(t, 1, result.Skipped)
+		require.NotNil(t, result.Items[0].EventID)
+
+		var overrides []models.ProgramClassEventOverride
+		require.NoError(t, env.DB.Where("event_id = ?", *result.Items[0].EventID).Find(&overrides).Error)
+		require.Len(t, overrides, 1)
+		require.True(t, overrides[0].IsCancelled)
+	})
+
+	t.Run("Only .ics files are accepted", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/calendar-imports", nil).
+			WithTestClaims(adminClaims).
+			WithMultipartFile("file", "schedule.csv", upload, nil).
+			Do().ExpectStatus(http.StatusBadRequest)
+	})
+}
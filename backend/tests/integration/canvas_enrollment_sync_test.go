@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanvasEnrollmentSyncRoutes(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Canvas Sync Facility")
+	require.NoError(t, err)
+	other, err := env.CreateTestFacility("Other Canvas Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("canvassyncadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Canvas Sync Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, nil)
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("canvassyncresident", models.Student, facility.ID, "CS100")
+	require.NoError(t, err)
+
+	canvas := &models.ProviderPlatform{Type: models.CanvasCloud, Name: "Sync Canvas", BaseUrl: "http://canvas-sync", AccountID: "1", State: models.Enabled}
+	require.NoError(t, env.DB.Create(canvas).Error)
+	kolibri := &models.ProviderPlatform{Type: models.Kolibri, Name: "Sync Kolibri", BaseUrl: "http://kolibri-sync", AccountID: "1", State: models.Enabled}
+	require.NoError(t, env.DB.Create(kolibri).Error)
+
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+	linkURL := fmt.Sprintf("/api/program-classes/%d/canvas-course", class.ID)
+	syncURL := fmt.Sprintf("/api/program-classes/%d/canvas-sync", class.ID)
+
+	t.Run("Classes are linked to courses on Canvas providers only", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPut, linkURL, map[string]any{"provider_platform_id": kolibri.ID, "canvas_course_id": "101"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPut, linkURL, map[string]any{"canvas_course_id": "101"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		linked := NewRequest[models.ProgramClass](env.Client, t, http.MethodPut, linkURL, map[string]any{"provider_platform_id": canvas.ID, "canvas_course_id": " 101 "}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.NotNil(t, linked.CanvasCourseID)
+		require.Equal(t, "101", *linked.CanvasCourseID)
+		require.Equal(t, canvas.ID, *linked.CanvasProviderID)
+
+		otherAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: other.ID}
+		NewRequest[any](env.Client, t, http.MethodPut, linkURL, map[string]any{}).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	failed := models.CanvasEnrollmentSync{ClassID: class.ID, UserID: resident.ID, ProviderPlatformID: canvas.ID, CanvasCourseID: "101",
+		State: models.CanvasEnrollmentActive, Status: models.CanvasSyncFailed, Attempts: models.MaxCanvasSyncAttempts, Error: "resident is not mapped to a Canvas user"}
+	require.NoError(t, env.DB.Create(&failed).Error)
+
+	t.Run("Failed pushes are listed for the class and can be retried", func(t *testing.T) {
+		syncs := NewRequest[[]models.CanvasEnrollmentSync](env.Client, t, http.MethodGet, syncURL+"?status=failed", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, syncs, 1)
+		require.Equal(t, "resident is not mapped to a Canvas user", syncs[0].Error)
+		require.NotNil(t, syncs[0].User)
+		require.Equal(t, resident.ID, syncs[0].User.ID)
+		NewRequest[any](env.Client, t, http.MethodGet, syncURL+"?status=broken", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		retried := NewRequest[map[string]int64](env.Client, t, http.MethodPost, syncURL+"/retry", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.EqualValues(t, 1, retried["retried"])
+		var sync models.CanvasEnrollmentSync
+		require.NoError(t, env.DB.First(&sync, failed.ID).Error)
+		require.Zero(t, sync.Attempts)
+	})
+
+	t.Run("A resident's changes are pushed to each linked class they are enrolled in", func(t *testing.T) {
+		unlinked, err := env.CreateTestClass(program, facility, models.Active, nil)
+		require.NoError(t, err)
+		_, err = env.CreateTestEnrollment(class.ID, resident.ID, models.Enrolled)
+		require.NoError(t, err)
+		_, err = env.CreateTestEnrollment(unlinked.ID, resident.ID, models.Enrolled)
+		require.NoError(t, err)
+		classIDs, err := env.DB.GetCanvasClassIDsForUser(t.Context(), resident.ID)
+		require.NoError(t, err)
+		require.Equal(t, []int{int(class.ID)}, classIDs)
+	})
+
+	t.Run("Unlinking keeps what was pushed so it can be deactivated", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPut, linkURL, map[string]any{"provider_platform_id": canvas.ID, "canvas_course_id": "101"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		var count int64
+		require.NoError(t, env.DB.Model(&models.CanvasEnrollmentSync{}).Where("class_id = ?", class.ID).Count(&count).Error)
+		require.EqualValues(t, 1, count, "relinking the same course keeps the sync rows")
+
+		unlinked := NewRequest[models.ProgramClass](env.Client, t, http.MethodPut, linkURL, map[string]any{"canvas_course_id": ""}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Nil(t, unlinked.CanvasCourseID)
+		var sync models.CanvasEnrollmentSync
+		require.NoError(t, env.DB.First(&sync, failed.ID).Error)
+		require.Equal(t, "101", sync.CanvasCourseID, "the old course is kept so the enrollment is deactivated there")
+		require.Equal(t, models.CanvasEnrollmentInactive, sync.State)
+		require.Empty(t, sync.Status)
+		require.Zero(t, sync.Attempts)
+	})
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionCertificates(t *testing.T) {
+	templateDir := t.TempDir()
+	for _, name := range []string{"certificate_of_completion", "certificate_education"} {
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, name+".jrxml"), []byte("<jasperReport/>"), 0o600))
+	}
+	t.Setenv("JASPER_TEMPLATE_DIR", templateDir)
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Certificate Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("certadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	deptAdmin, err := env.CreateTestUser("certdept", models.DepartmentAdmin, facility.ID, "")
+	require.NoError(t, err)
+	graduate, err := env.CreateTestUser("certgrad", models.Student, facility.ID, "C100")
+	require.NoError(t, err)
+	classmate, err := env.CreateTestUser("certmate", models.Student, facility.ID, "C101")
+	require.NoError(t, err)
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+	deptClaims := &handlers.Claims{Role: models.DepartmentAdmin, UserID: deptAdmin.ID, FacilityID: facility.ID}
+	graduateClaims := &handlers.Claims{Role: models.Student, UserID: graduate.ID, FacilityID: facility.ID}
+	classmateClaims := &handlers.Claims{Role: models.Student, UserID: classmate.ID, FacilityID: facility.ID}
+
+	program, err := env.CreateTestProgram("Certificate Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Completed, nil)
+	require.NoError(t, err)
+	complete := func(user *models.User) {
+		require.NoError(t, env.DB.Create(&models.ProgramCompletion{
+			UserID:              user.ID,
+			ProgramClassID:      class.ID,
+			FacilityName:        facility.Name,
+			CreditType:          string(models.Education),
+			AdminEmail:          facilityAdmin.Email,
+			ProgramOwner:        "Education Department",
+			ProgramName:         program.Name,
+			ProgramID:           program.ID,
+			ProgramClassName:    class.Name,
+			ProgramClassStartDt: time.Now().AddDate(0, -3, 0),
+			EnrolledOnDt:        time.Now().AddDate(0, -3, 0),
+		}).Error)
+	}
+	complete(graduate)
+	issuePath := fmt.Sprintf("/api/program-classes/%d/certificates", class.ID)
+
+	t.Run("Templates are chosen per program before credit type", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/certificate-templates", map[string]any{
+			"credit_type":   "Education",
+			"template_name": "../../etc/passwd",
+		}).WithTestClaims(deptClaims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/certificate-templates", map[string]any{
+			"template_name": "certificate_education",
+		}).WithTestClaims(deptClaims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/certificate-templates", map[string]any{
+			"credit_type":   "Education",
+			"template_name": "certificate_missing",
+		}).WithTestClaims(deptClaims).Do().ExpectStatus(http.StatusBadRequest)
+
+		NewRequest[models.CertificateTemplate](env.Client, t, http.MethodPost, "/api/certificate-templates", map[string]any{
+			"credit_type":   "Education",
+			"template_name": "certificate_education",
+			"heading":       "Certificate of Achievement",
+		}).WithTestClaims(deptClaims).Do().ExpectStatus(http.StatusCreated)
+		NewRequest[models.CertificateTemplate](env.Client, t, http.MethodPost, "/api/certificate-templates", map[string]any{
+			"program_id":    program.ID,
+			"template_name": "certificate_of_completion",
+			"signatory":     "Director of Education",
+		}).WithTestClaims(deptClaims).Do().ExpectStatus(http.StatusCreated)
+	})
+
+	var certificate models.CompletionCertificate
+	t.Run("Issuing for a class covers every completion once", func(t *testing.T) {
+		result := NewRequest[models.BulkCertificateResult](env.Client, t, http.MethodPost, issuePath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Len(t, result.Issued, 1)
+		certificate = result.Issued[0]
+		require.Equal(t, graduate.ID, certificate.UserID)
+		require.Equal(t, "certificate_of_completion", certificate.TemplateName, "the program template wins")
+		require.Equal(t, "Director of Education", certificate.Signatory)
+		require.Equal(t, graduate.NameFirst+" "+graduate.NameLast, certificate.ResidentName)
+		require.Regexp(t, `^[2-9A-Z]{4}-[2-9A-Z]{4}-[2-9A-Z]{4}$`, certificate.VerificationCode)
+
+		complete(classmate)
+		result = NewRequest[models.BulkCertificateResult](env.Client, t, http.MethodPost, issuePath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Len(t, result.Issued, 1)
+		require.Equal(t, classmate.ID, result.Issued[0].UserID)
+		require.Equal(t, 1, result.AlreadyIssued)
+		require.NotEqual(t, certificate.VerificationCode, result.Issued[0].VerificationCode)
+
+		certificates := NewRequest[[]models.CompletionCertificate](env.Client, t, http.MethodGet, issuePath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, certificates, 2)
+	})
+
+	t.Run("Residents see only their own certificates", func(t *testing.T) {
+		mine := NewRequest[[]models.CompletionCertificate](env.Client, t, http.MethodGet, fmt.Sprintf("/api/users/%d/certificates", graduate.ID), nil).
+			WithTestClaims(graduateClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, mine, 1)
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/users/%d/certificates", graduate.ID), nil).
+			WithTestClaims(classmateClaims).Do().ExpectStatus(http.StatusUnauthorized)
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/users/%d/certificates/%d/pdf", graduate.ID, certificate.ID), nil).
+			WithTestClaims(classmateClaims).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("Anyone can verify a code without signing in", func(t *testing.T) {
+		code := strings.ToLower(strings.ReplaceAll(certificate.VerificationCode, "-", ""))
+		verification := NewRequest[models.CertificateVerification](env.Client, t, http.MethodGet, "/api/certificates/verify/"+code, nil).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.True(t, verification.Valid)
+		require.Equal(t, certificate.ResidentName, verification.ResidentName)
+		require.Equal(t, program.Name, verification.ProgramName)
+
+		resp := NewRequest[any](env.Client, t, http.MethodGet, "/api/certificates/verify/"+certificate.VerificationCode, nil).AsRaw().Do()
+		require.NotContains(t, resp.rawBody, "C100", "the resident's DOC ID is never exposed")
+		require.NotContains(t, resp.rawBody, "user_id")
+
+		NewRequest[models.CertificateVerification](env.Client, t, http.MethodGet, "/api/certificates/verify/ZZZZ-ZZZZ-ZZZZ", nil).
+			Do().ExpectStatus(http.StatusNotFound)
+	})
+
+	t.Run("Revoked certificates no longer verify", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/certificates/%d", certificate.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK)
+		verification := NewRequest[models.CertificateVerification](env.Client, t, http.MethodGet, "/api/certificates/verify/"+certificate.VerificationCode, nil).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.False(t, verification.Valid)
+		require.True(t, verification.Revoked)
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/users/%d/certificates/%d/pdf", graduate.ID, certificate.ID), nil).
+			WithTestClaims(graduateClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Graduating residents issues their certificates", func(t *testing.T) {
+		active, err := env.CreateTestClass(program, facility, models.Active, nil)
+		require.NoError(t, err)
+		_, err = env.CreateTestEnrollment(active.ID, classmate.ID, models.Enrolled)
+		require.NoError(t, err)
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/program-classes/%d/enrollments", active.ID), map[string]any{
+			"enrollment_status": string(models.EnrollmentCompleted),
+			"user_ids":          []int{int(classmate.ID)},
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK)
+
+		certificates := NewRequest[[]models.CompletionCertificate](env.Client, t, http.MethodGet, fmt.Sprintf("/api/program-classes/%d/certificates", active.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, certificates, 1)
+		require.Equal(t, classmate.ID, certificates[0].UserID)
+	})
+}
@@ -199,12 +199,13 @@ func runTestEventReschedulingExtendsMultipleMonths(t *testing.T, env *TestEnv, f
 		"instructor_id":   instructor.ID,
 	}
 
-	eventResp := NewRequest[*models.ProgramClassEvent](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
+	NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
 		WithTestClaims(&handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}).
 		Do().
 		ExpectStatus(http.StatusCreated)
 
-	event := eventResp.GetData()
+	var event models.ProgramClassEvent
+	require.NoError(t, env.DB.Where("class_id = ?", createdClass.ID).First(&event).Error)
 
 	reschedulePayload := map[string]interface{}{
 		"event_series": map[string]interface{}{
@@ -272,12 +273,13 @@ func runTestMultipleOverridesExtendEndDate(t *testing.T, env *TestEnv, facility
 		"instructor_id":   instructor.ID,
 	}
 
-	eventResp := NewRequest[*models.ProgramClassEvent](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
+	NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
 		WithTestClaims(&handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}).
 		Do().
 		ExpectStatus(http.StatusCreated)
 
-	event := eventResp.GetData()
+	var event models.ProgramClassEvent
+	require.NoError(t, env.DB.Where("class_id = ?", createdClass.ID).First(&event).Error)
 
 	overridePayload := []map[string]interface{}{
 		{
@@ -320,8 +322,8 @@ func runTestMultipleOverridesExtendEndDate(t *testing.T, env *TestEnv, facility
 		ExpectStatus(http.StatusOK)
 
 	extendedClass := extendedClassResp.GetData()
-	expectedExtendedEnd := time.Date(2025, 12, 20, 23, 59, 59, 0, time.UTC)
-	require.Equal(t, expectedExtendedEnd, *extendedClass.EndDt, "Class end date should reflect base event UNTIL date")
+	expectedExtendedEnd := time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC)
+	require.Equal(t, expectedExtendedEnd, *extendedClass.EndDt, "Class end date should extend to the latest override")
 }
 
 func runTestCannotModifyEventsForCompletedClasses(t *testing.T, env *TestEnv, facility *models.Facility, facilityAdmin *models.User, program *models.Program, roomID uint) {
@@ -427,12 +429,13 @@ func runTestStartDateUpdatesWhenEventMovedEarlier(t *testing.T, env *TestEnv, fa
 		"instructor_id":   instructor.ID,
 	}
 
-	eventResp := NewRequest[*models.ProgramClassEvent](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
+	NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
 		WithTestClaims(&handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}).
 		Do().
 		ExpectStatus(http.StatusCreated)
 
-	event := eventResp.GetData()
+	var event models.ProgramClassEvent
+	require.NoError(t, env.DB.Where("class_id = ?", createdClass.ID).First(&event).Error)
 
 	overridePayload := []map[string]interface{}{
 		{
@@ -457,8 +460,8 @@ func runTestStartDateUpdatesWhenEventMovedEarlier(t *testing.T, env *TestEnv, fa
 		ExpectStatus(http.StatusOK)
 
 	updatedClass := updatedClassResp.GetData()
-	expectedStartDate := time.Date(2025, 12, 20, 18, 0, 0, 0, time.UTC) // Original RRULE start date
-	require.Equal(t, expectedStartDate, updatedClass.StartDt, "Class start date should sync with original RRULE start date when rescheduled events exist")
+	expectedStartDate := time.Date(2025, 12, 10, 18, 0, 0, 0, time.UTC) // Rescheduled session before the RRULE start date
+	require.Equal(t, expectedStartDate, updatedClass.StartDt, "Class start date should move to a session rescheduled before the original RRULE start date")
 }
 
 func runTestEventCancellationAffectsBoundaries(t *testing.T, env *TestEnv, facility *models.Facility, facilityAdmin *models.User, program *models.Program, roomID uint) {
@@ -495,12 +498,13 @@ func runTestEventCancellationAffectsBoundaries(t *testing.T, env *TestEnv, facil
 		"instructor_id":   instructor.ID,
 	}
 
-	eventResp := NewRequest[*models.ProgramClassEvent](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
+	NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", createdClass.ID), eventPayload).
 		WithTestClaims(&handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}).
 		Do().
 		ExpectStatus(http.StatusCreated)
 
-	event := eventResp.GetData()
+	var event models.ProgramClassEvent
+	require.NoError(t, env.DB.Where("class_id = ?", createdClass.ID).First(&event).Error)
 
 	overridePayload := []map[string]interface{}{
 		{
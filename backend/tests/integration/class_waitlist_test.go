@@ -0,0 +1,221 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassWaitlist(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Waitlist Facility")
+	require.NoError(t, err)
+
+	facilityAdmin, err := env.CreateTestUser("waitlistadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+
+	program, err := env.CreateTestProgram("Waitlist Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+
+	t.Run("Overflow residents are waitlisted in order", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 1)
+		residents := createWaitlistResidents(t, env, facility.ID, "overflow", 3)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids": []int{int(residents[0].ID), int(residents[1].ID), int(residents[2].ID)},
+			"confirm":  true,
+			"waitlist": true,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, residents[0].ID))
+		waitlist := NewRequest[[]map[string]any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/program-classes/%d/waitlist", class.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, waitlist, 2)
+		require.EqualValues(t, residents[1].ID, waitlist[0]["user_id"])
+		require.EqualValues(t, 1, waitlist[0]["waitlist_position"])
+		require.EqualValues(t, residents[2].ID, waitlist[1]["user_id"])
+		require.EqualValues(t, 2, waitlist[1]["waitlist_position"])
+	})
+
+	t.Run("Dropping an enrollment promotes the next resident", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 1)
+		residents := createWaitlistResidents(t, env, facility.ID, "promote", 3)
+		_, err := env.CreateTestEnrollment(class.ID, residents[0].ID, models.Enrolled)
+		require.NoError(t, err)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/waitlist", class.ID), map[string]any{
+			"user_ids": []int{int(residents[1].ID), int(residents[2].ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"enrollment_status": models.EnrollmentIncompleteDropped,
+			"user_ids":          []int{int(residents[0].ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, residents[1].ID))
+		require.Equal(t, models.EnrollmentWaitlisted, enrollmentStatusFor(t, env, class.ID, residents[2].ID))
+
+		var remaining models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("class_id = ? AND user_id = ?", class.ID, residents[2].ID).First(&remaining).Error)
+		require.NotNil(t, remaining.WaitlistPosition)
+		require.Equal(t, 1, *remaining.WaitlistPosition)
+
+		var history []models.UserAccountHistory
+		require.NoError(t, env.DB.Where("user_id = ? AND action = ?", residents[1].ID, models.WaitlistPromoted).Find(&history).Error)
+		require.Len(t, history, 1)
+		require.NotNil(t, history[0].ClassName)
+		require.Equal(t, class.Name, *history[0].ClassName)
+		require.NotNil(t, history[0].AdminID)
+		require.Equal(t, facilityAdmin.ID, *history[0].AdminID)
+	})
+
+	t.Run("Deactivated residents are passed over", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 1)
+		residents := createWaitlistResidents(t, env, facility.ID, "skip", 3)
+		_, err := env.CreateTestEnrollment(class.ID, residents[0].ID, models.Enrolled)
+		require.NoError(t, err)
+		_, err = env.DB.AddUsersToClassWaitlist(int(class.ID), []int{int(residents[1].ID), int(residents[2].ID)}, nil)
+		require.NoError(t, err)
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", residents[1].ID).Update("deactivated_at", class.StartDt).Error)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"enrollment_status": models.EnrollmentIncompleteWithdrawn,
+			"user_ids":          []int{int(residents[0].ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+
+		require.Equal(t, models.EnrollmentWaitlisted, enrollmentStatusFor(t, env, class.ID, residents[1].ID))
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, residents[2].ID))
+	})
+
+	t.Run("Transferring a resident frees their seat and leaves their waitlists", func(t *testing.T) {
+		other, err := env.CreateTestFacility("Waitlist Transfer Facility")
+		require.NoError(t, err)
+		class := createClassWithCapacity(t, env, program, facility, 1)
+		full := createClassWithCapacity(t, env, program, facility, 0)
+		residents := createWaitlistResidents(t, env, facility.ID, "transfer", 3)
+		_, err = env.CreateTestEnrollment(class.ID, residents[0].ID, models.Enrolled)
+		require.NoError(t, err)
+		_, err = env.DB.AddUsersToClassWaitlist(int(class.ID), []int{int(residents[1].ID)}, nil)
+		require.NoError(t, err)
+		_, err = env.DB.AddUsersToClassWaitlist(int(full.ID), []int{int(residents[0].ID), int(residents[2].ID)}, nil)
+		require.NoError(t, err)
+
+		tx, err := env.DB.TransferResident(&models.QueryContext{Ctx: t.Context()}, int(residents[0].ID), int(facility.ID), int(other.ID))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit().Error)
+
+		require.Equal(t, models.ProgramEnrollmentStatus("Incomplete: Transferred"), enrollmentStatusFor(t, env, class.ID, residents[0].ID))
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, residents[1].ID), "the freed seat goes to the next resident waiting")
+		require.Equal(t, models.EnrollmentCancelled, enrollmentStatusFor(t, env, full.ID, residents[0].ID))
+		var remaining models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("class_id = ? AND user_id = ?", full.ID, residents[2].ID).First(&remaining).Error)
+		require.Equal(t, 1, *remaining.WaitlistPosition)
+	})
+
+	t.Run("Raising capacity promotes and closing the class cancels the waitlist", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 0)
+		residents := createWaitlistResidents(t, env, facility.ID, "resize", 3)
+		instructor, err := env.CreateTestInstructor(facility.ID, "resize")
+		require.NoError(t, err)
+		_, err = env.CreateTestEvent(class.ID, "", instructor.ID)
+		require.NoError(t, err)
+		_, err = env.DB.AddUsersToClassWaitlist(int(class.ID), []int{int(residents[0].ID), int(residents[1].ID), int(residents[2].ID)}, nil)
+		require.NoError(t, err)
+		classURL := fmt.Sprintf("/api/programs/%d/classes/%d", program.ID, class.ID)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, classURL, map[string]any{"capacity": 1}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, residents[0].ID))
+		require.Equal(t, models.EnrollmentWaitlisted, enrollmentStatusFor(t, env, class.ID, residents[1].ID))
+
+		NewRequest[any](env.Client, t, http.MethodPatch, classURL, map[string]any{"status": models.Cancelled, "capacity": 1}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		require.Equal(t, models.EnrollmentCancelled, enrollmentStatusFor(t, env, class.ID, residents[0].ID))
+		for _, resident := range residents[1:] {
+			require.Equal(t, models.EnrollmentCancelled, enrollmentStatusFor(t, env, class.ID, resident.ID), "nobody is left waiting for a closed class")
+		}
+	})
+
+	t.Run("Removing a resident from the waitlist renumbers the queue", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 0)
+		residents := createWaitlistResidents(t, env, facility.ID, "remove", 3)
+		_, err := env.DB.AddUsersToClassWaitlist(int(class.ID), []int{int(residents[0].ID), int(residents[1].ID), int(residents[2].ID)}, nil)
+		require.NoError(t, err)
+
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/program-classes/%d/waitlist/%d", class.ID, residents[0].ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusNoContent)
+
+		require.Equal(t, models.EnrollmentCancelled, enrollmentStatusFor(t, env, class.ID, residents[0].ID))
+		var queue []models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("class_id = ? AND enrollment_status = ?", class.ID, models.EnrollmentWaitlisted).Order("waitlist_position").Find(&queue).Error)
+		require.Len(t, queue, 2)
+		require.Equal(t, residents[1].ID, queue[0].UserID)
+		require.Equal(t, 1, *queue[0].WaitlistPosition)
+		require.Equal(t, 2, *queue[1].WaitlistPosition)
+	})
+
+	t.Run("Enrolling a waitlisted resident converts their waitlisted enrollment", func(t *testing.T) {
+		class := createClassWithCapacity(t, env, program, facility, 0)
+		residents := createWaitlistResidents(t, env, facility.ID, "direct", 2)
+		_, err := env.DB.AddUsersToClassWaitlist(int(class.ID), []int{int(residents[0].ID), int(residents[1].ID)}, nil)
+		require.NoError(t, err)
+		require.NoError(t, env.DB.Model(class).Update("capacity", 1).Error)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids": []int{int(residents[1].ID)},
+			"confirm":  true,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+
+		var enrollments []models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("class_id = ? AND user_id = ?", class.ID, residents[1].ID).Find(&enrollments).Error)
+		require.Len(t, enrollments, 1)
+		require.Equal(t, models.Enrolled, enrollments[0].EnrollmentStatus)
+		require.Nil(t, enrollments[0].WaitlistPosition)
+
+		var queue []models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("class_id = ? AND enrollment_status = ?", class.ID, models.EnrollmentWaitlisted).Find(&queue).Error)
+		require.Len(t, queue, 1)
+		require.Equal(t, residents[0].ID, queue[0].UserID)
+		require.Equal(t, 1, *queue[0].WaitlistPosition)
+
+		duplicate := models.ProgramClassEnrollment{ClassID: class.ID, UserID: residents[1].ID, EnrollmentStatus: models.Enrolled}
+		require.Error(t, env.DB.Create(&duplicate).Error, "a second active enrollment in the class is rejected")
+	})
+}
+
+func createClassWithCapacity(t *testing.T, env *TestEnv, program *models.Program, facility *models.Facility, capacity int64) *models.ProgramClass {
+	t.Helper()
+	class, err := env.CreateTestClass(program, facility, models.Active, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.DB.Model(class).Update("capacity", capacity).Error)
+	class.Capacity = capacity
+	return class
+}
+
+func createWaitlistResidents(t *testing.T, env *TestEnv, facilityID uint, prefix string, count int) []*models.User {
+	t.Helper()
+	residents := make([]*models.User, 0, count)
+	for i := range count {
+		user, err := env.CreateTestUser(fmt.Sprintf("%swait%d", prefix, i), models.Student, facilityID, fmt.Sprintf("%s%d", prefix, i))
+		require.NoError(t, err)
+		residents = append(residents, user)
+	}
+	return residents
+}
+
+func enrollmentStatusFor(t *testing.T, env *TestEnv, classID, userID uint) models.ProgramEnrollmentStatus {
+	t.Helper()
+	var enrollment models.ProgramClassEnrollment
+	require.NoError(t, env.DB.Where("class_id = ? AND user_id = ?", classID, userID).Order("id desc").First(&enrollment).Error)
+	return enrollment.EnrollmentStatus
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -89,6 +90,25 @@ func (r *Request[T]) WithHeader(key, value string) *Request[T] {
 	return r
 }
 
+// replaces the request body with a multipart form holding the given fields and a single file
+func (r *Request[T]) WithMultipartFile(field, filename string, contents []byte, fields map[string]string) *Request[T] {
+	r.t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for key, value := range fields {
+		require.NoError(r.t, writer.WriteField(key, value), "failed to write multipart field")
+	}
+	part, err := writer.CreateFormFile(field, filename)
+	require.NoError(r.t, err, "failed to create multipart file")
+	_, err = part.Write(contents)
+	require.NoError(r.t, err, "failed to write multipart file")
+	require.NoError(r.t, writer.Close(), "failed to close multipart writer")
+	r.req.Body = io.NopCloser(&buf)
+	r.req.ContentLength = int64(buf.Len())
+	r.req.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
 // instructs the client not to parse the response body into an object
 func (r *Request[T]) AsRaw() *Request[T] {
 	r.t.Helper()
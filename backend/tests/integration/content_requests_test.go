@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentRequests(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Request Facility")
+	require.NoError(t, err)
+	otherFacility, err := env.CreateTestFacility("Other Request Facility")
+	require.NoError(t, err)
+	first, err := env.CreateTestUser("requestfirst", models.Student, facility.ID, "req-1")
+	require.NoError(t, err)
+	second, err := env.CreateTestUser("requestsecond", models.Student, otherFacility.ID, "req-2")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("requestadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	otherAdmin, err := env.CreateTestUser("requestotheradmin", models.FacilityAdmin, otherFacility.ID, "")
+	require.NoError(t, err)
+	deptAdmin, err := env.CreateTestUser("requestdeptadmin", models.DepartmentAdmin, facility.ID, "")
+	require.NoError(t, err)
+	firstClaims := &handlers.Claims{UserID: first.ID, Role: models.Student, FacilityID: facility.ID}
+	secondClaims := &handlers.Claims{UserID: second.ID, Role: models.Student, FacilityID: otherFacility.ID}
+	adminClaims := &handlers.Claims{UserID: admin.ID, Role: models.FacilityAdmin, FacilityID: facility.ID}
+	otherAdminClaims := &handlers.Claims{UserID: otherAdmin.ID, Role: models.FacilityAdmin, FacilityID: otherFacility.ID}
+	deptAdminClaims := &handlers.Claims{UserID: deptAdmin.ID, Role: models.DepartmentAdmin, FacilityID: facility.ID}
+
+	requestContent := func(claims *handlers.Claims, content string, status int) models.ContentRequest {
+		return NewRequest[models.ContentRequest](env.Client, t, http.MethodPost, "/api/open-content/request-content", map[string]any{"content": content}).
+			WithTestClaims(claims).Do().ExpectStatus(status).GetData()
+	}
+
+	var original, duplicate, unrelated models.ContentRequest
+	t.Run("Requests for the same content are detected as duplicates", func(t *testing.T) {
+		original = requestContent(firstClaims, "Khan Academy: Algebra", http.StatusCreated)
+		require.Equal(t, models.ContentRequestSubmitted, original.Status)
+		require.Nil(t, original.DuplicateOfID)
+
+		requestContent(firstClaims, "khan academy algebra", http.StatusConflict)
+		duplicate = requestContent(secondClaims, "  KHAN academy - algebra ", http.StatusCreated)
+		require.NotNil(t, duplicate.DuplicateOfID)
+		require.Equal(t, original.ID, *duplicate.DuplicateOfID)
+		unrelated = requestContent(secondClaims, "Spanish Wikipedia", http.StatusCreated)
+		require.Nil(t, unrelated.DuplicateOfID)
+
+		own := NewRequest[[]models.ContentRequest](env.Client, t, http.MethodGet, "/api/open-content/requests", nil).
+			WithTestClaims(secondClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, own, 2)
+	})
+
+	t.Run("The queue folds duplicates into the first request", func(t *testing.T) {
+		queue := NewRequest[[]models.ContentRequest](env.Client, t, http.MethodGet, "/api/content-requests", nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, queue, 1)
+		require.Equal(t, original.ID, queue[0].ID)
+		require.Equal(t, int64(2), queue[0].RequesterCount)
+
+		otherQueue := NewRequest[[]models.ContentRequest](env.Client, t, http.MethodGet, "/api/content-requests", nil).
+			WithTestClaims(otherAdminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, otherQueue, 2, "a duplicate from the facility brings the request into its queue")
+
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/content-requests/%d", unrelated.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusNotFound)
+	})
+
+	kiwix := &models.OpenContentProvider{Title: "Kiwix", Url: "http://kiwix"}
+	require.NoError(t, env.DB.Create(kiwix).Error)
+	library := &models.Library{OpenContentProviderID: kiwix.ID, Title: "Khan Academy", Url: "/khan"}
+	require.NoError(t, env.DB.Create(library).Error)
+	requestPath := fmt.Sprintf("/api/content-requests/%d", original.ID)
+	fulfill := map[string]any{"content_type": "library", "content_id": library.ID}
+
+	t.Run("Requests are reviewed before they are fulfilled", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPut, requestPath+"/fulfill", fulfill).
+			WithTestClaims(deptAdminClaims).Do().ExpectStatus(http.StatusConflict)
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/content-requests/%d", duplicate.ID), map[string]any{"status": models.ContentRequestApproved}).
+			WithTestClaims(otherAdminClaims).Do().ExpectStatus(http.StatusConflict)
+
+		reviewed := NewRequest[models.ContentRequest](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/content-requests/%d", unrelated.ID), map[string]any{"status": models.ContentRequestUnderReview}).
+			WithTestClaims(otherAdminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.ContentRequestUnderReview, reviewed.Status, "facility admins review requests made only at their facility")
+
+		approve := map[string]any{"status": models.ContentRequestApproved, "review_note": "adding the kiwix library"}
+		NewRequest[any](env.Client, t, http.MethodPatch, requestPath, approve).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusForbidden)
+		approved := NewRequest[models.ContentRequest](env.Client, t, http.MethodPatch, requestPath, approve).
+			WithTestClaims(deptAdminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.ContentRequestApproved, approved.Status)
+		require.NotNil(t, approved.ReviewedByID)
+		require.Equal(t, deptAdmin.ID, *approved.ReviewedByID)
+	})
+
+	t.Run("Fulfilling links every requester to the content", func(t *testing.T) {
+		require.NoError(t, env.DB.Create(&models.FacilityVisibilityStatus{FacilityID: facility.ID, OpenContentProviderID: kiwix.ID, ContentID: library.ID, VisibilityStatus: true}).Error)
+		NewRequest[any](env.Client, t, http.MethodPut, requestPath+"/fulfill", fulfill).
+			WithTestClaims(deptAdminClaims).Do().ExpectStatus(http.StatusBadRequest)
+		require.NoError(t, env.DB.Create(&models.FacilityVisibilityStatus{FacilityID: otherFacility.ID, OpenContentProviderID: kiwix.ID, ContentID: library.ID, VisibilityStatus: true}).Error)
+
+		fulfilled := NewRequest[models.ContentRequest](env.Client, t, http.MethodPut, requestPath+"/fulfill", fulfill).
+			WithTestClaims(deptAdminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.ContentRequestFulfilled, fulfilled.Status)
+
+		own := NewRequest[[]models.ContentRequest](env.Client, t, http.MethodGet, "/api/open-content/requests", nil).
+			WithTestClaims(secondClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		for _, req := range own {
+			if req.ID != duplicate.ID {
+				require.Equal(t, models.ContentRequestUnderReview, req.Status)
+				continue
+			}
+			require.Equal(t, models.ContentRequestFulfilled, req.Status)
+			require.Equal(t, "library", req.ContentType)
+			require.NotNil(t, req.ContentID)
+			require.Equal(t, library.ID, *req.ContentID)
+			require.Equal(t, "adding the kiwix library", req.ReviewNote)
+		}
+
+		NewRequest[any](env.Client, t, http.MethodPatch, requestPath, map[string]any{"status": models.ContentRequestDeclined}).
+			WithTestClaims(deptAdminClaims).Do().ExpectStatus(http.StatusConflict)
+		again := requestContent(firstClaims, "Khan Academy: Algebra", http.StatusCreated)
+		require.Nil(t, again.DuplicateOfID, "fulfilled requests are no longer open")
+	})
+}
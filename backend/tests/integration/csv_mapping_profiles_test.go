@@ -0,0 +1,186 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVMappingProfiles(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Profile Facility")
+	require.NoError(t, err)
+	other, err := env.CreateTestFacility("Other Facility")
+	require.NoError(t, err)
+	_, err = env.CreateTestUser("profileexisting", models.Student, facility.ID, "99")
+	require.NoError(t, err)
+
+	deptAdmin := &handlers.Claims{Role: models.DepartmentAdmin, UserID: 1, FacilityID: facility.ID}
+	facilityAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: 1, FacilityID: facility.ID}
+	otherAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: 1, FacilityID: other.ID}
+
+	offenderExport := map[string]any{
+		"name": "Offender Management Export",
+		"columns": []map[string]any{
+			{"field": "full_name", "column": "Inmate Name", "transforms": []string{"split_last_first"}},
+			{"field": "resident_id", "column": "Inmate #", "transforms": []string{"trim_leading_zeros"}},
+			{"field": "housing_unit", "column": "Housing", "transforms": []string{"uppercase"}},
+			{"field": "release_date", "column": "PRD", "date_format": "DD-MON-YYYY"},
+			{"field": "date_of_birth", "column": "DOB", "date_format": "YYYYMMDD"},
+			{"field": "preferred_language", "column": "Language"},
+		},
+	}
+
+	var profile models.CSVMappingProfile
+	t.Run("Facility admins save profiles for their own facility", func(t *testing.T) {
+		profile = NewRequest[models.CSVMappingProfile](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", offenderExport).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.NotNil(t, profile.FacilityID)
+		require.Equal(t, facility.ID, *profile.FacilityID)
+		require.Len(t, profile.Columns, 6)
+
+		unsplit := map[string]any{"name": "No split", "columns": []map[string]any{
+			{"field": "full_name", "column": "Name"},
+			{"field": "resident_id", "column": "ID"},
+		}}
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", unsplit).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusBadRequest)
+		splitLastName := map[string]any{"name": "Split last name", "columns": []map[string]any{
+			{"field": "last_name", "column": "Last", "transforms": []string{"split_last_first", "split_first_last"}},
+			{"field": "first_name", "column": "First"},
+			{"field": "resident_id", "column": "ID"},
+		}}
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", splitLastName).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusBadRequest)
+		noID := map[string]any{"name": "No ID", "columns": []map[string]any{
+			{"field": "last_name", "column": "Last"},
+			{"field": "first_name", "column": "First"},
+		}}
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", noID).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusBadRequest)
+
+		listed := NewRequest[[]models.CSVMappingProfile](env.Client, t, http.MethodGet, "/api/users/bulk/profiles", nil).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, listed, "another facility doesn't see the profile")
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/users/bulk/profiles/%d", profile.ID), offenderExport).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	upload := func(t *testing.T, contents string, fields map[string]string) models.BulkUploadResponse {
+		return NewRequest[models.BulkUploadResponse](env.Client, t, http.MethodPost, "/api/users/bulk/upload", nil).
+			WithMultipartFile("file", "export.csv", []byte(contents), fields).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusOK).GetData()
+	}
+	export := `Inmate #,Inmate Name,Housing,PRD,DOB,Language
+000123,"Garcia, Ana",b-12,15-Mar-2031,19900102,Español
+000124,"Lopez, Luis",c-4,,19851120,english
+000125,Nguyen,a-1,,,
+0099,"Park, Min",a-2,01/02/2031,21000101,Klingon
+`
+
+	t.Run("Uploads read with a profile use its columns, transforms and date formats", func(t *testing.T) {
+		result := upload(t, export, map[string]string{"profile_id": fmt.Sprint(profile.ID)})
+		require.Equal(t, 2, result.ValidCount)
+		require.Equal(t, 2, result.ErrorCount)
+
+		garcia := result.ValidRows[0]
+		require.Equal(t, "Garcia", garcia.LastName)
+		require.Equal(t, "Ana", garcia.FirstName)
+		require.Equal(t, "123", garcia.ResidentID)
+		require.Equal(t, "123", garcia.Username)
+		require.Equal(t, "B-12", garcia.HousingUnit)
+		require.Equal(t, "2031-03-15", garcia.ReleaseDate)
+		require.Equal(t, "1990-01-02", garcia.DateOfBirth)
+		require.Equal(t, "es", garcia.PreferredLanguage)
+
+		require.Equal(t, []string{"Full name could not be split into a last and first name"}, result.InvalidRows[0].ErrorReasons)
+		require.ElementsMatch(t, []string{
+			"Release date must be written as DD-MON-YYYY",
+			"Date of birth must be in the past",
+			`Preferred language "Klingon" is not supported`,
+			"Resident ID already exists",
+		}, result.InvalidRows[1].ErrorReasons)
+
+		lines := strings.Split(strings.TrimSpace(string(result.ErrorCSVData)), "\n")
+		require.Len(t, lines, 3)
+		require.Equal(t, "Inmate #,Inmate Name,Housing,PRD,DOB,Language,Error Reason", lines[0])
+		require.Equal(t, "000125,Nguyen,a-1,,,,Full name could not be split into a last and first name", lines[1])
+		require.True(t, strings.HasPrefix(lines[2], `0099,"Park, Min",a-2,01/02/2031,21000101,Klingon,`), lines[2])
+
+		created := NewRequest[int](env.Client, t, http.MethodPost, "/api/users/bulk/create", result.ValidRows).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, 2, created)
+		var user models.User
+		require.NoError(t, env.DB.Where("doc_id = ?", "123").First(&user).Error)
+		require.Equal(t, "B-12", *user.HousingUnit)
+		require.Equal(t, "1990-01-02", *user.DateOfBirth)
+		require.Equal(t, i18n.Spanish, user.Locale)
+	})
+
+	t.Run("Two digit birth years are always in the past", func(t *testing.T) {
+		shortYears := NewRequest[models.CSVMappingProfile](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", map[string]any{
+			"name": "Short years",
+			"columns": []map[string]any{
+				{"field": "last_name", "column": "Last"},
+				{"field": "first_name", "column": "First"},
+				{"field": "resident_id", "column": "ID"},
+				{"field": "date_of_birth", "column": "DOB", "date_format": "MM/DD/YY"},
+			},
+		}).WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusCreated).GetData()
+		result := upload(t, "Last,First,ID,DOB\nDoe,Jo,501,01/02/65\nRoe,Al,502,03/04/99\nPoe,Ed,503,05/06/01\n",
+			map[string]string{"profile_id": fmt.Sprint(shortYears.ID)})
+		require.Equal(t, 3, result.ValidCount)
+		require.Equal(t, "1965-01-02", result.ValidRows[0].DateOfBirth)
+		require.Equal(t, "1999-03-04", result.ValidRows[1].DateOfBirth)
+		require.Equal(t, "2001-05-06", result.ValidRows[2].DateOfBirth)
+	})
+
+	t.Run("Release dates in the past are rejected", func(t *testing.T) {
+		past := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+		result := upload(t, "Last Name,First Name,Resident ID,Release Date\nDoe,Jo,601,"+past+"\nRoe,Al,602,2040-01-01\n", nil)
+		require.Equal(t, 1, result.ValidCount)
+		require.Equal(t, "2040-01-01", result.ValidRows[0].ReleaseDate)
+		require.Equal(t, []string{"Release date cannot be in the past"}, result.InvalidRows[0].ErrorReasons)
+	})
+
+	t.Run("A file missing a mapped column is rejected", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/users/bulk/upload", nil).
+			WithMultipartFile("file", "export.csv", []byte("Inmate #,Inmate Name\n1,\"Doe, Jo\"\n"), map[string]string{"profile_id": fmt.Sprint(profile.ID)}).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Profiles shared with every facility are managed by department admins", func(t *testing.T) {
+		shared := NewRequest[models.CSVMappingProfile](env.Client, t, http.MethodPost, "/api/users/bulk/profiles", map[string]any{
+			"name": "Statewide",
+			"columns": []map[string]any{
+				{"field": "last_name", "column": "Surname"},
+				{"field": "first_name", "column": "Given"},
+				{"field": "resident_id", "column": "Number"},
+			},
+		}).WithTestClaims(deptAdmin).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Nil(t, shared.FacilityID)
+
+		listed := NewRequest[[]models.CSVMappingProfile](env.Client, t, http.MethodGet, "/api/users/bulk/profiles", nil).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, listed, 1)
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/users/bulk/profiles/%d", shared.ID), nil).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusUnauthorized)
+
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/users/bulk/upload", nil).
+			WithMultipartFile("file", "export.csv", []byte(export), map[string]string{"profile_id": fmt.Sprint(profile.ID)}).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusBadRequest)
+
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/users/bulk/profiles/%d", shared.ID), nil).
+			WithTestClaims(deptAdmin).Do().ExpectStatus(http.StatusOK)
+	})
+}
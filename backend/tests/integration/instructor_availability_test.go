@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstructorAvailability(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Instructor Availability Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("availadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	instructor, err := env.CreateTestInstructor(facility.ID, "avail")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("availresident", models.Student, facility.ID, "AVAIL1")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID, TimeZone: facility.Timezone}
+
+	program, err := env.CreateTestProgram("Availability Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	morningClass, err := env.CreateTestClass(program, facility, models.Active, &instructor.ID)
+	require.NoError(t, err)
+	secondClass, err := env.CreateTestClass(program, facility, models.Active, &instructor.ID)
+	require.NoError(t, err)
+	room := &models.Room{FacilityID: facility.ID, Name: "Availability Room", IsActive: true}
+	require.NoError(t, env.DB.Create(room).Error)
+
+	eventPayload := func(rule string) map[string]any {
+		return map[string]any{
+			"duration":        "2h",
+			"room_id":         room.ID,
+			"instructor_id":   instructor.ID,
+			"recurrence_rule": rule,
+		}
+	}
+	createEvent := func(classID uint, rule string) {
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", classID), eventPayload(rule)).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+	}
+	createConflictingEvent := func(classID uint, rule string) *Response[[]models.RoomConflict] {
+		return NewRequest[[]models.RoomConflict](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", classID), eventPayload(rule)).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusConflict)
+	}
+	conflictTypes := func(conflicts []models.RoomConflict) []string {
+		types := make([]string, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			types = append(types, conflict.ConflictType)
+		}
+		return types
+	}
+
+	t.Run("Availability can be set and read back", func(t *testing.T) {
+		windows := make([]map[string]any, 0, 5)
+		for day := 1; day <= 5; day++ {
+			windows = append(windows, map[string]any{"day_of_week": day, "start_time": "08:00", "end_time": "12:00"})
+		}
+		profile := NewRequest[models.InstructorAvailabilityProfile](env.Client, t, http.MethodPut, fmt.Sprintf("/api/instructors/%d/availability", instructor.ID), map[string]any{
+			"windows":          windows,
+			"max_weekly_hours": 6,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, profile.Windows, 5)
+		require.NotNil(t, profile.MaxWeeklyHours)
+		require.Equal(t, 6.0, *profile.MaxWeeklyHours)
+
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/instructors/%d/availability", instructor.ID), map[string]any{
+			"windows": []map[string]any{{"day_of_week": 1, "start_time": "12:00", "end_time": "08:00"}},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/instructors/%d/availability", resident.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Sessions outside availability are rejected", func(t *testing.T) {
+		// 14:00 on Monday, November 9 2026
+		conflicts := createConflictingEvent(morningClass.ID, "DTSTART;TZID=Local:20261109T140000\nRRULE:FREQ=WEEKLY;COUNT=2").
+			ExpectMessage("the instructor is not available during this time").
+			GetData()
+		require.Len(t, conflicts, 2)
+		require.Equal(t, models.ConflictTypeInstructorUnavailable, conflicts[0].ConflictType)
+	})
+
+	t.Run("Weekly hour limit counts the instructor's existing sessions", func(t *testing.T) {
+		// Monday and Tuesday 09:00-11:00: 4 of the 6 allowed hours
+		createEvent(morningClass.ID, "DTSTART;TZID=Local:20261109T090000\nRRULE:FREQ=DAILY;COUNT=2")
+
+		conflicts := createConflictingEvent(secondClass.ID, "DTSTART;TZID=Local:20261111T090000\nRRULE:FREQ=DAILY;COUNT=2").
+			ExpectMessage("this schedule would put the instructor over their weekly hour limit").
+			GetData()
+		require.Equal(t, []string{models.ConflictTypeInstructorOverHours}, conflictTypes(conflicts))
+		require.Contains(t, conflicts[0].Message, "8.0 teaching hours")
+
+		createEvent(secondClass.ID, "DTSTART;TZID=Local:20261111T090000\nRRULE:FREQ=DAILY;COUNT=1")
+	})
+
+	t.Run("Blackouts block moving a session onto them", func(t *testing.T) {
+		blackout := NewRequest[models.InstructorBlackout](env.Client, t, http.MethodPost, fmt.Sprintf("/api/instructors/%d/blackouts", instructor.ID), map[string]any{
+			"start_date": "2026-11-16",
+			"reason":     "Training",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated).GetData()
+
+		var event models.ProgramClassEvent
+		require.NoError(t, env.DB.Where("class_id = ?", morningClass.ID).First(&event).Error)
+		conflicts := NewRequest[[]models.RoomConflict](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/program-classes/%d/events/%d", morningClass.ID, event.ID), map[string]any{
+			"date":     "2026-11-09",
+			"new_date": "2026-11-16",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusConflict).GetData()
+		require.Equal(t, []string{models.ConflictTypeInstructorUnavailable}, conflictTypes(conflicts))
+		require.Contains(t, conflicts[0].Message, "Training")
+
+		NewRequest[any](env.Client, t, http.MethodDelete, fmt.Sprintf("/api/instructors/%d/blackouts/%d", instructor.ID, blackout.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/program-classes/%d/events/%d", morningClass.ID, event.ID), map[string]any{
+			"date":     "2026-11-09",
+			"new_date": "2026-11-16",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+	})
+
+	t.Run("Rescheduling a series checks the new times", func(t *testing.T) {
+		var event models.ProgramClassEvent
+		require.NoError(t, env.DB.Where("class_id = ?", morningClass.ID).First(&event).Error)
+		series := map[string]any{
+			"id":              event.ID,
+			"duration":        "2h",
+			"room_id":         room.ID,
+			"instructor_id":   instructor.ID,
+			"recurrence_rule": "DTSTART;TZID=Local:20261123T140000\nRRULE:FREQ=DAILY;COUNT=2",
+		}
+		closed := map[string]any{
+			"id":              event.ID,
+			"duration":        "2h",
+			"room_id":         room.ID,
+			"instructor_id":   instructor.ID,
+			"recurrence_rule": "DTSTART;TZID=Local:20261109T090000\nRRULE:FREQ=DAILY;UNTIL=20261122T000000Z",
+		}
+		conflicts := NewRequest[[]models.RoomConflict](env.Client, t, http.MethodPut, fmt.Sprintf("/api/program-classes/%d/events", morningClass.ID), map[string]any{
+			"event_series":        series,
+			"closed_event_series": closed,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusConflict).GetData()
+		require.Equal(t, []string{models.ConflictTypeInstructorUnavailable, models.ConflictTypeInstructorUnavailable}, conflictTypes(conflicts))
+	})
+}
@@ -0,0 +1,210 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+type kioskSessionResponse struct {
+	models.KioskSession
+	Token string `json:"token"`
+}
+
+type kioskReviewResponse struct {
+	Session        models.KioskSession                  `json:"session"`
+	Attendance     []models.ProgramClassEventAttendance `json:"attendance"`
+	NotCheckedIn   []models.User                        `json:"not_checked_in"`
+	StillCheckedIn int                                  `json:"still_checked_in"`
+}
+
+type kioskAttendanceResponse struct {
+	NameFirst        string            `json:"name_first"`
+	AttendanceStatus models.Attendance `json:"attendance_status"`
+	CheckInAt        *string           `json:"check_in_at"`
+	CheckOutAt       *string           `json:"check_out_at"`
+}
+
+func TestKioskCheckIn(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Kiosk Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("kioskadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	early, err := env.CreateTestUser("kioskearly", models.Student, facility.ID, "KIOSK001")
+	require.NoError(t, err)
+	late, err := env.CreateTestUser("kiosklate", models.Student, facility.ID, "KIOSK002")
+	require.NoError(t, err)
+	absent, err := env.CreateTestUser("kioskabsent", models.Student, facility.ID, "KIOSK003")
+	require.NoError(t, err)
+	outsider, err := env.CreateTestUser("kioskoutsider", models.Student, facility.ID, "KIOSK004")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Kiosk Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	teacher, err := env.CreateTestInstructor(facility.ID, "kiosk")
+	require.NoError(t, err)
+	class, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	event, err := env.CreateTestEvent(class.ID, "", teacher.ID)
+	require.NoError(t, err)
+	otherEvent, err := env.CreateTestEvent(class.ID, "", teacher.ID)
+	require.NoError(t, err)
+	for _, student := range []*models.User{early, late, absent} {
+		_, err = env.CreateTestEnrollment(class.ID, student.ID, models.Enrolled)
+		require.NoError(t, err)
+	}
+
+	adminClaims := &handlers.Claims{UserID: admin.ID, Role: models.FacilityAdmin, FacilityID: facility.ID}
+	for _, student := range []*models.User{early, late, outsider} {
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/users/%d/kiosk-pin", student.ID), map[string]string{"pin": "2468"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK)
+	}
+
+	session := NewRequest[kioskSessionResponse](env.Client, t, http.MethodPost,
+		fmt.Sprintf("/api/program-classes/%d/events/%d/kiosk-sessions", class.ID, event.ID), map[string]string{}).
+		WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+	require.NotEmpty(t, session.Token)
+	kiosk := func(path string, body any) *Request[kioskAttendanceResponse] {
+		return NewRequest[kioskAttendanceResponse](env.Client, t, http.MethodPost, path, body).WithHeader("X-Kiosk-Token", session.Token)
+	}
+	reviewPath := fmt.Sprintf("/api/program-classes/%d/kiosk-sessions/%d", class.ID, session.ID)
+
+	t.Run("PIN must be digits", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/users/%d/kiosk-pin", early.ID), map[string]string{"pin": "12ab"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Sessions open only for today", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost,
+			fmt.Sprintf("/api/program-classes/%d/events/%d/kiosk-sessions", class.ID, event.ID),
+			map[string]string{"date": time.Now().AddDate(0, 0, -3).Format("2006-01-02")}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Requests without a valid token are refused", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			WithHeader("X-Kiosk-Token", session.Token+"x").Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("Enrolled resident checks in with DOC ID and PIN", func(t *testing.T) {
+		checkedIn := kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.Present, checkedIn.AttendanceStatus)
+		require.NotNil(t, checkedIn.CheckInAt)
+		require.Nil(t, checkedIn.CheckOutAt)
+
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusConflict)
+
+		var att models.ProgramClassEventAttendance
+		require.NoError(t, env.DB.Where("event_id = ? AND user_id = ?", event.ID, early.ID).First(&att).Error)
+		require.Equal(t, session.Date, att.Date)
+		require.NotNil(t, att.CheckInAt)
+		var others int64
+		require.NoError(t, env.DB.Model(&models.ProgramClassEventAttendance{}).Where("event_id = ?", otherEvent.ID).Count(&others).Error)
+		require.Zero(t, others, "the token only records attendance for its own event")
+	})
+
+	t.Run("Residents not enrolled and wrong PINs get the same answer", func(t *testing.T) {
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK004", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK002", "pin": "0000"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK003", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("Too many wrong PINs lock the resident out until the PIN is reset", func(t *testing.T) {
+		for range models.MaxKioskPinAttempts - 1 {
+			kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK002", "pin": "0000"}).
+				Do().ExpectStatus(http.StatusUnauthorized)
+		}
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK002", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/users/%d/kiosk-pin", late.ID), map[string]string{"pin": "1357"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK)
+		kiosk("/api/kiosk/check-in", map[string]string{"doc_id": "KIOSK002", "pin": "1357"}).
+			Do().ExpectStatus(http.StatusOK)
+	})
+
+	t.Run("Too many incorrect attempts pause the kiosk", func(t *testing.T) {
+		var current models.KioskSession
+		require.NoError(t, env.DB.First(&current, session.ID).Error)
+		for range models.MaxKioskSessionFailedAttempts - current.FailedAttempts {
+			kiosk("/api/kiosk/check-out", map[string]string{"doc_id": "KIOSK999", "pin": "2468"}).
+				Do().ExpectStatus(http.StatusUnauthorized)
+		}
+		kiosk("/api/kiosk/check-out", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusTooManyRequests)
+
+		require.NoError(t, env.DB.Model(&models.KioskSession{}).Where("id = ?", session.ID).
+			UpdateColumn("failed_attempts_since", time.Now().Add(-models.KioskAttemptWindow)).Error)
+		kiosk("/api/kiosk/check-out", map[string]string{"doc_id": "KIOSK999", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusUnauthorized)
+		require.NoError(t, env.DB.First(&current, session.ID).Error)
+		require.Equal(t, 1, current.FailedAttempts, "a new window starts once the last one is over")
+	})
+
+	t.Run("Checking out early records partial attendance", func(t *testing.T) {
+		checkedOut := kiosk("/api/kiosk/check-out", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.Partial, checkedOut.AttendanceStatus)
+		require.NotNil(t, checkedOut.CheckOutAt)
+
+		var att models.ProgramClassEventAttendance
+		require.NoError(t, env.DB.Where("event_id = ? AND user_id = ?", event.ID, early.ID).First(&att).Error)
+		require.NotNil(t, att.MinutesAttended)
+		require.Equal(t, 120, *att.ScheduledMinutes)
+
+		kiosk("/api/kiosk/check-out", map[string]string{"doc_id": "KIOSK001", "pin": "2468"}).
+			Do().ExpectStatus(http.StatusConflict)
+	})
+
+	t.Run("Instructor reviews and finalizes the session", func(t *testing.T) {
+		review := NewRequest[kioskReviewResponse](env.Client, t, http.MethodGet, reviewPath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, review.Attendance, 2)
+		require.Equal(t, 1, review.StillCheckedIn)
+		require.Len(t, review.NotCheckedIn, 1)
+		require.Equal(t, absent.ID, review.NotCheckedIn[0].ID)
+
+		final := NewRequest[kioskReviewResponse](env.Client, t, http.MethodPut, reviewPath+"/finalize", map[string]bool{"mark_absent": true}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.NotNil(t, final.Session.FinalizedAt)
+		require.Zero(t, final.StillCheckedIn)
+		require.Empty(t, final.NotCheckedIn)
+		require.Len(t, final.Attendance, 3)
+		for _, att := range final.Attendance {
+			if att.UserID == absent.ID {
+				require.Equal(t, models.Absent_Unexcused, att.AttendanceStatus)
+				continue
+			}
+			require.NotNil(t, att.CheckOutAt)
+			require.Contains(t, []models.Attendance{models.Present, models.Partial}, att.AttendanceStatus)
+		}
+
+		NewRequest[any](env.Client, t, http.MethodPut, reviewPath+"/finalize", map[string]bool{}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusConflict)
+		NewRequest[any](env.Client, t, http.MethodGet, "/api/kiosk/session", nil).
+			WithHeader("X-Kiosk-Token", session.Token).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("Admins at other facilities cannot open or review kiosks", func(t *testing.T) {
+		other, err := env.CreateTestFacility("Other Kiosk Facility")
+		require.NoError(t, err)
+		otherClaims := &handlers.Claims{UserID: admin.ID, Role: models.FacilityAdmin, FacilityID: other.ID}
+		NewRequest[any](env.Client, t, http.MethodGet, reviewPath, nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src"
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLocale(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Locale Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("localeadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, i18n.English, admin.Locale, "users start out in English")
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+
+	t.Run("Users choose their own locale", func(t *testing.T) {
+		user := NewRequest[models.User](env.Client, t, http.MethodPut, "/api/users/me/locale", map[string]any{"locale": "es-MX"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, i18n.Spanish, user.Locale)
+
+		NewRequest[any](env.Client, t, http.MethodPut, "/api/users/me/locale", map[string]any{"locale": "fr"}).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	req := &models.ReportGenerateRequest{
+		Type:       models.RoomUtilizationReport,
+		Format:     models.FormatCSV,
+		StartDate:  time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+		FacilityID: &facility.ID,
+	}
+
+	t.Run("Reports are written in the requester's locale", func(t *testing.T) {
+		spanishClaims := *claims
+		spanishClaims.Locale = i18n.Spanish
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/reports/generate", req).
+			WithTestClaims(&spanishClaims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectBodyContains("Semana del,Centro,Sala,Sesiones,Sesiones canceladas,Horas reservadas,Horas disponibles,% de uso")
+
+		english := *req
+		english.Locale = i18n.English
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/reports/generate", &english).
+			WithTestClaims(&spanishClaims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectBodyContains("Week Of,Facility,Room,Sessions")
+	})
+
+	t.Run("Import error files translate the reasons but not the headers", func(t *testing.T) {
+		headerMap, err := src.ValidateCSVHeaders([]string{"Last Name", "First Name", "Resident ID"})
+		require.NoError(t, err)
+		noIdentities := func(string, string) (bool, bool) { return false, false }
+		_, invalid := src.ValidateUserRow([]string{"Garcia", "", "123"}, 2, headerMap, map[string]int{}, noIdentities, map[string]int{}, i18n.Spanish)
+		require.NotNil(t, invalid)
+		errorCSV, err := src.GenerateErrorCSV([]models.InvalidUserRow{*invalid})
+		require.NoError(t, err)
+		require.Contains(t, string(errorCSV), "LastName,FirstName,ResidentID,Username,Error Reason")
+		require.Contains(t, string(errorCSV), "Falta un campo obligatorio - Nombre")
+	})
+
+	t.Run("Dates use the locale's month names", func(t *testing.T) {
+		date := time.Date(2025, 3, 3, 15, 4, 0, 0, time.UTC)
+		require.Equal(t, "3 de marzo de 2025", i18n.Spanish.FormatDate(date, "January 2, 2006"))
+		require.Equal(t, "March 3, 2025", i18n.English.FormatDate(date, "January 2, 2006"))
+	})
+}
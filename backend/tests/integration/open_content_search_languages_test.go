@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenContentSearchLanguages(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Language Facility")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("languageresident", models.Student, facility.ID, "lang-1")
+	require.NoError(t, err)
+	claims := &handlers.Claims{UserID: resident.ID, Role: models.Student, FacilityID: facility.ID}
+
+	kiwix := &models.OpenContentProvider{Title: "Kiwix", Url: "http://kiwix"}
+	require.NoError(t, env.DB.Create(kiwix).Error)
+	createLibrary := func(title, language string, visible bool) *models.Library {
+		library := &models.Library{OpenContentProviderID: kiwix.ID, Title: title, Url: "/" + title, Language: &language}
+		require.NoError(t, env.DB.Create(library).Error)
+		require.NoError(t, env.DB.Create(&models.FacilityVisibilityStatus{
+			FacilityID: facility.ID, OpenContentProviderID: kiwix.ID, ContentID: library.ID, VisibilityStatus: visible,
+		}).Error)
+		return library
+	}
+	createLibrary("english-one", "eng", true)
+	createLibrary("english-two", "eng", true)
+	spanish := createLibrary("spanish", "spa", true)
+	createLibrary("hidden-french", "fra", false)
+	for range 3 {
+		require.NoError(t, env.DB.Create(&models.OpenContentActivity{
+			OpenContentProviderID: kiwix.ID, FacilityID: facility.ID, UserID: resident.ID, ContentID: spanish.ID, OpenContentUrlID: 1,
+		}).Error)
+	}
+
+	args := &models.QueryContext{Ctx: context.Background(), FacilityID: facility.ID}
+	t.Run("Languages are ordered by how much the facility uses them", func(t *testing.T) {
+		languages, err := env.DB.GetFacilityLibraryLanguages(args)
+		require.NoError(t, err)
+		require.Len(t, languages, 2)
+		require.Equal(t, "spa", languages[0].Language)
+		require.Equal(t, int64(3), languages[0].Activity)
+		require.Equal(t, "eng", languages[1].Language)
+		require.Equal(t, 2, languages[1].Libraries)
+
+		libraries, err := env.DB.GetAllLibrariesByLangs(args, []string{"eng", "fra"})
+		require.NoError(t, err)
+		require.Len(t, libraries, 2, "hidden libraries are not searched")
+	})
+
+	t.Run("Facets list every visible language and the searched ones", func(t *testing.T) {
+		result := NewRequest[[]models.OpenContentSearchResult](env.Client, t, http.MethodGet, "/api/open-content/search?search=water&language=fra", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, result, 1)
+		facets := result[0].Languages
+		require.Len(t, facets, 3)
+		for _, facet := range facets {
+			require.Equal(t, facet.Language == "fra", facet.Selected, facet.Language)
+			if facet.Selected {
+				require.NotNil(t, facet.Results)
+				require.Zero(t, *facet.Results)
+			} else {
+				require.Nil(t, facet.Results)
+			}
+		}
+	})
+
+	t.Run("Too many languages are rejected", func(t *testing.T) {
+		path := "/api/open-content/search?search=water"
+		for _, language := range []string{"ara", "deu", "eng", "fra", "ita", "nld", "por", "rus", "spa", "zho", "hin"} {
+			path += "&language=" + language
+		}
+		NewRequest[any](env.Client, t, http.MethodGet, path, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+}
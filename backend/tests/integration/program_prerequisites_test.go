@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ineligibleResponse struct {
+	Ineligible []models.EnrollmentIneligibility `json:"ineligible"`
+}
+
+func TestProgramPrerequisites(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Prerequisite Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("prereqadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+
+	intro, err := env.CreateTestProgram("Intro to Welding", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	advanced, err := env.CreateTestProgram("Advanced Welding", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(advanced.ID, []uint{facility.ID}))
+
+	t.Run("Sets prerequisites and rejects cycles", func(t *testing.T) {
+		prerequisites := NewRequest[[]models.ProgramPrerequisite](env.Client, t, http.MethodPut, fmt.Sprintf("/api/programs/%d/prerequisites", advanced.ID), map[string]any{
+			"prerequisite_program_ids": []uint{intro.ID},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, prerequisites, 1)
+		require.Equal(t, intro.ID, prerequisites[0].PrerequisiteProgramID)
+		require.NotNil(t, prerequisites[0].PrerequisiteProgram)
+		require.Equal(t, intro.Name, prerequisites[0].PrerequisiteProgram.Name)
+
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/programs/%d/prerequisites", intro.ID), map[string]any{
+			"prerequisite_program_ids": []uint{advanced.ID},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/programs/%d/prerequisites", intro.ID), map[string]any{
+			"prerequisite_program_ids": []uint{intro.ID},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	class, err := env.CreateTestClass(advanced, facility, models.Active, nil)
+	require.NoError(t, err)
+
+	t.Run("Blocks enrollment with a per-resident ineligibility list", func(t *testing.T) {
+		resident, err := env.CreateTestUser("prereqmissing", models.Student, facility.ID, "P100")
+		require.NoError(t, err)
+
+		ineligible := NewRequest[ineligibleResponse](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids": []int{int(resident.ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusUnprocessableEntity).GetData().Ineligible
+		require.Len(t, ineligible, 1)
+		require.Equal(t, resident.ID, ineligible[0].UserID)
+		require.Len(t, ineligible[0].MissingPrerequisites, 1)
+		require.Equal(t, intro.ID, ineligible[0].MissingPrerequisites[0].ProgramID)
+
+		var count int64
+		require.NoError(t, env.DB.Model(&models.ProgramClassEnrollment{}).Where("class_id = ? AND user_id = ?", class.ID, resident.ID).Count(&count).Error)
+		require.Zero(t, count)
+	})
+
+	t.Run("Residents who completed the prerequisite can enroll", func(t *testing.T) {
+		resident, err := env.CreateTestUser("prereqdone", models.Student, facility.ID, "P200")
+		require.NoError(t, err)
+		require.NoError(t, env.DB.Create(&models.ProgramCompletion{
+			UserID:              resident.ID,
+			ProgramClassID:      class.ID,
+			FacilityName:        facility.Name,
+			CreditType:          string(models.Completion),
+			AdminEmail:          facilityAdmin.Email,
+			ProgramOwner:        "owner",
+			ProgramName:         intro.Name,
+			ProgramID:           intro.ID,
+			ProgramClassStartDt: time.Now().AddDate(0, -3, 0),
+			EnrolledOnDt:        time.Now().AddDate(0, -3, 0),
+		}).Error)
+
+		checked := NewRequest[ineligibleResponse](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollment-eligibility", class.ID), map[string]any{
+			"user_ids": []int{int(resident.ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, checked.Ineligible)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids": []int{int(resident.ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+	})
+
+	t.Run("Admin override enrolls and records the reason", func(t *testing.T) {
+		resident, err := env.CreateTestUser("prereqoverride", models.Student, facility.ID, "P300")
+		require.NoError(t, err)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids":              []int{int(resident.ID)},
+			"eligibility_overrides": []map[string]any{{"user_id": resident.ID, "reason": ""}},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollments", class.ID), map[string]any{
+			"user_ids":              []int{int(resident.ID)},
+			"eligibility_overrides": []map[string]any{{"user_id": resident.ID, "reason": "Completed equivalent course at prior facility"}},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+
+		require.Equal(t, models.Enrolled, enrollmentStatusFor(t, env, class.ID, resident.ID))
+		overrides := NewRequest[[]models.ProgramEligibilityOverride](env.Client, t, http.MethodGet, fmt.Sprintf("/api/program-classes/%d/eligibility-overrides", class.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, overrides, 1)
+		require.Equal(t, resident.ID, overrides[0].UserID)
+		require.Equal(t, advanced.ID, overrides[0].ProgramID)
+		require.Equal(t, "Completed equivalent course at prior facility", overrides[0].Reason)
+		require.NotNil(t, overrides[0].CreateUserID)
+		require.Equal(t, facilityAdmin.ID, *overrides[0].CreateUserID)
+	})
+
+	t.Run("Overrides are only recorded for residents who were placed", func(t *testing.T) {
+		resident, err := env.CreateTestUser("prereqplaced", models.Student, facility.ID, "P400")
+		require.NoError(t, err)
+		_, err = env.CreateTestEnrollment(class.ID, resident.ID, models.Enrolled)
+		require.NoError(t, err)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/waitlist", class.ID), map[string]any{
+			"user_ids":              []int{int(resident.ID)},
+			"eligibility_overrides": []map[string]any{{"user_id": resident.ID, "reason": "Instructor approval"}},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated)
+
+		var count int64
+		require.NoError(t, env.DB.Model(&models.ProgramEligibilityOverride{}).Where("class_id = ? AND user_id = ?", class.ID, resident.ID).Count(&count).Error)
+		require.Zero(t, count, "the resident was already enrolled so nothing was overridden")
+	})
+
+	t.Run("Eligibility is only checked for residents at the class's facility", func(t *testing.T) {
+		other, err := env.CreateTestFacility("Other Prerequisite Facility")
+		require.NoError(t, err)
+		outsider, err := env.CreateTestUser("prereqoutsider", models.Student, other.ID, "P500")
+		require.NoError(t, err)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollment-eligibility", class.ID), map[string]any{
+			"user_ids": []int{int(outsider.ID)},
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		otherAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: other.ID}
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/enrollment-eligibility", class.ID), map[string]any{
+			"user_ids": []int{int(outsider.ID)},
+		}).WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src"
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/i18n"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func releaseHistoryCount(t *testing.T, env *TestEnv, userID uint, action models.ActivityHistoryAction) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, env.DB.Model(&models.UserAccountHistory{}).Where("user_id = ? AND action = ?", userID, action).Count(&count).Error)
+	return count
+}
+
+func TestReleaseLifecycle(t *testing.T) {
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	packetDir := t.TempDir()
+	t.Setenv("RELEASE_PACKET_DIR", packetDir)
+	t.Setenv("RELEASE_WARNING_DAYS", "14")
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Lifecycle Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("lifecycleadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Lifecycle Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, nil)
+	require.NoError(t, err)
+
+	setReleaseDate := func(user *models.User, date string) {
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("release_date", date).Error)
+	}
+	// noon UTC is the same date in every US time zone
+	now := time.Date(2030, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	leaving, err := env.CreateTestUser("lifecycleleaving", models.Student, facility.ID, "L100")
+	require.NoError(t, err)
+	setReleaseDate(leaving, "2030-06-15")
+	_, err = env.CreateTestEnrollment(class.ID, leaving.ID, models.Enrolled)
+	require.NoError(t, err)
+	soon, err := env.CreateTestUser("lifecyclesoon", models.Student, facility.ID, "L200")
+	require.NoError(t, err)
+	setReleaseDate(soon, "2030-06-20")
+	later, err := env.CreateTestUser("lifecyclelater", models.Student, facility.ID, "L300")
+	require.NoError(t, err)
+	setReleaseDate(later, "2030-09-01")
+
+	t.Run("Residents released today are withdrawn, packeted and deactivated", func(t *testing.T) {
+		require.NoError(t, env.Server.RunReleaseLifecycle(context.Background(), now))
+
+		var enrollment models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("user_id = ? AND class_id = ?", leaving.ID, class.ID).First(&enrollment).Error)
+		require.Equal(t, models.EnrollmentIncompleteReleased, enrollment.EnrollmentStatus)
+		require.Equal(t, models.ReleaseChangeReason, enrollment.ChangeReason)
+		require.NotNil(t, enrollment.EnrollmentEndedAt)
+
+		var packets int64
+		require.NoError(t, env.DB.Model(&models.ReleasePacket{}).Where("user_id = ?", leaving.ID).Count(&packets).Error)
+		require.EqualValues(t, 1, packets)
+
+		var user models.User
+		require.NoError(t, env.DB.First(&user, leaving.ID).Error)
+		require.NotNil(t, user.DeactivatedAt)
+		for _, action := range []models.ActivityHistoryAction{models.ReleaseEnrollmentsWithdrawn, models.ReleasePacketGenerated, models.UserDeactivated} {
+			require.EqualValues(t, 1, releaseHistoryCount(t, env, leaving.ID, action), action)
+		}
+	})
+
+	t.Run("A release packet that fails is produced by the next run without releasing again", func(t *testing.T) {
+		blocked, err := env.CreateTestUser("lifecycleblocked", models.Student, facility.ID, "L150")
+		require.NoError(t, err)
+		setReleaseDate(blocked, "2030-06-15")
+		_, err = env.CreateTestEnrollment(class.ID, blocked.ID, models.Enrolled)
+		require.NoError(t, err)
+		// a file where the resident's packet directory goes makes storing the packet fail
+		blocker := filepath.Join(packetDir, "release-packets", fmt.Sprint(blocked.ID))
+		require.NoError(t, os.MkdirAll(filepath.Dir(blocker), 0o750))
+		require.NoError(t, os.WriteFile(blocker, nil, 0o640))
+
+		require.Error(t, env.Server.RunReleaseLifecycle(context.Background(), now))
+		var user models.User
+		require.NoError(t, env.DB.First(&user, blocked.ID).Error)
+		require.NotNil(t, user.DeactivatedAt, "the release stands without its packet")
+		require.True(t, user.ReleasePacketPending)
+		var enrollment models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("user_id = ? AND class_id = ?", blocked.ID, class.ID).First(&enrollment).Error)
+		require.Equal(t, models.EnrollmentIncompleteReleased, enrollment.EnrollmentStatus)
+
+		require.NoError(t, os.Remove(blocker))
+		require.NoError(t, env.Server.RunReleaseLifecycle(context.Background(), now))
+		require.NoError(t, env.DB.First(&user, blocked.ID).Error)
+		require.False(t, user.ReleasePacketPending)
+		var packets int64
+		require.NoError(t, env.DB.Model(&models.ReleasePacket{}).Where("user_id = ?", blocked.ID).Count(&packets).Error)
+		require.EqualValues(t, 1, packets)
+		for _, action := range []models.ActivityHistoryAction{models.ReleaseEnrollmentsWithdrawn, models.ReleasePacketGenerated, models.UserDeactivated} {
+			require.EqualValues(t, 1, releaseHistoryCount(t, env, blocked.ID, action), action)
+		}
+	})
+
+	t.Run("Admins are warned once about residents released within the window", func(t *testing.T) {
+		require.EqualValues(t, 1, releaseHistoryCount(t, env, soon.ID, models.ReleaseWarningSent))
+		require.Zero(t, releaseHistoryCount(t, env, later.ID, models.ReleaseWarningSent))
+
+		require.NoError(t, env.Server.RunReleaseLifecycle(context.Background(), now.Add(time.Hour)))
+		require.EqualValues(t, 1, releaseHistoryCount(t, env, soon.ID, models.ReleaseWarningSent))
+
+		setReleaseDate(soon, "2030-06-22")
+		require.NoError(t, env.Server.RunReleaseLifecycle(context.Background(), now.Add(2*time.Hour)))
+		require.EqualValues(t, 2, releaseHistoryCount(t, env, soon.ID, models.ReleaseWarningSent), "a changed date is warned about again")
+
+		var user models.User
+		require.NoError(t, env.DB.First(&user, soon.ID).Error)
+		require.Nil(t, user.DeactivatedAt)
+	})
+
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+
+	t.Run("Admins set and clear release dates", func(t *testing.T) {
+		path := fmt.Sprintf("/api/users/%d/release-date", later.ID)
+		user := NewRequest[models.User](env.Client, t, http.MethodPut, path, map[string]any{"release_date": "12/31/2099"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, "2099-12-31", *user.ReleaseDate)
+		require.EqualValues(t, 1, releaseHistoryCount(t, env, later.ID, models.ReleaseDateSet))
+
+		NewRequest[any](env.Client, t, http.MethodPut, path, map[string]any{"release_date": "2020-01-01"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPut, path, map[string]any{"release_date": "next spring"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("/api/users/%d/release-date", admin.ID), map[string]any{"release_date": "2099-12-31"}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+
+		user = NewRequest[models.User](env.Client, t, http.MethodPut, path, map[string]any{"release_date": nil}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Nil(t, user.ReleaseDate)
+	})
+
+	t.Run("Admins see residents released within the window", func(t *testing.T) {
+		setReleaseDate(later, time.Now().AddDate(0, 0, 5).Format(models.ReleaseDateLayout))
+		releases := NewRequest[[]models.UpcomingRelease](env.Client, t, http.MethodGet, "/api/users/upcoming-releases", nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, releases, 1)
+		require.Equal(t, later.ID, releases[0].UserID)
+	})
+
+	t.Run("Bulk imports read the release date column", func(t *testing.T) {
+		headerMap, err := src.ValidateCSVHeaders([]string{"Last Name", "First Name", "Resident ID", "Projected Release Date"})
+		require.NoError(t, err)
+		noIdentities := func(string, string) (bool, bool) { return false, false }
+		valid, _ := src.ValidateUserRow([]string{"Garcia", "Ana", "B100", "6/20/2030"}, 2, headerMap, map[string]int{}, noIdentities, map[string]int{}, i18n.English)
+		require.NotNil(t, valid)
+		require.Equal(t, "2030-06-20", valid.ReleaseDate)
+		_, invalid := src.ValidateUserRow([]string{"Lopez", "Luis", "B200", "soon"}, 3, headerMap, map[string]int{}, noIdentities, map[string]int{}, i18n.English)
+		require.NotNil(t, invalid)
+		require.Contains(t, invalid.ErrorReasons[0], "Release date")
+	})
+}
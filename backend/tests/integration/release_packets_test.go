@@ -0,0 +1,228 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readPacketFile(t *testing.T, packet []byte, name string) []byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(packet), int64(len(packet)))
+	require.NoError(t, err)
+	file, err := archive.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return content
+}
+
+type deactivateUserResponse struct {
+	Message            string                `json:"message"`
+	ReleasePacket      *models.ReleasePacket `json:"release_packet"`
+	ReleasePacketError string                `json:"release_packet_error"`
+}
+
+func TestReleasePackets(t *testing.T) {
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	t.Setenv("RELEASE_PACKET_DIR", t.TempDir())
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Release Facility")
+	require.NoError(t, err)
+	otherFacility, err := env.CreateTestFacility("Other Release Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("releaseadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("releaseres", models.Student, facility.ID, "R100")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Release Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	teacher, err := env.CreateTestInstructor(facility.ID, "release")
+	require.NoError(t, err)
+
+	finished, err := env.CreateTestClass(program, facility, models.Completed, &teacher.ID)
+	require.NoError(t, err)
+	require.NoError(t, env.SetClassCreditHours(finished.ID, 4))
+	current, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(finished.ID, resident.ID, models.EnrollmentCompleted)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(current.ID, resident.ID, models.Enrolled)
+	require.NoError(t, err)
+	require.NoError(t, env.DB.Create(&models.ProgramCompletion{
+		UserID:              resident.ID,
+		ProgramClassID:      finished.ID,
+		FacilityName:        facility.Name,
+		CreditType:          string(models.Education),
+		AdminEmail:          admin.Email,
+		ProgramName:         program.Name,
+		ProgramID:           program.ID,
+		ProgramClassName:    "Finished Class",
+		ProgramClassStartDt: time.Now().AddDate(0, -3, 0),
+		EnrolledOnDt:        time.Now().AddDate(0, -3, 0),
+	}).Error)
+	require.NoError(t, env.DB.Create(&models.LearningRecordEntry{
+		UserID: resident.ID, ClientID: "release-reflection", ProgramName: program.Name, Summary: "Built a budget",
+	}).Error)
+
+	kiwix := &models.OpenContentProvider{Title: "Kiwix", Url: "http://kiwix-release"}
+	require.NoError(t, env.DB.Create(kiwix).Error)
+	library := &models.Library{OpenContentProviderID: kiwix.ID, Title: "Release Library", Url: "/release-library"}
+	require.NoError(t, env.DB.Create(library).Error)
+	require.NoError(t, env.DB.Create(&models.OpenContentFavorite{
+		UserID: resident.ID, ContentID: library.ID, OpenContentProviderID: kiwix.ID, FacilityID: &facility.ID,
+	}).Error)
+
+	platform := &models.ProviderPlatform{Type: models.CanvasOSS, Name: "Release Canvas", BaseUrl: "http://canvas-release"}
+	require.NoError(t, env.DB.Create(platform).Error)
+	course := &models.Course{ProviderPlatformID: platform.ID, Name: "Intro to Typing", TotalProgressMilestones: 4}
+	require.NoError(t, env.DB.Create(course).Error)
+	require.NoError(t, env.DB.Create(&models.UserEnrollment{UserID: resident.ID, CourseID: course.ID}).Error)
+	for i, done := range []bool{true, true, false} {
+		require.NoError(t, env.DB.Create(&models.Milestone{
+			UserID: resident.ID, CourseID: course.ID, ExternalID: fmt.Sprintf("release-milestone-%d", i),
+			Type: models.AssignmentSubmission, IsCompleted: done,
+		}).Error)
+	}
+
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+	packetsPath := fmt.Sprintf("/api/users/%d/release-packets", resident.ID)
+
+	var packet models.ReleasePacket
+	t.Run("Deactivating with a release packet produces one", func(t *testing.T) {
+		resp := NewRequest[deactivateUserResponse](env.Client, t, http.MethodPost,
+			fmt.Sprintf("/api/users/%d/deactivate?release_packet=true", resident.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, resp.ReleasePacketError)
+		require.NotNil(t, resp.ReleasePacket)
+		packet = *resp.ReleasePacket
+		require.NotZero(t, packet.ID)
+		require.Len(t, packet.Checksum, 64)
+		require.Contains(t, packet.Filename, "R100")
+
+		var history []models.UserAccountHistory
+		require.NoError(t, env.DB.Where("user_id = ? AND action = ?", resident.ID, models.ReleasePacketGenerated).Find(&history).Error)
+		require.Len(t, history, 1)
+		require.Equal(t, admin.ID, *history[0].AdminID)
+	})
+
+	t.Run("Admins download a signed packet holding the resident's learning history", func(t *testing.T) {
+		resp := NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("%s/%d/download", packetsPath, packet.ID), nil).
+			WithTestClaims(adminClaims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", "application/zip")
+		zipBytes := []byte(resp.rawBody)
+		require.Equal(t, packet.Checksum, models.ReleasePacketChecksum(zipBytes))
+
+		manifest, err := models.VerifyReleasePacket(zipBytes)
+		require.NoError(t, err)
+		require.Equal(t, resident.ID, manifest.UserID)
+		require.Len(t, manifest.Files, 6)
+
+		verification := NewRequest[models.ReleasePacketVerification](env.Client, t, http.MethodPost, "/api/release-packets/verify", nil).
+			WithMultipartFile("file", packet.Filename, zipBytes, nil).Do().ExpectStatus(http.StatusOK).GetData()
+		require.True(t, verification.Valid)
+		require.Equal(t, "R100", verification.DocID)
+
+		completions, err := csv.NewReader(bytes.NewReader(readPacketFile(t, zipBytes, "completions.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, completions, 2)
+		require.Equal(t, "Finished Class", completions[1][1])
+		require.Equal(t, "4", completions[1][4])
+
+		enrollments, err := csv.NewReader(bytes.NewReader(readPacketFile(t, zipBytes, "enrollments.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, enrollments, 3)
+		require.Equal(t, "Withdrawn", enrollments[2][3], "the packet records enrollments withdrawn by the deactivation")
+
+		favorites, err := csv.NewReader(bytes.NewReader(readPacketFile(t, zipBytes, "favorites.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, favorites, 2, "favorites from disabled providers are kept")
+		require.Equal(t, "Release Library", favorites[1][1])
+
+		progress, err := csv.NewReader(bytes.NewReader(readPacketFile(t, zipBytes, "course_progress.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{"Release Canvas", "Intro to Typing"}, progress[1][:2])
+		require.Equal(t, "2", progress[1][3])
+		require.Equal(t, "4", progress[1][4])
+
+		require.Contains(t, string(readPacketFile(t, zipBytes, "learning_records.json")), "Built a budget")
+	})
+
+	t.Run("Edited packets fail verification", func(t *testing.T) {
+		data := models.NewReleasePacketData(resident, "")
+		zipBytes, err := data.ToZip()
+		require.NoError(t, err)
+		_, err = models.VerifyReleasePacket(zipBytes)
+		require.NoError(t, err)
+
+		archive, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+		require.NoError(t, err)
+		var tampered bytes.Buffer
+		writer := zip.NewWriter(&tampered)
+		for _, file := range archive.File {
+			content := readPacketFile(t, zipBytes, file.Name)
+			if file.Name == "completions.csv" {
+				content = append(content, []byte("Forged Program,Forged Class,,,40,,,\n")...)
+			}
+			w, err := writer.Create(file.Name)
+			require.NoError(t, err)
+			_, err = w.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		_, err = models.VerifyReleasePacket(tampered.Bytes())
+		require.ErrorContains(t, err, "completions.csv")
+
+		verification := NewRequest[models.ReleasePacketVerification](env.Client, t, http.MethodPost, "/api/release-packets/verify", nil).
+			WithMultipartFile("file", "packet.zip", tampered.Bytes(), nil).Do().ExpectStatus(http.StatusUnprocessableEntity).GetData()
+		require.False(t, verification.Valid)
+		require.Contains(t, verification.Reason, "completions.csv")
+		require.Empty(t, verification.DocID)
+	})
+
+	t.Run("Admins can produce another packet and list them", func(t *testing.T) {
+		NewRequest[models.ReleasePacket](env.Client, t, http.MethodPost, packetsPath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated)
+		packets := NewRequest[[]models.ReleasePacket](env.Client, t, http.MethodGet, packetsPath, nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, packets, 2)
+	})
+
+	t.Run("Packets are only produced for residents", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/users/%d/release-packets", admin.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+
+		staff, err := env.CreateTestUser("releasestaff", models.FacilityAdmin, facility.ID, "")
+		require.NoError(t, err)
+		resp := NewRequest[deactivateUserResponse](env.Client, t, http.MethodPost,
+			fmt.Sprintf("/api/users/%d/deactivate?release_packet=true", staff.ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Nil(t, resp.ReleasePacket)
+		require.Equal(t, "release packets are only available for residents", resp.ReleasePacketError,
+			"the deactivation stands when its packet fails")
+		var deactivated models.User
+		require.NoError(t, env.DB.Unscoped().First(&deactivated, staff.ID).Error)
+		require.NotNil(t, deactivated.DeactivatedAt)
+	})
+
+	t.Run("Admins at other facilities cannot see packets", func(t *testing.T) {
+		otherClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: otherFacility.ID}
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("%s/%d/download", packetsPath, packet.ID), nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncReportJobs(t *testing.T) {
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Report Job Facility")
+	require.NoError(t, err)
+	otherFacility, err := env.CreateTestFacility("Other Report Job Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("reportjobadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	otherAdmin, err := env.CreateTestUser("reportjobother", models.FacilityAdmin, otherFacility.ID, "")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+	otherClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: otherAdmin.ID, FacilityID: otherFacility.ID}
+
+	var job models.ReportJob
+	t.Run("Async requests are queued and scoped to the admin's facility", func(t *testing.T) {
+		job = NewRequest[models.ReportJob](env.Client, t, http.MethodPost, "/api/reports/generate", map[string]any{
+			"type":         models.AttendanceReport,
+			"format":       models.FormatCSV,
+			"start_date":   time.Now().AddDate(-1, 0, 0),
+			"end_date":     time.Now(),
+			"facility_ids": []uint{facility.ID, otherFacility.ID},
+			"async":        true,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.NotZero(t, job.ID)
+		require.Equal(t, models.ReportJobPending, job.Status)
+		require.NotNil(t, job.Request.FacilityID)
+		require.Equal(t, facility.ID, *job.Request.FacilityID)
+		require.Empty(t, job.Request.FacilityIDs)
+
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/reports/generate", map[string]any{
+			"type":       models.ClassRosterReport,
+			"format":     models.FormatCSV,
+			"start_date": time.Now().AddDate(-1, 0, 0),
+			"end_date":   time.Now(),
+			"async":      true,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	jobPath := fmt.Sprintf("/api/reports/jobs/%d", job.ID)
+	t.Run("The finished report is downloadable by the requesting admin only", func(t *testing.T) {
+		polled := NewRequest[models.ReportJob](env.Client, t, http.MethodGet, jobPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.ReportJobCompleted, polled.Status)
+		require.Equal(t, jobPath+"/download", polled.DownloadPath)
+		require.NotNil(t, polled.ExpiresAt)
+		require.True(t, polled.ExpiresAt.After(time.Now()))
+
+		NewRequest[any](env.Client, t, http.MethodGet, polled.DownloadPath, nil).
+			WithTestClaims(claims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", "text/csv").
+			ExpectBodyContains("Date,Program Name,Class Name,Facility,Resident Name,DOC ID,Attendance Status,Note")
+
+		NewRequest[any](env.Client, t, http.MethodGet, jobPath, nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusNotFound)
+		NewRequest[any](env.Client, t, http.MethodGet, polled.DownloadPath, nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusNotFound)
+
+		jobs := NewRequest[[]models.ReportJob](env.Client, t, http.MethodGet, "/api/reports/jobs", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, jobs, 1)
+		others := NewRequest[[]models.ReportJob](env.Client, t, http.MethodGet, "/api/reports/jobs", nil).
+			WithTestClaims(otherClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, others)
+	})
+
+	t.Run("Expired reports can no longer be downloaded", func(t *testing.T) {
+		require.NoError(t, env.DB.Model(&models.ReportJob{}).Where("id = ?", job.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+		polled := NewRequest[models.ReportJob](env.Client, t, http.MethodGet, jobPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.ReportJobExpired, polled.Status)
+		NewRequest[any](env.Client, t, http.MethodGet, jobPath+"/download", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusGone)
+	})
+}
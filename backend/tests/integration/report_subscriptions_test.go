@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSubscriptionDateRanges(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2025, 5, 14, 9, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		dateRange  models.ReportDateRange
+		start, end time.Time
+	}{
+		{models.Last7Days, day(5, 7), day(5, 13)},
+		{models.Last30Days, day(4, 14), day(5, 13)},
+		{models.PreviousWeek, day(5, 5), day(5, 11)},
+		{models.PreviousMonth, day(4, 1), day(4, 30)},
+		{models.PreviousQuarter, day(1, 1), day(3, 31)},
+		{models.YearToDate, day(1, 1), day(5, 13)},
+	}
+	for _, tc := range cases {
+		start, end, err := tc.dateRange.Resolve(now)
+		require.NoError(t, err)
+		require.Equal(t, tc.start, start, string(tc.dateRange))
+		require.Equal(t, tc.end, end, string(tc.dateRange))
+	}
+
+	sub := models.ReportSubscription{Schedule: "0 7 * * 1", TimeZone: "America/Chicago"}
+	next, err := sub.NextRun(now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 5, 19, 12, 0, 0, 0, time.UTC), next.UTC(), "Monday 7am in Chicago")
+}
+
+func TestReportSubscriptions(t *testing.T) {
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Subscription Facility")
+	require.NoError(t, err)
+	otherFacility, err := env.CreateTestFacility("Other Subscription Facility")
+	require.NoError(t, err)
+	owner, err := env.CreateTestUser("subscriptionowner", models.DepartmentAdmin, facility.ID, "")
+	require.NoError(t, err)
+	stranger, err := env.CreateTestUser("subscriptionstranger", models.DepartmentAdmin, facility.ID, "")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.DepartmentAdmin, UserID: owner.ID, FacilityID: facility.ID, TimeZone: "America/Chicago"}
+	strangerClaims := &handlers.Claims{Role: models.DepartmentAdmin, UserID: stranger.ID, FacilityID: facility.ID, TimeZone: "America/Chicago"}
+
+	subscribe := func(name string, reportType models.ReportType) map[string]any {
+		return map[string]any{
+			"name":       name,
+			"schedule":   "0 7 * * 1",
+			"date_range": models.PreviousWeek,
+			"request": map[string]any{
+				"type":         reportType,
+				"format":       models.FormatCSV,
+				"facility_ids": []uint{facility.ID, otherFacility.ID},
+			},
+		}
+	}
+
+	var attendance, comparison models.ReportSubscription
+	t.Run("Subscriptions are saved with their next run", func(t *testing.T) {
+		invalid := subscribe("Too Often", models.AttendanceReport)
+		invalid["schedule"] = "*/5 * * * *"
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/report-subscriptions", invalid).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		invalid = subscribe("Half Hourly on Mondays", models.AttendanceReport)
+		invalid["schedule"] = "0,30 9 * * 1"
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/report-subscriptions", invalid).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+		invalid = subscribe("Unknown Range", models.AttendanceReport)
+		invalid["date_range"] = "last_fortnight"
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/report-subscriptions", invalid).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+
+		attendance = NewRequest[models.ReportSubscription](env.Client, t, http.MethodPost, "/api/report-subscriptions", subscribe("Weekly Attendance", models.AttendanceReport)).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.True(t, attendance.Enabled)
+		require.Equal(t, "America/Chicago", attendance.TimeZone)
+		require.NotNil(t, attendance.NextRunAt)
+		require.Equal(t, time.Monday, attendance.NextRunAt.In(time.FixedZone("CDT", -5*60*60)).Weekday())
+		require.ElementsMatch(t, []uint{facility.ID, otherFacility.ID}, attendance.Request.FacilityIDs)
+
+		comparison = NewRequest[models.ReportSubscription](env.Client, t, http.MethodPost, "/api/report-subscriptions", subscribe("Weekly Comparison", models.FacilityComparisonReport)).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated).GetData()
+
+		NewRequest[any](env.Client, t, http.MethodGet, fmt.Sprintf("/api/report-subscriptions/%d/runs", attendance.ID), nil).
+			WithTestClaims(strangerClaims).Do().ExpectStatus(http.StatusNotFound)
+	})
+
+	runPath := fmt.Sprintf("/api/report-subscriptions/%d/run", attendance.ID)
+	t.Run("Each run resolves the date range and keeps its artifact", func(t *testing.T) {
+		job := NewRequest[models.ReportJob](env.Client, t, http.MethodPost, runPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.NotNil(t, job.SubscriptionID)
+		require.Equal(t, attendance.ID, *job.SubscriptionID)
+		require.False(t, job.Request.StartDate.IsZero())
+		require.Equal(t, time.Monday, job.Request.StartDate.Weekday())
+		require.Equal(t, 6*24*time.Hour, job.Request.EndDate.Sub(job.Request.StartDate))
+
+		runs := NewRequest[[]models.ReportJob](env.Client, t, http.MethodGet, fmt.Sprintf("/api/report-subscriptions/%d/runs", attendance.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, runs, 1)
+		require.Equal(t, models.ReportJobCompleted, runs[0].Status)
+		NewRequest[any](env.Client, t, http.MethodGet, runs[0].DownloadPath, nil).
+			WithTestClaims(claims).AsRaw().Do().ExpectStatus(http.StatusOK).ExpectHeader("Content-Type", "text/csv")
+	})
+
+	t.Run("Deactivated owners get no data", func(t *testing.T) {
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", owner.ID).Update("deactivated_at", time.Now()).Error)
+		job := NewRequest[models.ReportJob](env.Client, t, http.MethodPost, runPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.Equal(t, models.ReportJobFailed, job.Status)
+		require.Contains(t, job.Error, "deactivated")
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", owner.ID).Update("deactivated_at", nil).Error)
+	})
+
+	t.Run("A demoted owner's runs are narrowed to their facility", func(t *testing.T) {
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", owner.ID).Update("role", models.FacilityAdmin).Error)
+
+		job := NewRequest[models.ReportJob](env.Client, t, http.MethodPost, runPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.NotNil(t, job.Request.FacilityID)
+		require.Equal(t, facility.ID, *job.Request.FacilityID)
+		require.Empty(t, job.Request.FacilityIDs)
+
+		failed := NewRequest[models.ReportJob](env.Client, t, http.MethodPost, fmt.Sprintf("/api/report-subscriptions/%d/run", comparison.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.Equal(t, models.ReportJobFailed, failed.Status)
+		require.Contains(t, failed.Error, "DepartmentAdmin")
+	})
+
+	t.Run("Owners who are no longer admins get no data", func(t *testing.T) {
+		require.NoError(t, env.DB.Model(&models.User{}).Where("id = ?", owner.ID).Update("role", models.Student).Error)
+		job := NewRequest[models.ReportJob](env.Client, t, http.MethodPost, runPath, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusAccepted).GetData()
+		require.Equal(t, models.ReportJobFailed, job.Status)
+		require.Empty(t, job.Filename)
+
+		runs := NewRequest[[]models.ReportJob](env.Client, t, http.MethodGet, fmt.Sprintf("/api/report-subscriptions/%d/runs", attendance.ID), nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, runs, 4)
+	})
+}
@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomCapacityAndFinder(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Room Finder Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("roomfinderadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID}
+
+	program, err := env.CreateTestProgram("Room Finder Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Scheduled, &facilityAdmin.ID)
+	require.NoError(t, err)
+	busyStart := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour).Add(15 * time.Hour)
+	event, err := env.CreateTestEvent(class.ID, fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=1", busyStart.Format("20060102T150405Z")), facilityAdmin.ID)
+	require.NoError(t, err)
+
+	createRoom := func(name string, capacity int64, attrs ...models.RoomAttributeType) models.Room {
+		attributes := make([]models.RoomAttribute, 0, len(attrs))
+		for _, attr := range attrs {
+			attributes = append(attributes, models.RoomAttribute{Attribute: attr})
+		}
+		return NewRequest[models.Room](env.Client, t, http.MethodPost, "/api/rooms", map[string]any{
+			"name":       name,
+			"capacity":   capacity,
+			"attributes": attributes,
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+	}
+	lab := createRoom("Computer Lab", 20, models.RoomComputerLab, models.RoomProjector)
+	small := createRoom("Small Lab", 8, models.RoomComputerLab)
+	closed := createRoom("Closed Lab", 30, models.RoomComputerLab)
+	require.True(t, lab.IsActive)
+	require.Len(t, lab.Attributes, 2)
+
+	t.Run("Rooms can be updated and deactivated", func(t *testing.T) {
+		updated := NewRequest[models.Room](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/rooms/%d", closed.ID), map[string]any{
+			"is_active": false,
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.False(t, updated.IsActive)
+		require.Equal(t, int64(30), *updated.Capacity)
+		require.Len(t, updated.Attributes, 1)
+
+		updated = NewRequest[models.Room](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/rooms/%d", *event.RoomID), map[string]any{
+			"capacity":   30,
+			"attributes": []models.RoomAttribute{{Attribute: models.RoomComputerLab}},
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, int64(30), *updated.Capacity)
+
+		NewRequest[any](env.Client, t, http.MethodPatch, fmt.Sprintf("/api/rooms/%d", lab.ID), map[string]any{
+			"attributes": []map[string]string{{"attribute": "swimming_pool"}},
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+
+		active := NewRequest[[]models.Room](env.Client, t, http.MethodGet, "/api/rooms?active=true", nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		for _, room := range active {
+			require.NotEqual(t, closed.ID, room.ID)
+		}
+	})
+
+	t.Run("Room finder returns free active rooms with the required seats and attributes", func(t *testing.T) {
+		window := url.Values{
+			"start": {busyStart.Add(30 * time.Minute).Format(time.RFC3339)},
+			"end":   {busyStart.Add(90 * time.Minute).Format(time.RFC3339)},
+		}
+		roomIDs := func(query url.Values) []uint {
+			rooms := NewRequest[[]models.Room](env.Client, t, http.MethodGet, "/api/rooms/available?"+query.Encode(), nil).
+				WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+			ids := make([]uint, 0, len(rooms))
+			for _, room := range rooms {
+				ids = append(ids, room.ID)
+			}
+			return ids
+		}
+		require.ElementsMatch(t, []uint{lab.ID, small.ID}, roomIDs(window))
+
+		window.Set("class_id", fmt.Sprint(class.ID))
+		require.ElementsMatch(t, []uint{lab.ID}, roomIDs(window))
+
+		window.Del("class_id")
+		window.Add("attribute", string(models.RoomProjector))
+		require.ElementsMatch(t, []uint{lab.ID}, roomIDs(window))
+
+		later := url.Values{
+			"start":     {busyStart.Add(4 * time.Hour).Format(time.RFC3339)},
+			"end":       {busyStart.Add(5 * time.Hour).Format(time.RFC3339)},
+			"attribute": {string(models.RoomComputerLab)},
+		}
+		require.ElementsMatch(t, []uint{lab.ID, small.ID, *event.RoomID}, roomIDs(later))
+	})
+
+	t.Run("Schedule check warns when the room is smaller than the class", func(t *testing.T) {
+		rule := fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=1", busyStart.Format("20060102T150405Z"))
+		result := NewRequest[models.ScheduleCheckResult](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/schedule-check", class.ID), map[string]any{
+			"room_id":         small.ID,
+			"recurrence_rule": rule,
+			"duration":        "1h",
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, result.Conflicts)
+		require.Len(t, result.Warnings, 1)
+		require.Equal(t, int64(8), result.Warnings[0].RoomCapacity)
+		require.Equal(t, class.Capacity, result.Warnings[0].ClassCapacity)
+
+		result = NewRequest[models.ScheduleCheckResult](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/schedule-check", class.ID), map[string]any{
+			"room_id":         *event.RoomID,
+			"recurrence_rule": rule,
+			"duration":        "1h",
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, result.Warnings)
+		require.NotEmpty(t, result.Conflicts)
+		require.Equal(t, models.ConflictTypeRoom, result.Conflicts[0].ConflictType)
+	})
+
+	t.Run("Saving a schedule warns about small rooms and rejects inactive ones", func(t *testing.T) {
+		rule := fmt.Sprintf("DTSTART:%s\nRRULE:FREQ=DAILY;COUNT=1", busyStart.AddDate(0, 0, 1).Format("20060102T150405Z"))
+		saved := NewRequest[models.ScheduleCheckResult](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", class.ID), map[string]any{
+			"room_id":         small.ID,
+			"instructor_id":   facilityAdmin.ID,
+			"recurrence_rule": rule,
+			"duration":        "1h",
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Len(t, saved.Warnings, 1)
+		require.Equal(t, small.ID, saved.Warnings[0].RoomID)
+
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/events", class.ID), map[string]any{
+			"room_id":         closed.ID,
+			"instructor_id":   facilityAdmin.ID,
+			"recurrence_rule": rule,
+			"duration":        "1h",
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPost, fmt.Sprintf("/api/program-classes/%d/schedule-check", class.ID), map[string]any{
+			"room_id":         closed.ID,
+			"recurrence_rule": rule,
+			"duration":        "1h",
+		}).WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomUtilizationReport(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Utilization Facility")
+	require.NoError(t, err)
+	instructor, err := env.CreateTestInstructor(facility.ID, "utilization")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Utilization Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, &instructor.ID)
+	require.NoError(t, err)
+
+	// five 2 hour sessions, Monday 2025-03-03 through Friday, with Wednesday cancelled
+	event, err := env.CreateTestEvent(class.ID, "DTSTART:20250303T090000Z\nRRULE:FREQ=DAILY;COUNT=5", instructor.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEventOverride(event.ID, "2025-03-05", true, "Lockdown")
+	require.NoError(t, err)
+	require.NoError(t, env.DB.Create(&models.Room{FacilityID: facility.ID, Name: "Unused Room"}).Error)
+	require.NoError(t, env.DB.Create(&models.Room{FacilityID: facility.ID, Name: "Closed Room"}).Error)
+	require.NoError(t, env.DB.Model(&models.Room{}).Where("name = ?", "Closed Room").Update("is_active", false).Error)
+
+	req := &models.ReportGenerateRequest{
+		Type:       models.RoomUtilizationReport,
+		Format:     models.FormatCSV,
+		StartDate:  time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC),
+		FacilityID: &facility.ID,
+	}
+
+	t.Run("Booked hours are totalled per room per week", func(t *testing.T) {
+		rows, err := env.DB.GenerateRoomUtilizationReport(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, rows, 4, "two weeks for each active room, none for the unused inactive room")
+
+		byRoomWeek := make(map[string]models.RoomUtilizationReportRow)
+		for _, row := range rows {
+			byRoomWeek[row.RoomName+" "+row.WeekStart.Format("2006-01-02")] = row
+		}
+		busy := byRoomWeek["Test Room 2025-03-03"]
+		require.Equal(t, 4, busy.Sessions)
+		require.Equal(t, 1, busy.CancelledSessions)
+		require.InDelta(t, 8.0, busy.BookedHours, 0.001)
+		require.InDelta(t, models.DefaultRoomHoursPerWeek, busy.AvailableHours, 0.001)
+		require.Equal(t, 20, busy.Utilization())
+
+		require.Zero(t, byRoomWeek["Test Room 2025-03-10"].Sessions)
+		require.Zero(t, byRoomWeek["Unused Room 2025-03-03"].BookedHours)
+	})
+
+	t.Run("Partial weeks prorate the available hours", func(t *testing.T) {
+		hours := 35.0
+		partial := *req
+		partial.EndDate = time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)
+		partial.RoomHoursPerWeek = &hours
+		rows, err := env.DB.GenerateRoomUtilizationReport(context.Background(), &partial)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		for _, row := range rows {
+			require.InDelta(t, 20.0, row.AvailableHours, 0.001)
+		}
+	})
+
+	t.Run("Report downloads through the generate endpoint", func(t *testing.T) {
+		admin, err := env.CreateTestUser("utilizationadmin", models.FacilityAdmin, facility.ID, "")
+		require.NoError(t, err)
+		claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/reports/generate", req).
+			WithTestClaims(claims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", "text/csv").
+			ExpectBodyContains("Week Of,Facility,Room,Sessions,Cancelled Sessions,Booked Hours,Available Hours,Utilization %").
+			ExpectBodyContains("2025-03-03,Utilization Facility,Test Room,4,1,8.0,40.0,20")
+	})
+}
@@ -0,0 +1,190 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRosterFile(t *testing.T, dir, name, contents string, modified time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	require.NoError(t, os.Chtimes(path, modified, modified))
+}
+
+func TestRosterSync(t *testing.T) {
+	rosterDir := t.TempDir()
+	t.Setenv("ROSTER_SYNC_DIR", rosterDir)
+	t.Setenv("ROSTER_SYNC_MAX_RELEASE_PERCENT", "50")
+	t.Setenv("REPORT_ARTIFACT_DIR", t.TempDir())
+	t.Setenv("RELEASE_PACKET_DIR", t.TempDir())
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	north, err := env.CreateTestFacility("North Unit")
+	require.NoError(t, err)
+	south, err := env.CreateTestFacility("South Unit")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Roster Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{north.ID}))
+	class, err := env.CreateTestClass(program, north, models.Active, nil)
+	require.NoError(t, err)
+
+	staying, err := env.CreateTestUser("rosterstaying", models.Student, north.ID, "N100")
+	require.NoError(t, err)
+	moving, err := env.CreateTestUser("rostermoving", models.Student, north.ID, "N200")
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(class.ID, moving.ID, models.Enrolled)
+	require.NoError(t, err)
+	missing, err := env.CreateTestUser("rostermissing", models.Student, north.ID, "N300")
+	require.NoError(t, err)
+	paroled, err := env.CreateTestUser("rosterparoled", models.Student, south.ID, "N400")
+	require.NoError(t, err)
+	returning, err := env.CreateTestUser("rosterreturning", models.Student, north.ID, "N500")
+	require.NoError(t, err)
+	require.NoError(t, env.DB.DeactivateUser(context.Background(), returning.ID, nil))
+	redated, err := env.CreateTestUser("rosterredated", models.Student, north.ID, "N600")
+	require.NoError(t, err)
+
+	writeRosterFile(t, rosterDir, "roster-20300614.csv", `Offender ID,Last Name,First Name,Facility,Custody Status,Projected Release Date
+N100,User,Test,North Unit,Active,
+N200,User,Test,south unit,Active,
+N400,User,Test,South Unit,Released,
+N500,User,Test,North Unit,Active,
+N600,User,Test,North Unit,Active,01/15/2031
+N700,Okafor,Nia,North Unit,Active,2032-03-01
+N800,Reyes,Tomas,East Annex,Active,
+N100,User,Test,North Unit,Active,
+`, time.Now().Add(-time.Hour))
+
+	loadUser := func(t *testing.T, id uint) models.User {
+		var user models.User
+		require.NoError(t, env.DB.First(&user, id).Error)
+		return user
+	}
+	countChanges := func(run *models.RosterSyncRun, action models.RosterSyncAction) int {
+		count := 0
+		for _, change := range run.Changes {
+			if change.Action == action {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("Dry runs report the changes and write nothing", func(t *testing.T) {
+		run, err := env.Server.RunRosterSync(context.Background(), true, nil)
+		require.NoError(t, err)
+		require.Equal(t, models.RosterSyncCompleted, run.Status)
+		require.Equal(t, 8, run.RowCount)
+		require.Equal(t, 1, run.Admitted)
+		require.Equal(t, 1, run.Transferred)
+		require.Equal(t, 2, run.Released)
+		require.Equal(t, 1, run.Updated)
+		require.Equal(t, 3, run.Skipped, "the unknown facility, the repeated DOC ID and the deactivated resident")
+		require.Zero(t, run.Failed)
+
+		var runs, admitted int64
+		require.NoError(t, env.DB.Model(&models.RosterSyncRun{}).Count(&runs).Error)
+		require.Zero(t, runs)
+		require.NoError(t, env.DB.Model(&models.User{}).Where("doc_id = ?", "N700").Count(&admitted).Error)
+		require.Zero(t, admitted)
+		require.Nil(t, loadUser(t, missing.ID).DeactivatedAt)
+	})
+
+	adminClaims := &handlers.Claims{Role: models.SystemAdmin, UserID: 1, FacilityID: 1}
+
+	t.Run("Runs admit, transfer and release residents and are kept for review", func(t *testing.T) {
+		run := NewRequest[models.RosterSyncRun](env.Client, t, http.MethodPost, "/api/roster-sync/runs", map[string]any{"dry_run": false}).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Equal(t, models.RosterSyncCompleted, run.Status)
+		require.Zero(t, run.Failed)
+		require.Equal(t, 1, run.Admitted)
+
+		var admitted models.User
+		require.NoError(t, env.DB.Where("doc_id = ?", "N700").First(&admitted).Error)
+		require.Equal(t, north.ID, admitted.FacilityID)
+		require.Equal(t, "N700", admitted.Username)
+		require.Equal(t, "2032-03-01", *admitted.ReleaseDate)
+
+		require.Equal(t, south.ID, loadUser(t, moving.ID).FacilityID)
+		var enrollment models.ProgramClassEnrollment
+		require.NoError(t, env.DB.Where("user_id = ? AND class_id = ?", moving.ID, class.ID).First(&enrollment).Error)
+		require.Equal(t, models.ProgramEnrollmentStatus("Incomplete: Transferred"), enrollment.EnrollmentStatus)
+		require.EqualValues(t, 1, releaseHistoryCount(t, env, moving.ID, models.FacilityTransfer))
+
+		for _, released := range []*models.User{missing, paroled} {
+			require.NotNil(t, loadUser(t, released.ID).DeactivatedAt, released.DocID)
+			var packets int64
+			require.NoError(t, env.DB.Model(&models.ReleasePacket{}).Where("user_id = ?", released.ID).Count(&packets).Error)
+			require.EqualValues(t, 1, packets)
+		}
+		require.Equal(t, "2031-01-15", *loadUser(t, redated.ID).ReleaseDate)
+		require.NotNil(t, loadUser(t, returning.ID).DeactivatedAt, "readmissions are left for a person to reactivate")
+		require.Nil(t, loadUser(t, staying.ID).DeactivatedAt)
+
+		runs := NewRequest[[]models.RosterSyncRun](env.Client, t, http.MethodGet, "/api/roster-sync/runs", nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, runs, 1)
+		saved := NewRequest[models.RosterSyncRun](env.Client, t, http.MethodGet, fmt.Sprintf("/api/roster-sync/runs/%d", runs[0].ID), nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, saved.Changes, len(run.Changes))
+		require.Equal(t, 2, countChanges(&saved, models.RosterSyncRelease))
+		for _, change := range saved.Changes {
+			require.Equal(t, change.Action != models.RosterSyncSkip, change.Applied, change.DocID)
+		}
+
+		again, err := env.Server.RunRosterSync(context.Background(), false, nil)
+		require.NoError(t, err)
+		require.Zero(t, again.Admitted+again.Transferred+again.Released+again.Updated, "a second run of the same file changes nothing")
+	})
+
+	t.Run("A roster that would release too many residents is not applied", func(t *testing.T) {
+		t.Setenv("ROSTER_SYNC_MAX_RELEASE_PERCENT", "10")
+		writeRosterFile(t, rosterDir, "roster-20300615.csv", "Offender ID,Last Name,First Name,Facility\nN100,User,Test,North Unit\n", time.Now())
+		run, err := env.Server.RunRosterSync(context.Background(), false, nil)
+		require.NoError(t, err)
+		require.Equal(t, models.RosterSyncFailed, run.Status)
+		require.Equal(t, "roster-20300615.csv", run.Filename)
+		require.Contains(t, run.Error, "would release")
+		require.Nil(t, loadUser(t, moving.ID).DeactivatedAt)
+	})
+
+	t.Run("Release dates already past are reported rather than set", func(t *testing.T) {
+		writeRosterFile(t, rosterDir, "roster-20300616.csv", `Offender ID,Last Name,First Name,Facility,Custody Status,Projected Release Date
+N100,User,Test,North Unit,Active,2020-01-01
+N200,User,Test,South Unit,Active,
+N600,User,Test,North Unit,Active,01/15/2031
+N700,Okafor,Nia,North Unit,Active,2032-03-01
+N900,Mensah,Kofi,North Unit,Active,2020-02-01
+`, time.Now().Add(time.Minute))
+		run, err := env.Server.RunRosterSync(context.Background(), false, nil)
+		require.NoError(t, err)
+		require.Equal(t, models.RosterSyncCompleted, run.Status)
+		require.Equal(t, 1, run.Admitted)
+		require.Zero(t, run.Released+run.Updated)
+		require.Equal(t, 2, run.Skipped, "both past release dates")
+
+		require.Nil(t, loadUser(t, staying.ID).ReleaseDate)
+		var admitted models.User
+		require.NoError(t, env.DB.Where("doc_id = ?", "N900").First(&admitted).Error)
+		require.Nil(t, admitted.ReleaseDate, "the resident is admitted without the past date")
+	})
+
+	t.Run("Only department and system admins can run the sync", func(t *testing.T) {
+		facilityAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: 1, FacilityID: north.ID}
+		NewRequest[any](env.Client, t, http.MethodPost, "/api/roster-sync/runs", map[string]any{"dry_run": true}).
+			WithTestClaims(facilityAdmin).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+}
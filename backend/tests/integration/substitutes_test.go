@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstituteInstructors(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Substitute Facility")
+	require.NoError(t, err)
+	facilityAdmin, err := env.CreateTestUser("subadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	claims := &handlers.Claims{Role: models.FacilityAdmin, UserID: facilityAdmin.ID, FacilityID: facility.ID, TimeZone: facility.Timezone}
+	regular, err := env.CreateTestInstructor(facility.ID, "subregular")
+	require.NoError(t, err)
+	free, err := env.CreateTestInstructor(facility.ID, "subfree")
+	require.NoError(t, err)
+	busy, err := env.CreateTestInstructor(facility.ID, "subbusy")
+	require.NoError(t, err)
+	onLeave, err := env.CreateTestInstructor(facility.ID, "subleave")
+	require.NoError(t, err)
+
+	program, err := env.CreateTestProgram("Substitute Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	class, err := env.CreateTestClass(program, facility, models.Active, &regular.ID)
+	require.NoError(t, err)
+	event, err := env.CreateTestEvent(class.ID, "DTSTART;TZID=Local:20261109T090000\nRRULE:FREQ=DAILY;COUNT=5", regular.ID)
+	require.NoError(t, err)
+	otherClass, err := env.CreateTestClass(program, facility, models.Active, &busy.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEvent(otherClass.ID, "DTSTART;TZID=Local:20261110T100000\nRRULE:FREQ=DAILY;COUNT=1", busy.ID)
+	require.NoError(t, err)
+	location, err := time.LoadLocation(facility.Timezone)
+	require.NoError(t, err)
+	require.NoError(t, env.DB.Create(&models.InstructorBlackout{
+		InstructorID: onLeave.ID,
+		StartsAt:     time.Date(2026, 11, 10, 0, 0, 0, 0, location),
+		EndsAt:       time.Date(2026, 11, 11, 0, 0, 0, 0, location),
+		Reason:       "Leave",
+	}).Error)
+
+	substitutesPath := fmt.Sprintf("/api/program-classes/%d/events/%d/substitutes", class.ID, event.ID)
+	assignPath := fmt.Sprintf("/api/program-classes/%d/events/%d/substitute", class.ID, event.ID)
+	candidateIDs := func(date string) []uint {
+		instructors := NewRequest[[]models.Instructor](env.Client, t, http.MethodGet, substitutesPath+"?date="+date, nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		ids := make([]uint, 0, len(instructors))
+		for _, instructor := range instructors {
+			ids = append(ids, uint(instructor.ID))
+		}
+		return ids
+	}
+	coverageFor := func(instructorID uint) models.InstructorCoverageSummary {
+		summaries := NewRequest[[]models.InstructorCoverageSummary](env.Client, t, http.MethodGet, "/api/instructors/coverage-summary?start_date=2026-11-01&end_date=2026-11-30", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusOK).GetData()
+		idx := slices.IndexFunc(summaries, func(summary models.InstructorCoverageSummary) bool { return summary.InstructorID == instructorID })
+		require.NotEqual(t, -1, idx)
+		return summaries[idx]
+	}
+
+	t.Run("Only free instructors are offered", func(t *testing.T) {
+		ids := candidateIDs("2026-11-10")
+		require.Contains(t, ids, free.ID)
+		require.NotContains(t, ids, regular.ID, "the session's own instructor is not a substitute")
+		require.NotContains(t, ids, busy.ID, "teaching another class at 10:00")
+		require.NotContains(t, ids, onLeave.ID, "on leave that day")
+
+		NewRequest[any](env.Client, t, http.MethodGet, substitutesPath+"?date=2026-11-20", nil).
+			WithTestClaims(claims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Busy instructors cannot be assigned", func(t *testing.T) {
+		conflicts := NewRequest[[]models.RoomConflict](env.Client, t, http.MethodPost, assignPath, map[string]any{
+			"date":          "2026-11-10",
+			"instructor_id": busy.ID,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusConflict).GetData()
+		require.Equal(t, models.ConflictTypeInstructor, conflicts[0].ConflictType)
+	})
+
+	t.Run("Assigning a substitute overrides the session and records coverage", func(t *testing.T) {
+		coverage := NewRequest[models.SessionCoverage](env.Client, t, http.MethodPost, assignPath, map[string]any{
+			"date":          "2026-11-10",
+			"instructor_id": free.ID,
+			"reason":        "Sick day",
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.Equal(t, free.ID, coverage.SubstituteInstructorID)
+		require.NotNil(t, coverage.OriginalInstructorID)
+		require.Equal(t, regular.ID, *coverage.OriginalInstructorID)
+
+		var override models.ProgramClassEventOverride
+		require.NoError(t, env.DB.First(&override, coverage.OverrideID).Error)
+		require.False(t, override.IsCancelled)
+		require.Equal(t, free.ID, *override.InstructorID)
+
+		ids := candidateIDs("2026-11-10")
+		require.Contains(t, ids, regular.ID, "the regular instructor can take the session back")
+		require.NotContains(t, ids, free.ID)
+
+		require.Equal(t, int64(1), coverageFor(free.ID).SessionsCovered)
+		require.Equal(t, 2.0, coverageFor(free.ID).HoursCovered)
+		require.Equal(t, int64(1), coverageFor(regular.ID).SessionsMissed)
+	})
+
+	t.Run("Handing the session back clears the coverage", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, assignPath, map[string]any{
+			"date":          "2026-11-10",
+			"instructor_id": regular.ID,
+		}).WithTestClaims(claims).Do().ExpectStatus(http.StatusOK)
+		require.Equal(t, int64(0), coverageFor(regular.ID).SessionsMissed)
+		require.Equal(t, int64(0), coverageFor(free.ID).SessionsCovered)
+	})
+}
@@ -0,0 +1,165 @@
+package integration
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"UnlockEdv2/src/handlers"
+	"UnlockEdv2/src/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResidentTranscripts(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Transcript Facility")
+	require.NoError(t, err)
+	admin, err := env.CreateTestUser("transcriptadmin", models.FacilityAdmin, facility.ID, "")
+	require.NoError(t, err)
+	resident, err := env.CreateTestUser("transcriptres", models.Student, facility.ID, "T100")
+	require.NoError(t, err)
+	classmate, err := env.CreateTestUser("transcriptmate", models.Student, facility.ID, "T101")
+	require.NoError(t, err)
+	program, err := env.CreateTestProgram("Transcript Program", models.FundingType(models.FederalGrants), []models.ProgramType{}, []models.ProgramCreditType{}, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.SetFacilitiesToProgram(program.ID, []uint{facility.ID}))
+	teacher, err := env.CreateTestInstructor(facility.ID, "transcript")
+	require.NoError(t, err)
+
+	finished, err := env.CreateTestClass(program, facility, models.Completed, &teacher.ID)
+	require.NoError(t, err)
+	require.NoError(t, env.SetClassCreditHours(finished.ID, 6))
+	current, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(finished.ID, resident.ID, models.EnrollmentCompleted)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(current.ID, resident.ID, models.Enrolled)
+	require.NoError(t, err)
+	waitlisted, err := env.CreateTestClass(program, facility, models.Active, &teacher.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(waitlisted.ID, resident.ID, models.EnrollmentWaitlisted)
+	require.NoError(t, err)
+	closed, err := env.CreateTestClass(program, facility, models.Cancelled, &teacher.ID)
+	require.NoError(t, err)
+	_, err = env.CreateTestEnrollment(closed.ID, resident.ID, models.EnrollmentCancelled)
+	require.NoError(t, err)
+	require.NoError(t, env.DB.Create(&models.ProgramCompletion{
+		UserID:              resident.ID,
+		ProgramClassID:      finished.ID,
+		FacilityName:        facility.Name,
+		CreditType:          string(models.Education),
+		AdminEmail:          admin.Email,
+		ProgramOwner:        "Education Department",
+		ProgramName:         program.Name,
+		ProgramID:           program.ID,
+		ProgramClassName:    "Finished Class",
+		ProgramClassStartDt: time.Now().AddDate(0, -3, 0),
+		EnrolledOnDt:        time.Now().AddDate(0, -3, 0),
+	}).Error)
+	event, err := env.CreateTestEvent(finished.ID, "", teacher.ID)
+	require.NoError(t, err)
+	for i, status := range []models.Attendance{models.Present, models.Present, models.Present, models.Absent_Unexcused} {
+		require.NoError(t, env.DB.Create(&models.ProgramClassEventAttendance{
+			EventID: event.ID, UserID: resident.ID, Date: time.Now().AddDate(0, 0, -i-1).Format("2006-01-02"), AttendanceStatus: status,
+		}).Error)
+	}
+	require.NoError(t, env.DB.Create(&models.LearningRecordEntry{
+		UserID: resident.ID, ClientID: "reflection-1", ProgramName: program.Name, Summary: "Learned to write a resume",
+		TopSkills: models.StringSlice{"Writing", "Planning"}, CompletionDate: "2026-06-01",
+	}).Error)
+	require.NoError(t, env.DB.Create(&models.LearningRecordEntry{
+		UserID: resident.ID, ClientID: "reflection-draft", IsDraft: true, Summary: "Not finished yet",
+	}).Error)
+
+	residentClaims := &handlers.Claims{Role: models.Student, UserID: resident.ID, FacilityID: facility.ID}
+	classmateClaims := &handlers.Claims{Role: models.Student, UserID: classmate.ID, FacilityID: facility.ID}
+	adminClaims := &handlers.Claims{Role: models.FacilityAdmin, UserID: admin.ID, FacilityID: facility.ID}
+	transcriptPath := fmt.Sprintf("/api/users/%d/transcript", resident.ID)
+	issuePath := fmt.Sprintf("/api/users/%d/transcripts", resident.ID)
+
+	t.Run("Residents preview their own transcript", func(t *testing.T) {
+		data := NewRequest[models.TranscriptData](env.Client, t, http.MethodGet, transcriptPath, nil).
+			WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Nil(t, data.Transcript)
+		require.Equal(t, "T100", data.DocID)
+		require.Len(t, data.Classes, 2, "waitlist entries are not part of a transcript")
+		require.Equal(t, 1, data.ClassesCompleted)
+		require.Equal(t, int64(6), data.TotalCreditHours, "only completed classes earn credit hours")
+
+		completed := data.Classes[0]
+		require.Equal(t, "Finished Class", completed.ClassName)
+		require.NotNil(t, completed.CompletedAt)
+		require.Equal(t, 3, completed.SessionsAttended)
+		require.Equal(t, 4, completed.TotalSessions)
+		require.NotNil(t, completed.AttendanceRate)
+		require.InDelta(t, 75.0, *completed.AttendanceRate, 0.01)
+
+		require.Len(t, data.Reflections, 1, "drafts are not part of a transcript")
+		require.Equal(t, []string{"Writing", "Planning"}, data.Reflections[0].TopSkills)
+	})
+
+	t.Run("Residents cannot see another resident's transcript", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodGet, transcriptPath, nil).
+			WithTestClaims(classmateClaims).Do().ExpectStatus(http.StatusUnauthorized)
+		NewRequest[any](env.Client, t, http.MethodPost, issuePath+"?format=json", nil).
+			WithTestClaims(classmateClaims).Do().ExpectStatus(http.StatusUnauthorized)
+	})
+
+	var issued models.TranscriptData
+	t.Run("Case managers receive a verifiable JSON transcript", func(t *testing.T) {
+		issued = NewRequest[models.TranscriptData](env.Client, t, http.MethodPost, issuePath+"?format=json", nil).
+			WithTestClaims(adminClaims).Do().ExpectStatus(http.StatusCreated).GetData()
+		require.NotNil(t, issued.Transcript)
+		require.NotEmpty(t, issued.Transcript.VerificationCode)
+		require.Contains(t, issued.VerifyURL, issued.Transcript.VerificationCode)
+		require.Equal(t, admin.ID, issued.Transcript.IssuedByID)
+
+		history := NewRequest[[]models.Transcript](env.Client, t, http.MethodGet, issuePath, nil).
+			WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, history, 1)
+	})
+
+	t.Run("CSV transcripts carry the verification code and one row per class", func(t *testing.T) {
+		resp := NewRequest[any](env.Client, t, http.MethodPost, issuePath+"?format=csv", nil).
+			WithTestClaims(residentClaims).AsRaw().Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", "text/csv")
+		reader := csv.NewReader(strings.NewReader(resp.rawBody))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{"DOC ID", "T100"}, rows[1])
+		require.Equal(t, "Verification Code", rows[3][0])
+		require.Len(t, rows[3][1], 14)
+		require.Equal(t, "Program Name", rows[8][0])
+		require.Len(t, rows, 11)
+		require.Equal(t, "6", rows[9][6])
+		require.Equal(t, "75.0%", rows[9][7])
+	})
+
+	t.Run("Unknown formats are refused", func(t *testing.T) {
+		NewRequest[any](env.Client, t, http.MethodPost, issuePath+"?format=xlsx", nil).
+			WithTestClaims(residentClaims).Do().ExpectStatus(http.StatusBadRequest)
+	})
+
+	t.Run("Anyone can verify a transcript code without signing in", func(t *testing.T) {
+		code := strings.ToLower(strings.ReplaceAll(issued.Transcript.VerificationCode, "-", ""))
+		verification := NewRequest[models.TranscriptVerification](env.Client, t, http.MethodGet, "/api/transcripts/verify/"+code, nil).
+			Do().ExpectStatus(http.StatusOK).GetData()
+		require.True(t, verification.Valid)
+		require.Equal(t, 1, verification.ClassesCompleted)
+		require.Equal(t, int64(6), verification.TotalCreditHours)
+
+		resp := NewRequest[any](env.Client, t, http.MethodGet, "/api/transcripts/verify/"+code, nil).AsRaw().Do()
+		require.NotContains(t, resp.rawBody, "T100", "the resident's DOC ID is never exposed")
+
+		NewRequest[models.TranscriptVerification](env.Client, t, http.MethodGet, "/api/transcripts/verify/ZZZZ-ZZZZ-ZZZZ", nil).
+			Do().ExpectStatus(http.StatusNotFound)
+	})
+}
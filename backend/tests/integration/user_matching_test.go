@@ -3,6 +3,9 @@ package integration
 import (
 	"UnlockEdv2/src/handlers"
 	"UnlockEdv2/src/models"
+	"context"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -111,3 +114,156 @@ func TestMatchUsers(t *testing.T) {
 	require.Len(t, result.Unmatched, 1)
 	require.Equal(t, "c3", result.Unmatched[0].ExternalUserID)
 }
+
+func TestScoreUserMatchSignals(t *testing.T) {
+	resident := &models.User{NameFirst: "Marcus", NameLast: "Bell", Username: "mbell", Email: "mbell@unlocked.v2", DocID: "00451"}
+
+	ambiguous := models.DefaultMatchAmbiguous
+	score, signals := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "M", NameLast: "Bell", DocID: "451"}, resident, ambiguous)
+	require.Equal(t, 1.0, score, "the same DOC ID with names that agree confirms the match")
+	require.Equal(t, []models.UserMatchSignal{models.MatchSignalName, models.MatchSignalDocID}, signals)
+	stranger, signals := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Alice", NameLast: "Jones", DocID: "451"}, resident, ambiguous)
+	require.Less(t, stranger, ambiguous, "the same DOC ID doesn't match residents with different names")
+	require.Equal(t, []models.UserMatchSignal{models.MatchSignalDocID}, signals)
+
+	byName, _ := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Mark", NameLast: "Bell"}, resident, ambiguous)
+	byEmail, signals := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Mark", NameLast: "Bell", Email: "MBell@kolibri.example"}, resident, ambiguous)
+	require.Greater(t, byEmail, byName)
+	require.Contains(t, signals, models.MatchSignalEmail)
+	byUsername, signals := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Mark", NameLast: "Bell", Username: "m.bell"}, resident, ambiguous)
+	require.InDelta(t, byEmail, byUsername, 0.0001, "a matching username and email count once")
+	require.Contains(t, signals, models.MatchSignalUsername)
+	_, signals = handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Mark", NameLast: "Bell"}, resident, 0.9)
+	require.NotContains(t, signals, models.MatchSignalName, "names count as in common at the provider's own threshold")
+
+	conflicting, _ := handlers.ScoreUserMatch(&models.ImportUser{NameFirst: "Mark", NameLast: "Bell", DocID: "A999"}, resident, ambiguous)
+	require.Less(t, conflicting, byName, "a different DOC ID counts against the match")
+
+	kolibri := []models.ImportUser{{NameFirst: "Mark", NameLast: "Bell", ExternalUserID: "k1"}}
+	result := handlers.MatchUsers(kolibri, []models.User{*resident})
+	require.Len(t, result.Ambiguous, 1)
+	result = handlers.MatchUsersWithThresholds(kolibri, []models.User{*resident}, 0.85, 0.5)
+	require.Len(t, result.AutoConfirmed, 1, "a provider with a lower auto-confirm threshold confirms the match")
+	result = handlers.MatchUsersWithThresholds(kolibri, []models.User{*resident}, 0.95, 0.9)
+	require.Len(t, result.Unmatched, 1)
+}
+
+func TestUserMatchReviewQueue(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.CleanupTestEnv()
+
+	facility, err := env.CreateTestFacility("Review Facility")
+	require.NoError(t, err)
+	provider := &models.ProviderPlatform{Name: "Kolibri", Type: models.Kolibri, BaseUrl: "https://kolibri.example.com", AccountID: "1", AccessKey: "user:pass", State: models.Enabled}
+	require.NoError(t, env.DB.Create(provider).Error)
+	suggested, err := env.CreateTestUser("reviewsuggested", models.Student, facility.ID, "")
+	require.NoError(t, err)
+	other, err := env.CreateTestUser("reviewother", models.Student, facility.ID, "")
+	require.NoError(t, err)
+
+	queue := func(t *testing.T, externalID string, suggestedID uint) {
+		require.NoError(t, env.DB.QueueUserMatchReviews(context.Background(), []models.UserMatchReview{{
+			ProviderPlatformID: provider.ID,
+			FacilityID:         facility.ID,
+			ExternalUserID:     externalID,
+			ImportUser:         models.ImportUser{NameFirst: "Test", NameLast: "User", ExternalUserID: externalID, ExternalUsername: "k_" + externalID},
+			SuggestedUserID:    &suggestedID,
+			Score:              0.7,
+			Signals:            []models.UserMatchSignal{models.MatchSignalName},
+		}}))
+	}
+	queue(t, "k1", suggested.ID)
+	queue(t, "k2", suggested.ID)
+	queue(t, "k1", suggested.ID)
+
+	admin := &handlers.Claims{Role: models.FacilityAdmin, UserID: 1, FacilityID: facility.ID}
+	reviewsURL := fmt.Sprintf("/api/actions/provider-platforms/%d/match-reviews", provider.ID)
+	pending := NewRequest[[]models.UserMatchReview](env.Client, t, http.MethodGet, reviewsURL+"?status=pending", nil).
+		WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+	require.Len(t, pending, 2, "queueing the same provider user again updates its review")
+	require.Equal(t, "Test", pending[0].ImportUser.NameFirst)
+	require.NotNil(t, pending[0].SuggestedUser)
+
+	byExternalID := map[string]models.UserMatchReview{}
+	for _, review := range pending {
+		byExternalID[review.ExternalUserID] = review
+	}
+
+	t.Run("Rejected reviews stay closed until someone else is suggested", func(t *testing.T) {
+		review := NewRequest[models.UserMatchReview](env.Client, t, http.MethodPut, fmt.Sprintf("%s/%d", reviewsURL, byExternalID["k2"].ID), map[string]any{"status": "rejected"}).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.UserMatchRejected, review.Status)
+		queue(t, "k2", suggested.ID)
+		var reloaded models.UserMatchReview
+		require.NoError(t, env.DB.First(&reloaded, review.ID).Error)
+		require.Equal(t, models.UserMatchRejected, reloaded.Status)
+
+		queue(t, "k2", other.ID)
+		var reopened models.UserMatchReview
+		require.NoError(t, env.DB.First(&reopened, review.ID).Error)
+		require.Equal(t, models.UserMatchPending, reopened.Status)
+		require.Equal(t, other.ID, *reopened.SuggestedUserID)
+	})
+
+	t.Run("Confirming maps the provider user, to another resident when the admin picks one", func(t *testing.T) {
+		review := NewRequest[models.UserMatchReview](env.Client, t, http.MethodPut, fmt.Sprintf("%s/%d", reviewsURL, byExternalID["k1"].ID), map[string]any{"status": "confirmed", "user_id": other.ID}).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Equal(t, models.UserMatchConfirmed, review.Status)
+		require.Equal(t, other.ID, *review.ResolvedUserID)
+		mapping, err := env.DB.GetProviderUserMapping(int(other.ID), int(provider.ID))
+		require.NoError(t, err)
+		require.Equal(t, "k1", mapping.ExternalUserID)
+		require.Equal(t, "k_k1", mapping.ExternalUsername)
+
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("%s/%d", reviewsURL, byExternalID["k1"].ID), map[string]any{"status": "rejected"}).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusBadRequest)
+		queue(t, "k1", suggested.ID)
+		var reloaded models.UserMatchReview
+		require.NoError(t, env.DB.First(&reloaded, review.ID).Error)
+		require.Equal(t, models.UserMatchConfirmed, reloaded.Status, "confirmed reviews are never reopened")
+	})
+
+	t.Run("Other facilities can't see or resolve the queue", func(t *testing.T) {
+		elsewhere, err := env.CreateTestFacility("Elsewhere")
+		require.NoError(t, err)
+		otherAdmin := &handlers.Claims{Role: models.FacilityAdmin, UserID: 1, FacilityID: elsewhere.ID}
+		reviews := NewRequest[[]models.UserMatchReview](env.Client, t, http.MethodGet, reviewsURL, nil).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Empty(t, reviews)
+		NewRequest[any](env.Client, t, http.MethodPut, fmt.Sprintf("%s/%d", reviewsURL, byExternalID["k2"].ID), map[string]any{"status": "rejected"}).
+			WithTestClaims(otherAdmin).Do().ExpectStatus(http.StatusForbidden)
+	})
+
+	t.Run("Pending reviews the matcher no longer finds ambiguous are closed", func(t *testing.T) {
+		queue(t, "k3", suggested.ID)
+		closed, err := env.DB.ClosePendingUserMatchReviews(context.Background(), provider.ID, facility.ID, []string{"k2"})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, closed, "only the pending review left out of the run is closed")
+		pending := NewRequest[[]models.UserMatchReview](env.Client, t, http.MethodGet, reviewsURL+"?status=pending", nil).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, pending, 1)
+		require.Equal(t, "k2", pending[0].ExternalUserID)
+		confirmed := NewRequest[[]models.UserMatchReview](env.Client, t, http.MethodGet, reviewsURL+"?status=confirmed", nil).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, confirmed, 1, "decided reviews are kept")
+
+		queue(t, "k3", suggested.ID)
+		pending = NewRequest[[]models.UserMatchReview](env.Client, t, http.MethodGet, reviewsURL+"?status=pending", nil).
+			WithTestClaims(admin).Do().ExpectStatus(http.StatusOK).GetData()
+		require.Len(t, pending, 2, "a closed review is queued again when it turns up")
+	})
+
+	t.Run("Thresholds are tuned per provider", func(t *testing.T) {
+		systemAdmin := &handlers.Claims{Role: models.SystemAdmin, UserID: 1, FacilityID: facility.ID}
+		url := fmt.Sprintf("/api/provider-platforms/%d", provider.ID)
+		NewRequest[any](env.Client, t, http.MethodPatch, url, map[string]any{"match_ambiguous": 0.95}).
+			WithTestClaims(systemAdmin).Do().ExpectStatus(http.StatusBadRequest)
+		NewRequest[any](env.Client, t, http.MethodPatch, url, map[string]any{"match_auto_confirm": 0.97, "match_ambiguous": 0.6}).
+			WithTestClaims(systemAdmin).Do().ExpectStatus(http.StatusOK)
+		updated, err := env.DB.GetProviderPlatformByID(int(provider.ID))
+		require.NoError(t, err)
+		autoConfirm, ambiguous := updated.MatchThresholds()
+		require.Equal(t, 0.97, autoConfirm)
+		require.Equal(t, 0.6, ambiguous)
+	})
+}
@@ -180,6 +180,18 @@ export function formatHistoryEntry(
         case 'user_deactivated':
             introText = ['Account deactivated by ', emphasize(adminName)];
             break;
+        case 'release_packet_generated':
+            introText = ['Release packet generated by ', emphasize(adminName)];
+            break;
+        case 'release_date_set':
+            introText = ['Release date updated by ', emphasize(adminName)];
+            break;
+        case 'release_warning_sent':
+            introText = ['Admins warned of upcoming release by ', emphasize(adminName)];
+            break;
+        case 'release_enrollments_withdrawn':
+            introText = ['Class enrollments ended for release by ', emphasize(adminName)];
+            break;
         case 'facility_transfer':
             introText = [
                 'Account assigned to ',
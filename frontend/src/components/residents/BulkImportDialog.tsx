@@ -1,9 +1,12 @@
 import { useState } from 'react';
+import useSWR from 'swr';
 import API from '@/api/api';
 import {
     BulkUploadResponse,
+    CSVMappingProfile,
     InvalidUserRow,
     ValidatedUserRow,
+    ServerResponseMany,
     ServerResponseOne,
     Facility
 } from '@/types';
@@ -49,6 +52,11 @@ export function BulkImportDialog({
     const [selectedFacilityId, setSelectedFacilityId] = useState<
         number | undefined
     >(defaultFacilityId);
+    const [profileId, setProfileId] = useState<string>('none');
+    const { data: profilesResp } = useSWR<
+        ServerResponseMany<CSVMappingProfile>
+    >(open ? '/api/users/bulk/profiles' : null);
+    const profiles = profilesResp?.data ?? [];
 
     const reset = () => {
         setStep('upload');
@@ -57,6 +65,7 @@ export function BulkImportDialog({
         setInvalidRows([]);
         setErrorCsvData(undefined);
         setSelectedFacilityId(defaultFacilityId);
+        setProfileId('none');
     };
 
     const handleOpenChange = (value: boolean) => {
@@ -86,6 +95,7 @@ export function BulkImportDialog({
         setValidating(true);
         const formData = new FormData();
         formData.append('file', uploadedFile);
+        if (profileId !== 'none') formData.append('profile_id', profileId);
         const response = (await API.post<BulkUploadResponse, FormData>(
             'users/bulk/upload',
             formData
@@ -189,6 +199,37 @@ export function BulkImportDialog({
                                 </Select>
                             </div>
                         )}
+                        {profiles.length > 0 && (
+                            <div>
+                                <Label htmlFor="bulk-import-profile">
+                                    Column Mapping
+                                </Label>
+                                <Select
+                                    value={profileId}
+                                    onValueChange={setProfileId}
+                                >
+                                    <SelectTrigger
+                                        id="bulk-import-profile"
+                                        className="mt-2"
+                                    >
+                                        <SelectValue />
+                                    </SelectTrigger>
+                                    <SelectContent>
+                                        <SelectItem value="none">
+                                            Recognize columns by name
+                                        </SelectItem>
+                                        {profiles.map((p) => (
+                                            <SelectItem
+                                                key={p.id}
+                                                value={String(p.id)}
+                                            >
+                                                {p.name}
+                                            </SelectItem>
+                                        ))}
+                                    </SelectContent>
+                                </Select>
+                            </div>
+                        )}
                         <Label
                             htmlFor="csv-upload"
                             className="block cursor-pointer"
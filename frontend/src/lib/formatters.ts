@@ -89,10 +89,10 @@ export function formatRoomConflictRange(
 }
 
 export function conflictSubjectLabel(conflicts: RoomConflict[]): string {
-    const hasInstructor = conflicts.some(
-        (c) => c.conflict_type === 'instructor'
+    const hasInstructor = conflicts.some((c) =>
+        c.conflict_type.startsWith('instructor')
     );
-    const hasRoom = conflicts.some((c) => c.conflict_type !== 'instructor');
+    const hasRoom = conflicts.some((c) => c.conflict_type === 'room');
     if (hasInstructor && hasRoom) return 'This class';
     if (hasInstructor) return 'Instructor';
     return 'Room';
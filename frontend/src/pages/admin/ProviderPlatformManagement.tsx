@@ -28,7 +28,8 @@ import {
 const providerTypeLabels: Partial<Record<ProviderPlatformType, string>> = {
     [ProviderPlatformType.CANVAS_CLOUD]: 'Canvas',
     [ProviderPlatformType.KOLIBRI]: 'Kolibri',
-    [ProviderPlatformType.BRIGHTSPACE]: 'Brightspace'
+    [ProviderPlatformType.BRIGHTSPACE]: 'Brightspace',
+    [ProviderPlatformType.MOODLE]: 'Moodle'
 };
 
 const providerStateStyles: Record<ProviderPlatformState, string> = {
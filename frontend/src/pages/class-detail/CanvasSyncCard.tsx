@@ -0,0 +1,80 @@
+import useSWR from 'swr';
+import { AlertCircle, RefreshCw } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Badge } from '@/components/ui/badge';
+import { CanvasEnrollmentSync } from '@/types/program';
+import { ServerResponseMany } from '@/types/server';
+import API from '@/api/api';
+import { toast } from 'sonner';
+
+interface CanvasSyncCardProps {
+    classId: number;
+    canvasCourseId: string;
+}
+
+// Enrollments that could not be pushed to the class's linked Canvas course
+export function CanvasSyncCard({ classId, canvasCourseId }: CanvasSyncCardProps) {
+    const { data, mutate } = useSWR<ServerResponseMany<CanvasEnrollmentSync>>(
+        `/api/program-classes/${classId}/canvas-sync?status=failed`
+    );
+    const failures = data?.data ?? [];
+    if (failures.length === 0) return null;
+
+    const handleRetry = async () => {
+        const resp = await API.post<{ retried: number }, object>(
+            `program-classes/${classId}/canvas-sync/retry`,
+            {}
+        );
+        if (resp.success) {
+            toast.success('Retrying enrollments in Canvas');
+            void mutate();
+        } else {
+            toast.error(resp.message || 'Failed to retry Canvas enrollments');
+        }
+    };
+
+    return (
+        <div className="card-block">
+            <div className="border-b border-gray-200 px-6 py-4 flex items-start justify-between">
+                <div>
+                    <h3 className="text-brand-dark">
+                        Canvas Enrollment Issues ({data?.meta?.total ?? 0})
+                    </h3>
+                    <p className="text-sm text-gray-600 mt-1">
+                        These enrollments could not be updated in Canvas course{' '}
+                        {canvasCourseId}
+                    </p>
+                </div>
+                <Button variant="outline" onClick={() => void handleRetry()}>
+                    <RefreshCw className="size-4 mr-2" />
+                    Retry
+                </Button>
+            </div>
+            <div className="divide-y divide-gray-200">
+                {failures.map((sync) => (
+                    <div
+                        key={sync.id}
+                        className="px-6 py-3 flex items-start justify-between bg-amber-50/20"
+                    >
+                        <div>
+                            <div className="text-brand-dark font-medium">
+                                {sync.user
+                                    ? `${sync.user.name_last}, ${sync.user.name_first}`
+                                    : `Resident ${sync.user_id}`}
+                            </div>
+                            <div className="text-sm text-gray-600 mt-0.5">
+                                {sync.error}
+                            </div>
+                        </div>
+                        <Badge variant="outline" className="badge-amber">
+                            <AlertCircle className="size-3 mr-1" />
+                            {sync.state === 'active'
+                                ? 'Not enrolled'
+                                : 'Not deactivated'}
+                        </Badge>
+                    </div>
+                ))}
+            </div>
+        </div>
+    );
+}
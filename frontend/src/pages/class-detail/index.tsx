@@ -28,6 +28,7 @@ import { ScheduleTab } from './ScheduleTab';
 import { CanvasScheduleTab } from './CanvasScheduleTab';
 import { CanvasSessionsTab } from './CanvasSessionsTab';
 import { SupportTab } from './SupportTab';
+import { CanvasSyncCard } from './CanvasSyncCard';
 import { AuditTab } from './AuditTab';
 import { TakeAttendanceModal } from './TakeAttendanceModal';
 import { DeleteClassModal } from './DeleteClassModal';
@@ -274,6 +275,12 @@ export default function ClassDetailPage() {
                     </TabsList>
 
                     <TabsContent value="roster" className="space-y-4">
+                        {cls.canvas_course_id && (
+                            <CanvasSyncCard
+                                classId={cls.id}
+                                canvasCourseId={cls.canvas_course_id}
+                            />
+                        )}
                         <RosterTab
                             classId={cls.id}
                             classFacilityId={cls.facility_id}
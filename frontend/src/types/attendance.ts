@@ -36,7 +36,8 @@ export enum EnrollmentStatus {
     Dropped = 'Incomplete: Dropped',
     Segregated = 'Incomplete: Segregated',
     'Failed To Complete' = 'Incomplete: Failed to Complete',
-    Transfered = 'Incomplete: Transfered'
+    Transfered = 'Incomplete: Transfered',
+    Released = 'Incomplete: Released'
 }
 
 export interface EnrollmentAttendance {
@@ -59,6 +60,79 @@ export interface EnrollmentAttendance {
     scheduled_minutes?: number;
 }
 
+export interface AttendanceSyncMark {
+    client_id: string;
+    event_id: number;
+    user_id: number;
+    date: string;
+    attendance_status: Attendance;
+    note?: string;
+    reason_category?: string;
+    check_in_at?: string;
+    check_out_at?: string;
+    captured_at: string;
+}
+
+export type AttendanceSyncResult = 'applied' | 'conflict' | 'rejected';
+
+export interface AttendanceSyncRecord {
+    id: number;
+    client_id: string;
+    class_id: number;
+    event_id: number;
+    user_id: number;
+    date: string;
+    attendance_status: Attendance;
+    captured_at: string;
+    synced_by_id: number;
+    result: AttendanceSyncResult;
+    message?: string;
+    recorded_status?: Attendance;
+    attendance_id?: number;
+    replayed: boolean;
+}
+
+export interface KioskSession {
+    id: number;
+    class_id: number;
+    event_id: number;
+    facility_id: number;
+    date: string;
+    opened_by_id: number;
+    expires_at: string;
+    finalized_at?: string;
+    finalized_by_id?: number;
+    token?: string;
+}
+
+export interface KioskSessionAttendance {
+    id: number;
+    event_id: number;
+    user_id: number;
+    date: string;
+    attendance_status: Attendance;
+    check_in_at?: string;
+    check_out_at?: string;
+    minutes_attended?: number;
+    scheduled_minutes?: number;
+    user?: { id: number; name_first: string; name_last: string; doc_id: string };
+}
+
+export interface KioskSessionReview {
+    session: KioskSession;
+    attendance: KioskSessionAttendance[];
+    not_checked_in: { id: number; name_first: string; name_last: string; doc_id: string }[];
+    still_checked_in: number;
+}
+
+export interface KioskCheckIn {
+    name_first: string;
+    name_last: string;
+    attendance_status: Attendance;
+    check_in_at?: string;
+    check_out_at?: string;
+}
+
 export interface ClassEnrollment {
     id: number;
     created_at: string;
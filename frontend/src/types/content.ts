@@ -98,6 +98,7 @@ export interface OpenContentItem {
     content_type: string;
     provider_name?: string;
     channel_title?: string;
+    language?: string;
 }
 
 export interface OpenContentResponse extends OpenContentItem {
@@ -116,6 +117,14 @@ export interface SearchResult {
     start_index: string;
     items_per_page: string;
     items?: SearchResultItem[];
+    languages: LanguageFacet[];
+}
+
+export interface LanguageFacet {
+    language: string;
+    libraries: number;
+    results?: number;
+    selected: boolean;
 }
 
 export interface SearchResultItem extends OpenContentItem {
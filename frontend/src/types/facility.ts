@@ -23,7 +23,12 @@ export interface RoomConflict {
     class_name: string;
     start_time: string;
     end_time: string;
-    conflict_type: 'room' | 'instructor';
+    conflict_type:
+        | 'room'
+        | 'instructor'
+        | 'instructor_unavailable'
+        | 'instructor_over_hours';
+    message?: string;
 }
 
 export interface FacilityWithStats {
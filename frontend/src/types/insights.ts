@@ -191,7 +191,11 @@ export type ActivityHistoryAction =
     | 'reset_password'
     | 'progclass_history'
     | 'user_deactivated'
-    | 'attendance_recorded';
+    | 'attendance_recorded'
+    | 'release_packet_generated'
+    | 'release_date_set'
+    | 'release_warning_sent'
+    | 'release_enrollments_withdrawn';
 
 export enum FilterPastTime {
     'Past 30 days' = '30',
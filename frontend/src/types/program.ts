@@ -163,6 +163,27 @@ export interface Class {
     attendance_rate?: number;
     is_canvas?: boolean;
     canvas_timezone?: string;
+    /** Canvas provider and course the class's enrollments are pushed to */
+    canvas_provider_id?: number;
+    canvas_course_id?: string;
+}
+
+export interface CanvasEnrollmentSync {
+    id: number;
+    class_id: number;
+    user_id: number;
+    provider_platform_id: number;
+    canvas_course_id: string;
+    canvas_user_id: string;
+    canvas_enrollment_id: string;
+    /** Canvas enrollment state the resident should have */
+    state: 'active' | 'inactive';
+    status: 'synced' | 'failed';
+    attempts: number;
+    error: string;
+    synced_at?: string | null;
+    user?: User;
+    updated_at: string;
 }
 
 export interface MissingAttendanceItem {
@@ -244,6 +265,64 @@ export interface ProgramCompletion {
     user?: User;
 }
 
+export interface Transcript {
+    id: number;
+    user_id: number;
+    verification_code: string;
+    resident_name: string;
+    doc_id: string;
+    facility_name: string;
+    classes_completed: number;
+    total_credit_hours: number;
+    issued_at: string;
+    issued_by_id: number;
+    created_at: string;
+}
+
+export interface TranscriptClass {
+    program_name: string;
+    class_name: string;
+    facility_name: string;
+    enrollment_status: string;
+    enrolled_at?: string;
+    ended_at?: string;
+    completed_at?: string;
+    credit_hours?: number;
+    sessions_attended: number;
+    total_sessions: number;
+    attendance_rate?: number;
+}
+
+export interface TranscriptReflection {
+    program_name: string;
+    facility_name: string;
+    completion_date: string;
+    summary: string;
+    top_skills: string[];
+}
+
+export interface TranscriptData {
+    transcript?: Transcript;
+    verify_url?: string;
+    resident_name: string;
+    doc_id: string;
+    facility_name: string;
+    generated_at: string;
+    classes_completed: number;
+    total_credit_hours: number;
+    classes: TranscriptClass[];
+    reflections: TranscriptReflection[];
+}
+
+export interface TranscriptVerification {
+    valid: boolean;
+    resident_name?: string;
+    facility_name?: string;
+    classes_completed?: number;
+    total_credit_hours?: number;
+    issued_at?: string;
+}
+
 export interface ConflictDetail {
     user_id: number;
     user_name: string;
@@ -4,7 +4,8 @@ export enum ProviderPlatformType {
     CANVAS_CLOUD = 'canvas_cloud',
     CANVAS_OSS = 'canvas_oss',
     KOLIBRI = 'kolibri',
-    BRIGHTSPACE = 'brightspace'
+    BRIGHTSPACE = 'brightspace',
+    MOODLE = 'moodle'
 }
 
 export enum ProviderPlatformState {
@@ -22,6 +23,10 @@ export interface ProviderPlatform {
     state: ProviderPlatformState;
     type: ProviderPlatformType;
     oidc_id: number;
+    /** Score at which the user matcher confirms a match on its own */
+    match_auto_confirm: number;
+    /** Score at which the user matcher queues a match for review */
+    match_ambiguous: number;
 }
 
 export interface ProviderUser {
@@ -31,6 +36,7 @@ export interface ProviderUser {
     email: string;
     external_user_id: string;
     external_username: string;
+    doc_id?: string;
 }
 
 export interface UserImports {
@@ -56,6 +62,27 @@ export interface UserMatchResult {
     canvas_user: ProviderUser;
     suggested_user?: User;
     score: number;
+    signals: UserMatchSignal[];
+}
+
+export type UserMatchSignal = 'doc_id' | 'username' | 'email' | 'name';
+
+export interface UserMatchReview {
+    id: number;
+    provider_platform_id: number;
+    facility_id: number;
+    external_user_id: string;
+    import_user: ProviderUser;
+    suggested_user_id?: number;
+    suggested_user?: User;
+    score: number;
+    signals: UserMatchSignal[];
+    status: 'pending' | 'confirmed' | 'rejected';
+    resolved_user_id?: number;
+    resolved_user?: User;
+    resolved_by_id?: number;
+    resolved_at?: string;
+    created_at: string;
 }
 
 export interface MatchUsersResponse {
@@ -5,7 +5,8 @@ export enum ReportType {
     PROGRAM_OUTCOMES = 'program_outcomes',
     FACILITY_COMPARISON = 'facility_comparison',
     CLASS_ROSTER = 'class_roster',
-    RESIDENT_PROFILE = 'resident_profile'
+    RESIDENT_PROFILE = 'resident_profile',
+    ROOM_UTILIZATION = 'room_utilization'
 }
 
 export enum ReportFormat {
@@ -36,4 +37,6 @@ export interface ReportGenerateRequest {
     include_incomplete_reason?: boolean;
     include_attendance_rate?: boolean;
     include_enrollment_dates?: boolean;
+    // Room Utilization options
+    room_hours_per_week?: number;
 }
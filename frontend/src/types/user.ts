@@ -42,9 +42,15 @@ export interface User {
     facility: Facility;
     feature_access: FeatureAccess[];
     timezone: string;
+    locale?: string;
     facilities?: Facility[];
     login_metrics: LoginMetrics;
     deactivated_at?: string | null;
+    /** Projected release date (YYYY-MM-DD) in the facility's time zone */
+    release_date?: string | null;
+    housing_unit?: string;
+    /** Date of birth (YYYY-MM-DD) */
+    date_of_birth?: string;
     /** Canvas user's display name — populated by the mapped-users endpoint from the live provider API */
     canvas_name_first?: string;
     canvas_name_last?: string;
@@ -76,6 +82,72 @@ export interface ResetPasswordResponse {
     message: string;
 }
 
+export interface ReleasePacket {
+    id: number;
+    user_id: number;
+    facility_id: number;
+    generated_by_id?: number;
+    filename: string;
+    size_bytes: number;
+    checksum: string;
+    created_at: string;
+    generated_by?: User;
+}
+
+export interface UpcomingRelease {
+    user_id: number;
+    name_first: string;
+    name_last: string;
+    doc_id: string;
+    release_date: string;
+    days_until: number;
+    open_enrollments: number;
+}
+
+export type RosterSyncAction =
+    | 'admit'
+    | 'transfer'
+    | 'release'
+    | 'release_date'
+    | 'skip';
+
+export interface RosterSyncChange {
+    id: number;
+    run_id: number;
+    action: RosterSyncAction;
+    row_number: number;
+    doc_id: string;
+    name_first: string;
+    name_last: string;
+    user_id?: number;
+    from_facility_id?: number;
+    to_facility_id?: number;
+    release_date?: string;
+    detail: string;
+    applied: boolean;
+    created_at: string;
+}
+
+export interface RosterSyncRun {
+    id: number;
+    filename: string;
+    checksum: string;
+    dry_run: boolean;
+    status: 'completed' | 'failed';
+    error?: string;
+    row_count: number;
+    admitted: number;
+    transferred: number;
+    released: number;
+    updated: number;
+    skipped: number;
+    failed: number;
+    triggered_by_id?: number;
+    triggered_by?: User;
+    changes?: RosterSyncChange[];
+    created_at: string;
+}
+
 export interface ValidResident {
     user: User;
     program_names: TransferResidentProgamConflicts[];
@@ -95,12 +167,52 @@ export interface ValidatedUserRow {
     first_name: string;
     resident_id: string;
     username: string;
+    release_date?: string;
+    housing_unit?: string;
+    date_of_birth?: string;
+    preferred_language?: string;
 }
 
 export interface InvalidUserRow extends ValidatedUserRow {
     error_reasons: string[];
 }
 
+export type CSVField =
+    | 'last_name'
+    | 'first_name'
+    | 'full_name'
+    | 'resident_id'
+    | 'username'
+    | 'housing_unit'
+    | 'release_date'
+    | 'date_of_birth'
+    | 'preferred_language';
+
+export type CSVTransform =
+    | 'split_last_first'
+    | 'split_first_last'
+    | 'trim_leading_zeros'
+    | 'uppercase'
+    | 'lowercase';
+
+export interface CSVColumnMapping {
+    field: CSVField;
+    column: string;
+    transforms?: CSVTransform[];
+    /** One of the server's date formats, e.g. 'DD-MON-YYYY', for release_date and date_of_birth */
+    date_format?: string;
+}
+
+export interface CSVMappingProfile {
+    id: number;
+    name: string;
+    /** Unset when the profile can be used at every facility */
+    facility_id?: number | null;
+    columns: CSVColumnMapping[];
+    created_at: string;
+    updated_at: string;
+}
+
 export interface BulkUploadResponse {
     valid_count: number;
     error_count: number;
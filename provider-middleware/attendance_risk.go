@@ -0,0 +1,182 @@
+package main
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
+)
+
+// handleEvaluateAttendanceRisk is the entrypoint for the nightly
+// `tasks.evaluate_attendance_risk` job. It checks every enrolled resident's
+// attendance in each active class against their facility's attendance-risk
+// thresholds and raises, refreshes or clears the persisted alerts.
+func (sh *ServiceHandler) handleEvaluateAttendanceRisk(ctx context.Context, msg *nats.Msg) {
+	var body map[string]any
+	if err := json.Unmarshal(msg.Data, &body); err != nil {
+		logger().Errorf("failed to unmarshal evaluate_attendance_risk message: %v", err)
+		return
+	}
+	jobId, ok := body["job_id"].(string)
+	if !ok {
+		logger().Errorf("job_id not found in evaluate_attendance_risk message: %v", body)
+		return
+	}
+	success := sh.evaluateAttendanceRisk(ctx) == nil
+	sh.cleanupJob(ctx, nil, jobId, success)
+}
+
+type riskKey struct {
+	userID  uint
+	classID uint
+	rule    models.AttendanceRiskRule
+}
+
+func (sh *ServiceHandler) evaluateAttendanceRisk(ctx context.Context) error {
+	batchUserID, err := sh.systemBatchUserID(ctx)
+	if err != nil {
+		logger().Errorf("cannot evaluate attendance risk: %v", err)
+		return err
+	}
+	batchCtx := context.WithValue(ctx, models.UserIDKey, batchUserID)
+
+	var facilityIDs []uint
+	if err := sh.db.WithContext(ctx).Model(&models.Facility{}).Pluck("id", &facilityIDs).Error; err != nil {
+		logger().Errorf("failed to query facilities for attendance risk: %v", err)
+		return err
+	}
+	var saved []models.AttendanceRiskSettings
+	if err := sh.db.WithContext(ctx).Find(&saved).Error; err != nil {
+		logger().Errorf("failed to query attendance risk settings: %v", err)
+		return err
+	}
+	settingsByFacility := make(map[uint]models.AttendanceRiskSettings, len(saved))
+	for _, settings := range saved {
+		settingsByFacility[settings.FacilityID] = settings
+	}
+
+	var failed error
+	for _, facilityID := range facilityIDs {
+		settings, ok := settingsByFacility[facilityID]
+		if !ok {
+			settings = models.DefaultAttendanceRiskSettings(facilityID)
+		}
+		if err := sh.evaluateFacilityAttendanceRisk(batchCtx, &settings, time.Now()); err != nil {
+			logger().Errorf("failed to evaluate attendance risk for facility %d: %v", facilityID, err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+func (sh *ServiceHandler) evaluateFacilityAttendanceRisk(ctx context.Context, settings *models.AttendanceRiskSettings, now time.Time) error {
+	type record struct {
+		UserID           uint
+		ClassID          uint
+		Date             string
+		AttendanceStatus models.Attendance
+	}
+	var records []record
+	if settings.Enabled {
+		if err := sh.db.WithContext(ctx).Raw(`
+			SELECT e.user_id, e.class_id, att.date, att.attendance_status
+			FROM program_class_enrollments e
+			INNER JOIN program_classes c ON c.id = e.class_id
+			INNER JOIN users u ON u.id = e.user_id AND u.deleted_at IS NULL
+			INNER JOIN program_class_events evt ON evt.class_id = c.id
+			INNER JOIN program_class_event_attendance att ON att.event_id = evt.id AND att.user_id = e.user_id AND att.deleted_at IS NULL
+			WHERE c.facility_id = ? AND c.status = ? AND c.archived_at IS NULL
+				AND e.enrollment_status = ?
+				AND att.attendance_status IS NOT NULL AND att.attendance_status <> ''
+			ORDER BY e.user_id, e.class_id, att.date DESC, evt.id DESC`,
+			settings.FacilityID, models.Active, models.Enrolled,
+		).Scan(&records).Error; err != nil {
+			return fmt.Errorf("failed to query attendance: %w", err)
+		}
+	}
+
+	var existing []models.AttendanceRiskAlert
+	if err := sh.db.WithContext(ctx).
+		Where("facility_id = ?", settings.FacilityID).
+		Order("id").
+		Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to query alerts: %w", err)
+	}
+	unresolved := make(map[riskKey]*models.AttendanceRiskAlert)
+	lastResolved := make(map[riskKey]*models.AttendanceRiskAlert)
+	for idx := range existing {
+		alert := &existing[idx]
+		key := riskKey{alert.UserID, alert.ClassID, alert.Rule}
+		if alert.Status == models.RiskAlertResolved {
+			lastResolved[key] = alert
+		} else {
+			unresolved[key] = alert
+		}
+	}
+
+	return sh.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		raised, refreshed := 0, 0
+		for start := 0; start < len(records); {
+			end := start
+			history := make([]models.Attendance, 0, 16)
+			for end < len(records) && records[end].UserID == records[start].UserID && records[end].ClassID == records[start].ClassID {
+				history = append(history, records[end].AttendanceStatus)
+				end++
+			}
+			userID, classID, latestDate := records[start].UserID, records[start].ClassID, records[start].Date
+			start = end
+
+			for _, finding := range settings.Evaluate(history) {
+				key := riskKey{userID, classID, finding.Rule}
+				alert, ok := unresolved[key]
+				if ok {
+					delete(unresolved, key)
+					refreshed++
+				} else {
+					if previous, ok := lastResolved[key]; ok && previous.LastSessionDate >= latestDate {
+						continue // already handled, and no attendance has been taken since
+					}
+					alert = &models.AttendanceRiskAlert{
+						FacilityID: settings.FacilityID,
+						UserID:     userID,
+						ClassID:    classID,
+						Rule:       finding.Rule,
+						Status:     models.RiskAlertOpen,
+					}
+					raised++
+				}
+				if alert.Status == models.RiskAlertSnoozed && alert.SnoozedUntil != nil && !alert.SnoozedUntil.After(now) {
+					alert.Status = models.RiskAlertOpen
+					alert.SnoozedUntil = nil
+				}
+				alert.Detail = finding.Detail
+				alert.ConsecutiveAbsences = finding.ConsecutiveAbsences
+				alert.AttendanceRate = finding.AttendanceRate
+				alert.SessionsConsidered = finding.SessionsConsidered
+				alert.LastSessionDate = latestDate
+				alert.LastEvaluatedAt = now
+				if err := tx.Omit("User", "Class", "Note").Save(alert).Error; err != nil {
+					return fmt.Errorf("failed to save alert for user %d in class %d: %w", userID, classID, err)
+				}
+			}
+		}
+
+		// whatever is left no longer breaks a rule, or the resident is no longer enrolled in an active class
+		for _, alert := range unresolved {
+			if err := tx.Model(alert).Updates(map[string]any{
+				"status":            models.RiskAlertResolved,
+				"resolved_at":       now,
+				"snoozed_until":     nil,
+				"last_evaluated_at": now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to clear alert %d: %w", alert.ID, err)
+			}
+		}
+		logger().Infof("facility %d attendance risk: %d alert(s) raised, %d refreshed, %d cleared", settings.FacilityID, raised, refreshed, len(unresolved))
+		return nil
+	})
+}
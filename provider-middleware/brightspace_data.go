@@ -150,11 +150,13 @@ type BrightspaceUserContentDto struct {
 
 func (srv *BrightspaceService) IntoImportUser(bsUser BrightspaceUser) *models.ImportUser {
 	user := models.ImportUser{
-		Username:       bsUser.UserName,
-		NameFirst:      bsUser.FirstName,
-		NameLast:       bsUser.LastName,
-		Email:          bsUser.ExternalEmail,
-		ExternalUserID: bsUser.UserId,
+		Username:         bsUser.UserName,
+		NameFirst:        bsUser.FirstName,
+		NameLast:         bsUser.LastName,
+		Email:            bsUser.ExternalEmail,
+		ExternalUserID:   bsUser.UserId,
+		ExternalUsername: bsUser.UserName,
+		DocID:            bsUser.OrgDefinedId,
 	}
 	return &user
 }
@@ -112,6 +112,7 @@ func (srv *CanvasService) fetchCanvasUsers() ([]models.ImportUser, error) {
 			nameLast = name[0]
 		}
 		userId, _ := user["id"].(float64)
+		sisUserID, _ := user["sis_user_id"].(string)
 		result = append(result, models.ImportUser{
 			ExternalUserID:   fmt.Sprintf("%d", int(userId)),
 			ExternalUsername: loginId,
@@ -119,6 +120,7 @@ func (srv *CanvasService) fetchCanvasUsers() ([]models.ImportUser, error) {
 			NameLast:         nameLast,
 			Email:            loginId,
 			Username:         nameLast + nameFirst,
+			DocID:            sisUserID,
 		})
 	}
 	return result, nil
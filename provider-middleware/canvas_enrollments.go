@@ -0,0 +1,368 @@
+package main
+
+import (
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm/clause"
+)
+
+// handleCanvasEnrollmentSync is the entrypoint for the `tasks.canvas_enrollment_sync` job. The scheduled run pushes the
+// enrollments of every class linked to a Canvas course, the backend also publishes it with a class_id whenever a
+// class's enrollments change, without a job_id since there is no task to clean up.
+func (sh *ServiceHandler) handleCanvasEnrollmentSync(ctx context.Context, msg *nats.Msg) {
+	var body map[string]any
+	if err := json.Unmarshal(msg.Data, &body); err != nil {
+		logger().Errorf("failed to unmarshal canvas_enrollment_sync message: %v", err)
+		return
+	}
+	var classID *uint
+	if id, ok := body["class_id"].(float64); ok {
+		classIDPtr := uint(id)
+		classID = &classIDPtr
+	}
+	success := sh.syncCanvasEnrollments(ctx, classID) == nil
+	if jobId, ok := body["job_id"].(string); ok {
+		sh.cleanupJob(ctx, nil, jobId, success)
+	}
+}
+
+// syncCanvasEnrollments pushes the enrollments of the class, or of every class linked to a Canvas course when classID is nil
+func (sh *ServiceHandler) syncCanvasEnrollments(ctx context.Context, classID *uint) error {
+	services := make(map[uint]*CanvasService)
+	var errs []error
+	// deactivated first, so residents of a class linked to another course leave the old course before joining the new one
+	if err := sh.deactivateUnlinkedCanvasEnrollments(ctx, services, classID); err != nil {
+		errs = append(errs, err)
+	}
+	var classes []models.ProgramClass
+	tx := sh.db.WithContext(ctx).Where("canvas_provider_id IS NOT NULL AND canvas_course_id IS NOT NULL AND canvas_course_id <> ''")
+	if classID != nil {
+		tx = tx.Where("id = ?", *classID)
+	}
+	if err := tx.Find(&classes).Error; err != nil {
+		logger().Errorf("failed to query classes linked to canvas: %v", err)
+		return errors.Join(append(errs, err)...)
+	}
+	for idx := range classes {
+		class := &classes[idx]
+		canvas, err := sh.canvasServiceFor(ctx, services, *class.CanvasProviderID)
+		if err != nil {
+			logger().Errorf("failed to push enrollments of class %d to canvas: %v", class.ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := sh.syncCanvasClassEnrollments(ctx, canvas, class); err != nil {
+			logger().Errorf("failed to push enrollments of class %d to canvas: %v", class.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// canvasServiceFor returns the service for the Canvas provider, creating it the first time the run needs it
+func (sh *ServiceHandler) canvasServiceFor(ctx context.Context, services map[uint]*CanvasService, providerID uint) (*CanvasService, error) {
+	if canvas, ok := services[providerID]; ok {
+		return canvas, nil
+	}
+	var provider models.ProviderPlatform
+	if err := sh.db.WithContext(ctx).First(&provider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find canvas provider %d: %w", providerID, err)
+	}
+	if provider.Type != models.CanvasCloud && provider.Type != models.CanvasOSS {
+		return nil, fmt.Errorf("provider %d is not a canvas platform", provider.ID)
+	}
+	canvas := newCanvasService(&provider, nil)
+	services[provider.ID] = canvas
+	return canvas, nil
+}
+
+/*
+deactivateUnlinkedCanvasEnrollments deactivates the enrollments pushed to a course their class is no longer linked to,
+in that course. Once deactivated the sync row is left for the class's new course to reuse, one that fails is retried on
+the next run like any other push.
+*/
+func (sh *ServiceHandler) deactivateUnlinkedCanvasEnrollments(ctx context.Context, services map[uint]*CanvasService, classID *uint) error {
+	var syncs []models.CanvasEnrollmentSync
+	tx := sh.db.WithContext(ctx).Model(&models.CanvasEnrollmentSync{}).
+		Joins("JOIN program_classes c ON c.id = canvas_enrollment_syncs.class_id").
+		Where(`c.canvas_provider_id IS NULL OR c.canvas_course_id IS NULL OR
+			canvas_enrollment_syncs.provider_platform_id <> c.canvas_provider_id OR canvas_enrollment_syncs.canvas_course_id <> c.canvas_course_id`).
+		Where("NOT (canvas_enrollment_syncs.state = ? AND canvas_enrollment_syncs.status = ?)", models.CanvasEnrollmentInactive, models.CanvasSyncSynced).
+		Where("NOT (canvas_enrollment_syncs.status = ? AND canvas_enrollment_syncs.attempts >= ?)", models.CanvasSyncFailed, models.MaxCanvasSyncAttempts)
+	if classID != nil {
+		tx = tx.Where("canvas_enrollment_syncs.class_id = ?", *classID)
+	}
+	if err := tx.Find(&syncs).Error; err != nil {
+		logger().Errorf("failed to query canvas enrollments left in unlinked courses: %v", err)
+		return err
+	}
+	var errs []error
+	for idx := range syncs {
+		sync := &syncs[idx]
+		canvas, err := sh.canvasServiceFor(ctx, services, sync.ProviderPlatformID)
+		if err != nil {
+			logger().Errorf("failed to deactivate enrollment of class %d in canvas course %s: %v", sync.ClassID, sync.CanvasCourseID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if sync.State != models.CanvasEnrollmentInactive {
+			sync.State, sync.Status, sync.Attempts = models.CanvasEnrollmentInactive, "", 0
+		}
+		// deactivated for the Canvas user it was pushed for, the resident's mapping may have changed since
+		recordCanvasPush(sync, canvas.pushEnrollment(sync, ""))
+		if err := sh.saveCanvasEnrollmentSync(ctx, sync); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+	}
+	if len(syncs) > 0 {
+		logger().Infof("deactivated %d enrollment(s) in canvas courses their class is no longer linked to", len(syncs))
+	}
+	return errors.Join(errs...)
+}
+
+/*
+syncCanvasClassEnrollments brings the Canvas course in line with the class: residents enrolled in the class are active
+students in the course and residents who left it, or whose enrollment was deleted, are deactivated. Enrollments already
+pushed are skipped, so running it again after a partial failure only retries what failed. A push that fails is kept on
+the class's sync rows for the class page, it does not fail the run.
+*/
+func (sh *ServiceHandler) syncCanvasClassEnrollments(ctx context.Context, canvas *CanvasService, class *models.ProgramClass) error {
+	var enrollments []models.ProgramClassEnrollment
+	if err := sh.db.WithContext(ctx).Select("user_id", "enrollment_status").
+		Where("class_id = ?", class.ID).Find(&enrollments).Error; err != nil {
+		return err
+	}
+	var existing []models.CanvasEnrollmentSync
+	if err := sh.db.WithContext(ctx).Where("class_id = ?", class.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	var mappings []models.ProviderUserMapping
+	if err := sh.db.WithContext(ctx).Select("user_id", "external_user_id").
+		Where("provider_platform_id = ?", canvas.ProviderPlatformID).Find(&mappings).Error; err != nil {
+		return err
+	}
+	canvasUsers := make(map[uint]string, len(mappings))
+	for _, mapping := range mappings {
+		canvasUsers[mapping.UserID] = mapping.ExternalUserID
+	}
+
+	desired := make(map[uint]models.CanvasEnrollmentState, len(enrollments)+len(existing))
+	for _, sync := range existing {
+		desired[sync.UserID] = models.CanvasEnrollmentInactive
+	}
+	for _, enrollment := range enrollments {
+		// a resident can have ended enrollments in the class next to their current one, they stay active while one is enrolled
+		state := models.CanvasEnrollmentStateFor(enrollment.EnrollmentStatus)
+		if _, seen := desired[enrollment.UserID]; !seen || state == models.CanvasEnrollmentActive {
+			desired[enrollment.UserID] = state
+		}
+	}
+	syncs := make(map[uint]*models.CanvasEnrollmentSync, len(existing))
+	for idx := range existing {
+		syncs[existing[idx].UserID] = &existing[idx]
+	}
+
+	pushed, failed := 0, 0
+	for userID, state := range desired {
+		sync, ok := syncs[userID]
+		if !ok {
+			if state == models.CanvasEnrollmentInactive {
+				// never pushed, so there is nothing in Canvas to deactivate
+				continue
+			}
+			sync = &models.CanvasEnrollmentSync{ClassID: class.ID, UserID: userID}
+		}
+		if sync.ProviderPlatformID != canvas.ProviderPlatformID || sync.CanvasCourseID != *class.CanvasCourseID {
+			if ok && (sync.State != models.CanvasEnrollmentInactive || sync.Status != models.CanvasSyncSynced) {
+				// still to be deactivated in the course the class was linked to before
+				continue
+			}
+			sync.ProviderPlatformID, sync.CanvasCourseID = canvas.ProviderPlatformID, *class.CanvasCourseID
+			sync.CanvasUserID, sync.CanvasEnrollmentID, sync.Status = "", "", ""
+		}
+		if sync.State != state {
+			sync.State, sync.Status, sync.Attempts = state, "", 0
+		}
+		if sync.Status == models.CanvasSyncSynced || (sync.Status == models.CanvasSyncFailed && sync.Attempts >= models.MaxCanvasSyncAttempts) {
+			continue
+		}
+		if recordCanvasPush(sync, canvas.pushEnrollment(sync, canvasUsers[userID])) {
+			pushed++
+		} else {
+			failed++
+		}
+		if err := sh.saveCanvasEnrollmentSync(ctx, sync); err != nil {
+			return err
+		}
+	}
+	if pushed > 0 || failed > 0 {
+		logger().Infof("pushed %d enrollment(s) of class %d to canvas course %s, %d failed", pushed, class.ID, *class.CanvasCourseID, failed)
+	}
+	return nil
+}
+
+// recordCanvasPush records the outcome of pushing the enrollment on its sync row, reporting whether it succeeded
+func recordCanvasPush(sync *models.CanvasEnrollmentSync, err error) bool {
+	if err != nil {
+		sync.Status, sync.Error = models.CanvasSyncFailed, err.Error()
+		sync.Attempts++
+		return false
+	}
+	now := time.Now().UTC()
+	sync.Status, sync.Error, sync.Attempts, sync.SyncedAt = models.CanvasSyncSynced, "", 0, &now
+	return true
+}
+
+/*
+saveCanvasEnrollmentSync saves the sync row. The scheduled run and a class's own push can both get to a resident who
+wasn't pushed yet, so a new row takes over the one the other run inserted rather than failing on the unique index.
+*/
+func (sh *ServiceHandler) saveCanvasEnrollmentSync(ctx context.Context, sync *models.CanvasEnrollmentSync) error {
+	if sync.ID != 0 {
+		return sh.db.WithContext(ctx).Save(sync).Error
+	}
+	return sh.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "class_id"}, {Name: "user_id"}},
+		UpdateAll: true,
+	}).Create(sync).Error
+}
+
+// pushEnrollment makes the resident's enrollment in the Canvas course match sync.State, remembering the Canvas IDs it used
+func (srv *CanvasService) pushEnrollment(sync *models.CanvasEnrollmentSync, canvasUserID string) error {
+	if canvasUserID == "" {
+		if sync.CanvasUserID == "" {
+			if sync.State == models.CanvasEnrollmentInactive {
+				return nil
+			}
+			return errors.New("resident is not mapped to a Canvas user")
+		}
+		canvasUserID = sync.CanvasUserID
+	}
+	if sync.CanvasUserID != canvasUserID {
+		sync.CanvasUserID, sync.CanvasEnrollmentID = canvasUserID, ""
+	}
+	if sync.State == models.CanvasEnrollmentActive {
+		return srv.activateEnrollment(sync)
+	}
+	return srv.deactivateEnrollment(sync)
+}
+
+func (srv *CanvasService) enrollmentsURL(courseID string) string {
+	return srv.BaseURL + "/api/v1/courses/" + url.PathEscape(courseID) + "/enrollments"
+}
+
+/*
+activateEnrollment reactivates the enrollment pushed before, or enrolls the resident as a student. Canvas hands back the
+resident's existing enrollment when they're enrolled already, so a retry after a lost response doesn't enroll them twice.
+*/
+func (srv *CanvasService) activateEnrollment(sync *models.CanvasEnrollmentSync) error {
+	if sync.CanvasEnrollmentID != "" {
+		status, _, err := srv.sendCanvasForm(http.MethodPut, srv.enrollmentsURL(sync.CanvasCourseID)+"/"+url.PathEscape(sync.CanvasEnrollmentID)+"/reactivate", nil)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusNotFound {
+			return nil
+		}
+		// the enrollment was deleted in Canvas, enroll them again
+		sync.CanvasEnrollmentID = ""
+	}
+	form := url.Values{}
+	form.Set("enrollment[user_id]", sync.CanvasUserID)
+	form.Set("enrollment[type]", "StudentEnrollment")
+	form.Set("enrollment[enrollment_state]", "active")
+	form.Set("enrollment[notify]", "false")
+	status, body, err := srv.sendCanvasForm(http.MethodPost, srv.enrollmentsURL(sync.CanvasCourseID), form)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("canvas course %s or user %s was not found", sync.CanvasCourseID, sync.CanvasUserID)
+	}
+	var enrollment struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal(body, &enrollment); err != nil || enrollment.ID == "" {
+		return fmt.Errorf("canvas returned an enrollment without an id: %s", truncateCanvasBody(body))
+	}
+	sync.CanvasEnrollmentID = enrollment.ID.String()
+	return nil
+}
+
+// deactivateEnrollment deactivates the resident's enrollment, an enrollment Canvas no longer has is as good as deactivated
+func (srv *CanvasService) deactivateEnrollment(sync *models.CanvasEnrollmentSync) error {
+	if sync.CanvasEnrollmentID == "" {
+		status, body, err := srv.sendCanvasForm(http.MethodGet, srv.enrollmentsURL(sync.CanvasCourseID)+"?type[]=StudentEnrollment&user_id="+url.QueryEscape(sync.CanvasUserID), nil)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusNotFound {
+			return nil
+		}
+		var enrollments []struct {
+			ID json.Number `json:"id"`
+		}
+		if err := json.Unmarshal(body, &enrollments); err != nil {
+			return fmt.Errorf("failed to read canvas enrollments: %v", err)
+		}
+		if len(enrollments) == 0 {
+			return nil
+		}
+		sync.CanvasEnrollmentID = enrollments[0].ID.String()
+	}
+	_, _, err := srv.sendCanvasForm(http.MethodDelete, srv.enrollmentsURL(sync.CanvasCourseID)+"/"+url.PathEscape(sync.CanvasEnrollmentID)+"?task=deactivate", nil)
+	return err
+}
+
+// sendCanvasForm sends a request to the Canvas API, any status other than success or not found is returned as an error
+func (srv *CanvasService) sendCanvasForm(method, requestURL string, form url.Values) (int, []byte, error) {
+	var reader io.Reader
+	if form != nil {
+		reader = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, requestURL, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	for key, value := range srv.BaseHeaders {
+		req.Header.Add(key, value)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := srv.Client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to reach canvas: %v", err)
+	}
+	defer func() {
+		if resp.Body.Close() != nil {
+			logger().Error("Failed to close response body")
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return resp.StatusCode, body, nil
+	}
+	return resp.StatusCode, body, fmt.Errorf("canvas returned %d: %s", resp.StatusCode, truncateCanvasBody(body))
+}
+
+func truncateCanvasBody(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) > 200 {
+		return text[:200] + "..."
+	}
+	return text
+}
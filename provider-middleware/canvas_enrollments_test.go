@@ -0,0 +1,345 @@
+package main
+
+import (
+	"UnlockEdv2/src/database"
+	"UnlockEdv2/src/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+const canvasFixtureToken = "canvas-fixture-token"
+
+/*
+fakeCanvasCourse answers the Canvas enrollment endpoints for one course the way Canvas does: enrolling someone who is
+already enrolled hands back their enrollment, and a deactivated enrollment is kept so it can be reactivated.
+*/
+type fakeCanvasCourse struct {
+	mu          sync.Mutex
+	nextID      int
+	enrollments map[int]*fakeCanvasEnrollment
+	// failures makes the next enrollments of a Canvas user fail with a 500
+	failures map[string]int
+	calls    []string
+}
+
+type fakeCanvasEnrollment struct {
+	UserID string `json:"user_id"`
+	State  string `json:"enrollment_state"`
+}
+
+func newFakeCanvasCourse(t *testing.T, courseID string) (*fakeCanvasCourse, *httptest.Server) {
+	t.Helper()
+	course := &fakeCanvasCourse{nextID: 900, enrollments: make(map[int]*fakeCanvasEnrollment), failures: make(map[string]int)}
+	prefix := "/api/v1/courses/" + courseID + "/enrollments"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+canvasFixtureToken {
+			http.Error(w, `{"errors":[{"message":"Invalid access token."}]}`, http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		course.mu.Lock()
+		defer course.mu.Unlock()
+		course.calls = append(course.calls, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		switch {
+		case r.Method == http.MethodPost && rest == "":
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("enrollment[type]") != "StudentEnrollment" {
+				http.Error(w, `{"errors":[{"message":"bad enrollment"}]}`, http.StatusBadRequest)
+				return
+			}
+			userID := r.PostForm.Get("enrollment[user_id]")
+			if course.failures[userID] > 0 {
+				course.failures[userID]--
+				http.Error(w, `{"errors":[{"message":"An error occurred."}]}`, http.StatusInternalServerError)
+				return
+			}
+			for id, enrollment := range course.enrollments {
+				if enrollment.UserID == userID {
+					enrollment.State = "active"
+					_ = json.NewEncoder(w).Encode(map[string]any{"id": id})
+					return
+				}
+			}
+			course.nextID++
+			course.enrollments[course.nextID] = &fakeCanvasEnrollment{UserID: userID, State: "active"}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": course.nextID})
+		case r.Method == http.MethodGet && rest == "":
+			found := make([]map[string]any, 0)
+			for id, enrollment := range course.enrollments {
+				if enrollment.UserID == r.URL.Query().Get("user_id") {
+					found = append(found, map[string]any{"id": id, "user_id": enrollment.UserID})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(found)
+		default:
+			idPart, action, _ := strings.Cut(rest, "/")
+			id, _ := strconv.Atoi(idPart)
+			enrollment, ok := course.enrollments[id]
+			if !ok {
+				http.Error(w, `{"errors":[{"message":"The specified resource does not exist."}]}`, http.StatusNotFound)
+				return
+			}
+			switch {
+			case r.Method == http.MethodPut && action == "reactivate":
+				enrollment.State = "active"
+			case r.Method == http.MethodDelete && r.URL.Query().Get("task") == "deactivate":
+				enrollment.State = "inactive"
+			default:
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return course, server
+}
+
+// takeCalls returns the requests Canvas got since the last call
+func (course *fakeCanvasCourse) takeCalls() []string {
+	course.mu.Lock()
+	defer course.mu.Unlock()
+	calls := course.calls
+	course.calls = nil
+	return calls
+}
+
+func (course *fakeCanvasCourse) stateOf(canvasUserID string) string {
+	course.mu.Lock()
+	defer course.mu.Unlock()
+	for _, enrollment := range course.enrollments {
+		if enrollment.UserID == canvasUserID {
+			return enrollment.State
+		}
+	}
+	return ""
+}
+
+func TestCanvasEnrollmentSync(t *testing.T) {
+	course, server := newFakeCanvasCourse(t, "101")
+	sh := newTestServiceHandler(t, "canvas")
+	provider := &models.ProviderPlatform{
+		Type: models.CanvasCloud, Name: "Canvas", BaseUrl: server.URL, AccessKey: canvasFixtureToken, AccountID: "1", State: models.Enabled,
+	}
+	if err := sh.db.Create(provider).Error; err != nil {
+		t.Fatal(err)
+	}
+	courseID := "101"
+	class := &models.ProgramClass{ProgramID: 1, FacilityID: 1, Capacity: 10, Name: "GED Math", Description: "GED Math",
+		Status: models.Active, CanvasProviderID: &provider.ID, CanvasCourseID: &courseID}
+	unlinked := &models.ProgramClass{ProgramID: 1, FacilityID: 1, Capacity: 10, Name: "GED Reading", Description: "GED Reading", Status: models.Active}
+	if err := sh.db.Create(class).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.db.Create(unlinked).Error; err != nil {
+		t.Fatal(err)
+	}
+	enroll := func(t *testing.T, classID uint, username, canvasUserID string) *models.User {
+		t.Helper()
+		user := &models.User{Username: username, NameFirst: username, NameLast: "Resident", Email: username + "@unlocked.v2", Role: models.Student}
+		if err := sh.db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		if canvasUserID != "" {
+			mapping := &models.ProviderUserMapping{UserID: user.ID, ProviderPlatformID: provider.ID, ExternalUserID: canvasUserID, ExternalUsername: username}
+			if err := sh.db.Create(mapping).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sh.db.Create(&models.ProgramClassEnrollment{ClassID: classID, UserID: user.ID, EnrollmentStatus: models.Enrolled}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	ana := enroll(t, class.ID, "ana", "11")
+	ben := enroll(t, class.ID, "ben", "12")
+	cruz := enroll(t, class.ID, "cruz", "")
+	dee := enroll(t, class.ID, "dee", "14")
+	enroll(t, unlinked.ID, "eli", "15")
+	course.failures["12"] = 1
+
+	loadSync := func(t *testing.T, user *models.User) models.CanvasEnrollmentSync {
+		t.Helper()
+		var sync models.CanvasEnrollmentSync
+		if err := sh.db.Where("class_id = ? AND user_id = ?", class.ID, user.ID).First(&sync).Error; err != nil {
+			t.Fatalf("no sync row for %s: %v", user.Username, err)
+		}
+		return sync
+	}
+	runJob := func(body map[string]any) {
+		data, _ := json.Marshal(body)
+		sh.handleCanvasEnrollmentSync(context.Background(), &nats.Msg{Data: data})
+	}
+
+	t.Run("Enrolled residents are enrolled in the linked course and failures are kept", func(t *testing.T) {
+		runJob(map[string]any{"job_id": "canvas-enrollments"})
+		if len(course.takeCalls()) != 3 {
+			t.Fatal("expected one enrollment request for each mapped resident")
+		}
+		for _, user := range []*models.User{ana, dee} {
+			sync := loadSync(t, user)
+			if sync.Status != models.CanvasSyncSynced || sync.State != models.CanvasEnrollmentActive || sync.CanvasEnrollmentID == "" || sync.SyncedAt == nil {
+				t.Fatalf("expected %s to be synced, got %+v", user.Username, sync)
+			}
+		}
+		if sync := loadSync(t, ben); sync.Status != models.CanvasSyncFailed || sync.Attempts != 1 || !strings.Contains(sync.Error, "500") {
+			t.Fatalf("expected ben's enrollment to fail once, got %+v", sync)
+		}
+		if sync := loadSync(t, cruz); sync.Status != models.CanvasSyncFailed || !strings.Contains(sync.Error, "not mapped") {
+			t.Fatalf("expected cruz to fail as unmapped, got %+v", sync)
+		}
+		if course.stateOf("15") != "" {
+			t.Fatal("expected enrollments of unlinked classes not to be pushed")
+		}
+	})
+
+	t.Run("Running again only retries what failed", func(t *testing.T) {
+		runJob(map[string]any{"job_id": "canvas-enrollments"})
+		if calls := course.takeCalls(); len(calls) != 1 {
+			t.Fatalf("expected only ben's enrollment to be retried, got %v", calls)
+		}
+		if sync := loadSync(t, ben); sync.Status != models.CanvasSyncSynced || sync.Attempts != 0 || sync.Error != "" {
+			t.Fatalf("expected ben's retry to succeed, got %+v", sync)
+		}
+		if sync := loadSync(t, cruz); sync.Attempts != 2 {
+			t.Fatalf("expected cruz's second attempt to be counted, got %+v", sync)
+		}
+		var count int64
+		sh.db.Model(&models.CanvasEnrollmentSync{}).Count(&count)
+		if count != 4 {
+			t.Fatalf("expected one sync row per resident, got %d", count)
+		}
+	})
+
+	t.Run("Residents who leave the class are deactivated and can be reactivated", func(t *testing.T) {
+		if err := sh.db.Model(&models.ProgramClassEnrollment{}).Where("class_id = ? AND user_id = ?", class.ID, dee.ID).
+			Update("enrollment_status", models.EnrollmentIncompleteWithdrawn).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := sh.db.Where("class_id = ? AND user_id = ?", class.ID, ana.ID).Delete(&models.ProgramClassEnrollment{}).Error; err != nil {
+			t.Fatal(err)
+		}
+		runJob(map[string]any{"class_id": class.ID})
+		for _, user := range []*models.User{ana, dee} {
+			if sync := loadSync(t, user); sync.State != models.CanvasEnrollmentInactive || sync.Status != models.CanvasSyncSynced {
+				t.Fatalf("expected %s to be deactivated, got %+v", user.Username, sync)
+			}
+		}
+		if course.stateOf("11") != "inactive" || course.stateOf("14") != "inactive" {
+			t.Fatal("expected the canvas enrollments to be deactivated")
+		}
+		course.takeCalls()
+
+		if err := sh.db.Model(&models.ProgramClassEnrollment{}).Where("class_id = ? AND user_id = ?", class.ID, dee.ID).
+			Update("enrollment_status", models.Enrolled).Error; err != nil {
+			t.Fatal(err)
+		}
+		runJob(map[string]any{"class_id": class.ID})
+		deeSync := loadSync(t, dee)
+		if calls := course.takeCalls(); len(calls) != 1 || calls[0] != fmt.Sprintf("PUT /api/v1/courses/101/enrollments/%s/reactivate", deeSync.CanvasEnrollmentID) {
+			t.Fatalf("expected dee's enrollment to be reactivated, got %v", calls)
+		}
+		if course.stateOf("14") != "active" || deeSync.State != models.CanvasEnrollmentActive {
+			t.Fatalf("expected dee to be active again, got %+v", deeSync)
+		}
+	})
+
+	t.Run("Pushes that keep failing wait for an admin to retry them", func(t *testing.T) {
+		if err := sh.db.Model(&models.CanvasEnrollmentSync{}).Where("user_id = ?", cruz.ID).
+			Update("attempts", models.MaxCanvasSyncAttempts).Error; err != nil {
+			t.Fatal(err)
+		}
+		runJob(map[string]any{"job_id": "canvas-enrollments"})
+		if sync := loadSync(t, cruz); sync.Attempts != models.MaxCanvasSyncAttempts {
+			t.Fatalf("expected cruz not to be tried again, got %+v", sync)
+		}
+		if calls := course.takeCalls(); len(calls) != 0 {
+			t.Fatalf("expected nothing to be pushed, got %v", calls)
+		}
+	})
+
+	t.Run("Ended enrollments next to the current one keep the resident active", func(t *testing.T) {
+		ended := &models.ProgramClassEnrollment{ClassID: class.ID, UserID: ben.ID, EnrollmentStatus: models.EnrollmentCancelled}
+		if err := sh.db.Create(ended).Error; err != nil {
+			t.Fatal(err)
+		}
+		runJob(map[string]any{"class_id": class.ID})
+		if sync := loadSync(t, ben); sync.State != models.CanvasEnrollmentActive || course.stateOf("12") != "active" {
+			t.Fatalf("expected ben to stay active, got %+v", sync)
+		}
+		if calls := course.takeCalls(); len(calls) != 0 {
+			t.Fatalf("expected nothing to be pushed, got %v", calls)
+		}
+	})
+
+	t.Run("A push racing another run takes over its sync row", func(t *testing.T) {
+		sync := &models.CanvasEnrollmentSync{ClassID: class.ID, UserID: ben.ID, ProviderPlatformID: provider.ID, CanvasCourseID: courseID,
+			CanvasUserID: "12", State: models.CanvasEnrollmentActive, Status: models.CanvasSyncSynced}
+		if err := sh.saveCanvasEnrollmentSync(context.Background(), sync); err != nil {
+			t.Fatalf("expected the second insert to update the row, got %v", err)
+		}
+		var count int64
+		sh.db.Model(&models.CanvasEnrollmentSync{}).Where("class_id = ? AND user_id = ?", class.ID, ben.ID).Count(&count)
+		if count != 1 {
+			t.Fatalf("expected one sync row for ben, got %d", count)
+		}
+	})
+
+	t.Run("Linking another course deactivates the old course's enrollments first", func(t *testing.T) {
+		newCourse, newServer := newFakeCanvasCourse(t, "202")
+		other := &models.ProviderPlatform{
+			Type: models.CanvasCloud, Name: "Other Canvas", BaseUrl: newServer.URL, AccessKey: canvasFixtureToken, AccountID: "1", State: models.Enabled,
+		}
+		if err := sh.db.Create(other).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range []*models.User{ben, dee} {
+			mapping := &models.ProviderUserMapping{UserID: user.ID, ProviderPlatformID: other.ID, ExternalUserID: loadSync(t, user).CanvasUserID, ExternalUsername: user.Username}
+			if err := sh.db.Create(mapping).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		db := &database.DB{DB: sh.db}
+		newCourseID := "202"
+		if err := db.LinkClassToCanvasCourse(context.Background(), class, &other.ID, &newCourseID); err != nil {
+			t.Fatal(err)
+		}
+		if sync := loadSync(t, ben); sync.CanvasCourseID != courseID || sync.State != models.CanvasEnrollmentInactive {
+			t.Fatalf("expected ben's old enrollment to be kept for deactivation, got %+v", sync)
+		}
+		runJob(map[string]any{"class_id": class.ID})
+		if course.stateOf("12") != "inactive" || course.stateOf("14") != "inactive" {
+			t.Fatal("expected the enrollments in the old course to be deactivated")
+		}
+		if newCourse.stateOf("12") != "active" || newCourse.stateOf("14") != "active" {
+			t.Fatal("expected the enrolled residents to be enrolled in the new course")
+		}
+		if sync := loadSync(t, dee); sync.CanvasCourseID != newCourseID || sync.Status != models.CanvasSyncSynced {
+			t.Fatalf("expected dee's sync row to follow the new course, got %+v", sync)
+		}
+
+		if err := db.LinkClassToCanvasCourse(context.Background(), class, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		runJob(map[string]any{"job_id": "canvas-enrollments"})
+		if newCourse.stateOf("12") != "inactive" || newCourse.stateOf("14") != "inactive" {
+			t.Fatal("expected unlinking the class to deactivate its enrollments")
+		}
+		if sync := loadSync(t, ben); sync.State != models.CanvasEnrollmentInactive || sync.Status != models.CanvasSyncSynced {
+			t.Fatalf("expected ben's deactivation to be recorded, got %+v", sync)
+		}
+	})
+}
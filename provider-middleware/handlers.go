@@ -38,6 +38,8 @@ func (sh *ServiceHandler) initSubscription() error {
 		{models.RetryManualDownloadJob.PubName(), sh.handleManualRetryDownload},
 		{models.SyncVideoMetadataJob.PubName(), sh.handleSyncVideoMetadata},
 		{models.ActivateScheduledClassesJob.PubName(), sh.handleActivateScheduledClasses},
+		{models.EvaluateAttendanceRiskJob.PubName(), sh.handleEvaluateAttendanceRisk},
+		{models.CanvasEnrollmentSyncJob.PubName(), sh.handleCanvasEnrollmentSync},
 	}
 	for _, sub := range subscriptions {
 		timeout := CANCEL_TIMEOUT
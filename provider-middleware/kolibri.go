@@ -63,7 +63,7 @@ func (ks *KolibriService) GetJobParams() map[string]any {
 **/
 func (ks *KolibriService) GetUsers(db *gorm.DB) ([]models.ImportUser, error) {
 	// query kolibri database directly for users
-	query := `SELECT full_name, username, id FROM kolibriauth_facilityuser WHERE facility_id = ?`
+	query := `SELECT full_name, username, id, id_number FROM kolibriauth_facilityuser WHERE facility_id = ?`
 	var users []map[string]interface{}
 	if err := ks.db.Raw(query, ks.AccountID).Scan(&users).Error; err != nil {
 		log.Errorln("error querying kolibri database for users")
@@ -82,12 +82,14 @@ func (ks *KolibriService) GetUsers(db *gorm.DB) ([]models.ImportUser, error) {
 		} else {
 			first, last = split[0], ""
 		}
+		idNumber, _ := user["id_number"].(string)
 		importUsers = append(importUsers, models.ImportUser{
 			NameFirst:      first,
 			NameLast:       last,
 			Username:       user["username"].(string),
 			Email:          user["username"].(string) + "@unlocked.v2",
 			ExternalUserID: user["id"].(string),
+			DocID:          idNumber,
 		})
 	}
 	return importUsers, nil
@@ -59,6 +59,7 @@ func (ku *KolibriUser) IntoImportUser() (*models.ImportUser, error) {
 		Email:            email,
 		ExternalUserID:   ku.Id,
 		ExternalUsername: ku.Username,
+		DocID:            ku.IdNumber,
 	}
 	log.Printf("user to return: %v", user)
 	return &user, nil
@@ -32,6 +32,8 @@ func (sh *ServiceHandler) initServiceFromRequest(ctx context.Context, r *http.Re
 		return newCanvasService(&provider, nil), nil
 	case models.Brightspace:
 		return newBrightspaceService(&provider, sh.db, nil)
+	case models.Moodle:
+		return newMoodleService(&provider, nil), nil
 	}
 	return nil, fmt.Errorf("unsupported provider type: %s", provider.Type)
 }
@@ -66,6 +68,8 @@ func (sh *ServiceHandler) initProviderPlatformService(ctx context.Context, msg *
 		return newCanvasService(&provider, body), nil
 	case models.Brightspace:
 		return newBrightspaceService(&provider, sh.db, body)
+	case models.Moodle:
+		return newMoodleService(&provider, body), nil
 	}
 	return nil, fmt.Errorf("unsupported provider type: %s", provider.Type)
 }
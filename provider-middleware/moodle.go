@@ -0,0 +1,221 @@
+package main
+
+import (
+	"UnlockEdv2/src/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const moodleRestPath = "/webservice/rest/server.php"
+
+/***
+* MoodleService talks to a Moodle site through its web services REST API. The access key is a web service token
+* for a user allowed to call the functions below, see the README for the service setup.
+***/
+type MoodleService struct {
+	ProviderPlatformID uint
+	Client             *http.Client
+	BaseURL            string
+	Token              string
+	JobParams          map[string]any
+}
+
+func newMoodleService(provider *models.ProviderPlatform, params map[string]any) *MoodleService {
+	return &MoodleService{
+		ProviderPlatformID: provider.ID,
+		Client:             &http.Client{Timeout: 2 * time.Minute},
+		BaseURL:            strings.TrimSuffix(provider.BaseUrl, "/"),
+		Token:              strings.TrimSpace(provider.AccessKey),
+		JobParams:          params,
+	}
+}
+
+func (ms *MoodleService) GetJobParams() map[string]any {
+	return ms.JobParams
+}
+
+/**
+* call invokes a web service function and decodes its JSON result. The token is sent in the form body rather than
+* the URL so it stays out of proxy logs. Moodle reports failures with a 200 and an exception body, so the body is
+* checked for one before it is decoded.
+**/
+func (ms *MoodleService) call(function string, args url.Values, result any) error {
+	form := url.Values{}
+	for key, values := range args {
+		form[key] = values
+	}
+	form.Set("wstoken", ms.Token)
+	form.Set("wsfunction", function)
+	form.Set("moodlewsrestformat", "json")
+	resp, err := ms.Client.PostForm(ms.BaseURL+moodleRestPath, form)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resp.Body.Close() != nil {
+			logger().Error("Failed to close response body")
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("moodle %s returned status %d", function, resp.StatusCode)
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var exception moodleException
+		if json.Unmarshal(trimmed, &exception) == nil && exception.Exception != "" {
+			return &exception
+		}
+	}
+	return json.Unmarshal(body, result)
+}
+
+func (ms *MoodleService) fetchUsers() ([]MoodleUser, error) {
+	// '%' is Moodle's wildcard, matching every account with an email
+	args := url.Values{"criteria[0][key]": {"email"}, "criteria[0][value]": {"%"}}
+	var resp MoodleUsersResponse
+	if err := ms.call("core_user_get_users", args, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
+// GetUsers returns the Moodle users that have not yet been mapped to an UnlockEd user
+func (ms *MoodleService) GetUsers(db *gorm.DB) ([]models.ImportUser, error) {
+	users, err := ms.fetchUsers()
+	if err != nil {
+		log.Errorf("Failed to fetch Moodle users: %v", err)
+		return nil, err
+	}
+	unmapped := make([]models.ImportUser, 0, len(users))
+	for idx := range users {
+		if !users[idx].importable() {
+			continue
+		}
+		user := users[idx].IntoImportUser()
+		var count int64
+		if err := db.Model(&models.ProviderUserMapping{}).
+			Where("external_user_id = ? AND provider_platform_id = ?", user.ExternalUserID, ms.ProviderPlatformID).
+			Count(&count).Error; err != nil {
+			log.Errorf("Error counting provider_user_mappings: %v", err)
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		unmapped = append(unmapped, user)
+	}
+	log.Printf("returning %d unmapped Moodle users", len(unmapped))
+	return unmapped, nil
+}
+
+func (ms *MoodleService) ImportCourses(db *gorm.DB) error {
+	fields := log.Fields{"provider": ms.ProviderPlatformID, "Function": "ImportCourses"}
+	log.WithFields(fields).Info("importing courses from provider")
+	var resp MoodleCoursesResponse
+	if err := ms.call("core_course_get_courses_by_field", url.Values{}, &resp); err != nil {
+		log.WithFields(fields).Errorf("Failed to fetch Moodle courses: %v", err)
+		return err
+	}
+	for idx := range resp.Courses {
+		course := &resp.Courses[idx]
+		// the site's front page is returned as a course
+		if course.Format == "site" {
+			continue
+		}
+		externalID := fmt.Sprintf("%d", course.ID)
+		var count int64
+		if err := db.Model(&models.Course{}).
+			Where("provider_platform_id = ? AND external_id = ?", ms.ProviderPlatformID, externalID).
+			Count(&count).Error; err != nil {
+			log.WithFields(fields).Error("error getting count of provider courses")
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		var sections []MoodleSection
+		if err := ms.call("core_course_get_contents", url.Values{"courseid": {externalID}}, &sections); err != nil {
+			log.WithFields(fields).Errorf("Failed to get contents of course %s: %v", externalID, err)
+		}
+		if err := db.Create(ms.IntoCourse(course, countMilestones(sections))).Error; err != nil {
+			log.WithFields(fields).Errorf("Failed to create course: %v", err)
+			continue
+		}
+	}
+	return nil
+}
+
+/**
+* Moodle's user grade report has every enrolled user's assignment and quiz grades for a course, with when each was
+* submitted and graded, so one call covers the course.
+**/
+func (ms *MoodleService) ImportMilestones(coursePair map[string]any, mappings []map[string]any, db *gorm.DB, lastRun time.Time) error {
+	courseID := uint(coursePair["course_id"].(int64))
+	externalCourseID := coursePair["external_course_id"].(string)
+	fields := log.Fields{"task": "ImportMilestones", "course_id": courseID, "external_id": externalCourseID}
+	users := make(map[string]uint)
+	for _, mapping := range mappings {
+		users[mapping["external_user_id"].(string)] = uint(mapping["user_id"].(int64))
+	}
+	var report MoodleGradeReport
+	if err := ms.call("gradereport_user_get_grade_items", url.Values{"courseid": {externalCourseID}}, &report); err != nil {
+		log.WithFields(fields).Errorf("Failed to get grade items: %v", err)
+		return err
+	}
+	for idx := range report.UserGrades {
+		grades := &report.UserGrades[idx]
+		userID, ok := users[fmt.Sprintf("%d", grades.UserID)]
+		if !ok {
+			continue
+		}
+		for _, milestone := range ms.IntoMilestones(grades, userID, courseID, lastRun) {
+			if db.Where("external_id = ?", milestone.ExternalID).First(&models.Milestone{}).Error == nil {
+				continue
+			}
+			if err := db.Create(&milestone).Error; err != nil {
+				log.WithFields(fields).Errorf("failed to create milestone: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+/**
+* Records the mapped residents enrolled in the course. Moodle's web services don't report time spent in a course,
+* only when it was last opened, so no activity time is imported for Moodle courses.
+**/
+func (ms *MoodleService) ImportActivityForCourse(coursePair map[string]any, db *gorm.DB) error {
+	courseID := uint(coursePair["course_id"].(int64))
+	externalID := coursePair["external_course_id"].(string)
+	var enrolled []MoodleEnrolledUser
+	if err := ms.call("core_enrol_get_enrolled_users", url.Values{"courseid": {externalID}}, &enrolled); err != nil {
+		log.Errorf("Failed to get enrollments for course: %v", err)
+		return err
+	}
+	for _, enrollment := range enrolled {
+		var userID uint
+		if err := db.Model(models.ProviderUserMapping{}).Select("user_id").
+			First(&userID, "provider_platform_id = ? AND external_user_id = ?", ms.ProviderPlatformID, fmt.Sprintf("%d", enrollment.ID)).Error; err != nil {
+			continue
+		}
+		if db.Model(&models.UserEnrollment{}).First(&models.UserEnrollment{}, "user_id = ? AND course_id = ?", userID, courseID).RowsAffected == 0 {
+			if err := db.Create(&models.UserEnrollment{UserID: userID, CourseID: courseID}).Error; err != nil {
+				log.WithFields(log.Fields{"userId": userID, "course_id": courseID, "error": err}).Error("Failed to create enrollment")
+				continue
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"UnlockEdv2/src/models"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// moodleException is the body Moodle returns, with a 200 status, when a web service call fails
+type moodleException struct {
+	Exception string `json:"exception"`
+	ErrorCode string `json:"errorcode"`
+	Message   string `json:"message"`
+}
+
+func (e *moodleException) Error() string {
+	return fmt.Sprintf("moodle %s (%s): %s", e.Exception, e.ErrorCode, e.Message)
+}
+
+type MoodleUser struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+	FullName  string `json:"fullname"`
+	Email     string `json:"email"`
+	IDNumber  string `json:"idnumber"`
+	Suspended bool   `json:"suspended"`
+}
+
+type MoodleUsersResponse struct {
+	Users []MoodleUser `json:"users"`
+}
+
+// importable reports whether the account belongs to a person who can be mapped, Moodle's guest account never can
+func (mu *MoodleUser) importable() bool {
+	return mu.Username != "" && mu.Username != "guest" && !mu.Suspended
+}
+
+func (mu *MoodleUser) IntoImportUser() models.ImportUser {
+	email := mu.Email
+	if email == "" {
+		email = mu.Username + "@unlocked.v2"
+	}
+	return models.ImportUser{
+		Username:         mu.Username,
+		NameFirst:        mu.FirstName,
+		NameLast:         mu.LastName,
+		Email:            email,
+		ExternalUserID:   fmt.Sprintf("%d", mu.ID),
+		ExternalUsername: mu.Username,
+		DocID:            mu.IDNumber,
+	}
+}
+
+type MoodleCourse struct {
+	ID                int      `json:"id"`
+	FullName          string   `json:"fullname"`
+	ShortName         string   `json:"shortname"`
+	Summary           string   `json:"summary"`
+	Format            string   `json:"format"`
+	StartDate         int64    `json:"startdate"`
+	EndDate           int64    `json:"enddate"`
+	EnrollmentMethods []string `json:"enrollmentmethods"`
+}
+
+type MoodleCoursesResponse struct {
+	Courses []MoodleCourse `json:"courses"`
+}
+
+type MoodleModule struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	ModName string `json:"modname"`
+}
+
+type MoodleSection struct {
+	ID      int            `json:"id"`
+	Name    string         `json:"name"`
+	Modules []MoodleModule `json:"modules"`
+}
+
+// countMilestones counts the course's assignments and quizzes, the activities residents earn milestones in
+func countMilestones(sections []MoodleSection) int {
+	count := 0
+	for _, section := range sections {
+		for _, module := range section.Modules {
+			if module.ModName == "assign" || module.ModName == "quiz" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func moodleDate(unix int64) *time.Time {
+	if unix == 0 {
+		return nil
+	}
+	date := time.Unix(unix, 0).UTC()
+	return &date
+}
+
+func truncateRunes(value string, limit int) string {
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+	return string([]rune(value)[:limit])
+}
+
+func (ms *MoodleService) IntoCourse(course *MoodleCourse, totalMilestones int) *models.Course {
+	courseType := "fixed_enrollment"
+	for _, method := range course.EnrollmentMethods {
+		if method == "self" {
+			courseType = "open_enrollment"
+		}
+	}
+	// summaries are HTML written in Moodle's editor
+	description := strings.TrimSpace(bluemonday.StrictPolicy().Sanitize(course.Summary))
+	if description == "" {
+		description = course.ShortName
+	}
+	// course images are served through pluginfile.php, which needs the web service token, so they aren't linked
+	return &models.Course{
+		ProviderPlatformID:      ms.ProviderPlatformID,
+		Name:                    truncateRunes(course.FullName, 60),
+		AltName:                 course.ShortName,
+		Description:             truncateRunes(description, 510),
+		ExternalID:              fmt.Sprintf("%d", course.ID),
+		ExternalURL:             fmt.Sprintf("%s/course/view.php?id=%d", ms.BaseURL, course.ID),
+		Type:                    models.CourseType(courseType),
+		OutcomeTypes:            "grade, completion",
+		TotalProgressMilestones: uint(totalMilestones),
+		StartDt:                 moodleDate(course.StartDate),
+		EndDt:                   moodleDate(course.EndDate),
+	}
+}
+
+type MoodleGradeItem struct {
+	ID                 int      `json:"id"`
+	ItemName           string   `json:"itemname"`
+	ItemType           string   `json:"itemtype"`
+	ItemModule         string   `json:"itemmodule"`
+	GradeRaw           *float64 `json:"graderaw"`
+	GradeDateSubmitted *int64   `json:"gradedatesubmitted"`
+	GradeDateGraded    *int64   `json:"gradedategraded"`
+}
+
+type MoodleUserGrades struct {
+	CourseID   int               `json:"courseid"`
+	UserID     int               `json:"userid"`
+	GradeItems []MoodleGradeItem `json:"gradeitems"`
+}
+
+type MoodleGradeReport struct {
+	UserGrades []MoodleUserGrades `json:"usergrades"`
+}
+
+type MoodleEnrolledUser struct {
+	ID               int   `json:"id"`
+	LastCourseAccess int64 `json:"lastcourseaccess"`
+}
+
+/*
+IntoMilestones turns a resident's activity grades in a course into milestones, only counting submissions and grades
+since lastRun. Moodle's grade item IDs are only unique within one site, so external IDs carry the provider ID.
+*/
+func (ms *MoodleService) IntoMilestones(grades *MoodleUserGrades, userID, courseID uint, lastRun time.Time) []models.Milestone {
+	milestones := make([]models.Milestone, 0)
+	for _, item := range grades.GradeItems {
+		if item.ItemType != "mod" {
+			continue
+		}
+		externalID := fmt.Sprintf("moodle-%d-%d-%d", ms.ProviderPlatformID, item.ID, grades.UserID)
+		if item.GradeDateSubmitted != nil && time.Unix(*item.GradeDateSubmitted, 0).After(lastRun) {
+			submission := models.Milestone{
+				UserID:      userID,
+				CourseID:    courseID,
+				ExternalID:  externalID,
+				Type:        models.AssignmentSubmission,
+				IsCompleted: true,
+			}
+			if item.ItemModule == "quiz" {
+				submission.Type = models.QuizSubmission
+			}
+			milestones = append(milestones, submission)
+		}
+		if item.GradeRaw != nil && item.GradeDateGraded != nil && time.Unix(*item.GradeDateGraded, 0).After(lastRun) {
+			milestones = append(milestones, models.Milestone{
+				UserID:      userID,
+				CourseID:    courseID,
+				ExternalID:  externalID + "-grade",
+				Type:        models.GradeReceived,
+				IsCompleted: true,
+			})
+		}
+	}
+	return milestones
+}